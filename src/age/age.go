@@ -0,0 +1,130 @@
+// Package age implements the age v1 file format (https://age-encryption.org/v1)
+// with recipients of the Kyber family of KEMs.
+//
+// An age file is a text header, with one stanza per recipient wrapping a
+// random 16-byte file key, and a MAC of the header under the file key,
+// followed by the payload encrypted with the file key in chunks. The
+// payload is that of package stream, which follows the age design.
+//
+// The recipients and identities of this package use their own stanza types,
+// one per KEM (see KyberRecipient), and are encoded in bech32 like the
+// native age X25519 keys, as age1<type>1... and AGE-SECRET-KEY-<TYPE>-1....
+// The Recipient and Identity interfaces accept other stanza types too.
+package age
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Rohith04MVK/goKyber/stream"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const headerMACSize = sha256.Size
+
+// A Recipient wraps a file key into the stanzas of a header.
+type Recipient interface {
+	Wrap(fileKey []byte) ([]*Stanza, error)
+}
+
+// An Identity unwraps the file key from the stanzas of a header. It returns
+// an error wrapping ErrIncorrectIdentity if none of the stanzas is for it,
+// and any other error if the header is malformed, which stops decryption.
+type Identity interface {
+	Unwrap(stanzas []*Stanza) ([]byte, error)
+}
+
+// ErrIncorrectIdentity is returned by Identity.Unwrap when the identity
+// matches none of the stanzas.
+var ErrIncorrectIdentity = errors.New("incorrect identity for recipient block")
+
+// NoIdentityMatchError is returned by Decrypt when none of the identities
+// unwrap the file key.
+type NoIdentityMatchError struct {
+	// Errors are the ErrIncorrectIdentity errors of the identities.
+	Errors []error
+}
+
+func (*NoIdentityMatchError) Error() string {
+	return "no identity matched any of the recipients"
+}
+
+// Encrypt writes the header of an age file for the recipients to dst and
+// returns a writer that encrypts the payload. The writer must be closed to
+// write the final chunk; closing it does not close dst.
+func Encrypt(dst io.Writer, recipients ...Recipient) (io.WriteCloser, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients")
+	}
+	fileKey := make([]byte, stream.FileKeySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, err
+	}
+
+	h := &header{}
+	for _, r := range recipients {
+		stanzas, err := r.Wrap(fileKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wrap file key: %w", err)
+		}
+		h.recipients = append(h.recipients, stanzas...)
+	}
+	var raw bytes.Buffer
+	if err := h.marshalWithoutMAC(&raw); err != nil {
+		return nil, err
+	}
+	h.mac = stream.HeaderMAC(fileKey, raw.Bytes())
+	if err := h.marshal(dst); err != nil {
+		return nil, err
+	}
+	return stream.NewPayloadWriter(dst, fileKey)
+}
+
+// Decrypt reads the header of an age file from src, unwraps the file key
+// with the first identity that matches a stanza, checks the header MAC and
+// returns a reader of the decrypted payload. Reading returns an error if the
+// file was truncated, reordered or modified; plaintext read before such an
+// error must be discarded.
+func Decrypt(src io.Reader, identities ...Identity) (io.Reader, error) {
+	if len(identities) == 0 {
+		return nil, errors.New("no identities")
+	}
+	r := bufio.NewReaderSize(src, stream.ChunkSize+chacha20poly1305.Overhead+1)
+	h, raw, err := parseHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(h.recipients) == 0 {
+		return nil, errors.New("age file has no recipients")
+	}
+
+	var fileKey []byte
+	noMatch := &NoIdentityMatchError{}
+	for _, identity := range identities {
+		fileKey, err = identity.Unwrap(h.recipients)
+		if errors.Is(err, ErrIncorrectIdentity) {
+			noMatch.Errors = append(noMatch.Errors, err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		break
+	}
+	if fileKey == nil {
+		return nil, noMatch
+	}
+	if len(fileKey) != stream.FileKeySize {
+		return nil, errors.New("invalid file key length")
+	}
+	if !hmac.Equal(h.mac, stream.HeaderMAC(fileKey, raw)) {
+		return nil, errors.New("age header MAC mismatch")
+	}
+	return stream.NewPayloadReader(r, fileKey)
+}
@@ -0,0 +1,325 @@
+package age
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+	"github.com/Rohith04MVK/goKyber/stream"
+)
+
+func generate(t *testing.T, kem gokyber.KEM) *KyberIdentity {
+	t.Helper()
+	identity, err := GenerateKyberIdentity(kem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return identity
+}
+
+// encrypt returns an age file of the plaintext and its parsed header.
+func encrypt(t *testing.T, plaintext []byte, recipients ...Recipient) ([]byte, *header) {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := Encrypt(&buf, recipients...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	h, _, err := parseHeader(bufio.NewReader(bytes.NewReader(buf.Bytes())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), h
+}
+
+func decrypt(encrypted []byte, identities ...Identity) ([]byte, error) {
+	r, err := Decrypt(bytes.NewReader(encrypted), identities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// plainStanza is a Recipient and Identity of a stanza type outside this
+// package, which carries the file key in the clear.
+type plainStanza string
+
+func (p plainStanza) Wrap(fileKey []byte) ([]*Stanza, error) {
+	return []*Stanza{{Type: "plain", Args: []string{string(p)}, Body: fileKey}}, nil
+}
+
+func (p plainStanza) Unwrap(stanzas []*Stanza) ([]byte, error) {
+	for _, s := range stanzas {
+		if s.Type == "plain" && len(s.Args) == 1 && s.Args[0] == string(p) {
+			return s.Body, nil
+		}
+	}
+	return nil, ErrIncorrectIdentity
+}
+
+// failingIdentity rejects every header as malformed.
+type failingIdentity struct{}
+
+var errMalformed = errors.New("malformed")
+
+func (failingIdentity) Unwrap([]*Stanza) ([]byte, error) {
+	return nil, errMalformed
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, st := range stanzaTypes {
+		identity := generate(t, st.kem)
+		plaintext := make([]byte, stream.ChunkSize+100)
+		rand.Read(plaintext)
+		encrypted, _ := encrypt(t, plaintext, identity.Recipient())
+		if !bytes.HasPrefix(encrypted, []byte(versionLine+"\n-> "+st.name+" ")) {
+			t.Errorf("%s: unexpected header %q", st.name, encrypted[:40])
+		}
+		decrypted, err := decrypt(encrypted, identity)
+		if err != nil {
+			t.Fatalf("%s: %v", st.name, err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("%s: decrypted payload differs", st.name)
+		}
+	}
+}
+
+func TestRecipientStanzas(t *testing.T) {
+	kyber := []*KyberIdentity{generate(t, gokyber.Kyber512), generate(t, gokyber.XWing), generate(t, gokyber.Kyber768_90s)}
+	recipients := []Recipient{kyber[0].Recipient(), plainStanza("a"), kyber[1].Recipient(), kyber[2].Recipient()}
+	encrypted, h := encrypt(t, []byte("attack at dawn"), recipients...)
+
+	// Each recipient contributes one stanza, in order, and every identity
+	// unwraps the same file key from the whole list.
+	if len(h.recipients) != len(recipients) {
+		t.Fatalf("%d stanzas for %d recipients", len(h.recipients), len(recipients))
+	}
+	fileKey, err := plainStanza("a").Unwrap(h.recipients)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, identity := range kyber {
+		s := h.recipients[[]int{0, 2, 3}[i]]
+		name := stanzaTypes[identity.typ].name
+		if s.Type != name || len(s.Args) != 1 || len(s.Body) != wrappedKeySize {
+			t.Errorf("%s: stanza %q %q with a %d-byte body", name, s.Type, s.Args, len(s.Body))
+		}
+		got, err := identity.Unwrap(h.recipients)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(got, fileKey) {
+			t.Errorf("%s: unwrapped another file key", name)
+		}
+	}
+	if _, err := decrypt(encrypted, plainStanza("a")); err != nil {
+		t.Errorf("stanza type of another package: %v", err)
+	}
+
+	// Every identity that matches no stanza is reported.
+	var noMatch *NoIdentityMatchError
+	_, err = decrypt(encrypted, generate(t, gokyber.Kyber512), plainStanza("b"), generate(t, gokyber.Kyber1024))
+	if !errors.As(err, &noMatch) || len(noMatch.Errors) != 3 {
+		t.Fatalf("unrelated identities: %v", err)
+	}
+	for _, err := range noMatch.Errors {
+		if !errors.Is(err, ErrIncorrectIdentity) {
+			t.Errorf("unrelated identity: %v", err)
+		}
+	}
+
+	// Any other error stops decryption, even before a matching identity.
+	if _, err := decrypt(encrypted, failingIdentity{}, kyber[0]); !errors.Is(err, errMalformed) {
+		t.Errorf("failing identity: %v", err)
+	}
+	if _, err := decrypt(encrypted, plainStanza("b"), kyber[1]); err != nil {
+		t.Errorf("identity after a mismatch: %v", err)
+	}
+}
+
+func TestHeaderMAC(t *testing.T) {
+	identity := generate(t, gokyber.MLKEM768)
+	other := generate(t, gokyber.Kyber768)
+	encrypted, h := encrypt(t, []byte("attack at dawn"), identity.Recipient(), other.Recipient())
+	if _, err := decrypt(encrypted, identity); err != nil {
+		t.Fatal(err)
+	}
+
+	// The MAC covers the header up to and including the "---" of the
+	// footer.
+	_, raw, err := parseHeader(bufio.NewReader(bytes.NewReader(encrypted)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileKey, err := identity.Unwrap(h.recipients)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(raw, []byte("\n"+footerPrefix)) || !bytes.Equal(h.mac, stream.HeaderMAC(fileKey, raw)) {
+		t.Error("header MAC is not over the header without the MAC")
+	}
+	headerSize := bytes.Index(encrypted, []byte("\n"+footerPrefix+" ")) + len(footerPrefix) + 2 + len(b64.EncodeToString(h.mac)) + 1
+	payload := encrypted[headerSize:]
+
+	// Changing the stanza of the other recipient, which the identity does
+	// not read, is caught by the MAC.
+	modified := &header{recipients: []*Stanza{h.recipients[0], {Type: "kyber768", Args: h.recipients[1].Args, Body: make([]byte, wrappedKeySize)}}, mac: h.mac}
+	var buf bytes.Buffer
+	if err := modified.marshal(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decrypt(append(buf.Bytes(), payload...), identity); err == nil || !strings.Contains(err.Error(), "MAC") {
+		t.Errorf("modified stanza: %v", err)
+	}
+	// So is an added stanza.
+	modified.recipients = append(h.recipients, &Stanza{Type: "unknown", Args: []string{"a", "b"}, Body: []byte("body")})
+	buf.Reset()
+	if err := modified.marshal(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decrypt(append(buf.Bytes(), payload...), identity); err == nil || !strings.Contains(err.Error(), "MAC") {
+		t.Errorf("added stanza: %v", err)
+	}
+
+	for _, offset := range []int{0, len(versionLine) + 5, headerSize - 10, headerSize, len(encrypted) - 1} {
+		modified := bytes.Clone(encrypted)
+		modified[offset] ^= 1
+		if _, err := decrypt(modified, identity); err == nil {
+			t.Errorf("modified byte %d accepted", offset)
+		}
+	}
+	for _, n := range []int{0, len(versionLine) + 1, headerSize - 1, headerSize, len(encrypted) - 1} {
+		if _, err := decrypt(encrypted[:n], identity); err == nil {
+			t.Errorf("file truncated to %d bytes accepted", n)
+		}
+	}
+}
+
+func TestStanzaBody(t *testing.T) {
+	// Bodies that are a multiple of 48 bytes long end with an empty line.
+	for _, n := range []int{0, 1, 47, 48, 49, 96, 100} {
+		body := make([]byte, n)
+		rand.Read(body)
+		h := &header{recipients: []*Stanza{{Type: "test", Args: []string{"x"}, Body: body}}, mac: make([]byte, headerMACSize)}
+		var buf bytes.Buffer
+		if err := h.marshal(&buf); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(buf.String(), "\n")
+		if want := 2 + n/48 + 1 + 1; len(lines) != want+1 {
+			t.Errorf("%d bytes: %d lines, want %d", n, len(lines)-1, want)
+		}
+		got, raw, err := parseHeader(bufio.NewReader(&buf))
+		if err != nil {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+		if !bytes.Equal(got.recipients[0].Body, body) || got.recipients[0].Type != "test" {
+			t.Errorf("%d bytes: body differs", n)
+		}
+		if !bytes.HasSuffix(raw, []byte("\n---")) {
+			t.Errorf("%d bytes: MAC input %q", n, raw)
+		}
+	}
+}
+
+func TestMalformedHeaders(t *testing.T) {
+	mac := " " + b64.EncodeToString(make([]byte, headerMACSize)) + "\n"
+	for _, header := range []string{
+		"",
+		"age-encryption.org/v2\n-> X\n\n---" + mac,
+		versionLine + "\n---" + mac,
+		versionLine + "\n-> X\n",
+		versionLine + "\n->\n\n---" + mac,
+		versionLine + "\n-> X  a\n\n---" + mac,
+		versionLine + "\n-> X\n" + strings.Repeat("A", 65) + "\n---" + mac,
+		versionLine + "\n-> X\n" + strings.Repeat("A", 64) + "\n---" + mac,
+		versionLine + "\n-> X\nAA==\n---" + mac,
+		versionLine + "\n-> X\nAB\n---" + mac,
+		versionLine + "\n-> X\n\n--- AAAA\n",
+		versionLine + "\n-> X\n\n" + strings.Repeat("A", maxLineLength+1),
+	} {
+		identity := generate(t, gokyber.Kyber512)
+		if _, err := Decrypt(strings.NewReader(header), identity); err == nil {
+			t.Errorf("%q accepted", header)
+		}
+	}
+
+	// A malformed stanza of the identity's own type is an error, not a
+	// mismatch.
+	identity := generate(t, gokyber.Kyber512)
+	_, err := identity.Unwrap([]*Stanza{{Type: "kyber512", Args: []string{"AAAA"}, Body: make([]byte, wrappedKeySize)}})
+	if err == nil || errors.Is(err, ErrIncorrectIdentity) {
+		t.Errorf("malformed stanza: %v", err)
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	for _, st := range stanzaTypes {
+		identity := generate(t, st.kem)
+		s := identity.String()
+		if !strings.HasPrefix(s, "AGE-SECRET-KEY-"+strings.ToUpper(st.name)+"-1") {
+			t.Errorf("identity %.40s...", s)
+		}
+		parsed, err := ParseKyberIdentity(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.String() != s || !bytes.Equal(parsed.privateKey, identity.privateKey) {
+			t.Errorf("%s: identity does not round trip", st.name)
+		}
+
+		r := identity.Recipient().String()
+		if !strings.HasPrefix(r, "age1"+st.name+"1") {
+			t.Errorf("recipient %.40s...", r)
+		}
+		recipient, err := ParseKyberRecipient(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if recipient.String() != r {
+			t.Errorf("%s: recipient does not round trip", st.name)
+		}
+		encrypted, _ := encrypt(t, nil, recipient)
+		if _, err := decrypt(encrypted, parsed); err != nil {
+			t.Errorf("%s: %v", st.name, err)
+		}
+	}
+
+	// Traced parameter sets have the stanza type of their original.
+	identity, err := GenerateKyberIdentity(gokyber.MLKEM768.WithTracer(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(identity.Recipient().String(), "age1mlkem7681") {
+		t.Error("traced parameter set has another stanza type")
+	}
+
+	for _, s := range []string{
+		"",
+		"age1xwing1qqqqqq",
+		"age1unknown1" + strings.Repeat("q", 60),
+		strings.ToUpper(generate(t, gokyber.XWing).Recipient().String()[:10]) + generate(t, gokyber.XWing).Recipient().String()[10:],
+	} {
+		if _, err := ParseKyberRecipient(s); err == nil {
+			t.Errorf("recipient %.40q accepted", s)
+		}
+	}
+	if _, err := ParseKyberIdentity(generate(t, gokyber.XWing).Recipient().String()); err == nil {
+		t.Error("recipient parsed as identity")
+	}
+	if _, err := NewKyberRecipient(gokyber.MLKEM768P256, make([]byte, gokyber.MLKEM768P256.PublicKeyBytes())); err == nil {
+		t.Error("recipient of a KEM without stanza type accepted")
+	}
+}
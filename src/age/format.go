@@ -0,0 +1,197 @@
+package age
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The header of an age v1 file is
+//
+//	age-encryption.org/v1
+//	-> <type> <arg>...
+//	<body, in unpadded base64 wrapped at 64 columns>
+//	...
+//	--- <unpadded base64 header MAC>
+//
+// with one stanza per recipient. The last line of a stanza body is always
+// shorter than 64 columns, so a body that is a multiple of 48 bytes long
+// ends with an empty line. The header MAC covers everything from the
+// version line up to and including "---".
+const (
+	versionLine    = "age-encryption.org/v1"
+	stanzaPrefix   = "->"
+	footerPrefix   = "---"
+	columnsPerLine = 64
+
+	// maxLineLength bounds the lines of a header, which hold at most a
+	// Kyber-1024 ciphertext in base64.
+	maxLineLength = 4096
+)
+
+var b64 = base64.RawStdEncoding.Strict()
+
+// A Stanza is the part of a header that holds the file key for one
+// recipient. Its type and arguments are non-empty strings of printable
+// ASCII characters without spaces.
+type Stanza struct {
+	Type string
+	Args []string
+	Body []byte
+}
+
+func validString(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x21 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// marshal writes the stanza in its header encoding.
+func (s *Stanza) marshal(w io.Writer) error {
+	if !validString(s.Type) {
+		return fmt.Errorf("invalid stanza type %q", s.Type)
+	}
+	line := stanzaPrefix + " " + s.Type
+	for _, arg := range s.Args {
+		if !validString(arg) {
+			return fmt.Errorf("invalid stanza argument %q", arg)
+		}
+		line += " " + arg
+	}
+	if _, err := io.WriteString(w, line+"\n"); err != nil {
+		return err
+	}
+	body := b64.EncodeToString(s.Body)
+	for {
+		n := min(len(body), columnsPerLine)
+		if _, err := io.WriteString(w, body[:n]+"\n"); err != nil {
+			return err
+		}
+		if n < columnsPerLine {
+			return nil
+		}
+		body = body[n:]
+	}
+}
+
+// header is a parsed age v1 header.
+type header struct {
+	recipients []*Stanza
+	mac        []byte
+}
+
+// marshalWithoutMAC writes the part of the header covered by the MAC.
+func (h *header) marshalWithoutMAC(w io.Writer) error {
+	if _, err := io.WriteString(w, versionLine+"\n"); err != nil {
+		return err
+	}
+	for _, s := range h.recipients {
+		if err := s.marshal(w); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, footerPrefix)
+	return err
+}
+
+func (h *header) marshal(w io.Writer) error {
+	if err := h.marshalWithoutMAC(w); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, " "+b64.EncodeToString(h.mac)+"\n")
+	return err
+}
+
+// parseHeader reads a header from r, leaving r at the start of the payload.
+// It returns the header and the bytes covered by its MAC.
+func parseHeader(r *bufio.Reader) (*header, []byte, error) {
+	var raw bytes.Buffer
+	line, err := readLine(r, &raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	if line != versionLine {
+		return nil, nil, errors.New("not an age v1 file")
+	}
+
+	h := &header{}
+	for {
+		start := raw.Len()
+		line, err := readLine(r, &raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		if rest, ok := strings.CutPrefix(line, footerPrefix+" "); ok {
+			mac, err := b64.DecodeString(rest)
+			if err != nil || len(mac) != headerMACSize {
+				return nil, nil, errors.New("invalid age header MAC line")
+			}
+			h.mac = mac
+			raw.Truncate(start + len(footerPrefix))
+			return h, raw.Bytes(), nil
+		}
+
+		fields := strings.Split(line, " ")
+		if fields[0] != stanzaPrefix || len(fields) < 2 {
+			return nil, nil, fmt.Errorf("invalid age header line %q", line)
+		}
+		for _, f := range fields[1:] {
+			if !validString(f) {
+				return nil, nil, fmt.Errorf("invalid age stanza line %q", line)
+			}
+		}
+		s := &Stanza{Type: fields[1], Args: fields[2:]}
+		for {
+			line, err := readLine(r, &raw)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(line) > columnsPerLine {
+				return nil, nil, errors.New("age stanza body line too long")
+			}
+			chunk, err := b64.DecodeString(line)
+			if err != nil {
+				return nil, nil, errors.New("invalid age stanza body")
+			}
+			s.Body = append(s.Body, chunk...)
+			if len(line) < columnsPerLine {
+				break
+			}
+		}
+		h.recipients = append(h.recipients, s)
+	}
+}
+
+// readLine reads a line of the header, appending it to raw, and returns it
+// without its newline.
+func readLine(r *bufio.Reader, raw *bytes.Buffer) (string, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > maxLineLength {
+			return "", errors.New("age header line too long")
+		}
+		if err == nil {
+			break
+		}
+		if err != bufio.ErrBufferFull {
+			if err == io.EOF {
+				err = errors.New("age header truncated")
+			}
+			return "", err
+		}
+	}
+	raw.Write(line)
+	return string(line[:len(line)-1]), nil
+}
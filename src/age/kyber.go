@@ -0,0 +1,249 @@
+package age
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+	"github.com/Rohith04MVK/goKyber/internal/bech32"
+	"github.com/Rohith04MVK/goKyber/stream"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// seededKEM is a KEM whose key pairs are derived from seeds, which is what
+// identities store.
+type seededKEM interface {
+	gokyber.KEM
+	KemKeypairFromSeed(seed []byte) ([]byte, []byte, error)
+}
+
+// stanzaTypes are the stanza types of the supported KEMs, which also name
+// their recipients and identities.
+var stanzaTypes = []struct {
+	name      string
+	kem       seededKEM
+	seedBytes int
+}{
+	{"kyber512", gokyber.Kyber512, gokyber.Kyber512.SeedBytes()},
+	{"kyber768", gokyber.Kyber768, gokyber.Kyber768.SeedBytes()},
+	{"kyber1024", gokyber.Kyber1024, gokyber.Kyber1024.SeedBytes()},
+	{"kyber512-90s", gokyber.Kyber512_90s, gokyber.Kyber512_90s.SeedBytes()},
+	{"kyber768-90s", gokyber.Kyber768_90s, gokyber.Kyber768_90s.SeedBytes()},
+	{"kyber1024-90s", gokyber.Kyber1024_90s, gokyber.Kyber1024_90s.SeedBytes()},
+	{"mlkem512", gokyber.MLKEM512, gokyber.MLKEM512.SeedBytes()},
+	{"mlkem768", gokyber.MLKEM768, gokyber.MLKEM768.SeedBytes()},
+	{"mlkem1024", gokyber.MLKEM1024, gokyber.MLKEM1024.SeedBytes()},
+	{"xwing", gokyber.XWing, gokyber.XWing.PrivateKeyBytes()},
+}
+
+// stanzaType returns the index in stanzaTypes of a KEM, or of a traced copy
+// of a parameter set.
+func stanzaType(kem gokyber.KEM) (int, error) {
+	if id, err := gokyber.AlgorithmID(kem); err == nil {
+		kem, _ = gokyber.KEMForAlgorithmID(id)
+	}
+	for i, t := range stanzaTypes {
+		if gokyber.KEM(t.kem) == kem {
+			return i, nil
+		}
+	}
+	return 0, errors.New("KEM has no age stanza type")
+}
+
+func stanzaTypeByName(name string) (int, error) {
+	for i, t := range stanzaTypes {
+		if t.name == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown age stanza type %q", name)
+}
+
+const (
+	recipientPrefix = "age1"
+	identityPrefix  = "AGE-SECRET-KEY-"
+	wrappedKeySize  = stream.FileKeySize + chacha20poly1305.Overhead
+)
+
+// KyberRecipient is a Kyber, ML-KEM or X-Wing public key. It wraps the file
+// key in a stanza
+//
+//	-> <type> <base64 KEM ciphertext>
+//	<base64 wrapped file key>
+//
+// where the type is the lower-case name of the KEM, such as kyber768,
+// mlkem768 or xwing. Like the native X25519 stanza, the file key is
+// encrypted with ChaCha20-Poly1305 under a zero nonce and a key derived
+// with HKDF-SHA256 from the shared secret, taking the KEM ciphertext and the
+// public key as salt and "age-encryption.org/v1/<type>" as info.
+type KyberRecipient struct {
+	typ       int
+	publicKey []byte
+}
+
+// NewKyberRecipient returns the recipient of a public key of a KEM.
+func NewKyberRecipient(kem gokyber.KEM, publicKey []byte) (*KyberRecipient, error) {
+	typ, err := stanzaType(kem)
+	if err != nil {
+		return nil, err
+	}
+	if len(publicKey) != kem.PublicKeyBytes() {
+		return nil, errors.New("invalid public key length")
+	}
+	return &KyberRecipient{typ, append([]byte{}, publicKey...)}, nil
+}
+
+// ParseKyberRecipient parses a recipient encoded by String.
+func ParseKyberRecipient(s string) (*KyberRecipient, error) {
+	hrp, publicKey, err := bech32.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("malformed recipient %q: %v", s, err)
+	}
+	name, ok := strings.CutPrefix(hrp, recipientPrefix)
+	if !ok {
+		return nil, fmt.Errorf("malformed recipient %q: not an age recipient", s)
+	}
+	typ, err := stanzaTypeByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewKyberRecipient(stanzaTypes[typ].kem, publicKey)
+}
+
+// String returns the recipient in bech32, as "age1<type>1...".
+func (r *KyberRecipient) String() string {
+	s, _ := bech32.Encode(recipientPrefix+stanzaTypes[r.typ].name, r.publicKey)
+	return s
+}
+
+// Wrap implements Recipient.
+func (r *KyberRecipient) Wrap(fileKey []byte) ([]*Stanza, error) {
+	t := stanzaTypes[r.typ]
+	ciphertext, sharedSecret, err := t.kem.KemEncrypt(r.publicKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := wrapAEAD(t.name, sharedSecret, ciphertext, r.publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return []*Stanza{{
+		Type: t.name,
+		Args: []string{b64.EncodeToString(ciphertext)},
+		Body: aead.Seal(nil, make([]byte, chacha20poly1305.NonceSize), fileKey, nil),
+	}}, nil
+}
+
+// KyberIdentity is the private key of a KyberRecipient. It is stored as the
+// seed the key pair is derived from.
+type KyberIdentity struct {
+	typ        int
+	seed       []byte
+	privateKey []byte
+	publicKey  []byte
+}
+
+// GenerateKyberIdentity generates an identity of a KEM.
+func GenerateKyberIdentity(kem gokyber.KEM) (*KyberIdentity, error) {
+	typ, err := stanzaType(kem)
+	if err != nil {
+		return nil, err
+	}
+	seed := make([]byte, stanzaTypes[typ].seedBytes)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	return NewKyberIdentity(kem, seed)
+}
+
+// NewKyberIdentity returns the identity of a KEM derived from a seed, as
+// accepted by the KemKeypairFromSeed method of the KEM.
+func NewKyberIdentity(kem gokyber.KEM, seed []byte) (*KyberIdentity, error) {
+	typ, err := stanzaType(kem)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, publicKey, err := stanzaTypes[typ].kem.KemKeypairFromSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+	return &KyberIdentity{typ, append([]byte{}, seed...), privateKey, publicKey}, nil
+}
+
+// ParseKyberIdentity parses an identity encoded by String.
+func ParseKyberIdentity(s string) (*KyberIdentity, error) {
+	hrp, seed, err := bech32.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("malformed secret key: %v", err)
+	}
+	name, ok := strings.CutPrefix(hrp, strings.ToLower(identityPrefix))
+	if !ok || !strings.HasSuffix(name, "-") {
+		return nil, errors.New("malformed secret key: not an age identity")
+	}
+	typ, err := stanzaTypeByName(strings.TrimSuffix(name, "-"))
+	if err != nil {
+		return nil, err
+	}
+	return NewKyberIdentity(stanzaTypes[typ].kem, seed)
+}
+
+// String returns the identity in upper-case bech32, as
+// "AGE-SECRET-KEY-<TYPE>-1...".
+func (i *KyberIdentity) String() string {
+	s, _ := bech32.Encode(identityPrefix+strings.ToUpper(stanzaTypes[i.typ].name)+"-", i.seed)
+	return s
+}
+
+// Recipient returns the recipient of the identity's public key.
+func (i *KyberIdentity) Recipient() *KyberRecipient {
+	return &KyberRecipient{i.typ, i.publicKey}
+}
+
+// Unwrap implements Identity.
+func (i *KyberIdentity) Unwrap(stanzas []*Stanza) ([]byte, error) {
+	t := stanzaTypes[i.typ]
+	for _, s := range stanzas {
+		if s.Type != t.name {
+			continue
+		}
+		if len(s.Args) != 1 {
+			return nil, fmt.Errorf("invalid %s recipient block", t.name)
+		}
+		ciphertext, err := b64.DecodeString(s.Args[0])
+		if err != nil || len(ciphertext) != t.kem.CiphertextBytes() {
+			return nil, fmt.Errorf("invalid %s recipient block", t.name)
+		}
+		if len(s.Body) != wrappedKeySize {
+			return nil, fmt.Errorf("invalid %s recipient block", t.name)
+		}
+		sharedSecret, err := t.kem.KemDecrypt(ciphertext, i.privateKey)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := wrapAEAD(t.name, sharedSecret, ciphertext, i.publicKey)
+		if err != nil {
+			return nil, err
+		}
+		// Implicit rejection turns a ciphertext for another key into a
+		// random shared secret, which fails to open the file key.
+		if fileKey, err := aead.Open(nil, make([]byte, chacha20poly1305.NonceSize), s.Body, nil); err == nil {
+			return fileKey, nil
+		}
+	}
+	return nil, ErrIncorrectIdentity
+}
+
+func wrapAEAD(name string, sharedSecret, ciphertext, publicKey []byte) (cipher.AEAD, error) {
+	salt := append(append([]byte{}, ciphertext...), publicKey...)
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecret, salt, []byte("age-encryption.org/v1/"+name)), key); err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.New(key)
+}
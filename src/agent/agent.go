@@ -0,0 +1,255 @@
+package agent
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+	"github.com/Rohith04MVK/goKyber/securemem"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// agentKey is a key of an agent with its private key.
+type agentKey struct {
+	Key
+	privateKey *securemem.Buffer
+}
+
+// Agent holds private keys and serves decapsulations with them.
+type Agent struct {
+	mu       sync.RWMutex
+	keys     []*agentKey
+	locked   bool
+	lockSalt []byte
+	lockHash []byte
+}
+
+// New returns an agent without keys.
+func New() *Agent {
+	return &Agent{}
+}
+
+// Add adds a private key of a parameter set to the agent, which takes
+// ownership of the buffer and destroys it on Close.
+func (a *Agent) Add(name string, params *gokyber.ParameterSet, privateKey *securemem.Buffer) error {
+	if name == "" || len(name) > 255 {
+		return errors.New("agent: invalid key name")
+	}
+	if len(privateKey.Bytes()) != params.PrivateKeyBytes() {
+		return errors.New("agent: invalid private key length")
+	}
+	// The private key embeds the public key after the IND-CPA private key.
+	offset := params.IndcpaSecretKeyBytes()
+	publicKey := append([]byte{}, privateKey.Bytes()[offset:offset+params.PublicKeyBytes()]...)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, k := range a.keys {
+		if k.Name == name {
+			return fmt.Errorf("agent: duplicate key %q", name)
+		}
+	}
+	a.keys = append(a.keys, &agentKey{Key: Key{Name: name, Params: params, PublicKey: publicKey}, privateKey: privateKey})
+	return nil
+}
+
+// Close destroys the keys of the agent.
+func (a *Agent) Close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, k := range a.keys {
+		k.privateKey.Destroy()
+	}
+	a.keys = nil
+}
+
+// Serve accepts connections on a Unix domain socket listener and serves
+// them until the listener fails, returning its error. Connections from
+// other users are closed without a response.
+func (a *Agent) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			unixConn, ok := conn.(*net.UnixConn)
+			if !ok {
+				return
+			}
+			if err := checkPeer(unixConn); err != nil {
+				return
+			}
+			a.serveConn(conn)
+		}()
+	}
+}
+
+// serveConn answers the requests of a connection until it fails.
+func (a *Agent) serveConn(rw io.ReadWriter) error {
+	for {
+		msgType, fields, err := readMessage(rw)
+		if err != nil {
+			return err
+		}
+		if err := a.handle(rw, msgType, fields); err != nil {
+			return err
+		}
+	}
+}
+
+// Failures of requests, sent to the peer as messages.
+var (
+	errMalformed    = errors.New("malformed request")
+	errLocked       = errors.New("agent is locked")
+	errUnlockFailed = errors.New("agent is not locked or the passphrase is incorrect")
+)
+
+// handle answers a request. The agent mutex is held only to read or update
+// the state of the agent, never while writing the reply or hashing a lock
+// passphrase, so that a peer which does not read its replies, or a burst of
+// lock requests, stalls only its own connection.
+func (a *Agent) handle(w io.Writer, msgType byte, fields cryptobyte.String) error {
+	replyType, add, err := a.reply(msgType, fields)
+	if err != nil {
+		return writeFailure(w, err.Error())
+	}
+	return writeMessage(w, replyType, add)
+}
+
+// reply returns the type and fields of the reply to a request.
+func (a *Agent) reply(msgType byte, fields cryptobyte.String) (byte, func(*cryptobyte.Builder), error) {
+	switch msgType {
+	case msgListKeys:
+		add, err := a.listKeys(fields)
+		return msgKeyList, add, err
+	case msgDecapsulate:
+		add, err := a.decapsulate(fields)
+		return msgSharedSecret, add, err
+	case msgLock:
+		return msgSuccess, nil, a.lock(fields)
+	case msgUnlock:
+		return msgSuccess, nil, a.unlock(fields)
+	}
+	return 0, nil, fmt.Errorf("unknown request type %d", msgType)
+}
+
+func (a *Agent) listKeys(fields cryptobyte.String) (func(*cryptobyte.Builder), error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.locked {
+		return nil, errLocked
+	}
+	if !fields.Empty() {
+		return nil, errMalformed
+	}
+	keys := make([]Key, len(a.keys))
+	for i, k := range a.keys {
+		keys[i] = k.Key
+	}
+	return func(b *cryptobyte.Builder) {
+		for _, k := range keys {
+			b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes([]byte(k.Name)) })
+			b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes([]byte(k.Params.Name)) })
+			b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(k.PublicKey) })
+		}
+	}, nil
+}
+
+// decapsulate holds the read lock while using a private key, so that Close
+// does not destroy it meanwhile.
+func (a *Agent) decapsulate(fields cryptobyte.String) (func(*cryptobyte.Builder), error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.locked {
+		return nil, errLocked
+	}
+	var name, ciphertext cryptobyte.String
+	if !fields.ReadUint8LengthPrefixed(&name) || !fields.ReadUint24LengthPrefixed(&ciphertext) || !fields.Empty() {
+		return nil, errMalformed
+	}
+	k := a.key(string(name))
+	if k == nil {
+		return nil, fmt.Errorf("no key %q", name)
+	}
+	sharedSecret, err := k.Params.KemDecrypt(ciphertext, k.privateKey.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return func(b *cryptobyte.Builder) {
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(sharedSecret) })
+	}, nil
+}
+
+func (a *Agent) lock(fields cryptobyte.String) error {
+	a.mu.RLock()
+	locked := a.locked
+	a.mu.RUnlock()
+	if locked {
+		return errLocked
+	}
+	var passphrase cryptobyte.String
+	if !fields.ReadUint16LengthPrefixed(&passphrase) || !fields.Empty() {
+		return errMalformed
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	hash := hashPassphrase(passphrase, salt)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.locked {
+		return errLocked
+	}
+	a.locked, a.lockSalt, a.lockHash = true, salt, hash
+	return nil
+}
+
+func (a *Agent) unlock(fields cryptobyte.String) error {
+	var passphrase cryptobyte.String
+	if !fields.ReadUint16LengthPrefixed(&passphrase) || !fields.Empty() {
+		return errMalformed
+	}
+	a.mu.RLock()
+	locked, salt, lockHash := a.locked, a.lockSalt, a.lockHash
+	a.mu.RUnlock()
+	if !locked || subtle.ConstantTimeCompare(hashPassphrase(passphrase, salt), lockHash) != 1 {
+		return errUnlockFailed
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// The agent may have been unlocked, and locked again with another
+	// passphrase, while the passphrase was hashed.
+	if !a.locked || !bytes.Equal(a.lockHash, lockHash) {
+		return errUnlockFailed
+	}
+	a.locked, a.lockSalt, a.lockHash = false, nil, nil
+	return nil
+}
+
+// key returns the key with a name, or nil.
+func (a *Agent) key(name string) *agentKey {
+	for _, k := range a.keys {
+		if k.Name == name {
+			return k
+		}
+	}
+	return nil
+}
+
+// hashPassphrase hashes a lock passphrase with Argon2id, so that it is
+// slow to guess from the memory of the agent.
+func hashPassphrase(passphrase, salt []byte) []byte {
+	return argon2.IDKey(passphrase, salt, 1, 64*1024, 4, 32)
+}
@@ -0,0 +1,300 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+	"github.com/Rohith04MVK/goKyber/securemem"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+type testKey struct {
+	name       string
+	params     *gokyber.ParameterSet
+	publicKey  []byte
+	privateKey []byte
+}
+
+// startAgent serves an agent holding a Kyber-768 and an ML-KEM-512 key on
+// a socket in a temporary directory.
+func startAgent(t *testing.T) (*Agent, string, []testKey) {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("the agent checks peer credentials only on Linux")
+	}
+	a := New()
+	var keys []testKey
+	for _, k := range []struct {
+		name   string
+		params *gokyber.ParameterSet
+	}{{"alice", gokyber.Kyber768}, {"bob", gokyber.MLKEM512}} {
+		privateKey, publicKey, err := k.params.KemKeypair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf, err := securemem.NewFromBytes(append([]byte{}, privateKey...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := a.Add(k.name, k.params, buf); err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, testKey{k.name, k.params, publicKey, privateKey})
+	}
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go a.Serve(l)
+	t.Cleanup(func() {
+		l.Close()
+		a.Close()
+	})
+	return a, socket, keys
+}
+
+func dial(t *testing.T, socket string) *Client {
+	t.Helper()
+	c, err := Dial(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestList(t *testing.T) {
+	_, socket, keys := startAgent(t)
+	listed, err := dial(t, socket).List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != len(keys) {
+		t.Fatalf("%d keys listed", len(listed))
+	}
+	for i, k := range keys {
+		if listed[i].Name != k.name || listed[i].Params != k.params || !bytes.Equal(listed[i].PublicKey, k.publicKey) {
+			t.Errorf("key %d: %s %v", i, listed[i].Name, listed[i].Params)
+		}
+	}
+}
+
+func TestDecapsulate(t *testing.T) {
+	_, socket, keys := startAgent(t)
+	c := dial(t, socket)
+	for _, k := range keys {
+		ciphertext, sharedSecret, err := k.params.KemEncrypt(k.publicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range []Decapsulator{c.Decapsulator(k.name), NewLocalDecapsulator(k.params, k.privateKey)} {
+			got, err := d.Decapsulate(ciphertext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, sharedSecret) {
+				t.Errorf("%s: shared secrets differ", k.name)
+			}
+		}
+	}
+	if _, err := c.Decapsulate("carol", make([]byte, gokyber.Kyber768.CiphertextBytes())); err == nil {
+		t.Error("decapsulated with a missing key")
+	}
+	if _, err := c.Decapsulate("alice", make([]byte, 10)); err == nil {
+		t.Error("decapsulated a short ciphertext")
+	}
+	// The connection survives failures.
+	if _, err := c.List(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConcurrentClients(t *testing.T) {
+	_, socket, keys := startAgent(t)
+	k := keys[0]
+	shared := dial(t, socket)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		// Half the goroutines share a connection.
+		c := shared
+		if i%2 == 1 {
+			c = dial(t, socket)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				ciphertext, sharedSecret, err := k.params.KemEncrypt(k.publicKey)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				got, err := c.Decapsulate(k.name, ciphertext)
+				if err != nil || !bytes.Equal(got, sharedSecret) {
+					t.Errorf("decapsulation failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLock(t *testing.T) {
+	_, socket, keys := startAgent(t)
+	c := dial(t, socket)
+	other := dial(t, socket)
+	ciphertext, _, err := keys[0].params.KemEncrypt(keys[0].publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Unlock([]byte("pw")); err == nil {
+		t.Error("unlocked an unlocked agent")
+	}
+	if err := c.Lock([]byte("pw")); err != nil {
+		t.Fatal(err)
+	}
+	// Locking applies to every connection.
+	if _, err := other.List(); err == nil {
+		t.Error("listed keys of a locked agent")
+	}
+	if _, err := other.Decapsulate(keys[0].name, ciphertext); err == nil {
+		t.Error("decapsulated with a locked agent")
+	}
+	if err := other.Lock([]byte("other")); err == nil {
+		t.Error("locked a locked agent")
+	}
+	if err := other.Unlock([]byte("wrong")); err == nil {
+		t.Error("unlocked with the wrong passphrase")
+	}
+	if err := other.Unlock([]byte("pw")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Decapsulate(keys[0].name, ciphertext); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStalledClient(t *testing.T) {
+	_, socket, keys := startAgent(t)
+	// A peer that sends requests without reading the replies fills its
+	// socket buffers and blocks the writes to it. The requests are sent at
+	// once, as many small writes would fill the buffers of the peer first.
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var requests bytes.Buffer
+	for i := 0; i < 2000; i++ {
+		writeMessage(&requests, msgListKeys, nil)
+	}
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := conn.Write(requests.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	c := dial(t, socket)
+	ciphertext, _, err := keys[0].params.KemEncrypt(keys[0].publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() {
+		if _, err := c.List(); err != nil {
+			done <- err
+			return
+		}
+		if _, err := c.Decapsulate(keys[0].name, ciphertext); err != nil {
+			done <- err
+			return
+		}
+		if err := c.Lock([]byte("pw")); err != nil {
+			done <- err
+			return
+		}
+		done <- c.Unlock([]byte("pw"))
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("requests stalled by another connection")
+	}
+}
+
+func TestMalformedRequests(t *testing.T) {
+	_, socket, _ := startAgent(t)
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Requests of unknown types and with trailing fields get failures.
+	for _, msg := range [][]byte{{99}, {msgListKeys, 0}, {msgDecapsulate, 5, 'a'}} {
+		if err := writeMessage(conn, msg[0], func(b *cryptobyte.Builder) { b.AddBytes(msg[1:]) }); err != nil {
+			t.Fatal(err)
+		}
+		msgType, _, err := readMessage(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msgType != msgFailure {
+			t.Errorf("request %x: response type %d", msg, msgType)
+		}
+	}
+
+	// An oversized message closes the connection.
+	if _, err := conn.Write(binary.BigEndian.AppendUint32(nil, maxMessageBytes+1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("connection not closed: %v", err)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	a := New()
+	defer a.Close()
+	privateKey, _, err := gokyber.Kyber512.KemKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newBuffer := func(b []byte) *securemem.Buffer {
+		buf, err := securemem.NewFromBytes(append([]byte{}, b...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf
+	}
+	if err := a.Add("alice", gokyber.Kyber512, newBuffer(privateKey)); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Add("alice", gokyber.Kyber512, newBuffer(privateKey)); err == nil {
+		t.Error("added a duplicate key")
+	}
+	if err := a.Add("bob", gokyber.Kyber768, newBuffer(privateKey)); err == nil {
+		t.Error("added a key of the wrong length")
+	}
+	if err := a.Add("", gokyber.Kyber512, newBuffer(privateKey)); err == nil {
+		t.Error("added a key without a name")
+	}
+	buf := a.keys[0].privateKey
+	a.Close()
+	if buf.Bytes() != nil {
+		t.Error("Close did not destroy the private keys")
+	}
+}
@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// Decapsulator decapsulates shared secrets with a private key, which it
+// need not expose.
+type Decapsulator interface {
+	Decapsulate(ciphertext []byte) ([]byte, error)
+}
+
+// localDecapsulator is a Decapsulator holding its private key.
+type localDecapsulator struct {
+	kem        gokyber.KEM
+	privateKey []byte
+}
+
+// NewLocalDecapsulator returns a Decapsulator using a private key in
+// memory, for code that works with or without an agent.
+func NewLocalDecapsulator(kem gokyber.KEM, privateKey []byte) Decapsulator {
+	return &localDecapsulator{kem: kem, privateKey: privateKey}
+}
+
+func (d *localDecapsulator) Decapsulate(ciphertext []byte) ([]byte, error) {
+	return d.kem.KemDecrypt(ciphertext, d.privateKey)
+}
+
+// Client is a connection to an agent. Its methods may be called
+// concurrently.
+type Client struct {
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// Dial connects to the agent listening on a Unix domain socket.
+func Dial(socket string) (*Client, error) {
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient returns a client of the agent at the other end of a
+// connection.
+func NewClient(conn net.Conn) *Client {
+	return &Client{conn: conn}
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// call sends a request and returns the fields of the response, which must
+// be of the expected type or a failure.
+func (c *Client) call(msgType byte, add func(b *cryptobyte.Builder), responseType byte) (cryptobyte.String, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := writeMessage(c.conn, msgType, add); err != nil {
+		return nil, err
+	}
+	gotType, fields, err := readMessage(c.conn)
+	if err != nil {
+		return nil, err
+	}
+	switch gotType {
+	case responseType:
+		return fields, nil
+	case msgFailure:
+		var message cryptobyte.String
+		if !fields.ReadUint16LengthPrefixed(&message) || !fields.Empty() {
+			return nil, errors.New("agent: malformed failure response")
+		}
+		return nil, fmt.Errorf("agent: %s", message)
+	}
+	return nil, fmt.Errorf("agent: unexpected response type %d", gotType)
+}
+
+// List returns the keys of the agent.
+func (c *Client) List() ([]Key, error) {
+	fields, err := c.call(msgListKeys, nil, msgKeyList)
+	if err != nil {
+		return nil, err
+	}
+	var keys []Key
+	for !fields.Empty() {
+		var name, algorithm, publicKey cryptobyte.String
+		if !fields.ReadUint8LengthPrefixed(&name) || !fields.ReadUint8LengthPrefixed(&algorithm) ||
+			!fields.ReadUint24LengthPrefixed(&publicKey) {
+			return nil, errors.New("agent: malformed key list")
+		}
+		params, err := gokyber.ParameterSetByName(string(algorithm))
+		if err != nil {
+			return nil, fmt.Errorf("agent: key %q: %w", name, err)
+		}
+		keys = append(keys, Key{Name: string(name), Params: params, PublicKey: append([]byte{}, publicKey...)})
+	}
+	return keys, nil
+}
+
+// Decapsulate decapsulates a ciphertext with the key named name.
+func (c *Client) Decapsulate(name string, ciphertext []byte) ([]byte, error) {
+	if name == "" || len(name) > 255 {
+		return nil, errors.New("agent: invalid key name")
+	}
+	fields, err := c.call(msgDecapsulate, func(b *cryptobyte.Builder) {
+		b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes([]byte(name)) })
+		b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(ciphertext) })
+	}, msgSharedSecret)
+	if err != nil {
+		return nil, err
+	}
+	var sharedSecret cryptobyte.String
+	if !fields.ReadUint16LengthPrefixed(&sharedSecret) || !fields.Empty() {
+		return nil, errors.New("agent: malformed shared secret response")
+	}
+	return sharedSecret, nil
+}
+
+// Lock locks the agent with a passphrase: it refuses every request until
+// unlocked with the same passphrase.
+func (c *Client) Lock(passphrase []byte) error {
+	return c.lockRequest(msgLock, passphrase)
+}
+
+// Unlock unlocks an agent locked with Lock.
+func (c *Client) Unlock(passphrase []byte) error {
+	return c.lockRequest(msgUnlock, passphrase)
+}
+
+func (c *Client) lockRequest(msgType byte, passphrase []byte) error {
+	_, err := c.call(msgType, func(b *cryptobyte.Builder) {
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(passphrase) })
+	}, msgSuccess)
+	return err
+}
+
+// clientDecapsulator is the Decapsulator of a key of an agent.
+type clientDecapsulator struct {
+	client *Client
+	name   string
+}
+
+// Decapsulator returns the Decapsulator of the key of the agent named name.
+func (c *Client) Decapsulator(name string) Decapsulator {
+	return &clientDecapsulator{client: c, name: name}
+}
+
+func (d *clientDecapsulator) Decapsulate(ciphertext []byte) ([]byte, error) {
+	return d.client.Decapsulate(d.name, ciphertext)
+}
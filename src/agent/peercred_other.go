@@ -0,0 +1,14 @@
+//go:build !linux
+
+package agent
+
+import (
+	"errors"
+	"net"
+)
+
+// checkPeer refuses every peer: without SO_PEERCRED, the agent cannot
+// check who connects.
+func checkPeer(conn *net.UnixConn) error {
+	return errors.New("agent: peer credentials are only checked on Linux")
+}
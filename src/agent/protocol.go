@@ -0,0 +1,104 @@
+// Package agent implements a key agent, in the manner of ssh-agent, that
+// holds unlocked private keys and decapsulates ciphertexts for clients over
+// a Unix domain socket, so that applications never hold private keys.
+//
+// Messages are a four-octet big-endian length followed by a one-octet type
+// and its fields, whose variable-length fields carry length prefixes:
+//
+//	list-keys     (1)
+//	decapsulate   (2) name<1>, ciphertext<3>
+//	lock          (3) passphrase<2>
+//	unlock        (4) passphrase<2>
+//	success       (5)
+//	failure       (6) message<2>
+//	key-list      (7) { name<1>, algorithm<1>, public key<3> }*
+//	shared-secret (8) secret<2>
+//
+// Every request gets one response: key-list for list-keys, shared-secret
+// for decapsulate, success or failure otherwise. A locked agent answers
+// every request but unlock with a failure. The agent only serves peers
+// running as its own user, as checked with SO_PEERCRED, which limits it to
+// Linux.
+package agent
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// SocketEnv is the environment variable holding the socket of the agent.
+const SocketEnv = "GOKYBER_AGENT_SOCK"
+
+// Message types.
+const (
+	msgListKeys     = 1
+	msgDecapsulate  = 2
+	msgLock         = 3
+	msgUnlock       = 4
+	msgSuccess      = 5
+	msgFailure      = 6
+	msgKeyList      = 7
+	msgSharedSecret = 8
+)
+
+// maxMessageBytes bounds messages, far above the largest ciphertext.
+const maxMessageBytes = 256 * 1024
+
+// Key is a key held by an agent.
+type Key struct {
+	Name      string
+	Params    *gokyber.ParameterSet
+	PublicKey []byte
+}
+
+// writeMessage writes a message of a type with the fields added by add.
+func writeMessage(w io.Writer, msgType byte, add func(b *cryptobyte.Builder)) error {
+	var b cryptobyte.Builder
+	b.AddUint32LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddUint8(msgType)
+		if add != nil {
+			add(b)
+		}
+	})
+	msg, err := b.Bytes()
+	if err != nil {
+		return err
+	}
+	if len(msg)-4 > maxMessageBytes {
+		return errors.New("agent: message too large")
+	}
+	_, err = w.Write(msg)
+	return err
+}
+
+// readMessage reads a message and returns its type and fields.
+func readMessage(r io.Reader) (byte, cryptobyte.String, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(length[:])
+	if n == 0 || n > maxMessageBytes {
+		return 0, nil, fmt.Errorf("agent: invalid message length %d", n)
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+	return msg[0], cryptobyte.String(msg[1:]), nil
+}
+
+// writeFailure writes a failure message.
+func writeFailure(w io.Writer, message string) error {
+	return writeMessage(w, msgFailure, func(b *cryptobyte.Builder) {
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes([]byte(message)) })
+	})
+}
@@ -0,0 +1,99 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+// The figures published in the round-3 Kyber specification.
+var specificationFigures = []struct {
+	params           *gokyber.ParameterSet
+	failureLog2      float64
+	primalBlockSize  int
+	classicalCoreSVP float64
+}{
+	{gokyber.Kyber512, -139, 406, 118},
+	{gokyber.Kyber768, -164, 624, 182},
+	{gokyber.Kyber1024, -174, 874, 255},
+}
+
+func TestFailureProbabilityMatchesSpecification(t *testing.T) {
+	for _, v := range specificationFigures {
+		got, err := FailureProbability(v.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-v.failureLog2) > 1.5 {
+			t.Errorf("%s: failure probability 2^%.2f, want about 2^%.0f", v.params.Name, got, v.failureLog2)
+		}
+	}
+}
+
+func TestEstimateSecurityMatchesSpecification(t *testing.T) {
+	for _, v := range specificationFigures {
+		got, err := EstimateSecurity(v.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Primal.BlockSize != v.primalBlockSize {
+			t.Errorf("%s: primal block size %d, want %d", v.params.Name, got.Primal.BlockSize, v.primalBlockSize)
+		}
+		if math.Abs(got.Primal.Classical-v.classicalCoreSVP) > 1 {
+			t.Errorf("%s: primal classical cost %.1f, want about %.0f", v.params.Name, got.Primal.Classical, v.classicalCoreSVP)
+		}
+		if got.Classical() > got.Primal.Classical || got.Quantum() >= got.Classical() {
+			t.Errorf("%s: inconsistent security levels %+v", v.params.Name, got)
+		}
+	}
+}
+
+func TestDistributionsSumToOne(t *testing.T) {
+	for _, d := range []Distribution{
+		CenteredBinomial(2),
+		CenteredBinomial(3),
+		CompressionError(3329, 4),
+		CompressionError(3329, 11),
+		Product(CenteredBinomial(2), CenteredBinomial(3)),
+		ConvolvePower(CenteredBinomial(2), 37),
+	} {
+		sum := 0.0
+		for _, p := range d.Probability {
+			sum += p
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Errorf("distribution on [%d, %d] sums to %v", d.Min, d.Max(), sum)
+		}
+	}
+
+	// The sum of 37 centered binomials of parameter 2 is a centered
+	// binomial of parameter 74.
+	power := ConvolvePower(CenteredBinomial(2), 37)
+	want := CenteredBinomial(74)
+	for x := -74; x <= 74; x++ {
+		if math.Abs(power.At(x)-want.At(x)) > 1e-12 {
+			t.Fatalf("P(%d) = %v, want %v", x, power.At(x), want.At(x))
+		}
+	}
+}
+
+func TestFailureProbabilityGrowsWithNoise(t *testing.T) {
+	narrow := *gokyber.Kyber768
+	wide := narrow
+	wide.Eta1 = 4
+	a, err := FailureProbability(&narrow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := FailureProbability(&wide)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b <= a {
+		t.Errorf("eta1 = 4 fails with probability 2^%.1f, eta1 = 2 with 2^%.1f", b, a)
+	}
+	if _, err := FailureProbability(&gokyber.ParameterSet{K: 2}); err == nil {
+		t.Error("invalid parameter set accepted")
+	}
+}
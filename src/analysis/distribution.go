@@ -0,0 +1,178 @@
+// Package analysis computes the decryption failure probability and an
+// estimate of the lattice security of Kyber parameter sets.
+//
+// The computations follow the scripts published with the Kyber
+// specification: the failure probability is obtained by convolving the exact
+// distributions of every noise term of a decryption, and the security level
+// is the "core-SVP" cost of the best primal and dual attacks found by
+// searching over the BKZ block size and the number of LWE samples used.
+package analysis
+
+import (
+	"math"
+	"sort"
+)
+
+// negligible is the probability below which an outcome is dropped from a
+// distribution while convolving, to keep the supports small. It matches the
+// threshold of the reference scripts.
+var negligible = math.Ldexp(1, -300)
+
+// Distribution is the law of an integer-valued random variable: Probability[i]
+// is the probability of the value Min + i.
+type Distribution struct {
+	Min         int
+	Probability []float64
+}
+
+// Max returns the largest value of the support of the distribution.
+func (d Distribution) Max() int {
+	return d.Min + len(d.Probability) - 1
+}
+
+// At returns the probability of the value x.
+func (d Distribution) At(x int) float64 {
+	if x < d.Min || x > d.Max() {
+		return 0
+	}
+	return d.Probability[x-d.Min]
+}
+
+// CenteredBinomial returns the law of the centered binomial distribution
+// with parameter eta: the difference of two sums of eta uniform bits.
+func CenteredBinomial(eta int) Distribution {
+	d := Distribution{Min: -eta, Probability: make([]float64, 2*eta+1)}
+	for x := -eta; x <= eta; x++ {
+		// P(x) = C(2 eta, eta + x) / 2^(2 eta)
+		d.Probability[x+eta] = math.Exp(logBinomial(2*eta, eta+x) - float64(2*eta)*math.Ln2)
+	}
+	return d
+}
+
+// CompressionError returns the law of `Decompress(Compress(x, d), d) - x`,
+// taken as a centered representative modulo q, for x uniform in [0, q). It is
+// computed with the same rounding as the compression of ciphertexts.
+func CompressionError(q, d int) Distribution {
+	counts := make(map[int]int)
+	for x := 0; x < q; x++ {
+		t := ((x << d) + q/2) / q & (1<<d - 1)
+		y := (t*q + (1 << (d - 1))) >> d
+		counts[centered(y-x, q)]++
+	}
+	values := make([]int, 0, len(counts))
+	for v := range counts {
+		values = append(values, v)
+	}
+	sort.Ints(values)
+	dist := Distribution{Min: values[0], Probability: make([]float64, values[len(values)-1]-values[0]+1)}
+	for v, c := range counts {
+		dist.Probability[v-dist.Min] = float64(c) / float64(q)
+	}
+	return dist
+}
+
+// Convolve returns the law of the sum of two independent variables.
+func Convolve(a, b Distribution) Distribution {
+	c := Distribution{Min: a.Min + b.Min, Probability: make([]float64, len(a.Probability)+len(b.Probability)-1)}
+	for i, pa := range a.Probability {
+		if pa == 0 {
+			continue
+		}
+		for j, pb := range b.Probability {
+			c.Probability[i+j] += pa * pb
+		}
+	}
+	return c.trim()
+}
+
+// Product returns the law of the product of two independent variables.
+func Product(a, b Distribution) Distribution {
+	products := make(map[int]float64)
+	for i, pa := range a.Probability {
+		for j, pb := range b.Probability {
+			products[(a.Min+i)*(b.Min+j)] += pa * pb
+		}
+	}
+	minimum, maximum := 0, 0
+	for v := range products {
+		minimum, maximum = min(minimum, v), max(maximum, v)
+	}
+	c := Distribution{Min: minimum, Probability: make([]float64, maximum-minimum+1)}
+	for v, p := range products {
+		c.Probability[v-minimum] = p
+	}
+	return c.trim()
+}
+
+// ConvolvePower returns the law of the sum of `count` independent copies of
+// a variable, computed by square-and-multiply.
+func ConvolvePower(a Distribution, count int) Distribution {
+	result := Distribution{Min: 0, Probability: []float64{1}}
+	for bit := bitLength(count) - 1; bit >= 0; bit-- {
+		result = Convolve(result, result)
+		if count>>bit&1 == 1 {
+			result = Convolve(result, a)
+		}
+	}
+	return result
+}
+
+// Tail returns the probability that the absolute value of the variable is
+// strictly greater than bound.
+func (d Distribution) Tail(bound float64) float64 {
+	// Sum from the extremes inwards so that the smallest terms are added
+	// first.
+	tail := 0.0
+	for i := range d.Probability {
+		if x := d.Min + i; math.Abs(float64(x)) > bound {
+			tail += d.Probability[i]
+		} else {
+			break
+		}
+	}
+	for i := len(d.Probability) - 1; i >= 0; i-- {
+		if x := d.Min + i; math.Abs(float64(x)) > bound {
+			tail += d.Probability[i]
+		} else {
+			break
+		}
+	}
+	return tail
+}
+
+// trim drops the negligible outcomes at both ends of the support.
+func (d Distribution) trim() Distribution {
+	lo, hi := 0, len(d.Probability)-1
+	for lo < hi && d.Probability[lo] < negligible {
+		lo++
+	}
+	for hi > lo && d.Probability[hi] < negligible {
+		hi--
+	}
+	return Distribution{Min: d.Min + lo, Probability: d.Probability[lo : hi+1]}
+}
+
+func centered(x, q int) int {
+	x %= q
+	if x > q/2 {
+		x -= q
+	} else if x < -q/2 {
+		x += q
+	}
+	return x
+}
+
+func logBinomial(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
+
+func bitLength(x int) int {
+	length := 0
+	for ; x > 0; x >>= 1 {
+		length++
+	}
+	return length
+}
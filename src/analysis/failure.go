@@ -0,0 +1,45 @@
+package analysis
+
+import (
+	"math"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+// DecryptionNoise returns the exact law of one coefficient of the noise
+// `eᵀr - sᵀ(e1 + cu) + e2 + cv` left in `v - sᵀu` after decrypting a
+// ciphertext of the parameter set, where `cu` and `cv` are the errors
+// introduced by compressing `u` and `v`. A coefficient is decoded to the
+// wrong message bit when this noise exceeds q/4 in absolute value.
+func DecryptionNoise(p *gokyber.ParameterSet) (Distribution, error) {
+	if err := p.Validate(); err != nil {
+		return Distribution{}, err
+	}
+	r := p.PolynomialRing()
+	secret := CenteredBinomial(p.Eta1)
+	publicError := CenteredBinomial(p.Eta1)
+	ciphertextError := CenteredBinomial(p.Eta2)
+
+	// eᵀr and sᵀ(e1 + cu) are each a sum of k*n products of independent
+	// coefficients.
+	er := ConvolvePower(Product(publicError, secret), p.K*r.N)
+	su := ConvolvePower(Product(secret, Convolve(ciphertextError, CompressionError(r.Q, p.Du))), p.K*r.N)
+	// e2 + cv
+	ev := Convolve(ciphertextError, CompressionError(r.Q, p.Dv))
+
+	return Convolve(Convolve(er, su), ev), nil
+}
+
+// FailureProbability returns the probability that decapsulating an honestly
+// generated ciphertext of the parameter set fails, as a base-2 logarithm. It
+// is the union bound over the n coefficients of the message of the
+// probability that one coefficient is decoded wrongly.
+func FailureProbability(p *gokyber.ParameterSet) (float64, error) {
+	noise, err := DecryptionNoise(p)
+	if err != nil {
+		return 0, err
+	}
+	r := p.PolynomialRing()
+	probability := float64(r.N) * noise.Tail(float64(r.Q)/4)
+	return math.Min(0, math.Log2(probability)), nil
+}
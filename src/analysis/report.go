@@ -0,0 +1,96 @@
+package analysis
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+// Report summarizes the sizes, the correctness and the security of a
+// parameter set.
+type Report struct {
+	Name            string `json:"name"`
+	K               int    `json:"k"`
+	Eta1            int    `json:"eta1"`
+	Eta2            int    `json:"eta2"`
+	Du              int    `json:"du"`
+	Dv              int    `json:"dv"`
+	PublicKeyBytes  int    `json:"public_key_bytes"`
+	PrivateKeyBytes int    `json:"private_key_bytes"`
+	CiphertextBytes int    `json:"ciphertext_bytes"`
+	// FailureLog2 is the base-2 logarithm of the decryption failure
+	// probability. Probabilities too small for a float64 are reported as
+	// -1074, the exponent of the smallest positive float64.
+	FailureLog2 float64 `json:"failure_log2"`
+	// ClassicalCoreSVP and QuantumCoreSVP are the security levels in bits:
+	// the cost of the cheapest attack.
+	ClassicalCoreSVP float64  `json:"classical_core_svp"`
+	QuantumCoreSVP   float64  `json:"quantum_core_svp"`
+	Attacks          Security `json:"attacks"`
+}
+
+// NewReport computes the report of a parameter set.
+func NewReport(p *gokyber.ParameterSet) (*Report, error) {
+	failure, err := FailureProbability(p)
+	if err != nil {
+		return nil, err
+	}
+	if math.IsInf(failure, -1) {
+		failure = -1074
+	}
+	security, err := EstimateSecurity(p)
+	if err != nil {
+		return nil, err
+	}
+	return &Report{
+		Name:             p.Name,
+		K:                p.K,
+		Eta1:             p.Eta1,
+		Eta2:             p.Eta2,
+		Du:               p.Du,
+		Dv:               p.Dv,
+		PublicKeyBytes:   p.PublicKeyBytes(),
+		PrivateKeyBytes:  p.PrivateKeyBytes(),
+		CiphertextBytes:  p.CiphertextBytes(),
+		FailureLog2:      failure,
+		ClassicalCoreSVP: security.Classical(),
+		QuantumCoreSVP:   security.Quantum(),
+		Attacks:          security,
+	}, nil
+}
+
+// WriteMarkdown writes the reports as a Markdown table, one row per
+// parameter set, followed by the details of the attacks.
+func WriteMarkdown(w io.Writer, reports []*Report) error {
+	var err error
+	printf := func(format string, args ...any) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+
+	printf("| Parameter set | k | η1 | η2 | du | dv | pk | sk | ct | δ | Core-SVP (classical) | Core-SVP (quantum) |\n")
+	printf("|---|---|---|---|---|---|---|---|---|---|---|---|\n")
+	for _, r := range reports {
+		printf("| %s | %d | %d | %d | %d | %d | %d | %d | %d | 2^%.1f | %.1f | %.1f |\n",
+			r.Name, r.K, r.Eta1, r.Eta2, r.Du, r.Dv,
+			r.PublicKeyBytes, r.PrivateKeyBytes, r.CiphertextBytes,
+			r.FailureLog2, r.ClassicalCoreSVP, r.QuantumCoreSVP)
+	}
+
+	printf("\n| Parameter set | Attack | m | β | Classical | Quantum | Paranoid |\n")
+	printf("|---|---|---|---|---|---|---|\n")
+	for _, r := range reports {
+		for _, a := range []struct {
+			name     string
+			estimate AttackEstimate
+		}{{"primal", r.Attacks.Primal}, {"dual", r.Attacks.Dual}} {
+			printf("| %s | %s | %d | %d | %.1f | %.1f | %.1f |\n",
+				r.Name, a.name, a.estimate.Samples, a.estimate.BlockSize,
+				a.estimate.Classical, a.estimate.Quantum, a.estimate.Paranoid)
+		}
+	}
+	return err
+}
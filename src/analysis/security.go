@@ -0,0 +1,139 @@
+package analysis
+
+import (
+	"math"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+// Cost models of one SVP call in dimension b, as log2 of the number of
+// operations per unit of block size: the best known classical and quantum
+// sieves, and the "paranoid" lower bound used by the Kyber specification.
+const (
+	classicalSieve = 0.292
+	quantumSieve   = 0.265
+	paranoidSieve  = 0.2075
+)
+
+// minBlockSize is the smallest BKZ block size considered. The root Hermite
+// factor model is not meaningful for smaller blocks; attacks on toy
+// parameters that need less than this are reported with this block size,
+// or with the full lattice dimension when it is smaller.
+const minBlockSize = 50
+
+// AttackEstimate is the cheapest instance of an attack found by the search.
+type AttackEstimate struct {
+	// Samples is the number of LWE samples used.
+	Samples int `json:"samples"`
+	// BlockSize is the BKZ block size needed.
+	BlockSize int `json:"block_size"`
+	// Classical, Quantum and Paranoid are the base-2 logarithms of the
+	// cost under the three sieving models.
+	Classical float64 `json:"classical"`
+	Quantum   float64 `json:"quantum"`
+	Paranoid  float64 `json:"paranoid"`
+}
+
+// Security is the core-SVP estimate of a parameter set.
+type Security struct {
+	Primal AttackEstimate `json:"primal"`
+	Dual   AttackEstimate `json:"dual"`
+}
+
+// Classical returns the classical core-SVP security level in bits: the cost
+// of the cheapest of the two attacks.
+func (s Security) Classical() float64 {
+	return math.Min(s.Primal.Classical, s.Dual.Classical)
+}
+
+// Quantum returns the quantum core-SVP security level in bits.
+func (s Security) Quantum() float64 {
+	return math.Min(s.Primal.Quantum, s.Dual.Quantum)
+}
+
+// EstimateSecurity searches for the cheapest primal and dual attacks on the
+// Module-LWE problem underlying the public key of the parameter set, seen as
+// an LWE instance of dimension k*n with at most k*n samples. Only the
+// core-SVP cost of one call to the SVP oracle on the final block is counted,
+// which is the conservative methodology of the Kyber specification; it is an
+// estimate, not a proof.
+func EstimateSecurity(p *gokyber.ParameterSet) (Security, error) {
+	if err := p.Validate(); err != nil {
+		return Security{}, err
+	}
+	r := p.PolynomialRing()
+	n := p.K * r.N
+	// Secret and error are both drawn from the centered binomial
+	// distribution of parameter eta1, of standard deviation sqrt(eta1 / 2).
+	sigma := math.Sqrt(float64(p.Eta1) / 2)
+	return Security{
+		Primal: optimizeAttack(n, r.Q, sigma, primalBlockSize),
+		Dual:   optimizeAttack(n, r.Q, sigma, dualCost),
+	}, nil
+}
+
+// optimizeAttack tries every number of samples m and returns the attack of
+// least classical cost. cost returns the block size needed with m samples
+// and the extra log2 factor paid on top of one SVP call, or a block size of
+// zero if the attack does not apply.
+func optimizeAttack(n, q int, sigma float64, cost func(n, m, q int, sigma float64) (int, float64)) AttackEstimate {
+	best := AttackEstimate{Classical: math.Inf(1), Quantum: math.Inf(1), Paranoid: math.Inf(1)}
+	for m := n / 2; m <= n; m++ {
+		b, extra := cost(n, m, q, sigma)
+		if b == 0 {
+			continue
+		}
+		if c := classicalSieve*float64(b) + extra; c < best.Classical {
+			best = AttackEstimate{
+				Samples:   m,
+				BlockSize: b,
+				Classical: c,
+				Quantum:   quantumSieve*float64(b) + extra,
+				Paranoid:  paranoidSieve*float64(b) + extra,
+			}
+		}
+	}
+	return best
+}
+
+// logDelta returns the log of the root Hermite factor reached by BKZ with
+// block size b.
+func logDelta(b int) float64 {
+	fb := float64(b)
+	return (math.Log(math.Pi*fb)/fb + math.Log(fb/(2*math.Pi*math.E))) / (2*fb - 2)
+}
+
+// primalBlockSize returns the smallest block size for which BKZ finds the
+// unique shortest vector of the embedding lattice of dimension d = n + m + 1,
+// following the 2016 estimate of Alkim, Ducas, Pöppelmann and Schwabe:
+// sigma * sqrt(b) < delta^(2b - d - 1) * q^(m / d).
+func primalBlockSize(n, m, q int, sigma float64) (int, float64) {
+	d := n + m + 1
+	logQ := math.Log(float64(q))
+	for b := min(minBlockSize, d); b <= d; b++ {
+		lhs := math.Log(sigma) + math.Log(float64(b))/2
+		rhs := float64(2*b-d-1)*logDelta(b) + float64(m)/float64(d)*logQ
+		if lhs < rhs {
+			return b, 0
+		}
+	}
+	return 0, 0
+}
+
+// dualCost returns the block size minimizing the cost of the dual attack
+// with m samples and the log2 of the number of short dual vectors needed to
+// distinguish. Each sieve call provides (4/3)^(b/2) vectors for free.
+func dualCost(n, m, q int, sigma float64) (int, float64) {
+	d := n + m
+	bestB, bestExtra, bestCost := 0, 0.0, math.Inf(1)
+	for b := min(minBlockSize, d); b <= d; b++ {
+		logLength := float64(d)*logDelta(b) + float64(n)/float64(d)*math.Log(float64(q))
+		tau := math.Exp(logLength) * sigma / float64(q)
+		log2Advantage := -2 * math.Pi * math.Pi * tau * tau / math.Ln2
+		extra := math.Max(0, -2*log2Advantage-paranoidSieve*float64(b))
+		if c := classicalSieve*float64(b) + extra; c < bestCost {
+			bestB, bestExtra, bestCost = b, extra, c
+		}
+	}
+	return bestB, bestExtra
+}
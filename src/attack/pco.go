@@ -0,0 +1,250 @@
+// Package attack demonstrates why Kyber is only ever used through its KEM.
+//
+// The IND-CPA scheme exported by package gokyber is secure against passive
+// attackers only. An attacker who can submit ciphertexts of their choice
+// and learn whether each one decrypts to a message of their choice (a
+// plaintext-checking oracle) recovers the whole private key with a few
+// queries per coefficient. Such an oracle arises naturally whenever a
+// protocol reacts differently to a correct and an incorrect decryption.
+//
+// The Fujisaki-Okamoto transform in KemDecrypt closes the hole: every
+// decrypted message is re-encrypted and compared with the ciphertext, so the
+// hand-crafted ciphertexts of the attack are all implicitly rejected and the
+// oracle never learns anything.
+package attack
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+// Oracle reports whether a ciphertext decrypts to the given message.
+type Oracle func(ciphertext, message []byte) bool
+
+// IndcpaOracle returns the plaintext-checking oracle of an IND-CPA private
+// key: it decrypts with IndcpaDecrypt and compares the result.
+func IndcpaOracle(p *gokyber.ParameterSet, privateKey []byte) Oracle {
+	return func(ciphertext, message []byte) bool {
+		decrypted, err := p.IndcpaDecrypt(ciphertext, privateKey)
+		return err == nil && bytes.Equal(decrypted, message)
+	}
+}
+
+// KemOracle returns the plaintext-checking oracle of a KEM private key. The
+// attacker derives the shared secret that encapsulating the message would
+// give and compares it with the output of KemDecrypt, which is the best a
+// plaintext check can do through the KEM interface.
+func KemOracle(p *gokyber.ParameterSet, publicKey, privateKey []byte) Oracle {
+	pkh := p.Symmetric.Hash256(publicKey)
+	return func(ciphertext, message []byte) bool {
+		sharedSecret, err := p.KemDecrypt(ciphertext, privateKey)
+		if err != nil {
+			return false
+		}
+		kr := p.Symmetric.Hash512(append(append([]byte{}, message...), pkh[:]...))
+		ch := p.Symmetric.Hash256(ciphertext)
+		expected := p.Symmetric.Kdf(append(kr[:32], ch[:]...))
+		return bytes.Equal(sharedSecret, expected[:])
+	}
+}
+
+// Result is the outcome of a key-recovery attack.
+type Result struct {
+	// PrivateKey is the recovered IND-CPA private key.
+	PrivateKey []byte
+	// Secret holds the recovered coefficients of the secret vector `s`, k
+	// rows of n coefficients in [-eta1, eta1].
+	Secret [][]int
+	// Queries is the number of oracle queries made.
+	Queries int
+}
+
+// query is a chosen ciphertext targeting one coefficient: `u` is the
+// constant polynomial `U` in the targeted row and `v` is `V * X^i` for the
+// targeted coefficient `i`. It decrypts to the message whose only possibly
+// nonzero bit is bit `i`, equal to the decoding of `V - U * s_i`. ones is
+// the set of candidate values of `s_i`, as a bit mask indexed by s_i + eta1,
+// for which that bit is one.
+type query struct {
+	U, V int
+	ones uint32
+}
+
+// RecoverPrivateKey recovers the IND-CPA private key matching a public key
+// by querying a plaintext-checking oracle. Each coefficient of the secret
+// is found by an adaptive binary search over its 2*eta1 + 1 possible values.
+// The recovered key is checked by decrypting a fresh ciphertext; an error is
+// returned if the oracle answers are inconsistent or the check fails.
+func RecoverPrivateKey(p *gokyber.ParameterSet, publicKey []byte, oracle Oracle) (*Result, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if len(publicKey) != p.PublicKeyBytes() {
+		return nil, errors.New("invalid public key length")
+	}
+	ring := p.PolynomialRing()
+	queries := chooseQueries(p)
+	if len(queries) == 0 {
+		return nil, errors.New("no chosen ciphertext separates the secret coefficients")
+	}
+
+	result := &Result{Secret: make([][]int, p.K)}
+	zero := make([]byte, p.MessageBytes())
+	all := uint32(1)<<(2*p.Eta1+1) - 1
+	for row := 0; row < p.K; row++ {
+		result.Secret[row] = make([]int, ring.N)
+		for i := 0; i < ring.N; i++ {
+			candidates := all
+			for candidates&(candidates-1) != 0 {
+				q, ok := bestQuery(queries, candidates)
+				if !ok {
+					return nil, fmt.Errorf("coefficient %d of row %d cannot be narrowed down", i, row)
+				}
+				result.Queries++
+				if oracle(craftCiphertext(p, row, i, q), zero) {
+					candidates &^= q.ones
+				} else {
+					candidates &= q.ones
+				}
+			}
+			if candidates == 0 {
+				return nil, fmt.Errorf("inconsistent oracle answers for coefficient %d of row %d", i, row)
+			}
+			for candidates&1 == 0 {
+				candidates >>= 1
+				result.Secret[row][i]++
+			}
+			result.Secret[row][i] -= p.Eta1
+		}
+	}
+
+	secret := make(gokyber.PolynomialVector, p.K)
+	for row := range secret {
+		var s gokyber.Polynomial
+		for i, c := range result.Secret[row] {
+			s[i] = int16((c + ring.Q) % ring.Q)
+		}
+		secret[row] = ring.NTT(s)
+	}
+	result.PrivateKey = p.IndcpaPackPrivateKey(secret)
+
+	if err := checkPrivateKey(p, publicKey, result.PrivateKey); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// chooseQueries lists the chosen ciphertexts that leave every untargeted
+// coefficient decoding to zero whatever the secret, one per distinct split
+// of the candidate values. Only values that survive compression can be
+// placed in a ciphertext.
+func chooseQueries(p *gokyber.ParameterSet) []query {
+	q := p.PolynomialRing().Q
+	var queries []query
+	seen := make(map[uint32]bool)
+	for _, u := range compressible(q, p.Du) {
+		safe := true
+		for s := -p.Eta1; s <= p.Eta1; s++ {
+			if decodeBit(q, -u*s) != 0 {
+				safe = false
+				break
+			}
+		}
+		if !safe {
+			continue
+		}
+		for _, v := range compressible(q, p.Dv) {
+			var ones uint32
+			for s := -p.Eta1; s <= p.Eta1; s++ {
+				if decodeBit(q, v-u*s) == 1 {
+					ones |= 1 << (s + p.Eta1)
+				}
+			}
+			if !seen[ones] {
+				seen[ones] = true
+				queries = append(queries, query{U: u, V: v, ones: ones})
+			}
+		}
+	}
+	return queries
+}
+
+// bestQuery returns the query that splits the candidates most evenly.
+func bestQuery(queries []query, candidates uint32) (query, bool) {
+	var best query
+	bestSize := bitCount(candidates)
+	for _, q := range queries {
+		size := max(bitCount(candidates&q.ones), bitCount(candidates&^q.ones))
+		if size < bestSize {
+			best, bestSize = q, size
+		}
+	}
+	return best, bestSize < bitCount(candidates)
+}
+
+func bitCount(x uint32) int {
+	n := 0
+	for ; x != 0; x &= x - 1 {
+		n++
+	}
+	return n
+}
+
+// compressible returns the values `decompress(compress(x, d), d)`, the
+// only ones a ciphertext compressed to d bits can carry.
+func compressible(q, d int) []int {
+	var values []int
+	seen := make(map[int]bool)
+	for x := 0; x < q; x++ {
+		c := ((x << d) + q/2) / q & (1<<d - 1)
+		y := (c*q + 1<<(d-1)) >> d
+		if !seen[y] {
+			seen[y] = true
+			values = append(values, y)
+		}
+	}
+	return values
+}
+
+// decodeBit is the message bit a coefficient of `v - sᵀu` decodes to.
+func decodeBit(q, x int) int {
+	x = ((x % q) + q) % q
+	return ((x<<1 + q/2) / q) & 1
+}
+
+func craftCiphertext(p *gokyber.ParameterSet, row, i int, q query) []byte {
+	u := make(gokyber.PolynomialVector, p.K)
+	u[row][0] = int16(q.U)
+	var v gokyber.Polynomial
+	v[i] = int16(q.V)
+	return p.IndcpaPackCiphertext(u, v)
+}
+
+// checkPrivateKey encrypts a random message to the public key and checks
+// that the private key decrypts it.
+func checkPrivateKey(p *gokyber.ParameterSet, publicKey, privateKey []byte) error {
+	message := make([]byte, p.MessageBytes())
+	coins := make([]byte, 32)
+	if _, err := rand.Read(message); err != nil {
+		return err
+	}
+	if _, err := rand.Read(coins); err != nil {
+		return err
+	}
+	ciphertext, err := p.IndcpaEncrypt(message, publicKey, coins)
+	if err != nil {
+		return err
+	}
+	decrypted, err := p.IndcpaDecrypt(ciphertext, privateKey)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(decrypted, message) {
+		return errors.New("recovered private key does not decrypt: the oracle answers were not about the real key")
+	}
+	return nil
+}
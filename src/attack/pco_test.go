@@ -0,0 +1,94 @@
+package attack
+
+import (
+	"bytes"
+	"testing"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+func TestRecoverPrivateKeyWithIndcpaOracle(t *testing.T) {
+	for _, p := range []*gokyber.ParameterSet{gokyber.Kyber512, gokyber.Kyber768, gokyber.Kyber1024, gokyber.Kyber768_90s} {
+		privateKey, publicKey, err := p.IndcpaKeypair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := RecoverPrivateKey(p, publicKey, IndcpaOracle(p, privateKey))
+		if err != nil {
+			t.Fatalf("%s: %v", p.Name, err)
+		}
+		if !bytes.Equal(result.PrivateKey, privateKey) {
+			t.Errorf("%s: recovered private key differs from the real one", p.Name)
+		}
+		// A binary search over 2*eta1 + 1 values needs at most
+		// ceil(log2(2*eta1 + 1)) queries per coefficient.
+		coefficients := p.K * p.PolynomialRing().N
+		if limit := 3 * coefficients; result.Queries > limit {
+			t.Errorf("%s: %d queries, want at most %d", p.Name, result.Queries, limit)
+		}
+	}
+}
+
+func TestRecoverPrivateKeyFailsAgainstKem(t *testing.T) {
+	p := gokyber.Kyber768
+	privateKey, publicKey, err := p.KemKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := RecoverPrivateKey(p, publicKey, KemOracle(p, publicKey, privateKey))
+	if err == nil {
+		t.Fatal("attack succeeded against the KEM")
+	}
+	if result != nil && bytes.Equal(result.PrivateKey, privateKey[:p.IndcpaSecretKeyBytes()]) {
+		t.Fatal("attack recovered the KEM private key")
+	}
+}
+
+func TestKemOracleAcceptsHonestCiphertexts(t *testing.T) {
+	// The oracle itself is sound: it recognizes the message of an honest
+	// encapsulation, so the attack fails because of the re-encryption check
+	// alone.
+	p := gokyber.Kyber512
+	privateKey, publicKey, err := p.KemKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	indcpaPrivateKey := privateKey[:p.IndcpaSecretKeyBytes()]
+	ciphertext, _, err := p.KemEncrypt(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := p.IndcpaDecrypt(ciphertext, indcpaPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oracle := KemOracle(p, publicKey, privateKey)
+	if !oracle(ciphertext, message) {
+		t.Error("oracle rejected the message of an honest encapsulation")
+	}
+	message[0] ^= 1
+	if oracle(ciphertext, message) {
+		t.Error("oracle accepted a wrong message")
+	}
+}
+
+func TestRecoverPrivateKeyOnToyRing(t *testing.T) {
+	// The modulus is large enough against the noise that an honest
+	// decryption, with which the recovered key is checked, does not fail.
+	ring, err := gokyber.NewRing(64, 641)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &gokyber.ParameterSet{Name: "toy", K: 2, Eta1: 2, Eta2: 2, Du: 8, Dv: 6, Symmetric: gokyber.SymmetricSHAKE, Ring: ring}
+	privateKey, publicKey, err := p.IndcpaKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := RecoverPrivateKey(p, publicKey, IndcpaOracle(p, privateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(result.PrivateKey, privateKey) {
+		t.Error("recovered private key differs from the real one")
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "net"
+
+// listenUnix listens on a new Unix domain socket. Without a umask, the
+// socket is protected by its directory until it is chmodded.
+func listenUnix(socket string) (net.Listener, error) {
+	return net.Listen("unix", socket)
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package main
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenUnix listens on a new Unix domain socket with mode 0600. The umask
+// masks the group and other permissions while the socket is created, so
+// that it is never connectable by other users, even before it is chmodded.
+func listenUnix(socket string) (net.Listener, error) {
+	old := syscall.Umask(0o177)
+	defer syscall.Umask(old)
+	return net.Listen("unix", socket)
+}
@@ -0,0 +1,144 @@
+// Command gokyber-agent holds unlocked private keys and decapsulates
+// ciphertexts for the applications of its user over a Unix domain socket,
+// so that they never read private keys themselves.
+//
+// Usage:
+//
+//	gokyber-agent [-socket path] [-keystore private_keys] [-algorithm Kyber768] name...
+//
+// It unlocks the named keys of the key store with passphrases read from
+// standard input, prints the shell command setting GOKYBER_AGENT_SOCK to
+// the socket, and serves until interrupted, when it removes the socket and
+// wipes the keys. The socket defaults to gokyber-agent.sock in
+// $XDG_RUNTIME_DIR, or in a new private temporary directory.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/Rohith04MVK/goKyber/agent"
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+	"github.com/Rohith04MVK/goKyber/keystore"
+)
+
+func main() {
+	socket := flag.String("socket", "", "path of the socket to listen on")
+	keystoreDir := flag.String("keystore", "private_keys", "key store directory")
+	algorithm := flag.String("algorithm", gokyber.Kyber768.Name, "parameter set of the keys, such as Kyber768 or ML-KEM-768")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fatal(errors.New("no keys given"))
+	}
+	params, err := gokyber.ParameterSetByName(*algorithm)
+	if err != nil {
+		fatal(err)
+	}
+
+	a := agent.New()
+	defer a.Close()
+	if err := loadKeys(a, *keystoreDir, params, flag.Args()); err != nil {
+		a.Close()
+		fatal(err)
+	}
+
+	if *socket == "" {
+		if *socket, err = defaultSocket(); err != nil {
+			a.Close()
+			fatal(err)
+		}
+	}
+	l, err := listen(*socket)
+	if err != nil {
+		a.Close()
+		fatal(err)
+	}
+	fmt.Printf("%s=%s; export %s;\n", agent.SocketEnv, *socket, agent.SocketEnv)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		l.Close()
+	}()
+	if err := a.Serve(l); !errors.Is(err, net.ErrClosed) {
+		fmt.Fprintln(os.Stderr, "gokyber-agent:", err)
+	}
+	os.Remove(*socket)
+}
+
+// loadKeys unlocks keys of the key store into the agent, reading their
+// passphrases from standard input.
+func loadKeys(a *agent.Agent, dir string, params *gokyber.ParameterSet, names []string) error {
+	keys, err := keystore.New(dir)
+	if err != nil {
+		return err
+	}
+	stdin := bufio.NewReader(os.Stdin)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "Passphrase for %s: ", name)
+		passphrase, err := stdin.ReadString('\n')
+		if err != nil && passphrase == "" {
+			return err
+		}
+		privateKey, err := keys.UnlockBuffer(name, []byte(strings.TrimSpace(passphrase)))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if err := a.Add(name, params, privateKey); err != nil {
+			privateKey.Destroy()
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// defaultSocket returns the default socket path, in the runtime directory
+// of the user or in a new directory only the user can enter.
+func defaultSocket() (string, error) {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "gokyber-agent.sock"), nil
+	}
+	dir, err := os.MkdirTemp("", "gokyber-agent-")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "agent.sock"), nil
+}
+
+// listen listens on a socket only the user can connect to, replacing the
+// socket of an agent that is no longer running.
+func listen(socket string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", socket); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("an agent is already listening on %s", socket)
+	}
+	if info, err := os.Lstat(socket); err == nil {
+		if info.Mode().Type() != os.ModeSocket {
+			return nil, fmt.Errorf("%s exists and is not a socket", socket)
+		}
+		os.Remove(socket)
+	}
+	l, err := listenUnix(socket)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(socket, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "gokyber-agent:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,70 @@
+// Command kyber-cca-demo recovers an IND-CPA private key with a
+// plaintext-checking oracle, then runs the same attack against the KEM and
+// shows it fail.
+//
+// Usage:
+//
+//	kyber-cca-demo [-variant 512|768|1024]
+//
+// See package attack for how the attack works.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Rohith04MVK/goKyber/attack"
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+func main() {
+	variant := flag.Int("variant", 768, "Kyber variant: 512, 768 or 1024")
+	flag.Parse()
+
+	params, err := gokyber.ParameterSetForVariant(*variant)
+	if err != nil {
+		fatal(err)
+	}
+	coefficients := params.K * params.PolynomialRing().N
+
+	fmt.Printf("== %s, IND-CPA decryption ==\n", params.Name)
+	privateKey, publicKey, err := params.IndcpaKeypair()
+	if err != nil {
+		fatal(err)
+	}
+	start := time.Now()
+	result, err := attack.RecoverPrivateKey(params, publicKey, attack.IndcpaOracle(params, privateKey))
+	if err != nil {
+		fatal(err)
+	}
+	fmt.Printf("Recovered %d secret coefficients in %v.\n", coefficients, time.Since(start).Round(time.Millisecond))
+	fmt.Printf("Oracle queries: %d (%.2f per coefficient)\n", result.Queries, float64(result.Queries)/float64(coefficients))
+	fmt.Printf("Recovered private key equals the real one: %v\n", bytes.Equal(result.PrivateKey, privateKey))
+	fmt.Printf("First coefficients of s: %v\n", result.Secret[0][:16])
+
+	fmt.Printf("\n== %s, KEM decapsulation ==\n", params.Name)
+	kemPrivateKey, kemPublicKey, err := params.KemKeypair()
+	if err != nil {
+		fatal(err)
+	}
+	result, err = attack.RecoverPrivateKey(params, kemPublicKey, attack.KemOracle(params, kemPublicKey, kemPrivateKey))
+	if err == nil {
+		fatal(fmt.Errorf("the attack unexpectedly succeeded against the KEM"))
+	}
+	fmt.Printf("Attack failed: %v\n", err)
+	if result != nil {
+		fmt.Printf("Oracle queries: %d\n", result.Queries)
+		fmt.Printf("Recovered private key equals the real one: %v\n",
+			bytes.Equal(result.PrivateKey, kemPrivateKey[:params.IndcpaSecretKeyBytes()]))
+	}
+	fmt.Println("Every chosen ciphertext failed the re-encryption check and was implicitly rejected,")
+	fmt.Println("so the oracle answered \"no\" regardless of the secret.")
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "kyber-cca-demo:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,154 @@
+// Command kyber-noise measures the decryption noise of random
+// encapsulations and compares it with the exact distribution computed by
+// package analysis.
+//
+// Usage:
+//
+//	kyber-noise [-samples 1000] [-variant 512|768|1024|all] [-bucket 32]
+//
+// For every parameter set it runs the given number of encapsulations, each
+// to a fresh key pair, decapsulates them and reports the decapsulation
+// failures, the largest noise and the smallest margin observed, and a
+// histogram of the noise of all coefficients next to the predicted one.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Rohith04MVK/goKyber/analysis"
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+func main() {
+	samples := flag.Int("samples", 1000, "number of encapsulations per parameter set")
+	variant := flag.String("variant", "all", "standard parameter set: 512, 768, 1024 or all")
+	bucket := flag.Int("bucket", 32, "width of the histogram buckets")
+	flag.Parse()
+	if *samples < 1 || *bucket < 1 {
+		fatal(fmt.Errorf("-samples and -bucket must be positive"))
+	}
+
+	var paramSets []*gokyber.ParameterSet
+	if *variant == "all" {
+		paramSets = []*gokyber.ParameterSet{gokyber.Kyber512, gokyber.Kyber768, gokyber.Kyber1024}
+	} else {
+		kyberVariant, err := strconv.Atoi(*variant)
+		if err != nil {
+			fatal(fmt.Errorf("invalid variant %q", *variant))
+		}
+		p, err := gokyber.ParameterSetForVariant(kyberVariant)
+		if err != nil {
+			fatal(err)
+		}
+		paramSets = append(paramSets, p)
+	}
+
+	for i, p := range paramSets {
+		if i > 0 {
+			fmt.Println()
+		}
+		if err := report(p, *samples, *bucket); err != nil {
+			fatal(err)
+		}
+	}
+}
+
+// report runs the encapsulations of one parameter set and prints the
+// comparison.
+func report(p *gokyber.ParameterSet, samples, bucket int) error {
+	predicted, err := analysis.DecryptionNoise(p)
+	if err != nil {
+		return err
+	}
+	failureProbability, err := analysis.FailureProbability(p)
+	if err != nil {
+		return err
+	}
+
+	n := p.PolynomialRing().N
+	q := p.PolynomialRing().Q
+	counts := make(map[int]int)
+	failures, maxNoise, minMargin := 0, 0, q
+	for i := 0; i < samples; i++ {
+		privateKey, publicKey, err := p.KemKeypair()
+		if err != nil {
+			return err
+		}
+		ciphertext, sharedSecret, err := p.KemEncrypt(publicKey)
+		if err != nil {
+			return err
+		}
+		decapsulated, err := p.KemDecrypt(ciphertext, privateKey)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(decapsulated, sharedSecret) {
+			failures++
+		}
+		margins, err := p.NoiseMargins(ciphertext, privateKey)
+		if err != nil {
+			return err
+		}
+		for _, noise := range margins.Noise {
+			counts[floorDiv(noise, bucket)]++
+		}
+		maxNoise = max(maxNoise, margins.MaxNoise())
+		minMargin = min(minMargin, margins.MinMargin())
+	}
+
+	coefficients := float64(samples * n)
+	tail := predicted.Tail(float64(maxNoise))
+	fmt.Printf("== %s: %d encapsulations, %d coefficients ==\n", p.Name, samples, samples*n)
+	fmt.Printf("Decapsulation failures:  %d (predicted 2^%.1f per decapsulation)\n", failures, failureProbability)
+	fmt.Printf("Largest |noise|:         %d (decoding fails beyond q/4 = %d)\n", maxNoise, q/4)
+	fmt.Printf("Smallest margin:         %d\n", minMargin)
+	fmt.Printf("Predicted P(|noise| > %d) = 2^%.1f per coefficient, %.3g expected in this run\n",
+		maxNoise, math.Log2(tail), tail*coefficients)
+
+	fmt.Println()
+	fmt.Printf("%16s %10s %10s\n", "noise", "observed", "predicted")
+	limit := max(maxNoise, predictedRange(predicted, coefficients))
+	first, last := floorDiv(-limit, bucket), floorDiv(limit, bucket)
+	largest := 0
+	for _, count := range counts {
+		largest = max(largest, count)
+	}
+	for b := first; b <= last; b++ {
+		observed := float64(counts[b]) / coefficients
+		expected := 0.0
+		for x := b * bucket; x < (b+1)*bucket; x++ {
+			expected += predicted.At(x)
+		}
+		bar := strings.Repeat("#", int(math.Round(50*float64(counts[b])/float64(largest))))
+		fmt.Printf("[%6d, %5d) %9.4f%% %9.4f%%  %s\n", b*bucket, (b+1)*bucket, 100*observed, 100*expected, bar)
+	}
+	return nil
+}
+
+// predictedRange returns the noise bound beyond which fewer than one
+// coefficient of the run is expected.
+func predictedRange(d analysis.Distribution, coefficients float64) int {
+	bound := 0
+	for d.Tail(float64(bound))*coefficients >= 1 {
+		bound++
+	}
+	return bound
+}
+
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+	return a / b
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "kyber-noise:", err)
+	os.Exit(1)
+}
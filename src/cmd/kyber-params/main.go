@@ -0,0 +1,100 @@
+// Command kyber-params reports the decryption failure probability and the
+// estimated core-SVP security level of Kyber parameter sets.
+//
+// Usage:
+//
+//	kyber-params [-format markdown|json] [-variant 512|768|1024|all]
+//	kyber-params -k 3 -eta1 2 -eta2 2 -du 10 -dv 4 [-n 256 -q 3329]
+//
+// Without -k, the standard parameter sets are reported. With -k, a custom
+// parameter set is built from the remaining flags, whose defaults are those
+// of Kyber768; -n and -q select a toy ring.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/Rohith04MVK/goKyber/analysis"
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+func main() {
+	format := flag.String("format", "markdown", "output format: markdown or json")
+	variant := flag.String("variant", "all", "standard parameter set: 512, 768, 1024 or all")
+	k := flag.Int("k", 0, "module rank of a custom parameter set")
+	eta1 := flag.Int("eta1", gokyber.Kyber768.Eta1, "noise width of the secret (custom parameter set)")
+	eta2 := flag.Int("eta2", gokyber.Kyber768.Eta2, "noise width of the encryption errors (custom parameter set)")
+	du := flag.Int("du", gokyber.Kyber768.Du, "compression width of u (custom parameter set)")
+	dv := flag.Int("dv", gokyber.Kyber768.Dv, "compression width of v (custom parameter set)")
+	n := flag.Int("n", gokyber.KyberRing.N, "ring degree (custom parameter set)")
+	q := flag.Int("q", gokyber.KyberRing.Q, "ring modulus (custom parameter set)")
+	flag.Parse()
+
+	var paramSets []*gokyber.ParameterSet
+	switch {
+	case *k != 0:
+		ring, err := gokyber.NewRing(*n, *q)
+		if err != nil {
+			fatal(err)
+		}
+		name := fmt.Sprintf("k=%d η1=%d η2=%d du=%d dv=%d", *k, *eta1, *eta2, *du, *dv)
+		if *n != gokyber.KyberRing.N || *q != gokyber.KyberRing.Q {
+			name += fmt.Sprintf(" n=%d q=%d", *n, *q)
+		}
+		paramSets = append(paramSets, &gokyber.ParameterSet{
+			Name:      name,
+			K:         *k,
+			Eta1:      *eta1,
+			Eta2:      *eta2,
+			Du:        *du,
+			Dv:        *dv,
+			Symmetric: gokyber.SymmetricSHAKE,
+			Ring:      ring,
+		})
+	case *variant == "all":
+		paramSets = append(paramSets, gokyber.Kyber512, gokyber.Kyber768, gokyber.Kyber1024)
+	default:
+		kyberVariant, err := strconv.Atoi(*variant)
+		if err != nil {
+			fatal(fmt.Errorf("invalid variant %q", *variant))
+		}
+		p, err := gokyber.ParameterSetForVariant(kyberVariant)
+		if err != nil {
+			fatal(err)
+		}
+		paramSets = append(paramSets, p)
+	}
+
+	reports := make([]*analysis.Report, 0, len(paramSets))
+	for _, p := range paramSets {
+		r, err := analysis.NewReport(p)
+		if err != nil {
+			fatal(err)
+		}
+		reports = append(reports, r)
+	}
+
+	switch *format {
+	case "markdown":
+		if err := analysis.WriteMarkdown(os.Stdout, reports); err != nil {
+			fatal(err)
+		}
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(reports); err != nil {
+			fatal(err)
+		}
+	default:
+		fatal(fmt.Errorf("unknown format %q", *format))
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "kyber-params:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,325 @@
+// Package cms encrypts CMS (RFC 5652) messages to ML-KEM keys with the
+// KEMRecipientInfo of RFC 9629, for S/MIME-style encrypted messages.
+//
+// Messages are EnvelopedData, with AES-256-CBC content encryption, or
+// AuthEnvelopedData (RFC 5083), with AES-256-GCM, in a ContentInfo. The
+// content encryption key is wrapped once per recipient in a
+// KEMRecipientInfo, carried in an OtherRecipientInfo of type id-ori-kem:
+// the key encryption key is derived from the ML-KEM shared secret with
+// HKDF-SHA256 and the DER CMSORIforKEMOtherInfo as info, and wraps the
+// content encryption key with AES Key Wrap, AES-128 for ML-KEM-512 and
+// AES-256 otherwise, as draft-ietf-lamps-cms-kyber specifies.
+package cms
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"sort"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+	"github.com/Rohith04MVK/goKyber/internal/aeskw"
+	"github.com/Rohith04MVK/goKyber/x509kem"
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+	"golang.org/x/crypto/hkdf"
+)
+
+var (
+	oidData              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEnvelopedData     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+	oidAuthEnvelopedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 23}
+	oidORIKEM            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 13, 3}
+	oidHKDFWithSHA256    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 3, 28}
+	oidAES128Wrap        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 5}
+	oidAES256Wrap        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 45}
+	oidAES256CBC         = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidAES256GCM         = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 46}
+)
+
+const (
+	cekBytes      = 32
+	gcmNonceBytes = 12
+	gcmTagBytes   = 16
+
+	envelopedDataVersion     = 3 // with an OtherRecipientInfo
+	authEnvelopedDataVersion = 0
+	kemRecipientInfoVersion  = 0
+)
+
+// Recipient is the ML-KEM public key of a recipient, identified in the
+// message by its SubjectKeyID if set, and by the Issuer and SerialNumber of
+// its certificate otherwise.
+type Recipient struct {
+	Params    *gokyber.ParameterSet
+	PublicKey []byte
+
+	SubjectKeyID []byte
+	// Issuer is the DER-encoded issuer name of the certificate.
+	Issuer       []byte
+	SerialNumber *big.Int
+}
+
+// RecipientFromCertificate returns the recipient of a KEM certificate,
+// identified by its issuer and serial number.
+func RecipientFromCertificate(cert *x509kem.Certificate) *Recipient {
+	return &Recipient{Params: cert.Params, PublicKey: cert.KEMPublicKey, Issuer: cert.RawIssuer, SerialNumber: cert.SerialNumber}
+}
+
+// PrivateKey is the ML-KEM private key of a recipient, with the same
+// identifiers as its Recipient. A key without identifiers tries every
+// recipient info of its parameter set.
+type PrivateKey struct {
+	Params     *gokyber.ParameterSet
+	PrivateKey []byte
+
+	SubjectKeyID []byte
+	Issuer       []byte
+	SerialNumber *big.Int
+}
+
+// wrapAlgorithm returns the key wrap algorithm of a parameter set.
+func wrapAlgorithm(p *gokyber.ParameterSet) (asn1.ObjectIdentifier, int) {
+	if p.Predefined() == gokyber.MLKEM512 {
+		return oidAES128Wrap, 16
+	}
+	return oidAES256Wrap, 32
+}
+
+func addAlgorithmIdentifier(b *cryptobyte.Builder, oid asn1.ObjectIdentifier) {
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1ObjectIdentifier(oid)
+	})
+}
+
+// addRecipientIdentifier adds the RecipientIdentifier CHOICE.
+func addRecipientIdentifier(b *cryptobyte.Builder, r *Recipient) error {
+	if r.SubjectKeyID != nil {
+		b.AddASN1(cryptobyte_asn1.Tag(0).ContextSpecific(), func(b *cryptobyte.Builder) {
+			b.AddBytes(r.SubjectKeyID)
+		})
+		return nil
+	}
+	if r.Issuer == nil || r.SerialNumber == nil {
+		return errors.New("recipient has neither a subject key identifier nor an issuer and serial number")
+	}
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddBytes(r.Issuer)
+		b.AddASN1BigInt(r.SerialNumber)
+	})
+	return nil
+}
+
+// otherInfo returns the DER CMSORIforKEMOtherInfo, the HKDF info.
+func otherInfo(wrap []byte, kekLength int64, ukm []byte) []byte {
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddBytes(wrap)
+		b.AddASN1Int64(kekLength)
+		if ukm != nil {
+			b.AddASN1(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific(), func(b *cryptobyte.Builder) {
+				b.AddASN1OctetString(ukm)
+			})
+		}
+	})
+	return b.BytesOrPanic()
+}
+
+func deriveKEK(sharedSecret, info []byte, kekLength int) ([]byte, error) {
+	kek := make([]byte, kekLength)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecret, nil, info), kek); err != nil {
+		return nil, err
+	}
+	return kek, nil
+}
+
+// recipientInfo returns the DER RecipientInfo of a recipient, an
+// OtherRecipientInfo holding a KEMRecipientInfo.
+func recipientInfo(r *Recipient, cek []byte) ([]byte, error) {
+	kemOID, err := x509kem.OID(r.Params)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, sharedSecret, err := r.Params.KemEncrypt(r.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	wrapOID, kekLength := wrapAlgorithm(r.Params)
+	var wrap cryptobyte.Builder
+	addAlgorithmIdentifier(&wrap, wrapOID)
+	kek, err := deriveKEK(sharedSecret, otherInfo(wrap.BytesOrPanic(), int64(kekLength), nil), kekLength)
+	if err != nil {
+		return nil, err
+	}
+	encryptedKey, err := aeskw.Wrap(kek, cek)
+	if err != nil {
+		return nil, err
+	}
+
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.Tag(4).Constructed().ContextSpecific(), func(b *cryptobyte.Builder) {
+		b.AddASN1ObjectIdentifier(oidORIKEM)
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			b.AddASN1Int64(kemRecipientInfoVersion)
+			if err := addRecipientIdentifier(b, r); err != nil {
+				b.SetError(err)
+				return
+			}
+			addAlgorithmIdentifier(b, kemOID)
+			b.AddASN1OctetString(ciphertext)
+			addAlgorithmIdentifier(b, oidHKDFWithSHA256)
+			b.AddASN1Int64(int64(kekLength))
+			b.AddBytes(wrap.BytesOrPanic())
+			b.AddASN1OctetString(encryptedKey)
+		})
+	})
+	return b.Bytes()
+}
+
+// recipientInfos returns the RecipientInfos of the recipients, sorted as
+// the DER encoding of a SET OF requires.
+func recipientInfos(recipients []*Recipient, cek []byte) ([][]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients")
+	}
+	var infos [][]byte
+	for _, r := range recipients {
+		info, err := recipientInfo(r, cek)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return bytes.Compare(infos[i], infos[j]) < 0 })
+	return infos, nil
+}
+
+func addSet(b *cryptobyte.Builder, elements [][]byte) {
+	b.AddASN1(cryptobyte_asn1.SET, func(b *cryptobyte.Builder) {
+		for _, e := range elements {
+			b.AddBytes(e)
+		}
+	})
+}
+
+func addContentInfo(contentType asn1.ObjectIdentifier, content []byte) ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1ObjectIdentifier(contentType)
+		b.AddASN1(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific(), func(b *cryptobyte.Builder) {
+			b.AddBytes(content)
+		})
+	})
+	return b.Bytes()
+}
+
+// EncryptEnvelopedData encrypts data to the recipients in the DER
+// ContentInfo of an EnvelopedData with AES-256-CBC. The content is not
+// authenticated; prefer EncryptAuthEnvelopedData where recipients support
+// it.
+func EncryptEnvelopedData(content []byte, recipients ...*Recipient) ([]byte, error) {
+	cek := make([]byte, cekBytes)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(cek); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	padding := aes.BlockSize - len(content)%aes.BlockSize
+	encrypted := append(bytes.Clone(content), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+
+	infos, err := recipientInfos(recipients, cek)
+	if err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1Int64(envelopedDataVersion)
+		addSet(b, infos)
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			b.AddASN1ObjectIdentifier(oidData)
+			b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+				b.AddASN1ObjectIdentifier(oidAES256CBC)
+				b.AddASN1OctetString(iv)
+			})
+			b.AddASN1(cryptobyte_asn1.Tag(0).ContextSpecific(), func(b *cryptobyte.Builder) {
+				b.AddBytes(encrypted)
+			})
+		})
+	})
+	envelopedData, err := b.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return addContentInfo(oidEnvelopedData, envelopedData)
+}
+
+// EncryptAuthEnvelopedData encrypts and authenticates data to the
+// recipients in the DER ContentInfo of an AuthEnvelopedData with
+// AES-256-GCM.
+func EncryptAuthEnvelopedData(content []byte, recipients ...*Recipient) ([]byte, error) {
+	cek := make([]byte, cekBytes)
+	nonce := make([]byte, gcmNonceBytes)
+	if _, err := rand.Read(cek); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(cek)
+	if err != nil {
+		return nil, err
+	}
+	// Without authenticated attributes, the additional data is empty.
+	sealed := aead.Seal(nil, nonce, content, nil)
+	encrypted, tag := sealed[:len(content)], sealed[len(content):]
+
+	infos, err := recipientInfos(recipients, cek)
+	if err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1Int64(authEnvelopedDataVersion)
+		addSet(b, infos)
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			b.AddASN1ObjectIdentifier(oidData)
+			b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+				b.AddASN1ObjectIdentifier(oidAES256GCM)
+				b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+					b.AddASN1OctetString(nonce)
+					b.AddASN1Int64(gcmTagBytes)
+				})
+			})
+			b.AddASN1(cryptobyte_asn1.Tag(0).ContextSpecific(), func(b *cryptobyte.Builder) {
+				b.AddBytes(encrypted)
+			})
+		})
+		b.AddASN1OctetString(tag)
+	})
+	authEnvelopedData, err := b.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return addContentInfo(oidAuthEnvelopedData, authEnvelopedData)
+}
+
+func newGCM(cek []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCMWithTagSize(block, gcmTagBytes)
+}
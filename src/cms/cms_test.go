@@ -0,0 +1,254 @@
+package cms
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+	"github.com/Rohith04MVK/goKyber/x509kem"
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+var encrypters = []struct {
+	name    string
+	encrypt func([]byte, ...*Recipient) ([]byte, error)
+}{
+	{"EnvelopedData", EncryptEnvelopedData},
+	{"AuthEnvelopedData", EncryptAuthEnvelopedData},
+}
+
+func newKey(t *testing.T, params *gokyber.ParameterSet, ski string) (*Recipient, *PrivateKey) {
+	t.Helper()
+	privateKey, publicKey, err := params.KemKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Recipient{Params: params, PublicKey: publicKey, SubjectKeyID: []byte(ski)},
+		&PrivateKey{Params: params, PrivateKey: privateKey, SubjectKeyID: []byte(ski)}
+}
+
+// newCertificateKey returns a recipient and private key identified by the
+// issuer and serial number of a KEM certificate.
+func newCertificateKey(t *testing.T, params *gokyber.ParameterSet) (*Recipient, *PrivateKey) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "goKyber test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, template, template, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey, publicKey, err := params.KemKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := &x509.Certificate{
+		SerialNumber: big.NewInt(1234),
+		Subject:      pkix.Name{CommonName: "bob"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509kem.CreateCertificate(leaf, params, publicKey, ca, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509kem.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return RecipientFromCertificate(cert),
+		&PrivateKey{Params: params, PrivateKey: privateKey, Issuer: cert.RawIssuer, SerialNumber: cert.SerialNumber}
+}
+
+func TestRoundTrip(t *testing.T) {
+	content := []byte("Hello, post-quantum S/MIME!")
+	for _, e := range encrypters {
+		for _, params := range []*gokyber.ParameterSet{gokyber.MLKEM512, gokyber.MLKEM768, gokyber.MLKEM1024} {
+			recipient, key := newKey(t, params, "key id")
+			der, err := e.encrypt(content, recipient)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decrypted, err := Decrypt(der, key)
+			if err != nil {
+				t.Fatalf("%s %s: %v", e.name, params.Name, err)
+			}
+			if !bytes.Equal(decrypted, content) {
+				t.Errorf("%s %s: got %q", e.name, params.Name, decrypted)
+			}
+		}
+		// Content lengths around the AES block size.
+		recipient, key := newKey(t, gokyber.MLKEM768, "key id")
+		for _, n := range []int{0, 1, 15, 16, 17, 1000} {
+			content := bytes.Repeat([]byte{'a'}, n)
+			der, err := e.encrypt(content, recipient)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decrypted, err := Decrypt(der, key); err != nil || !bytes.Equal(decrypted, content) {
+				t.Errorf("%s, %d bytes: %v", e.name, n, err)
+			}
+		}
+	}
+}
+
+func TestWrapAlgorithm(t *testing.T) {
+	// The key wrap follows the parameters of the set, not its name.
+	custom := *gokyber.MLKEM512
+	custom.Name = gokyber.MLKEM1024.Name
+	if oid, n := wrapAlgorithm(&custom); !oid.Equal(oidAES128Wrap) || n != 16 {
+		t.Errorf("renamed copy of ML-KEM-512 wraps with %v", oid)
+	}
+	if oid, n := wrapAlgorithm(gokyber.MLKEM1024.WithTracer(nil)); !oid.Equal(oidAES256Wrap) || n != 32 {
+		t.Errorf("ML-KEM-1024 wraps with %v", oid)
+	}
+}
+
+func TestMultipleRecipients(t *testing.T) {
+	alice, aliceKey := newKey(t, gokyber.MLKEM512, "alice")
+	bob, bobKey := newCertificateKey(t, gokyber.MLKEM768)
+	carol, carolKey := newKey(t, gokyber.MLKEM1024, "carol")
+	_, daveKey := newKey(t, gokyber.MLKEM768, "dave")
+	content := []byte("to alice, bob and carol")
+	for _, e := range encrypters {
+		der, err := e.encrypt(content, alice, bob, carol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []*PrivateKey{aliceKey, bobKey, carolKey} {
+			if decrypted, err := Decrypt(der, key); err != nil || !bytes.Equal(decrypted, content) {
+				t.Errorf("%s: %x: %v", e.name, key.SubjectKeyID, err)
+			}
+		}
+		// A key without identifiers tries every recipient info.
+		anonymous := &PrivateKey{Params: carolKey.Params, PrivateKey: carolKey.PrivateKey}
+		if decrypted, err := Decrypt(der, anonymous); err != nil || !bytes.Equal(decrypted, content) {
+			t.Errorf("%s: key without identifiers: %v", e.name, err)
+		}
+		if _, err := Decrypt(der, daveKey); !errors.Is(err, ErrNoRecipientMatched) {
+			t.Errorf("%s: other recipient: %v", e.name, err)
+		}
+		// The SET OF recipient infos is in DER order.
+		infos := recipientInfoElements(t, der)
+		if len(infos) != 3 {
+			t.Fatalf("%s: %d recipient infos", e.name, len(infos))
+		}
+		for i := 1; i < len(infos); i++ {
+			if bytes.Compare(infos[i-1], infos[i]) > 0 {
+				t.Errorf("%s: recipient infos are not sorted", e.name)
+			}
+		}
+	}
+	if _, err := EncryptEnvelopedData(content); err == nil {
+		t.Error("encrypted to no recipients")
+	}
+}
+
+// recipientInfoElements returns the recipient infos of a message, which
+// alias der.
+func recipientInfoElements(t *testing.T, der []byte) [][]byte {
+	t.Helper()
+	s := cryptobyte.String(der)
+	var contentInfo, content, data, set cryptobyte.String
+	var version int64
+	if !s.ReadASN1(&contentInfo, cryptobyte_asn1.SEQUENCE) ||
+		!contentInfo.SkipASN1(cryptobyte_asn1.OBJECT_IDENTIFIER) ||
+		!contentInfo.ReadASN1(&content, cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) ||
+		!content.ReadASN1(&data, cryptobyte_asn1.SEQUENCE) ||
+		!data.ReadASN1Integer(&version) ||
+		!data.ReadASN1(&set, cryptobyte_asn1.SET) {
+		t.Fatal("malformed message")
+	}
+	var infos [][]byte
+	for !set.Empty() {
+		var info cryptobyte.String
+		if !set.ReadASN1Element(&info, cryptobyte_asn1.Tag(4).Constructed().ContextSpecific()) {
+			t.Fatal("malformed recipient info")
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+func TestTamperedRecipientInfo(t *testing.T) {
+	recipient, key := newKey(t, gokyber.MLKEM512, "key id")
+	for _, e := range encrypters {
+		der, err := e.encrypt([]byte("content"), recipient)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info := recipientInfoElements(t, der)[0]
+		// Every byte of the recipient info, from the identifier and the KEM
+		// ciphertext to the key encryption key length and the wrapped key,
+		// is checked.
+		for i := range info {
+			info[i] ^= 0x01
+			if _, err := Decrypt(der, key); err == nil {
+				t.Errorf("%s: decrypted with byte %d of the recipient info flipped", e.name, i)
+			}
+			info[i] ^= 0x01
+		}
+		if _, err := Decrypt(der, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	recipient, key := newKey(t, gokyber.MLKEM768, "key id")
+	_, wrongKey := newKey(t, gokyber.MLKEM768, "key id")
+	_, otherParams := newKey(t, gokyber.MLKEM1024, "key id")
+	for _, e := range encrypters {
+		der, err := e.encrypt([]byte("content"), recipient)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Decrypt(der, wrongKey); err == nil {
+			t.Errorf("%s: decrypted with the wrong private key", e.name)
+		}
+		if _, err := Decrypt(der, otherParams); !errors.Is(err, ErrNoRecipientMatched) {
+			t.Errorf("%s: other parameter set: %v", e.name, err)
+		}
+		for _, n := range []int{0, 1, len(der) / 2, len(der) - 1} {
+			if _, err := Decrypt(der[:n], key); err == nil {
+				t.Errorf("%s: decrypted a message truncated to %d bytes", e.name, n)
+			}
+		}
+		if _, err := Decrypt(append(der, 0), key); err == nil {
+			t.Errorf("%s: decrypted a message with trailing data", e.name)
+		}
+	}
+
+	// AuthEnvelopedData authenticates the content.
+	der, err := EncryptAuthEnvelopedData([]byte("content"), recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der[len(der)-20] ^= 0x01
+	if _, err := Decrypt(der, key); err == nil {
+		t.Error("decrypted tampered AuthEnvelopedData content")
+	}
+}
@@ -0,0 +1,307 @@
+package cms
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/Rohith04MVK/goKyber/internal/aeskw"
+	"github.com/Rohith04MVK/goKyber/x509kem"
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// ErrNoRecipientMatched is returned by Decrypt when no KEMRecipientInfo
+// matches the private key.
+var ErrNoRecipientMatched = errors.New("cms: no recipient info matches the private key")
+
+var errMalformed = errors.New("cms: malformed message")
+
+// Decrypt decrypts the DER ContentInfo of an EnvelopedData or
+// AuthEnvelopedData with a private key and returns the content.
+func Decrypt(der []byte, key *PrivateKey) ([]byte, error) {
+	s := cryptobyte.String(der)
+	var contentInfo, content cryptobyte.String
+	var contentType asn1.ObjectIdentifier
+	if !s.ReadASN1(&contentInfo, cryptobyte_asn1.SEQUENCE) || !s.Empty() ||
+		!contentInfo.ReadASN1ObjectIdentifier(&contentType) ||
+		!contentInfo.ReadASN1(&content, cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) || !contentInfo.Empty() {
+		return nil, errMalformed
+	}
+	switch {
+	case contentType.Equal(oidEnvelopedData):
+		return decryptEnvelopedData(content, key)
+	case contentType.Equal(oidAuthEnvelopedData):
+		return decryptAuthEnvelopedData(content, key)
+	}
+	return nil, fmt.Errorf("cms: unsupported content type %v", contentType)
+}
+
+func decryptEnvelopedData(s cryptobyte.String, key *PrivateKey) ([]byte, error) {
+	var envelopedData cryptobyte.String
+	var version int64
+	if !s.ReadASN1(&envelopedData, cryptobyte_asn1.SEQUENCE) || !s.Empty() ||
+		!envelopedData.ReadASN1Integer(&version) {
+		return nil, errMalformed
+	}
+	if version != envelopedDataVersion {
+		return nil, fmt.Errorf("cms: unsupported EnvelopedData version %d", version)
+	}
+	cek, err := readRecipientInfos(&envelopedData, key)
+	if err != nil {
+		return nil, err
+	}
+	algorithm, parameters, encrypted, err := readEncryptedContentInfo(&envelopedData)
+	if err != nil {
+		return nil, err
+	}
+	if !envelopedData.Empty() {
+		return nil, errors.New("cms: unsupported EnvelopedData attributes")
+	}
+	if !algorithm.Equal(oidAES256CBC) {
+		return nil, fmt.Errorf("cms: unsupported content encryption algorithm %v", algorithm)
+	}
+	var iv cryptobyte.String
+	if !parameters.ReadASN1(&iv, cryptobyte_asn1.OCTET_STRING) || !parameters.Empty() || len(iv) != aes.BlockSize {
+		return nil, errors.New("cms: invalid AES-CBC parameters")
+	}
+	if len(encrypted) == 0 || len(encrypted)%aes.BlockSize != 0 {
+		return nil, errors.New("cms: invalid encrypted content length")
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	content := bytes.Clone(encrypted)
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(content, content)
+	padding := int(content[len(content)-1])
+	if padding == 0 || padding > aes.BlockSize ||
+		subtle.ConstantTimeCompare(content[len(content)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) != 1 {
+		return nil, errors.New("cms: invalid content padding")
+	}
+	return content[:len(content)-padding], nil
+}
+
+func decryptAuthEnvelopedData(s cryptobyte.String, key *PrivateKey) ([]byte, error) {
+	var authEnvelopedData cryptobyte.String
+	var version int64
+	if !s.ReadASN1(&authEnvelopedData, cryptobyte_asn1.SEQUENCE) || !s.Empty() ||
+		!authEnvelopedData.ReadASN1Integer(&version) {
+		return nil, errMalformed
+	}
+	if version != authEnvelopedDataVersion {
+		return nil, fmt.Errorf("cms: unsupported AuthEnvelopedData version %d", version)
+	}
+	cek, err := readRecipientInfos(&authEnvelopedData, key)
+	if err != nil {
+		return nil, err
+	}
+	algorithm, parameters, encrypted, err := readEncryptedContentInfo(&authEnvelopedData)
+	if err != nil {
+		return nil, err
+	}
+	var tag cryptobyte.String
+	if !authEnvelopedData.ReadASN1(&tag, cryptobyte_asn1.OCTET_STRING) || !authEnvelopedData.Empty() {
+		return nil, errors.New("cms: unsupported or malformed AuthEnvelopedData attributes")
+	}
+	if !algorithm.Equal(oidAES256GCM) {
+		return nil, fmt.Errorf("cms: unsupported content encryption algorithm %v", algorithm)
+	}
+	var gcmParameters, nonce cryptobyte.String
+	tagLength := int64(12) // the DEFAULT of GCMParameters
+	if !parameters.ReadASN1(&gcmParameters, cryptobyte_asn1.SEQUENCE) || !parameters.Empty() ||
+		!gcmParameters.ReadASN1(&nonce, cryptobyte_asn1.OCTET_STRING) ||
+		(gcmParameters.PeekASN1Tag(cryptobyte_asn1.INTEGER) && !gcmParameters.ReadASN1Integer(&tagLength)) || !gcmParameters.Empty() {
+		return nil, errors.New("cms: invalid AES-GCM parameters")
+	}
+	if len(nonce) != gcmNonceBytes || tagLength != gcmTagBytes || len(tag) != gcmTagBytes {
+		return nil, errors.New("cms: unsupported AES-GCM nonce or tag length")
+	}
+	aead, err := newGCM(cek)
+	if err != nil {
+		return nil, err
+	}
+	content, err := aead.Open(nil, nonce, append(bytes.Clone(encrypted), tag...), nil)
+	if err != nil {
+		return nil, errors.New("cms: message authentication failed")
+	}
+	return content, nil
+}
+
+// readEncryptedContentInfo reads an EncryptedContentInfo of data, returning
+// its content encryption algorithm, the algorithm parameters and the
+// encrypted content.
+func readEncryptedContentInfo(s *cryptobyte.String) (asn1.ObjectIdentifier, cryptobyte.String, []byte, error) {
+	var info, algorithmIdentifier, encrypted cryptobyte.String
+	var contentType, algorithm asn1.ObjectIdentifier
+	if !s.ReadASN1(&info, cryptobyte_asn1.SEQUENCE) ||
+		!info.ReadASN1ObjectIdentifier(&contentType) ||
+		!info.ReadASN1(&algorithmIdentifier, cryptobyte_asn1.SEQUENCE) ||
+		!algorithmIdentifier.ReadASN1ObjectIdentifier(&algorithm) {
+		return nil, nil, nil, errMalformed
+	}
+	if !contentType.Equal(oidData) {
+		return nil, nil, nil, fmt.Errorf("cms: unsupported encrypted content type %v", contentType)
+	}
+	if !info.ReadASN1(&encrypted, cryptobyte_asn1.Tag(0).ContextSpecific()) || !info.Empty() {
+		return nil, nil, nil, errors.New("cms: detached or malformed encrypted content")
+	}
+	return algorithm, algorithmIdentifier, encrypted, nil
+}
+
+// readRecipientInfos reads the RecipientInfos and returns the content
+// encryption key unwrapped from the first KEMRecipientInfo matching the
+// key. Recipient infos of other types or for other keys are skipped; if
+// none matches, the error of the last one that failed is returned.
+func readRecipientInfos(s *cryptobyte.String, key *PrivateKey) ([]byte, error) {
+	var infos cryptobyte.String
+	if !s.ReadASN1(&infos, cryptobyte_asn1.SET) || infos.Empty() {
+		return nil, errMalformed
+	}
+	err := ErrNoRecipientMatched
+	for !infos.Empty() {
+		var info cryptobyte.String
+		var tag cryptobyte_asn1.Tag
+		if !infos.ReadAnyASN1(&info, &tag) {
+			return nil, errMalformed
+		}
+		if tag != cryptobyte_asn1.Tag(4).Constructed().ContextSpecific() {
+			continue
+		}
+		var oriType asn1.ObjectIdentifier
+		var kemri cryptobyte.String
+		if !info.ReadASN1ObjectIdentifier(&oriType) {
+			return nil, errMalformed
+		}
+		if !oriType.Equal(oidORIKEM) {
+			continue
+		}
+		if !info.ReadASN1(&kemri, cryptobyte_asn1.SEQUENCE) || !info.Empty() {
+			return nil, errors.New("cms: malformed KEMRecipientInfo")
+		}
+		cek, kerr := unwrapKEMRecipientInfo(kemri, key)
+		if kerr == errOtherRecipient {
+			continue
+		}
+		if kerr != nil {
+			err = kerr
+			continue
+		}
+		return cek, nil
+	}
+	return nil, err
+}
+
+var errOtherRecipient = errors.New("cms: recipient info is for another key")
+
+// unwrapKEMRecipientInfo returns the content encryption key of a
+// KEMRecipientInfo, or errOtherRecipient if it is for another key.
+func unwrapKEMRecipientInfo(s cryptobyte.String, key *PrivateKey) ([]byte, error) {
+	malformed := errors.New("cms: malformed KEMRecipientInfo")
+	var version int64
+	if !s.ReadASN1Integer(&version) || version != kemRecipientInfoVersion {
+		return nil, malformed
+	}
+	match, err := readRecipientIdentifier(&s, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var kemAlgorithm, kdfAlgorithm, wrap cryptobyte.String
+	var kemOID, kdfOID, wrapOID asn1.ObjectIdentifier
+	var ciphertext, ukm, encryptedKey cryptobyte.String
+	var kekLength int64
+	var hasUKM bool
+	if !s.ReadASN1(&kemAlgorithm, cryptobyte_asn1.SEQUENCE) || !kemAlgorithm.ReadASN1ObjectIdentifier(&kemOID) || !kemAlgorithm.Empty() ||
+		!s.ReadASN1(&ciphertext, cryptobyte_asn1.OCTET_STRING) ||
+		!s.ReadASN1(&kdfAlgorithm, cryptobyte_asn1.SEQUENCE) || !kdfAlgorithm.ReadASN1ObjectIdentifier(&kdfOID) || !kdfAlgorithm.Empty() ||
+		!s.ReadASN1Integer(&kekLength) ||
+		!s.ReadOptionalASN1(&ukm, &hasUKM, cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) {
+		return nil, malformed
+	}
+	wrapStart := s
+	if !s.ReadASN1(&wrap, cryptobyte_asn1.SEQUENCE) || !wrap.ReadASN1ObjectIdentifier(&wrapOID) || !wrap.Empty() {
+		return nil, malformed
+	}
+	wrapDER := wrapStart[:len(wrapStart)-len(s)]
+	if !s.ReadASN1(&encryptedKey, cryptobyte_asn1.OCTET_STRING) || !s.Empty() {
+		return nil, malformed
+	}
+
+	params, err := x509kem.ParameterSetForOID(kemOID)
+	if err != nil {
+		return nil, err
+	}
+	if params.Name != key.Params.Name || !match {
+		return nil, errOtherRecipient
+	}
+	if len(ciphertext) != params.CiphertextBytes() {
+		return nil, errors.New("cms: invalid KEM ciphertext length")
+	}
+	if !kdfOID.Equal(oidHKDFWithSHA256) {
+		return nil, fmt.Errorf("cms: unsupported key derivation algorithm %v", kdfOID)
+	}
+	var wantLength int64
+	switch {
+	case wrapOID.Equal(oidAES128Wrap):
+		wantLength = 16
+	case wrapOID.Equal(oidAES256Wrap):
+		wantLength = 32
+	default:
+		return nil, fmt.Errorf("cms: unsupported key wrap algorithm %v", wrapOID)
+	}
+	if kekLength != wantLength {
+		return nil, errors.New("cms: key encryption key length does not match the wrap algorithm")
+	}
+	var ukmBytes []byte
+	if hasUKM {
+		var octets cryptobyte.String
+		if !ukm.ReadASN1(&octets, cryptobyte_asn1.OCTET_STRING) || !ukm.Empty() {
+			return nil, malformed
+		}
+		ukmBytes = octets
+	}
+
+	sharedSecret, err := params.KemDecrypt(ciphertext, key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	kek, err := deriveKEK(sharedSecret, otherInfo(wrapDER, kekLength, ukmBytes), int(kekLength))
+	if err != nil {
+		return nil, err
+	}
+	cek, err := aeskw.Unwrap(kek, encryptedKey)
+	if err != nil {
+		return nil, errors.New("cms: content encryption key unwrap failed")
+	}
+	if len(cek) != cekBytes {
+		return nil, errors.New("cms: invalid content encryption key length")
+	}
+	return cek, nil
+}
+
+// readRecipientIdentifier reads a RecipientIdentifier and reports whether
+// it identifies the key, which a key without identifiers always does.
+func readRecipientIdentifier(s *cryptobyte.String, key *PrivateKey) (bool, error) {
+	anyID := key.SubjectKeyID == nil && key.Issuer == nil
+	if s.PeekASN1Tag(cryptobyte_asn1.Tag(0).ContextSpecific()) {
+		var ski cryptobyte.String
+		if !s.ReadASN1(&ski, cryptobyte_asn1.Tag(0).ContextSpecific()) {
+			return false, errMalformed
+		}
+		return anyID || (key.SubjectKeyID != nil && bytes.Equal(ski, key.SubjectKeyID)), nil
+	}
+	var issuerAndSerial, issuer cryptobyte.String
+	serial := new(big.Int)
+	if !s.ReadASN1(&issuerAndSerial, cryptobyte_asn1.SEQUENCE) ||
+		!issuerAndSerial.ReadASN1Element(&issuer, cryptobyte_asn1.SEQUENCE) ||
+		!issuerAndSerial.ReadASN1Integer(serial) || !issuerAndSerial.Empty() {
+		return false, errMalformed
+	}
+	return anyID || (key.Issuer != nil && key.SerialNumber != nil && bytes.Equal(issuer, key.Issuer) && serial.Cmp(key.SerialNumber) == 0), nil
+}
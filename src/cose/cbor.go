@@ -0,0 +1,282 @@
+package cose
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// This file is a small CBOR (RFC 8949) encoder and decoder, for the subset
+// COSE needs: integers, byte and text strings, arrays, maps, tags, booleans
+// and null. It encodes deterministically (section 4.2.1): integers and
+// lengths in their shortest form, definite lengths only, and map keys
+// sorted by their encodings. The decoder accepts that encoding only, so
+// every value has exactly one encoding.
+//
+// Values are int64, []byte, string, []any, cborMap, cborTag, bool and nil.
+
+const (
+	majorUnsigned = 0
+	majorNegative = 1
+	majorBytes    = 2
+	majorText     = 3
+	majorArray    = 4
+	majorMap      = 5
+	majorTag      = 6
+	majorSimple   = 7
+
+	simpleFalse = 20
+	simpleTrue  = 21
+	simpleNull  = 22
+
+	maxDepth = 16
+)
+
+// cborMap is a CBOR map, in any order; it is sorted when encoded.
+type cborMap []cborPair
+
+type cborPair struct {
+	key, value any
+}
+
+// get returns the value of an integer key.
+func (m cborMap) get(key int64) (any, bool) {
+	for _, p := range m {
+		if k, ok := p.key.(int64); ok && k == key {
+			return p.value, true
+		}
+	}
+	return nil, false
+}
+
+type cborTag struct {
+	number  uint64
+	content any
+}
+
+func appendHead(b []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(b, major<<5|byte(n))
+	case n <= 0xff:
+		return append(b, major<<5|24, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(b, major<<5|25), uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(b, major<<5|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, major<<5|27), n)
+	}
+}
+
+// cborMarshal returns the deterministic encoding of a value.
+func cborMarshal(v any) ([]byte, error) {
+	return appendValue(nil, v)
+}
+
+func appendValue(b []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case int64:
+		if v < 0 {
+			return appendHead(b, majorNegative, uint64(-1-v)), nil
+		}
+		return appendHead(b, majorUnsigned, uint64(v)), nil
+	case int:
+		return appendValue(b, int64(v))
+	case []byte:
+		return append(appendHead(b, majorBytes, uint64(len(v))), v...), nil
+	case string:
+		if !utf8.ValidString(v) {
+			return nil, errors.New("cbor: invalid UTF-8 text string")
+		}
+		return append(appendHead(b, majorText, uint64(len(v))), v...), nil
+	case []any:
+		b = appendHead(b, majorArray, uint64(len(v)))
+		for _, e := range v {
+			var err error
+			if b, err = appendValue(b, e); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case cborMap:
+		type entry struct{ key, value []byte }
+		entries := make([]entry, len(v))
+		for i, p := range v {
+			key, err := cborMarshal(p.key)
+			if err != nil {
+				return nil, err
+			}
+			value, err := cborMarshal(p.value)
+			if err != nil {
+				return nil, err
+			}
+			entries[i] = entry{key, value}
+		}
+		sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].key, entries[j].key) < 0 })
+		b = appendHead(b, majorMap, uint64(len(v)))
+		for i, e := range entries {
+			if i > 0 && bytes.Equal(e.key, entries[i-1].key) {
+				return nil, errors.New("cbor: duplicate map key")
+			}
+			b = append(append(b, e.key...), e.value...)
+		}
+		return b, nil
+	case cborTag:
+		return appendValue(appendHead(b, majorTag, v.number), v.content)
+	case bool:
+		if v {
+			return append(b, majorSimple<<5|simpleTrue), nil
+		}
+		return append(b, majorSimple<<5|simpleFalse), nil
+	case nil:
+		return append(b, majorSimple<<5|simpleNull), nil
+	}
+	return nil, fmt.Errorf("cbor: unsupported type %T", v)
+}
+
+// cborUnmarshal decodes a single value in the deterministic encoding, with
+// no trailing data.
+func cborUnmarshal(data []byte) (any, error) {
+	d := &decoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.off != len(d.data) {
+		return nil, errors.New("cbor: trailing data")
+	}
+	return v, nil
+}
+
+type decoder struct {
+	data []byte
+	off  int
+}
+
+var errTruncated = errors.New("cbor: unexpected end of data")
+
+// head reads the initial byte and argument of a data item, rejecting
+// indefinite lengths and arguments not in their shortest form.
+func (d *decoder) head() (byte, uint64, error) {
+	if d.off >= len(d.data) {
+		return 0, 0, errTruncated
+	}
+	major, info := d.data[d.off]>>5, d.data[d.off]&0x1f
+	d.off++
+	if info < 24 {
+		return major, uint64(info), nil
+	}
+	if info > 27 {
+		return 0, 0, errors.New("cbor: indefinite length or reserved value")
+	}
+	size := 1 << (info - 24)
+	if len(d.data)-d.off < size {
+		return 0, 0, errTruncated
+	}
+	var n uint64
+	for _, c := range d.data[d.off : d.off+size] {
+		n = n<<8 | uint64(c)
+	}
+	d.off += size
+	if (info == 24 && n < 24) || (info > 24 && n < 1<<(4<<(info-24))) {
+		return 0, 0, errors.New("cbor: argument not in its shortest form")
+	}
+	if major == majorSimple {
+		return 0, 0, errors.New("cbor: floating-point and extended simple values are not supported")
+	}
+	return major, n, nil
+}
+
+func (d *decoder) bytes(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.off) {
+		return nil, errTruncated
+	}
+	b := d.data[d.off : d.off+int(n)]
+	d.off += int(n)
+	return b, nil
+}
+
+func (d *decoder) value(depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errors.New("cbor: nesting too deep")
+	}
+	start := d.off
+	major, n, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case majorUnsigned:
+		if n > 1<<63-1 {
+			return nil, errors.New("cbor: integer overflow")
+		}
+		return int64(n), nil
+	case majorNegative:
+		if n > 1<<63-1 {
+			return nil, errors.New("cbor: integer overflow")
+		}
+		return -1 - int64(n), nil
+	case majorBytes:
+		b, err := d.bytes(n)
+		return bytes.Clone(b), err
+	case majorText:
+		b, err := d.bytes(n)
+		if err == nil && !utf8.Valid(b) {
+			err = errors.New("cbor: invalid UTF-8 text string")
+		}
+		return string(b), err
+	case majorArray:
+		// Every element takes at least one byte.
+		if n > uint64(len(d.data)-d.off) {
+			return nil, errTruncated
+		}
+		a := make([]any, n)
+		for i := range a {
+			if a[i], err = d.value(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	case majorMap:
+		if n > uint64(len(d.data)-d.off)/2 {
+			return nil, errTruncated
+		}
+		m := make(cborMap, n)
+		var previous []byte
+		for i := range m {
+			keyStart := d.off
+			if m[i].key, err = d.value(depth + 1); err != nil {
+				return nil, err
+			}
+			key := d.data[keyStart:d.off]
+			if i > 0 && bytes.Compare(previous, key) >= 0 {
+				return nil, errors.New("cbor: map keys not sorted or duplicated")
+			}
+			previous = key
+			if m[i].value, err = d.value(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case majorTag:
+		content, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return cborTag{n, content}, nil
+	default:
+		switch n {
+		case simpleFalse:
+			return false, nil
+		case simpleTrue:
+			return true, nil
+		case simpleNull:
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cbor: unsupported simple value at offset %d", start)
+	}
+}
@@ -0,0 +1,117 @@
+package cose
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestCBOREncoding(t *testing.T) {
+	// RFC 8949, appendix A.
+	for _, tc := range []struct {
+		value   any
+		encoded string
+	}{
+		{int64(0), "00"},
+		{int64(23), "17"},
+		{int64(24), "1818"},
+		{int64(100), "1864"},
+		{int64(1000), "1903e8"},
+		{int64(1000000), "1a000f4240"},
+		{int64(1000000000000), "1b000000e8d4a51000"},
+		{int64(-1), "20"},
+		{int64(-100), "3863"},
+		{int64(-1000), "3903e7"},
+		{[]byte{}, "40"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+		{"", "60"},
+		{"IETF", "6449455446"},
+		{"ü", "62c3bc"},
+		{[]any{}, "80"},
+		{[]any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}}, "8301820203820405"},
+		{cborMap{}, "a0"},
+		{cborMap{{int64(1), int64(2)}, {int64(3), int64(4)}}, "a201020304"},
+		{cborMap{{"a", int64(1)}, {"b", []any{int64(2), int64(3)}}}, "a26161016162820203"},
+		{false, "f4"},
+		{true, "f5"},
+		{nil, "f6"},
+		{cborTag{1, int64(1363896240)}, "c11a514b67b0"},
+	} {
+		encoded, err := cborMarshal(tc.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(encoded) != tc.encoded {
+			t.Errorf("%#v encoded to %x, want %s", tc.value, encoded, tc.encoded)
+		}
+		decoded, err := cborUnmarshal(encoded)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.encoded, err)
+		}
+		if !reflect.DeepEqual(decoded, tc.value) {
+			t.Errorf("%s decoded to %#v", tc.encoded, decoded)
+		}
+	}
+}
+
+func TestCBORDeterministicMapOrder(t *testing.T) {
+	// The key order of RFC 8949, section 4.2.1, whatever the input order.
+	m := cborMap{
+		{false, int64(0)},
+		{[]any{int64(-1)}, int64(0)},
+		{"aa", int64(0)},
+		{[]any{int64(100)}, int64(0)},
+		{int64(-1), int64(0)},
+		{"z", int64(0)},
+		{int64(100), int64(0)},
+		{int64(10), int64(0)},
+	}
+	encoded, err := cborMarshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a8" + "0a00" + "186400" + "2000" + "617a00" + "62616100" + "81186400" + "812000" + "f400"
+	if hex.EncodeToString(encoded) != want {
+		t.Errorf("encoded to %x, want %s", encoded, want)
+	}
+	if _, err := cborMarshal(cborMap{{int64(1), int64(1)}, {int64(1), int64(2)}}); err == nil {
+		t.Error("duplicate map key encoded")
+	}
+}
+
+func TestCBORRejectsNonDeterministic(t *testing.T) {
+	for name, encoded := range map[string]string{
+		"non-shortest integer":     "1817",
+		"non-shortest 16-bit":      "1900ff",
+		"non-shortest 64-bit":      "1b00000000ffffffff",
+		"non-shortest length":      "5801ff",
+		"indefinite byte string":   "5f4101ff",
+		"indefinite array":         "9f01ff",
+		"unsorted map":             "a203040102",
+		"duplicate map key":        "a201020103",
+		"trailing data":            "0000",
+		"half-precision float":     "f93c00",
+		"undefined":                "f7",
+		"extended simple value":    "f820",
+		"truncated byte string":    "4401",
+		"truncated array":          "830102",
+		"truncated argument":       "19",
+		"invalid UTF-8":            "62c328",
+		"integer overflow":         "1bffffffffffffffff",
+		"huge array length":        "9bffffffffffffffff",
+		"reserved additional info": "1c",
+	} {
+		data, err := hex.DecodeString(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, err := cborUnmarshal(data); err == nil {
+			t.Errorf("%s: %s decoded to %#v", name, encoded, v)
+		}
+	}
+	deep := append(bytes.Repeat([]byte{0x81}, maxDepth+2), 0)
+	if _, err := cborUnmarshal(deep); err == nil {
+		t.Error("deeply nested array decoded")
+	}
+}
@@ -0,0 +1,200 @@
+package cose
+
+import (
+	"bytes"
+	"testing"
+)
+
+func generate(t *testing.T, alg int64) *Key {
+	t.Helper()
+	key, err := GenerateKey(alg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestKey(t *testing.T) {
+	for _, a := range algorithms {
+		key := generate(t, a.id)
+		key.KeyID = []byte("device-1")
+		encoded, err := key.MarshalCBOR()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The labels in deterministic order: 1, 2, 3, -1, -2.
+		if !bytes.HasPrefix(encoded, []byte{0xa5, 0x01, 0x07, 0x02, 0x48}) {
+			t.Errorf("%d: COSE_Key %x...", a.id, encoded[:16])
+		}
+		parsed, err := ParseKey(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.Algorithm != a.id || !bytes.Equal(parsed.KeyID, key.KeyID) || !bytes.Equal(parsed.PublicKey, key.PublicKey) || !bytes.Equal(parsed.Seed, key.Seed) {
+			t.Errorf("%d: COSE_Key does not round trip", a.id)
+		}
+		if again, _ := parsed.MarshalCBOR(); !bytes.Equal(again, encoded) {
+			t.Errorf("%d: COSE_Key encoding is not deterministic", a.id)
+		}
+
+		public, err := key.Public().MarshalCBOR()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed, err := ParseKey(public); err != nil || parsed.Seed != nil {
+			t.Errorf("%d: public COSE_Key: %v", a.id, err)
+		}
+	}
+}
+
+func TestMalformedKey(t *testing.T) {
+	key := generate(t, AlgorithmHPKEMLKEM768)
+	other := generate(t, AlgorithmHPKEMLKEM768)
+	base := cborMap{{int64(labelKeyType), int64(keyTypeAKP)}, {int64(labelAlgorithm), AlgorithmHPKEMLKEM768}, {int64(labelPublic), key.PublicKey}, {int64(labelPrivate), key.Seed}}
+	with := func(label int64, value any) cborMap {
+		m := cborMap{}
+		for _, p := range base {
+			if p.key != label {
+				m = append(m, p)
+			}
+		}
+		if value != nil {
+			m = append(m, cborPair{label, value})
+		}
+		return m
+	}
+	for name, m := range map[string]any{
+		"OKP key type":        with(labelKeyType, int64(1)),
+		"missing algorithm":   with(labelAlgorithm, nil),
+		"unknown algorithm":   with(labelAlgorithm, int64(-7)),
+		"short public key":    with(labelPublic, key.PublicKey[:100]),
+		"text public key":     with(labelPublic, "key"),
+		"short private key":   with(labelPrivate, key.Seed[:32]),
+		"mismatched key pair": with(labelPublic, other.PublicKey),
+		"text key ID":         with(labelKeyID, "device"),
+		"unknown parameter":   with(-3, []byte{1}),
+		"array":               []any{int64(1)},
+	} {
+		encoded, err := cborMarshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ParseKey(encoded); err == nil {
+			t.Errorf("%s accepted", name)
+		}
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	var keys []*Key
+	var public []*Key
+	for _, a := range algorithms {
+		key := generate(t, a.id)
+		keys = append(keys, key)
+		public = append(public, key.Public())
+	}
+	plaintext := []byte("temperature=21.5")
+	for _, aad := range [][]byte{nil, []byte("device-1")} {
+		encrypted, err := Encrypt(plaintext, aad, public...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encrypted[0] != 0xd8 || encrypted[1] != tagEncrypt {
+			t.Errorf("COSE_Encrypt starts with %x", encrypted[:2])
+		}
+		for i, key := range keys {
+			decrypted, err := Decrypt(encrypted, aad, key)
+			if err != nil {
+				t.Fatalf("recipient %d: %v", i, err)
+			}
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("recipient %d: decrypted plaintext differs", i)
+			}
+		}
+		if _, err := Decrypt(encrypted, []byte("device-2"), keys[0]); err == nil {
+			t.Error("decrypted with other external additional data")
+		}
+		if _, err := Decrypt(encrypted, aad, generate(t, AlgorithmHPKEMLKEM768)); err == nil {
+			t.Error("decrypted with an unrelated key")
+		}
+		if _, err := Decrypt(encrypted, aad, keys[0].Public()); err == nil {
+			t.Error("decrypted with a public key")
+		}
+	}
+
+	// Key IDs select the recipient layer.
+	a, b := generate(t, AlgorithmHPKEMLKEM512), generate(t, AlgorithmHPKEMLKEM512)
+	a.KeyID, b.KeyID = []byte("a"), []byte("b")
+	encrypted, err := Encrypt(plaintext, nil, a.Public(), b.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []*Key{a, b} {
+		if _, err := Decrypt(encrypted, nil, key); err != nil {
+			t.Errorf("key %s: %v", key.KeyID, err)
+		}
+	}
+	a.KeyID = []byte("b")
+	if _, err := Decrypt(encrypted, nil, a); err == nil {
+		t.Error("decrypted with a key of another key ID")
+	}
+}
+
+func TestMalformedEncrypt(t *testing.T) {
+	key := generate(t, AlgorithmHPKEMLKEM768)
+	encrypted, err := Encrypt([]byte("payload"), nil, key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := cborUnmarshal(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := v.(cborTag).content.([]any)
+	recipient := message[3].([]any)[0].([]any)
+
+	modified := func(modify func(message, recipient []any)) []byte {
+		m := append([]any{}, message...)
+		r := append([]any{}, recipient...)
+		m[3] = []any{r}
+		modify(m, r)
+		encoded, err := cborMarshal(cborTag{tagEncrypt, m})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return encoded
+	}
+	if _, err := Decrypt(modified(func(m, r []any) {}), nil, key); err != nil {
+		t.Fatal(err)
+	}
+	untagged, _ := cborMarshal(message)
+	if _, err := Decrypt(untagged, nil, key); err != nil {
+		t.Errorf("untagged COSE_Encrypt: %v", err)
+	}
+
+	protectedA128GCM, _ := cborMarshal(cborMap{{int64(headerAlgorithm), int64(1)}})
+	protectedCrit, _ := cborMarshal(cborMap{{int64(headerAlgorithm), AlgorithmA256GCM}, {int64(headerCritical), []any{int64(99)}}})
+	for name, data := range map[string][]byte{
+		"content algorithm":   modified(func(m, r []any) { m[0] = protectedA128GCM }),
+		"critical parameters": modified(func(m, r []any) { m[0] = protectedCrit }),
+		"algorithm in both": modified(func(m, r []any) {
+			m[1] = cborMap{{int64(headerIV), m[1].(cborMap)[0].value}, {int64(headerAlgorithm), AlgorithmA256GCM}}
+		}),
+		"short IV":               modified(func(m, r []any) { m[1] = cborMap{{int64(headerIV), make([]byte, 8)}} }),
+		"detached ciphertext":    modified(func(m, r []any) { m[2] = nil }),
+		"modified ciphertext":    modified(func(m, r []any) { c := bytes.Clone(m[2].([]byte)); c[0] ^= 1; m[2] = c }),
+		"protected header array": modified(func(m, r []any) { m[0], _ = cborMarshal([]any{}) }),
+		"unprotected not a map":  modified(func(m, r []any) { m[1] = []any{} }),
+		"short ek":               modified(func(m, r []any) { r[1] = cborMap{{int64(headerEncapsulatedKey), make([]byte, 32)}} }),
+		"missing ek":             modified(func(m, r []any) { r[1] = cborMap{} }),
+		"modified wrapped key":   modified(func(m, r []any) { c := bytes.Clone(r[2].([]byte)); c[0] ^= 1; r[2] = c }),
+		"nested recipients":      modified(func(m, r []any) { m[3] = []any{append(r, []any{})} }),
+		"recipient array":        modified(func(m, r []any) { r[0] = []byte{} }),
+		"other tag":              func() []byte { b, _ := cborMarshal(cborTag{16, message}); return b }(),
+		"three elements":         func() []byte { b, _ := cborMarshal(message[:3]); return b }(),
+	} {
+		if _, err := Decrypt(data, nil, key); err == nil {
+			t.Errorf("%s accepted", name)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package cose
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// Header labels.
+const (
+	headerAlgorithm       = 1
+	headerCritical        = 2
+	headerKeyID           = 4
+	headerIV              = 5
+	headerEncapsulatedKey = -4
+)
+
+// tagEncrypt is the CBOR tag of COSE_Encrypt.
+const tagEncrypt = 96
+
+const (
+	cekBytes = 32
+	ivBytes  = 12
+)
+
+// encStructure returns the additional data of a layer: the Enc_structure
+// of RFC 9052, section 5.3.
+func encStructure(context string, protected, externalAAD []byte) ([]byte, error) {
+	return cborMarshal([]any{context, protected, externalAAD})
+}
+
+// recipientInfo returns the HPKE info of a recipient layer, the
+// Recipient_structure of the COSE-HPKE draft, which binds the content
+// encryption algorithm.
+func recipientInfo(protected []byte) ([]byte, error) {
+	return cborMarshal([]any{"HPKE Recipient", AlgorithmA256GCM, protected, []byte{}})
+}
+
+func newGCM(cek []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt encrypts a plaintext to one or more public keys as a tagged
+// COSE_Encrypt, authenticating the external additional data, which may be
+// nil.
+func Encrypt(plaintext, externalAAD []byte, recipients ...*Key) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients")
+	}
+	cek := make([]byte, cekBytes)
+	iv := make([]byte, ivBytes)
+	if _, err := rand.Read(cek); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	var layers []any
+	for _, r := range recipients {
+		a, err := algorithmByID(r.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		protected, err := cborMarshal(cborMap{{int64(headerAlgorithm), a.id}})
+		if err != nil {
+			return nil, err
+		}
+		info, err := recipientInfo(protected)
+		if err != nil {
+			return nil, err
+		}
+		aad, err := encStructure("Enc_Recipient", protected, nil)
+		if err != nil {
+			return nil, err
+		}
+		enc, encryptedKey, err := a.suite.Seal(r.PublicKey, info, aad, cek)
+		if err != nil {
+			return nil, err
+		}
+		unprotected := cborMap{{int64(headerEncapsulatedKey), enc}}
+		if r.KeyID != nil {
+			unprotected = append(unprotected, cborPair{int64(headerKeyID), r.KeyID})
+		}
+		layers = append(layers, []any{protected, unprotected, encryptedKey})
+	}
+
+	protected, err := cborMarshal(cborMap{{int64(headerAlgorithm), AlgorithmA256GCM}})
+	if err != nil {
+		return nil, err
+	}
+	aad, err := encStructure("Encrypt", protected, externalAAD)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(cek)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := aead.Seal(nil, iv, plaintext, aad)
+	return cborMarshal(cborTag{tagEncrypt, []any{protected, cborMap{{int64(headerIV), iv}}, ciphertext, layers}})
+}
+
+// Decrypt decrypts a COSE_Encrypt, tagged or not, with a private key, trying
+// the recipient layers whose algorithm, and key ID if both have one, match
+// the key.
+func Decrypt(data, externalAAD []byte, key *Key) ([]byte, error) {
+	v, err := cborUnmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	if tag, ok := v.(cborTag); ok {
+		if tag.number != tagEncrypt {
+			return nil, fmt.Errorf("CBOR tag %d is not COSE_Encrypt", tag.number)
+		}
+		v = tag.content
+	}
+	message, ok := v.([]any)
+	if !ok || len(message) != 4 {
+		return nil, errors.New("COSE_Encrypt is not an array of four elements")
+	}
+	protected, headers, err := parseHeaders(message[0], message[1])
+	if err != nil {
+		return nil, err
+	}
+	if alg, _ := headers.get(headerAlgorithm); alg != AlgorithmA256GCM {
+		return nil, fmt.Errorf("unsupported COSE content encryption algorithm %v", alg)
+	}
+	ivValue, _ := headers.get(headerIV)
+	iv, ok := ivValue.([]byte)
+	if !ok || len(iv) != ivBytes {
+		return nil, errors.New("invalid COSE_Encrypt IV")
+	}
+	ciphertext, ok := message[2].([]byte)
+	if !ok {
+		return nil, errors.New("detached COSE_Encrypt ciphertexts are not supported")
+	}
+	layers, ok := message[3].([]any)
+	if !ok || len(layers) == 0 {
+		return nil, errors.New("COSE_Encrypt has no recipients")
+	}
+
+	a, err := algorithmByID(key.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if key.Seed == nil {
+		return nil, errors.New("COSE_Key is not a private key")
+	}
+	aad, err := encStructure("Encrypt", protected, externalAAD)
+	if err != nil {
+		return nil, err
+	}
+	err = errors.New("no COSE recipient matches the key")
+	for _, layer := range layers {
+		cek, lerr := decryptRecipient(a, key, layer)
+		if lerr == errSkipRecipient {
+			continue
+		}
+		if lerr != nil {
+			err = lerr
+			continue
+		}
+		aead, lerr := newGCM(cek)
+		if lerr != nil {
+			return nil, lerr
+		}
+		plaintext, lerr := aead.Open(nil, iv, ciphertext, aad)
+		if lerr != nil {
+			err = errors.New("COSE_Encrypt decryption failed")
+			continue
+		}
+		return plaintext, nil
+	}
+	return nil, err
+}
+
+var errSkipRecipient = errors.New("recipient is for another key")
+
+// decryptRecipient decrypts the content encryption key of a recipient
+// layer, returning errSkipRecipient for layers of other keys.
+func decryptRecipient(a *algorithm, key *Key, layer any) ([]byte, error) {
+	r, ok := layer.([]any)
+	if !ok || len(r) != 3 {
+		return nil, errors.New("COSE_recipient is not an array of three elements")
+	}
+	protected, headers, err := parseHeaders(r[0], r[1])
+	if err != nil {
+		return nil, err
+	}
+	if alg, _ := headers.get(headerAlgorithm); alg != a.id {
+		return nil, errSkipRecipient
+	}
+	if kid, ok := headers.get(headerKeyID); ok && key.KeyID != nil {
+		if b, ok := kid.([]byte); !ok || !bytes.Equal(b, key.KeyID) {
+			return nil, errSkipRecipient
+		}
+	}
+	encValue, _ := headers.get(headerEncapsulatedKey)
+	enc, ok := encValue.([]byte)
+	if !ok || len(enc) != a.suite.KEM.EncBytes() {
+		return nil, errors.New("invalid COSE_recipient encapsulated key")
+	}
+	encryptedKey, ok := r[2].([]byte)
+	if !ok {
+		return nil, errors.New("invalid COSE_recipient ciphertext")
+	}
+	info, err := recipientInfo(protected)
+	if err != nil {
+		return nil, err
+	}
+	aad, err := encStructure("Enc_Recipient", protected, nil)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := a.suite.Open(enc, key.Seed, info, aad, encryptedKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(cek) != cekBytes {
+		return nil, errors.New("invalid COSE content encryption key length")
+	}
+	return cek, nil
+}
+
+// parseHeaders parses the protected header, a byte string holding an
+// encoded map or nothing, and the unprotected header map of a layer, and
+// returns the protected bytes and the merged headers. The labels of the two
+// must be disjoint, and no critical parameters are supported.
+func parseHeaders(protectedValue, unprotectedValue any) ([]byte, cborMap, error) {
+	protected, ok := protectedValue.([]byte)
+	if !ok {
+		return nil, nil, errors.New("COSE protected header is not a byte string")
+	}
+	var headers cborMap
+	if len(protected) > 0 {
+		v, err := cborUnmarshal(protected)
+		if err != nil {
+			return nil, nil, err
+		}
+		if headers, ok = v.(cborMap); !ok || len(headers) == 0 {
+			return nil, nil, errors.New("COSE protected header is not a non-empty map")
+		}
+	}
+	unprotected, ok := unprotectedValue.(cborMap)
+	if !ok {
+		return nil, nil, errors.New("COSE unprotected header is not a map")
+	}
+	for _, p := range unprotected {
+		label, ok := p.key.(int64)
+		if !ok {
+			continue
+		}
+		if _, dup := headers.get(label); dup {
+			return nil, nil, fmt.Errorf("COSE header parameter %d in both headers", label)
+		}
+	}
+	headers = append(headers[:len(headers):len(headers)], unprotected...)
+	if _, ok := headers.get(headerCritical); ok {
+		return nil, nil, errors.New("unsupported critical COSE header parameters")
+	}
+	return protected, headers, nil
+}
@@ -0,0 +1,197 @@
+// Package cose implements COSE (RFC 9052) keys and encrypted messages with
+// ML-KEM, for CBOR-based protocols, after the COSE-HPKE and COSE KEM drafts.
+//
+// Keys are COSE_Keys of the AKP (algorithm key pair) key type, with the
+// public key and the seed of the key pair as parameters. Messages are
+// COSE_Encrypt structures whose content is encrypted with A256GCM under a
+// random content encryption key, which every recipient layer encrypts with
+// HPKE in the Base mode, carrying the encapsulated key in the "ek" header
+// parameter. The drafts have not assigned algorithm values for ML-KEM yet,
+// so the HPKE algorithms use values of the private-use range.
+//
+// The package carries its own deterministic CBOR encoder and decoder.
+package cose
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+	"github.com/Rohith04MVK/goKyber/hpke"
+)
+
+// The COSE algorithms.
+const (
+	// AlgorithmA256GCM is AES-256-GCM content encryption.
+	AlgorithmA256GCM int64 = 3
+
+	// The HPKE key encryption algorithms, with HKDF-SHA256 and
+	// AES-256-GCM: private-use values until the drafts register them.
+	AlgorithmHPKEMLKEM512       int64 = -70001
+	AlgorithmHPKEMLKEM768       int64 = -70002
+	AlgorithmHPKEMLKEM1024      int64 = -70003
+	AlgorithmHPKEMLKEM768X25519 int64 = -70004
+)
+
+// seededKEM is the gokyber KEM whose seeds are the HPKE private keys.
+type seededKEM interface {
+	SeedBytes() int
+	KemKeypairFromSeed(seed []byte) ([]byte, []byte, error)
+}
+
+// xwingSeeds adapts gokyber.XWing, whose private keys are its seeds.
+type xwingSeeds struct{ *gokyber.XWingKEM }
+
+func (x xwingSeeds) SeedBytes() int { return x.PrivateKeyBytes() }
+
+type algorithm struct {
+	id    int64
+	suite *hpke.Suite
+	kem   seededKEM
+}
+
+var algorithms = []algorithm{
+	{AlgorithmHPKEMLKEM512, hpke.NewSuite(hpke.MLKEM512, hpke.HKDFSHA256, hpke.AES256GCM), gokyber.MLKEM512},
+	{AlgorithmHPKEMLKEM768, hpke.NewSuite(hpke.MLKEM768, hpke.HKDFSHA256, hpke.AES256GCM), gokyber.MLKEM768},
+	{AlgorithmHPKEMLKEM1024, hpke.NewSuite(hpke.MLKEM1024, hpke.HKDFSHA256, hpke.AES256GCM), gokyber.MLKEM1024},
+	{AlgorithmHPKEMLKEM768X25519, hpke.NewSuite(hpke.MLKEM768X25519, hpke.HKDFSHA256, hpke.AES256GCM), xwingSeeds{gokyber.XWing}},
+}
+
+func algorithmByID(id int64) (*algorithm, error) {
+	for i := range algorithms {
+		if algorithms[i].id == id {
+			return &algorithms[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported COSE algorithm %d", id)
+}
+
+// COSE_Key labels.
+const (
+	labelKeyType   = 1
+	labelKeyID     = 2
+	labelAlgorithm = 3
+	labelPublic    = -1
+	labelPrivate   = -2
+
+	keyTypeAKP = 7
+)
+
+// Key is a COSE_Key of the AKP key type for an HPKE algorithm.
+type Key struct {
+	Algorithm int64
+	KeyID     []byte
+	PublicKey []byte
+	// Seed is the seed of the key pair, the HPKE private key, or nil for
+	// public keys.
+	Seed []byte
+}
+
+// GenerateKey generates a private key for an HPKE algorithm.
+func GenerateKey(alg int64) (*Key, error) {
+	a, err := algorithmByID(alg)
+	if err != nil {
+		return nil, err
+	}
+	seed := make([]byte, a.kem.SeedBytes())
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	return NewKey(alg, seed)
+}
+
+// NewKey returns the private key of an HPKE algorithm derived from a seed.
+func NewKey(alg int64, seed []byte) (*Key, error) {
+	a, err := algorithmByID(alg)
+	if err != nil {
+		return nil, err
+	}
+	_, publicKey, err := a.kem.KemKeypairFromSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{Algorithm: alg, PublicKey: publicKey, Seed: bytes.Clone(seed)}, nil
+}
+
+// Public returns the public key of k.
+func (k *Key) Public() *Key {
+	return &Key{Algorithm: k.Algorithm, KeyID: k.KeyID, PublicKey: k.PublicKey}
+}
+
+// MarshalCBOR returns the deterministic CBOR encoding of the COSE_Key, with
+// the private key parameter for private keys only.
+func (k *Key) MarshalCBOR() ([]byte, error) {
+	a, err := algorithmByID(k.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if len(k.PublicKey) != a.suite.KEM.PublicKeyBytes() {
+		return nil, errors.New("invalid COSE_Key public key length")
+	}
+	m := cborMap{{int64(labelKeyType), int64(keyTypeAKP)}, {int64(labelAlgorithm), k.Algorithm}, {int64(labelPublic), k.PublicKey}}
+	if k.KeyID != nil {
+		m = append(m, cborPair{int64(labelKeyID), k.KeyID})
+	}
+	if k.Seed != nil {
+		m = append(m, cborPair{int64(labelPrivate), k.Seed})
+	}
+	return cborMarshal(m)
+}
+
+// ParseKey parses a COSE_Key encoded by MarshalCBOR. The public key of a
+// private key must match its seed.
+func ParseKey(data []byte) (*Key, error) {
+	v, err := cborUnmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(cborMap)
+	if !ok {
+		return nil, errors.New("COSE_Key is not a map")
+	}
+	for _, p := range m {
+		label, ok := p.key.(int64)
+		if !ok || (label != labelKeyType && label != labelKeyID && label != labelAlgorithm && label != labelPublic && label != labelPrivate) {
+			return nil, fmt.Errorf("unsupported COSE_Key parameter %v", p.key)
+		}
+	}
+	if kty, _ := m.get(labelKeyType); kty != int64(keyTypeAKP) {
+		return nil, fmt.Errorf("unsupported COSE_Key key type %v", kty)
+	}
+	alg, _ := m.get(labelAlgorithm)
+	id, ok := alg.(int64)
+	if !ok {
+		return nil, errors.New("COSE_Key lacks an algorithm")
+	}
+	a, err := algorithmByID(id)
+	if err != nil {
+		return nil, err
+	}
+	key := &Key{Algorithm: id}
+	if v, ok := m.get(labelKeyID); ok {
+		if key.KeyID, ok = v.([]byte); !ok {
+			return nil, errors.New("invalid COSE_Key key ID")
+		}
+	}
+	v, _ = m.get(labelPublic)
+	if key.PublicKey, ok = v.([]byte); !ok || len(key.PublicKey) != a.suite.KEM.PublicKeyBytes() {
+		return nil, errors.New("invalid COSE_Key public key")
+	}
+	if v, ok := m.get(labelPrivate); ok {
+		seed, ok := v.([]byte)
+		if !ok || len(seed) != a.kem.SeedBytes() {
+			return nil, errors.New("invalid COSE_Key private key")
+		}
+		derived, err := NewKey(id, seed)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(derived.PublicKey, key.PublicKey) {
+			return nil, errors.New("COSE_Key public key does not match the private key")
+		}
+		key.Seed = seed
+	}
+	return key, nil
+}
@@ -0,0 +1,161 @@
+// Command toy-attack breaks a toy Kyber key pair with lattice reduction.
+//
+// It builds a key pair over a small ring with the same IND-CPA code as the
+// real parameter sets, rewrites the public key as an LWE instance and
+// recovers the secret with the primal attack of package lattice. The
+// recovered private key is byte-for-byte the real one and decrypts
+// ciphertexts. With n = 256 the same attack would need a BKZ block size of
+// about 400, far beyond reach; that gap is what the parameters of Kyber buy.
+//
+// Usage:
+//
+//	toy-attack [-n 32] [-q 257] [-k 1] [-eta 1] [-block 10]
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+	"github.com/Rohith04MVK/goKyber/lattice"
+)
+
+func main() {
+	n := flag.Int("n", 32, "ring degree")
+	q := flag.Int("q", 257, "ring modulus, a prime equal to 1 modulo n")
+	k := flag.Int("k", 1, "module rank")
+	eta := flag.Int("eta", 1, "noise width")
+	blockSize := flag.Int("block", 10, "BKZ block size (2 for plain LLL)")
+	flag.Parse()
+
+	params, err := toyParameterSet(*n, *q, *k, *eta)
+	if err != nil {
+		fatal(err)
+	}
+	fmt.Printf("Toy parameter set: n = %d, q = %d, k = %d, eta = %d\n", *n, *q, *k, *eta)
+	fmt.Printf("The attack reduces a lattice of dimension %d.\n", 2**k**n+1)
+
+	privateKey, publicKey, err := params.IndcpaKeypair()
+	if err != nil {
+		fatal(err)
+	}
+
+	start := time.Now()
+	recovered, err := recoverPrivateKey(params, publicKey, *blockSize)
+	if err != nil {
+		fatal(err)
+	}
+	fmt.Printf("Secret recovered from the public key in %v.\n", time.Since(start).Round(time.Millisecond))
+	fmt.Printf("Recovered private key equals the real one: %v\n", bytes.Equal(recovered, privateKey))
+
+	message := make([]byte, params.MessageBytes())
+	coins := make([]byte, 32)
+	rand.Read(message)
+	rand.Read(coins)
+	ciphertext, err := params.IndcpaEncrypt(message, publicKey, coins)
+	if err != nil {
+		fatal(err)
+	}
+	decrypted, err := params.IndcpaDecrypt(ciphertext, recovered)
+	if err != nil {
+		fatal(err)
+	}
+	fmt.Printf("Message decrypted with the recovered key:    %x\n", decrypted)
+	fmt.Printf("Message that was encrypted:                  %x\n", message)
+}
+
+// toyParameterSet returns a parameter set over the ring Z_q[X]/(X^n + 1).
+func toyParameterSet(n, q, k, eta int) (*gokyber.ParameterSet, error) {
+	ring, err := gokyber.NewRing(n, q)
+	if err != nil {
+		return nil, err
+	}
+	params := &gokyber.ParameterSet{
+		Name:      fmt.Sprintf("toy-%d-%d", n, q),
+		K:         k,
+		Eta1:      eta,
+		Eta2:      eta,
+		Du:        11,
+		Dv:        11,
+		Symmetric: gokyber.SymmetricSHAKE,
+		Ring:      ring,
+	}
+	return params, params.Validate()
+}
+
+// publicKeyLWE rewrites an IND-CPA public key `t = A s + e` over the ring as
+// a plain LWE instance. Multiplication by a polynomial `a` in
+// Z_q[X]/(X^n + 1) is the negacyclic matrix whose column c holds the
+// coefficients of `a X^c`.
+func publicKeyLWE(params *gokyber.ParameterSet, publicKey []byte) (lattice.LWE, error) {
+	ring := params.PolynomialRing()
+	n := ring.N
+	publicKeyVector, seed := params.IndcpaUnpackPublicKey(publicKey)
+	matrix, err := params.IndcpaGenMatrix(seed, false)
+	if err != nil {
+		return lattice.LWE{}, err
+	}
+
+	dimension := params.K * n
+	instance := lattice.LWE{
+		A:     make([][]int64, dimension),
+		T:     make([]int64, dimension),
+		Q:     int64(ring.Q),
+		Bound: int64(params.Eta1),
+	}
+	for i := range instance.A {
+		instance.A[i] = make([]int64, dimension)
+	}
+	for i := 0; i < params.K; i++ {
+		t := ring.InvNTT(publicKeyVector[i])
+		for row := 0; row < n; row++ {
+			instance.T[i*n+row] = int64(t[row])
+		}
+		for j := 0; j < params.K; j++ {
+			a := ring.InvNTT(matrix[i][j])
+			for row := 0; row < n; row++ {
+				for column := 0; column < n; column++ {
+					if row >= column {
+						instance.A[i*n+row][j*n+column] = int64(a[row-column])
+					} else {
+						instance.A[i*n+row][j*n+column] = (int64(ring.Q) - int64(a[n+row-column])) % int64(ring.Q)
+					}
+				}
+			}
+		}
+	}
+	return instance, nil
+}
+
+// recoverPrivateKey runs the primal attack on a public key and packs the
+// recovered secret as an IND-CPA private key.
+func recoverPrivateKey(params *gokyber.ParameterSet, publicKey []byte, blockSize int) ([]byte, error) {
+	instance, err := publicKeyLWE(params, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	s, _, err := lattice.PrimalAttack(instance, blockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	ring := params.PolynomialRing()
+	secret := make(gokyber.PolynomialVector, params.K)
+	for i := range secret {
+		var p gokyber.Polynomial
+		for j := 0; j < ring.N; j++ {
+			p[j] = int16((s[i*ring.N+j] + int64(ring.Q)) % int64(ring.Q))
+		}
+		secret[i] = ring.NTT(p)
+	}
+	return params.IndcpaPackPrivateKey(secret), nil
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "toy-attack:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRecoverPrivateKey(t *testing.T) {
+	for _, tc := range []struct {
+		n, q, blockSize int
+	}{
+		{16, 97, 2},
+		{32, 257, 10},
+	} {
+		params, err := toyParameterSet(tc.n, tc.q, 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privateKey, publicKey, err := params.IndcpaKeypair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		recovered, err := recoverPrivateKey(params, publicKey, tc.blockSize)
+		if err != nil {
+			t.Fatalf("n=%d q=%d: %v", tc.n, tc.q, err)
+		}
+		if !bytes.Equal(recovered, privateKey) {
+			t.Errorf("n=%d q=%d: recovered private key differs from the real one", tc.n, tc.q)
+		}
+	}
+}
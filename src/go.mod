@@ -2,7 +2,10 @@ module github.com/Rohith04MVK/goKyber
 
 go 1.23.3
 
-require golang.org/x/crypto v0.37.0 // direct
+require (
+	golang.org/x/crypto v0.37.0
+	golang.org/x/sys v0.32.0
+)
 
 require (
 	github.com/bytedance/sonic v1.13.2 // indirect
@@ -27,7 +30,6 @@ require (
 	github.com/ugorji/go/codec v1.2.12 // indirect
 	golang.org/x/arch v0.16.0 // indirect
 	golang.org/x/net v0.38.0 // indirect
-	golang.org/x/sys v0.32.0 // indirect
 	golang.org/x/text v0.24.0 // indirect
 	google.golang.org/protobuf v1.36.6 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
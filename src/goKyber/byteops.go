@@ -58,11 +58,23 @@ func ByteopsLoad24(inputBytes []byte) uint32 {
 //
 // The function uses bitwise operations and shifts to manipulate the input bytes and compute the polynomial coefficients.
 func ByteopsCbd(uniformBytes []byte, kVariant int) Polynomial {
+	switch kVariant {
+	case 2:
+		return byteopsCbd(uniformBytes, paramsETAK512)
+	default:
+		return byteopsCbd(uniformBytes, paramsETAK768K1024)
+	}
+}
+
+// byteopsCbd samples a centered binomial distribution of width `eta`: each
+// coefficient is the difference of the Hamming weights of two consecutive
+// groups of `eta` bits. Widths 3 and 2 have dedicated implementations.
+func byteopsCbd(uniformBytes []byte, eta int) Polynomial {
 	var t, d uint32
 	var a, b int16
 	var resultPoly Polynomial
-	switch kVariant {
-	case 2:
+	switch eta {
+	case 3:
 		for i := 0; i < paramsN/4; i++ {
 			// $t = x_0 | x_1 << 8 | x_2 << 16$
 			t = ByteopsLoad24(uniformBytes[3*i:])
@@ -79,7 +91,7 @@ func ByteopsCbd(uniformBytes []byte, kVariant int) Polynomial {
 				resultPoly[4*i+j] = a - b
 			}
 		}
-	default:
+	case 2:
 		for i := 0; i < paramsN/8; i++ {
 			// $t = x_0 | x_1 << 8 | x_2 << 16 | x_3 << 24$
 			t = ByteopsLoad32(uniformBytes[4*i:])
@@ -95,10 +107,28 @@ func ByteopsCbd(uniformBytes []byte, kVariant int) Polynomial {
 				resultPoly[8*i+j] = a - b
 			}
 		}
+	default:
+		cbdBits(resultPoly[:paramsN], uniformBytes, eta)
 	}
 	return resultPoly
 }
 
+// cbdBits is the generic centered binomial sampler: coefficient `i` is the
+// number of set bits among bits `2*eta*i` to `2*eta*i + eta - 1` of
+// uniformBytes, minus the number among the next `eta` bits.
+func cbdBits(coefficients []int16, uniformBytes []byte, eta int) {
+	for i := range coefficients {
+		var a, b int16
+		for j := 0; j < eta; j++ {
+			pos := 2*eta*i + j
+			a += int16((uniformBytes[pos/8] >> (pos % 8)) & 1)
+			pos += eta
+			b += int16((uniformBytes[pos/8] >> (pos % 8)) & 1)
+		}
+		coefficients[i] = a - b
+	}
+}
+
 // ByteopsMontgomeryReduce reduces a 32-bit integer 'a' using Montgomery reduction.
 // This function performs the reduction by multiplying 'a' with a constant 'paramsQInv',
 // then subtracting the product from 'a' after converting it to a 32-bit integer.
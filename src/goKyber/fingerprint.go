@@ -0,0 +1,83 @@
+package gokyber
+
+import (
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Rohith04MVK/goKyber/internal/bech32"
+	"golang.org/x/crypto/sha3"
+)
+
+// fingerprintBytes is the length of fingerprints: 160 bits, 32 base32
+// characters.
+const fingerprintBytes = 20
+
+// publicKeyHRPs holds the human-readable parts of the textual encodings of
+// public keys, looked up like algorithmIDs.
+var publicKeyHRPs = map[string]string{
+	Kyber512.Name:      "kyber512pub",
+	Kyber768.Name:      "kyber768pub",
+	Kyber1024.Name:     "kyber1024pub",
+	Kyber512_90s.Name:  "kyber90s512pub",
+	Kyber768_90s.Name:  "kyber90s768pub",
+	Kyber1024_90s.Name: "kyber90s1024pub",
+	MLKEM512.Name:      "mlkem512pub",
+	MLKEM768.Name:      "mlkem768pub",
+	MLKEM1024.Name:     "mlkem1024pub",
+}
+
+// Fingerprint returns the fingerprint of a public key, for comparing keys
+// out of band: the SHA3-256 hash of the textual encoding of the key (see
+// EncodePublicKey), truncated to 160 bits and written as eight groups of
+// four base32 characters, such as "QWKC 7BM3 ...". The encoding names the
+// parameter set, so a Kyber768 and an ML-KEM-768 key of the same bytes have
+// different fingerprints; only the predefined parameter sets have one.
+func (p *ParameterSet) Fingerprint(publicKey []byte) (string, error) {
+	encoded, err := p.EncodePublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	hash := sha3.Sum256([]byte(encoded))
+	b32 := base32.StdEncoding.EncodeToString(hash[:fingerprintBytes])
+	groups := make([]string, 0, len(b32)/4)
+	for i := 0; i < len(b32); i += 4 {
+		groups = append(groups, b32[i:i+4])
+	}
+	return strings.Join(groups, " "), nil
+}
+
+// EncodePublicKey returns the textual encoding of a public key: bech32m
+// with a human-readable part naming the parameter set, such as
+// "kyber768pub1...". Only the predefined parameter sets have one.
+func (p *ParameterSet) EncodePublicKey(publicKey []byte) (string, error) {
+	q := p.Predefined()
+	if q == nil {
+		return "", fmt.Errorf("parameter set %s has no textual key encoding", p.Name)
+	}
+	hrp := publicKeyHRPs[q.Name]
+	if len(publicKey) != p.PublicKeyBytes() {
+		return "", errors.New("invalid public key length")
+	}
+	return bech32.EncodeM(hrp, publicKey)
+}
+
+// DecodePublicKey decodes a public key encoded with EncodePublicKey and
+// returns its parameter set.
+func DecodePublicKey(s string) (*ParameterSet, []byte, error) {
+	hrp, publicKey, err := bech32.DecodeM(s)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid textual public key: %w", err)
+	}
+	for _, p := range predefinedSets {
+		if publicKeyHRPs[p.Name] != hrp {
+			continue
+		}
+		if len(publicKey) != p.PublicKeyBytes() {
+			return nil, nil, errors.New("invalid public key length")
+		}
+		return p, publicKey, nil
+	}
+	return nil, nil, fmt.Errorf("unknown public key type %q", hrp)
+}
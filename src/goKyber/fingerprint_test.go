@@ -0,0 +1,114 @@
+package gokyber
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Rohith04MVK/goKyber/internal/bech32"
+)
+
+func TestFingerprint(t *testing.T) {
+	publicKey := make([]byte, Kyber768.PublicKeyBytes())
+	for i := range publicKey {
+		publicKey[i] = byte(i)
+	}
+	got, err := Kyber768.Fingerprint(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "WYVM KSGE 7TUK VAKO H7CW F4LD 22QJ CE74"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if other, err := MLKEM768.Fingerprint(publicKey); err != nil || other == got {
+		t.Error("fingerprint does not depend on the parameter set")
+	}
+	custom := *Kyber768
+	custom.Du = 11
+	if _, err := custom.Fingerprint(publicKey); err == nil {
+		t.Error("fingerprinted a key of a custom parameter set")
+	}
+	publicKey[0] ^= 1
+	if other, _ := Kyber768.Fingerprint(publicKey); other == got {
+		t.Error("fingerprint does not depend on the public key")
+	}
+	if _, err := Kyber1024.Fingerprint(publicKey); err == nil {
+		t.Error("fingerprinted a public key of the wrong length")
+	}
+}
+
+func TestEncodePublicKey(t *testing.T) {
+	for _, p := range predefinedSets {
+		_, publicKey, err := p.KemKeypair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := p.EncodePublicKey(publicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(s, publicKeyHRPs[p.Name]+"1") {
+			t.Errorf("%s: %.20s...", p.Name, s)
+		}
+		decodedParams, decoded, err := DecodePublicKey(s)
+		if err != nil {
+			t.Fatalf("%s: %v", p.Name, err)
+		}
+		if decodedParams != p || !bytes.Equal(decoded, publicKey) {
+			t.Errorf("%s: does not round trip", p.Name)
+		}
+		if _, _, err := DecodePublicKey(strings.ToUpper(s)); err != nil {
+			t.Errorf("%s: upper case: %v", p.Name, err)
+		}
+		// A mistyped character is caught by the checksum.
+		typo := []byte(s)
+		i := len(typo) / 2
+		if typo[i] == 'q' {
+			typo[i] = 'p'
+		} else {
+			typo[i] = 'q'
+		}
+		if _, _, err := DecodePublicKey(string(typo)); err == nil {
+			t.Errorf("%s: accepted a mistyped key", p.Name)
+		}
+	}
+
+	if _, err := Kyber768.EncodePublicKey(make([]byte, 10)); err == nil {
+		t.Error("encoded a public key of the wrong length")
+	}
+	// Parameter sets are matched on their parameters, not their names.
+	custom := *Kyber768
+	custom.Name = "custom"
+	if s, err := custom.EncodePublicKey(make([]byte, custom.PublicKeyBytes())); err != nil || !strings.HasPrefix(s, "kyber768pub1") {
+		t.Errorf("renamed copy of Kyber768 encoded as %.20q, %v", s, err)
+	}
+	custom.Name = Kyber768.Name
+	custom.Dv = 5
+	if _, err := custom.EncodePublicKey(make([]byte, custom.PublicKeyBytes())); err == nil {
+		t.Error("encoded a key of a custom parameter set")
+	}
+	// Valid bech32m strings of the wrong length or type.
+	for _, hrp := range []string{"kyber768pub", "other"} {
+		s, err := bech32.EncodeM(hrp, make([]byte, Kyber512.PublicKeyBytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := DecodePublicKey(s); err == nil {
+			t.Errorf("%s: decoded", hrp)
+		}
+	}
+}
+
+func TestPredefinedSets(t *testing.T) {
+	for _, p := range predefinedSets {
+		if _, ok := algorithmIDs[p.Name]; !ok {
+			t.Errorf("%s: no algorithm identifier", p.Name)
+		}
+		if _, ok := publicKeyHRPs[p.Name]; !ok {
+			t.Errorf("%s: no textual key encoding", p.Name)
+		}
+	}
+	if len(algorithmIDs) != len(predefinedSets) || len(publicKeyHRPs) != len(predefinedSets) {
+		t.Errorf("%d algorithm identifiers and %d textual key encodings for %d parameter sets", len(algorithmIDs), len(publicKeyHRPs), len(predefinedSets))
+	}
+}
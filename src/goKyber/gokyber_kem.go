@@ -4,10 +4,88 @@ import (
 	"crypto/rand"
 	"crypto/subtle"
 	"errors"
+)
+
+// KEM is a key encapsulation mechanism. Every ParameterSet is one, and so
+// are the hybrid KEMs of this package, so that code written against KEM
+// works with all of them.
+type KEM interface {
+	// KemKeypair returns a fresh private key and public key.
+	KemKeypair() (privateKey []byte, publicKey []byte, err error)
+	// KemEncrypt encapsulates a fresh shared secret to a public key and
+	// returns the ciphertext and the shared secret.
+	KemEncrypt(publicKey []byte) (ciphertext []byte, sharedSecret []byte, err error)
+	// KemDecrypt returns the shared secret encapsulated in a ciphertext.
+	KemDecrypt(ciphertext []byte, privateKey []byte) ([]byte, error)
+
+	PublicKeyBytes() int
+	PrivateKeyBytes() int
+	CiphertextBytes() int
+	SharedSecretBytes() int
+}
+
+var _ KEM = (*ParameterSet)(nil)
 
-	"golang.org/x/crypto/sha3"
+// algorithmIDs holds the algorithm identifiers of the predefined parameter
+// sets, by name. Other parameter sets are looked up by their Predefined
+// set, so that traced copies share the identifier of their original.
+var algorithmIDs = map[string]uint16{
+	Kyber512.Name:      0x0001,
+	Kyber768.Name:      0x0002,
+	Kyber1024.Name:     0x0003,
+	Kyber512_90s.Name:  0x0011,
+	Kyber768_90s.Name:  0x0012,
+	Kyber1024_90s.Name: 0x0013,
+	MLKEM512.Name:      0x0021,
+	MLKEM768.Name:      0x0022,
+	MLKEM1024.Name:     0x0023,
+}
+
+// The algorithm identifiers of the other predefined KEMs.
+const (
+	algorithmXWing         = 0x0031
+	algorithmMLKEM768P256  = 0x0032
+	algorithmMLKEM1024P384 = 0x0033
 )
 
+// AlgorithmID returns the identifier of a KEM in the binary formats of this
+// module, such as sealed messages. Only the predefined parameter sets,
+// XWing, MLKEM768P256 and MLKEM1024P384 have one.
+func AlgorithmID(kem KEM) (uint16, error) {
+	switch kem {
+	case XWing:
+		return algorithmXWing, nil
+	case MLKEM768P256:
+		return algorithmMLKEM768P256, nil
+	case MLKEM1024P384:
+		return algorithmMLKEM1024P384, nil
+	}
+	if p, ok := kem.(*ParameterSet); ok {
+		if q := p.Predefined(); q != nil {
+			return algorithmIDs[q.Name], nil
+		}
+	}
+	return 0, errors.New("KEM has no algorithm identifier")
+}
+
+// KEMForAlgorithmID returns the KEM with an algorithm identifier.
+func KEMForAlgorithmID(id uint16) (KEM, error) {
+	switch id {
+	case algorithmXWing:
+		return XWing, nil
+	case algorithmMLKEM768P256:
+		return MLKEM768P256, nil
+	case algorithmMLKEM1024P384:
+		return MLKEM1024P384, nil
+	}
+	for _, p := range predefinedSets {
+		if algorithmIDs[p.Name] == id {
+			return p, nil
+		}
+	}
+	return nil, errors.New("unknown algorithm identifier")
+}
+
 // KemKeypair generates a key pair for the Kyber KEM (Key Encapsulation Mechanism) based on the specified variant.
 //
 // Parameters:
@@ -24,43 +102,71 @@ import (
 // The function initializes the key pair based on the Kyber variant, generates the IND-CPA key pair,
 // computes the hash of the public key, and combines these components to form the private key.
 func KemKeypair(kyberVariant int) ([]byte, []byte, error) {
-	var privateKey, publicKey []byte
-	var paramsK int
-	var indcpaPrivateKey, indcpaPublicKey []byte
-
-	switch kyberVariant {
-	case 512:
-		paramsK = 2
-		privateKey = make([]byte, Kyber512SKBytes)
-		publicKey = make([]byte, Kyber512PKBytes)
-	case 768:
-		paramsK = 3
-		privateKey = make([]byte, Kyber768SKBytes)
-		publicKey = make([]byte, Kyber768PKBytes)
-	case 1024:
-		paramsK = 4
-		privateKey = make([]byte, Kyber1024SKBytes)
-		publicKey = make([]byte, Kyber1024PKBytes)
-	default:
-		return nil, nil, errors.New("invalid Kyber variant")
-	}
-
-	var err error
-	indcpaPrivateKey, indcpaPublicKey, err = IndcpaKeypair(paramsK)
+	params, err := ParameterSetForVariant(kyberVariant)
 	if err != nil {
 		return nil, nil, err
 	}
+	return params.KemKeypair()
+}
 
-	pkh := sha3.Sum256(indcpaPublicKey)
-	rnd := make([]byte, paramsSymBytes)
-	if _, err = rand.Read(rnd); err != nil {
+// KemKeypair90s generates a key pair for the Kyber-90s KEM, which uses AES-256-CTR
+// and SHA-2 in place of SHAKE and SHA-3. Its parameters and return values are the
+// same as those of KemKeypair; keys of the two instantiations are not interchangeable.
+func KemKeypair90s(kyberVariant int) ([]byte, []byte, error) {
+	params, err := parameterSet90sForVariant(kyberVariant)
+	if err != nil {
 		return nil, nil, err
 	}
+	return params.KemKeypair()
+}
+
+// KemKeypair generates a KEM key pair for the parameter set. It returns the
+// private key, of PrivateKeyBytes bytes, and the public key, of
+// PublicKeyBytes bytes.
+func (p *ParameterSet) KemKeypair() ([]byte, []byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, nil, err
+	}
+	seed := make([]byte, p.SeedBytes())
+	if _, err := rand.Read(seed); err != nil {
+		return nil, nil, err
+	}
+	return p.KemKeypairFromSeed(seed)
+}
+
+// KemKeypairFromSeed deterministically derives a KEM key pair from a seed
+// of SeedBytes bytes: the 32-byte seed of the IND-CPA key pair followed by
+// the 32-byte value `z` used for implicit rejection (`d || z` in FIPS 203).
+// Storing the seed is a compact alternative to storing the private key.
+func (p *ParameterSet) KemKeypairFromSeed(seed []byte) ([]byte, []byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if len(seed) != p.SeedBytes() {
+		return nil, nil, errors.New("invalid seed length")
+	}
+	return p.kemKeypairDerand(seed[:paramsSymBytes], seed[paramsSymBytes:])
+}
+
+// kemKeypairDerand is the deterministic core of KemKeypair. It takes the seed of
+// the IND-CPA key pair and the secret value `z` used for implicit rejection.
+func (p *ParameterSet) kemKeypairDerand(seed []byte, z []byte) ([]byte, []byte, error) {
+	privateKey := make([]byte, p.PrivateKeyBytes())
+	publicKey := make([]byte, p.PublicKeyBytes())
+
+	indcpaPrivateKey, indcpaPublicKey, err := p.indcpaKeypairDerand(seed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pkh := p.Symmetric.Hash256(indcpaPublicKey)
+	p.traceBytes("kem.keypair.z", z)
+	p.traceBytes("kem.keypair.publicKeyHash", pkh[:])
 
 	copy(privateKey, indcpaPrivateKey)
 	copy(privateKey[len(indcpaPrivateKey):], indcpaPublicKey)
 	copy(privateKey[len(indcpaPrivateKey)+len(indcpaPublicKey):], pkh[:])
-	copy(privateKey[len(indcpaPrivateKey)+len(indcpaPublicKey)+len(pkh):], rnd)
+	copy(privateKey[len(indcpaPrivateKey)+len(indcpaPublicKey)+len(pkh):], z)
 
 	copy(publicKey, indcpaPublicKey)
 
@@ -91,43 +197,83 @@ func KemKeypair(kyberVariant int) ([]byte, []byte, error) {
 //  6. Computes the hash of the ciphertext and generates the shared secret.
 //  7. Returns the ciphertext and shared secret.
 func KemEncrypt(publicKey []byte, kyberVariant int) ([]byte, []byte, error) {
-	var ciphertext []byte
-	var paramsK int
-
-	switch kyberVariant {
-	case 512:
-		paramsK = 2
-		ciphertext = make([]byte, Kyber512CTBytes)
-	case 768:
-		paramsK = 3
-		ciphertext = make([]byte, Kyber768CTBytes)
-	case 1024:
-		paramsK = 4
-		ciphertext = make([]byte, Kyber1024CTBytes)
-	default:
-		return nil, nil, errors.New("invalid Kyber variant")
-	}
-
-	sharedSecret := make([]byte, KyberSSBytes)
-	buf := make([]byte, 2*paramsSymBytes)
-	if _, err := rand.Read(buf[:paramsSymBytes]); err != nil {
+	params, err := ParameterSetForVariant(kyberVariant)
+	if err != nil {
 		return nil, nil, err
 	}
+	return params.KemEncrypt(publicKey)
+}
+
+// KemEncrypt90s encapsulates a shared secret to a Kyber-90s public key, as
+// returned by KemKeypair90s. Its parameters and return values are the same as
+// those of KemEncrypt.
+func KemEncrypt90s(publicKey []byte, kyberVariant int) ([]byte, []byte, error) {
+	params, err := parameterSet90sForVariant(kyberVariant)
+	if err != nil {
+		return nil, nil, err
+	}
+	return params.KemEncrypt(publicKey)
+}
 
-	buf1 := sha3.Sum256(buf[:paramsSymBytes])
-	buf2 := sha3.Sum256(publicKey)
-	kr := sha3.Sum512(append(buf1[:], buf2[:]...))
+// KemEncrypt encapsulates a fresh shared secret to a public key of the
+// parameter set and returns the ciphertext and the shared secret.
+func (p *ParameterSet) KemEncrypt(publicKey []byte) ([]byte, []byte, error) {
+	rnd := make([]byte, paramsSymBytes)
+	if _, err := rand.Read(rnd); err != nil {
+		return nil, nil, err
+	}
+	return p.KemEncryptFromSeed(publicKey, rnd)
+}
+
+// KemEncryptFromSeed deterministically encapsulates a shared secret derived
+// from a 32-byte seed, the random bytes KemEncrypt draws (`m` in FIPS 203).
+// It exists for known-answer tests and for protocols that specify how the
+// encapsulation randomness is derived; the seed must be secret and never
+// reused.
+func (p *ParameterSet) KemEncryptFromSeed(publicKey, seed []byte) ([]byte, []byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if len(publicKey) != p.PublicKeyBytes() {
+		return nil, nil, errors.New("invalid public key length")
+	}
+	if p.FIPS203 && !p.publicKeyReduced(publicKey) {
+		return nil, nil, errors.New("invalid public key: coefficient out of range")
+	}
+	if len(seed) != paramsSymBytes {
+		return nil, nil, errors.New("invalid seed length")
+	}
+	return p.kemEncryptDerand(publicKey, seed)
+}
+
+// kemEncryptDerand is the unchecked core of KemEncryptFromSeed, taking the
+// 32 random bytes the encapsulated message is derived from.
+func (p *ParameterSet) kemEncryptDerand(publicKey []byte, rnd []byte) ([]byte, []byte, error) {
+	if p.FIPS203 {
+		return p.mlkemEncapsulate(publicKey, rnd)
+	}
+	buf1 := p.Symmetric.Hash256(rnd)
+	buf2 := p.Symmetric.Hash256(publicKey)
+	// Toy rings encrypt messages shorter than 32 bytes.
+	message := buf1[:p.MessageBytes()]
+	kr := p.Symmetric.Hash512(append(message, buf2[:]...))
+	p.traceBytes("kem.encaps.random", rnd)
+	p.traceBytes("kem.encaps.message", message)
+	p.traceBytes("kem.encaps.publicKeyHash", buf2[:])
+	p.traceBytes("kem.encaps.preKey", kr[:paramsSymBytes])
+	p.traceBytes("kem.encaps.coins", kr[paramsSymBytes:])
 
-	ct, err := IndcpaEncrypt(buf1[:], publicKey, kr[paramsSymBytes:], paramsK)
+	ciphertext, err := p.IndcpaEncrypt(message, publicKey, kr[paramsSymBytes:])
 	if err != nil {
 		return nil, nil, err
 	}
 
-	krc := sha3.Sum256(ct)
-	sha3.ShakeSum256(sharedSecret, append(kr[:paramsSymBytes], krc[:]...))
+	krc := p.Symmetric.Hash256(ciphertext)
+	sharedSecret := p.Symmetric.Kdf(append(kr[:paramsSymBytes], krc[:]...))
+	p.traceBytes("kem.encaps.ciphertextHash", krc[:])
+	p.traceBytes("kem.encaps.sharedSecret", sharedSecret[:])
 
-	copy(ciphertext, ct)
-	return ciphertext, sharedSecret, nil
+	return ciphertext, sharedSecret[:], nil
 }
 
 // KemDecrypt decrypts a given ciphertext using the provided private key and Kyber variant.
@@ -155,55 +301,69 @@ func KemEncrypt(publicKey []byte, kyberVariant int) ([]byte, []byte, error) {
 //  7. Adjusts the key recovery value based on the comparison result.
 //  8. Computes the shared secret using SHAKE-256.
 func KemDecrypt(ciphertext, privateKey []byte, kyberVariant int) ([]byte, error) {
-	var paramsK, paramsIndcpaSecretKeyBytes, paramsIndcpaPublicKeyBytes int
-	var err error
-
-	switch kyberVariant {
-	case 512:
-		paramsK = 2
-		paramsIndcpaSecretKeyBytes = paramsIndcpaSecretKeyBytesK512
-		paramsIndcpaPublicKeyBytes = paramsIndcpaPublicKeyBytesK512
-	case 768:
-		paramsK = 3
-		paramsIndcpaSecretKeyBytes = paramsIndcpaSecretKeyBytesK768
-		paramsIndcpaPublicKeyBytes = paramsIndcpaPublicKeyBytesK768
-	case 1024:
-		paramsK = 4
-		paramsIndcpaSecretKeyBytes = paramsIndcpaSecretKeyBytesK1024
-		paramsIndcpaPublicKeyBytes = paramsIndcpaPublicKeyBytesK1024
-	default:
-		return nil, errors.New("invalid Kyber variant")
-	}
-	sharedSecret := make([]byte, KyberSSBytes)
-	indcpaPrivateKey := privateKey[:paramsIndcpaSecretKeyBytes]
-	publicKey := privateKey[paramsIndcpaSecretKeyBytes : paramsIndcpaSecretKeyBytes+paramsIndcpaPublicKeyBytes]
+	params, err := ParameterSetForVariant(kyberVariant)
+	if err != nil {
+		return nil, err
+	}
+	return params.KemDecrypt(ciphertext, privateKey)
+}
 
-	buf := IndcpaDecrypt(ciphertext, indcpaPrivateKey, paramsK)
+// KemDecrypt90s decapsulates a Kyber-90s ciphertext with a private key returned
+// by KemKeypair90s. Its parameters and return values are the same as those of
+// KemDecrypt.
+func KemDecrypt90s(ciphertext, privateKey []byte, kyberVariant int) ([]byte, error) {
+	params, err := parameterSet90sForVariant(kyberVariant)
+	if err != nil {
+		return nil, err
+	}
+	return params.KemDecrypt(ciphertext, privateKey)
+}
 
-	var privateKeyEnd int
-	switch kyberVariant {
-	case 512:
-		privateKeyEnd = Kyber512SKBytes
-	case 768:
-		privateKeyEnd = Kyber768SKBytes
-	case 1024:
-		privateKeyEnd = Kyber1024SKBytes
+// KemDecrypt decapsulates a ciphertext with a private key of the parameter
+// set. Invalid ciphertexts of the right length are implicitly rejected: they
+// yield a pseudo-random shared secret rather than an error.
+func (p *ParameterSet) KemDecrypt(ciphertext, privateKey []byte) ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if len(ciphertext) != p.CiphertextBytes() {
+		return nil, errors.New("invalid ciphertext length")
+	}
+	if len(privateKey) != p.PrivateKeyBytes() {
+		return nil, errors.New("invalid private key length")
+	}
+	if p.FIPS203 {
+		return p.mlkemDecapsulate(ciphertext, privateKey)
 	}
 
-	kr := sha3.Sum512(append(buf, privateKey[privateKeyEnd-2*paramsSymBytes:privateKeyEnd-paramsSymBytes]...))
-	cmp, err := IndcpaEncrypt(buf, publicKey, kr[paramsSymBytes:], paramsK)
+	paramsIndcpaSecretKeyBytes := p.IndcpaSecretKeyBytes()
+	paramsIndcpaPublicKeyBytes := p.IndcpaPublicKeyBytes()
+	indcpaPrivateKey := privateKey[:paramsIndcpaSecretKeyBytes]
+	publicKey := privateKey[paramsIndcpaSecretKeyBytes : paramsIndcpaSecretKeyBytes+paramsIndcpaPublicKeyBytes]
+
+	buf := p.indcpaDecrypt(ciphertext, indcpaPrivateKey)
+
+	privateKeyEnd := len(privateKey)
+	kr := p.Symmetric.Hash512(append(buf, privateKey[privateKeyEnd-2*paramsSymBytes:privateKeyEnd-paramsSymBytes]...))
+	p.traceBytes("kem.decaps.message", buf)
+	p.traceBytes("kem.decaps.preKey", kr[:paramsSymBytes])
+	p.traceBytes("kem.decaps.coins", kr[paramsSymBytes:])
+	cmp, err := p.IndcpaEncrypt(buf, publicKey, kr[paramsSymBytes:])
 	if err != nil {
 		return nil, err
 	}
 
 	fail := byte(subtle.ConstantTimeCompare(ciphertext, cmp) - 1)
-	krh := sha3.Sum256(ciphertext)
+	krh := p.Symmetric.Hash256(ciphertext)
+	p.traceBool("kem.decaps.valid", fail == 0)
+	p.traceBytes("kem.decaps.ciphertextHash", krh[:])
 
 	for i := 0; i < paramsSymBytes; i++ {
 		kr[i] = kr[i] ^ (fail & (kr[i] ^ privateKey[privateKeyEnd-paramsSymBytes+i]))
 	}
 
-	sha3.ShakeSum256(sharedSecret, append(kr[:paramsSymBytes], krh[:]...))
+	sharedSecret := p.Symmetric.Kdf(append(kr[:paramsSymBytes], krh[:]...))
+	p.traceBytes("kem.decaps.sharedSecret", sharedSecret[:])
 
-	return sharedSecret, nil
+	return sharedSecret[:], nil
 }
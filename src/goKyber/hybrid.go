@@ -0,0 +1,193 @@
+package gokyber
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// HybridKEM pairs a Kyber parameter set with ECDH on any curve of
+// crypto/ecdh, for peers that require a classical algorithm they already
+// trust, such as a NIST curve, next to Kyber. The shared secret stays secret
+// as long as either component is unbroken.
+//
+// Public keys are the Kyber public key followed by the ECDH public key in
+// the encoding of crypto/ecdh (an uncompressed point for the NIST curves),
+// private keys the Kyber private key followed by the ECDH private scalar,
+// and ciphertexts the Kyber ciphertext followed by an ephemeral ECDH public
+// key. Every part has a fixed length for a given pair, so the concatenations
+// are unambiguous.
+//
+// The shared secret is derived, in the spirit of the combiner of
+// draft-ietf-lamps-pq-composite-kem, as
+//
+//	Hash(ss_kyber || ss_ecdh || ct_kyber || ct_ecdh || pk_kyber || pk_ecdh || Label)
+//
+// binding it to both ciphertexts and both public keys.
+type HybridKEM struct {
+	// Kyber is the post-quantum component.
+	Kyber *ParameterSet
+	// Curve is the classical component.
+	Curve ecdh.Curve
+	// Label separates the shared secrets of different hybrids and
+	// applications.
+	Label string
+	// Hash is the hash function of the combiner; nil stands for SHA3-256.
+	// Its output is the shared secret.
+	Hash func() hash.Hash
+}
+
+var (
+	// MLKEM768P256 pairs ML-KEM-768 with ECDH on P-256.
+	MLKEM768P256 = NewHybridKEM(MLKEM768, ecdh.P256())
+	// MLKEM1024P384 pairs ML-KEM-1024 with ECDH on P-384.
+	MLKEM1024P384 = NewHybridKEM(MLKEM1024, ecdh.P384())
+)
+
+var _ KEM = MLKEM768P256
+
+// NewHybridKEM returns the hybrid of a Kyber parameter set and a curve, with
+// SHA3-256 as the hash of the combiner and a label naming both components,
+// such as "ML-KEM-768+P-256".
+func NewHybridKEM(kyber *ParameterSet, curve ecdh.Curve) *HybridKEM {
+	return &HybridKEM{Kyber: kyber, Curve: curve, Label: fmt.Sprintf("%s+%v", kyber.Name, curve)}
+}
+
+// ecdhSizes returns the byte lengths of the public and private keys of the
+// curve.
+func (h *HybridKEM) ecdhSizes() (publicKey, privateKey int) {
+	switch h.Curve {
+	case ecdh.P256():
+		return 65, 32
+	case ecdh.P384():
+		return 97, 48
+	case ecdh.P521():
+		return 133, 66
+	default:
+		// X25519
+		return 32, 32
+	}
+}
+
+// PublicKeyBytes returns the byte length of public keys.
+func (h *HybridKEM) PublicKeyBytes() int {
+	publicKey, _ := h.ecdhSizes()
+	return h.Kyber.PublicKeyBytes() + publicKey
+}
+
+// PrivateKeyBytes returns the byte length of private keys.
+func (h *HybridKEM) PrivateKeyBytes() int {
+	_, privateKey := h.ecdhSizes()
+	return h.Kyber.PrivateKeyBytes() + privateKey
+}
+
+// CiphertextBytes returns the byte length of ciphertexts.
+func (h *HybridKEM) CiphertextBytes() int {
+	publicKey, _ := h.ecdhSizes()
+	return h.Kyber.CiphertextBytes() + publicKey
+}
+
+// SharedSecretBytes returns the byte length of shared secrets, the output
+// size of the hash.
+func (h *HybridKEM) SharedSecretBytes() int {
+	return h.newHash().Size()
+}
+
+func (h *HybridKEM) newHash() hash.Hash {
+	if h.Hash == nil {
+		return sha3.New256()
+	}
+	return h.Hash()
+}
+
+// KemKeypair generates a hybrid key pair.
+func (h *HybridKEM) KemKeypair() ([]byte, []byte, error) {
+	kyberPrivateKey, kyberPublicKey, err := h.Kyber.KemKeypair()
+	if err != nil {
+		return nil, nil, err
+	}
+	ecdhPrivateKey, err := h.Curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(kyberPrivateKey, ecdhPrivateKey.Bytes()...), append(kyberPublicKey, ecdhPrivateKey.PublicKey().Bytes()...), nil
+}
+
+// KemEncrypt encapsulates a fresh shared secret to a hybrid public key.
+func (h *HybridKEM) KemEncrypt(publicKey []byte) ([]byte, []byte, error) {
+	if len(publicKey) != h.PublicKeyBytes() {
+		return nil, nil, errors.New("invalid public key length")
+	}
+	kyberPublicKey := publicKey[:h.Kyber.PublicKeyBytes()]
+	ecdhPublicKey, err := h.Curve.NewPublicKey(publicKey[h.Kyber.PublicKeyBytes():])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kyberCiphertext, kyberSharedSecret, err := h.Kyber.KemEncrypt(kyberPublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	ephemeral, err := h.Curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	ecdhSharedSecret, err := ephemeral.ECDH(ecdhPublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ciphertext := append(kyberCiphertext, ephemeral.PublicKey().Bytes()...)
+	return ciphertext, h.combine(kyberSharedSecret, ecdhSharedSecret, ciphertext, publicKey), nil
+}
+
+// KemDecrypt decapsulates a hybrid ciphertext. Invalid Kyber ciphertexts are
+// implicitly rejected; an invalid ECDH ciphertext, which is not a point of
+// the curve, is an error.
+func (h *HybridKEM) KemDecrypt(ciphertext []byte, privateKey []byte) ([]byte, error) {
+	if len(ciphertext) != h.CiphertextBytes() {
+		return nil, errors.New("invalid ciphertext length")
+	}
+	if len(privateKey) != h.PrivateKeyBytes() {
+		return nil, errors.New("invalid private key length")
+	}
+	kyberPrivateKey := privateKey[:h.Kyber.PrivateKeyBytes()]
+	ecdhPrivateKey, err := h.Curve.NewPrivateKey(privateKey[h.Kyber.PrivateKeyBytes():])
+	if err != nil {
+		return nil, err
+	}
+	ephemeral, err := h.Curve.NewPublicKey(ciphertext[h.Kyber.CiphertextBytes():])
+	if err != nil {
+		return nil, err
+	}
+
+	kyberSharedSecret, err := h.Kyber.KemDecrypt(ciphertext[:h.Kyber.CiphertextBytes()], kyberPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	ecdhSharedSecret, err := ecdhPrivateKey.ECDH(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+
+	// The Kyber private key embeds the Kyber public key.
+	kyberPublicKey := kyberPrivateKey[h.Kyber.IndcpaSecretKeyBytes() : h.Kyber.IndcpaSecretKeyBytes()+h.Kyber.PublicKeyBytes()]
+	publicKey := append(append([]byte{}, kyberPublicKey...), ecdhPrivateKey.PublicKey().Bytes()...)
+	return h.combine(kyberSharedSecret, ecdhSharedSecret, ciphertext, publicKey), nil
+}
+
+// combine is the KDF of the hybrid. Ciphertext and public key are the
+// concatenated hybrid encodings, whose order matches the formula.
+func (h *HybridKEM) combine(kyberSharedSecret, ecdhSharedSecret, ciphertext, publicKey []byte) []byte {
+	d := h.newHash()
+	d.Write(kyberSharedSecret)
+	d.Write(ecdhSharedSecret)
+	d.Write(ciphertext)
+	d.Write(publicKey)
+	d.Write([]byte(h.Label))
+	return d.Sum(nil)
+}
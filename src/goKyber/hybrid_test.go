@@ -0,0 +1,128 @@
+package gokyber
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/sha256"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func TestHybridKEMRoundTrip(t *testing.T) {
+	curves := []ecdh.Curve{ecdh.P256(), ecdh.P384(), ecdh.P521(), ecdh.X25519()}
+	for _, kyber := range []*ParameterSet{Kyber512, Kyber768_90s, Kyber1024, MLKEM768} {
+		for _, curve := range curves {
+			h := NewHybridKEM(kyber, curve)
+			privateKey, publicKey, err := h.KemKeypair()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(privateKey) != h.PrivateKeyBytes() || len(publicKey) != h.PublicKeyBytes() {
+				t.Fatalf("%s: key sizes %d and %d, want %d and %d", h.Label, len(privateKey), len(publicKey), h.PrivateKeyBytes(), h.PublicKeyBytes())
+			}
+			ciphertext, sharedSecret, err := h.KemEncrypt(publicKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ciphertext) != h.CiphertextBytes() || len(sharedSecret) != h.SharedSecretBytes() {
+				t.Fatalf("%s: ciphertext and shared secret sizes %d and %d", h.Label, len(ciphertext), len(sharedSecret))
+			}
+			decapsulated, err := h.KemDecrypt(ciphertext, privateKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(decapsulated, sharedSecret) {
+				t.Errorf("%s: shared secrets differ", h.Label)
+			}
+		}
+	}
+}
+
+func TestHybridKEMCombiner(t *testing.T) {
+	h := MLKEM768P256
+	privateKey, publicKey, err := h.KemKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, sharedSecret, err := h.KemEncrypt(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Recompute the combiner from the components.
+	kyber := h.Kyber
+	kyberSharedSecret, err := kyber.KemDecrypt(ciphertext[:kyber.CiphertextBytes()], privateKey[:kyber.PrivateKeyBytes()])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdhPrivateKey, err := ecdh.P256().NewPrivateKey(privateKey[kyber.PrivateKeyBytes():])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ephemeral, err := ecdh.P256().NewPublicKey(ciphertext[kyber.CiphertextBytes():])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdhSharedSecret, err := ecdhPrivateKey.ECDH(ephemeral)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := sha3.New256()
+	for _, part := range [][]byte{
+		kyberSharedSecret, ecdhSharedSecret,
+		ciphertext[:kyber.CiphertextBytes()], ciphertext[kyber.CiphertextBytes():],
+		publicKey[:kyber.PublicKeyBytes()], publicKey[kyber.PublicKeyBytes():],
+		[]byte("ML-KEM-768+P-256"),
+	} {
+		d.Write(part)
+	}
+	if !bytes.Equal(d.Sum(nil), sharedSecret) {
+		t.Error("shared secret does not follow the documented combiner")
+	}
+
+	// A different label or hash gives a different shared secret.
+	other := *h
+	other.Label = "another application"
+	if ss, _ := other.KemDecrypt(ciphertext, privateKey); bytes.Equal(ss, sharedSecret) {
+		t.Error("label does not separate shared secrets")
+	}
+	other = *h
+	other.Hash = sha256.New
+	if ss, _ := other.KemDecrypt(ciphertext, privateKey); bytes.Equal(ss, sharedSecret) {
+		t.Error("hash is not used")
+	}
+}
+
+func TestHybridKEMTampering(t *testing.T) {
+	h := MLKEM1024P384
+	privateKey, publicKey, err := h.KemKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, sharedSecret, err := h.KemEncrypt(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Flip a bit of the Kyber ciphertext: implicit rejection.
+	modified := bytes.Clone(ciphertext)
+	modified[0] ^= 1
+	if ss, err := h.KemDecrypt(modified, privateKey); err != nil || bytes.Equal(ss, sharedSecret) {
+		t.Errorf("modified Kyber ciphertext: %v", err)
+	}
+	// Flip a bit of the ECDH point: no longer on the curve.
+	modified = bytes.Clone(ciphertext)
+	modified[len(modified)-1] ^= 1
+	if _, err := h.KemDecrypt(modified, privateKey); err == nil {
+		t.Error("invalid ECDH point accepted")
+	}
+	// A public key whose ECDH part is not on the curve is rejected.
+	badPublicKey := bytes.Clone(publicKey)
+	badPublicKey[len(badPublicKey)-1] ^= 1
+	if _, _, err := h.KemEncrypt(badPublicKey); err == nil {
+		t.Error("invalid ECDH public key accepted")
+	}
+	if _, _, err := h.KemEncrypt(publicKey[1:]); err == nil {
+		t.Error("short public key accepted")
+	}
+}
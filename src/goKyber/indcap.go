@@ -2,8 +2,8 @@ package gokyber
 
 import (
 	"crypto/rand"
-
-	"golang.org/x/crypto/sha3"
+	"errors"
+	"io"
 )
 
 // IndcpaPackPublicKey serializes the public key as a concatenation of the
@@ -16,20 +16,20 @@ func IndcpaPackPublicKey(publicKeyVector PolynomialVector, seed []byte, kVariant
 // IndcpaUnpackPublicKey de-serializes the public key from a byte array
 // and represents the approximate inverse of IndcpaPackPublicKey.
 func IndcpaUnpackPublicKey(inputBytes []byte, kVariant int) (PolynomialVector, []byte) {
-	switch kVariant {
-	case 2:
-		publicKeyVector := PolyvecFromBytes(inputBytes[:paramsPolyvecBytesK512], kVariant)
-		seed := inputBytes[paramsPolyvecBytesK512:]
-		return publicKeyVector, seed
-	case 3:
-		publicKeyVector := PolyvecFromBytes(inputBytes[:paramsPolyvecBytesK768], kVariant)
-		seed := inputBytes[paramsPolyvecBytesK768:]
-		return publicKeyVector, seed
-	default:
-		publicKeyVector := PolyvecFromBytes(inputBytes[:paramsPolyvecBytesK1024], kVariant)
-		seed := inputBytes[paramsPolyvecBytesK1024:]
-		return publicKeyVector, seed
-	}
+	publicKeyVector := PolyvecFromBytes(inputBytes[:kVariant*paramsPolyBytes], kVariant)
+	seed := inputBytes[kVariant*paramsPolyBytes:]
+	return publicKeyVector, seed
+}
+
+// IndcpaUnpackPublicKey de-serializes a public key of the parameter set
+// into the vector `t`, in the NTT domain, and the seed of the matrix `A`.
+func (p *ParameterSet) IndcpaUnpackPublicKey(publicKey []byte) (PolynomialVector, []byte) {
+	publicKeyVector := p.PolynomialRing().polyvecFromBytes(publicKey, p.K)
+	return publicKeyVector, publicKey[p.PolyvecBytes():]
+}
+
+func (p *ParameterSet) indcpaPackPublicKey(publicKeyVector PolynomialVector, seed []byte) []byte {
+	return append(p.PolynomialRing().polyvecToBytes(publicKeyVector), seed...)
 }
 
 // IndcpaPackPrivateKey serializes the private key.
@@ -43,30 +43,56 @@ func IndcpaUnpackPrivateKey(inputBytes []byte, kVariant int) PolynomialVector {
 	return PolyvecFromBytes(inputBytes, kVariant)
 }
 
+// IndcpaPackPrivateKey serializes a secret vector `s`, given in the NTT
+// domain, as an IND-CPA private key of the parameter set.
+func (p *ParameterSet) IndcpaPackPrivateKey(privateKeyVector PolynomialVector) []byte {
+	return p.PolynomialRing().polyvecToBytes(privateKeyVector)
+}
+
+// IndcpaUnpackPrivateKey de-serializes an IND-CPA private key of the
+// parameter set into the secret vector `s`, in the NTT domain.
+func (p *ParameterSet) IndcpaUnpackPrivateKey(privateKey []byte) PolynomialVector {
+	return p.PolynomialRing().polyvecFromBytes(privateKey, p.K)
+}
+
 // IndcpaPackCiphertext serializes the ciphertext as a concatenation of
 // the compressed and serialized vector of polynomials `b` and the
 // compressed and serialized polynomial `v`.
 func IndcpaPackCiphertext(bVector PolynomialVector, v Polynomial, kVariant int) []byte {
-	return append(PolyvecCompress(bVector, kVariant), PolyCompress(v, kVariant)...)
+	return parameterSetForRank(kVariant).IndcpaPackCiphertext(bVector, v)
+}
+
+// IndcpaPackCiphertext compresses and serializes a ciphertext of the
+// parameter set. The coefficients of `b` and `v` must be in [0, Q).
+func (p *ParameterSet) IndcpaPackCiphertext(bVector PolynomialVector, v Polynomial) []byte {
+	r := p.PolynomialRing()
+	return append(r.polyvecCompress(bVector, p.Du), r.polyCompress(v, p.Dv)...)
 }
 
 // IndcpaUnpackCiphertext de-serializes and decompresses the ciphertext
 // from a byte array, and represents the approximate inverse of
 // IndcpaPackCiphertext.
 func IndcpaUnpackCiphertext(inputBytes []byte, kVariant int) (PolynomialVector, Polynomial) {
+	return parameterSetForRank(kVariant).indcpaUnpackCiphertext(inputBytes)
+}
+
+func (p *ParameterSet) indcpaUnpackCiphertext(inputBytes []byte) (PolynomialVector, Polynomial) {
+	r := p.PolynomialRing()
+	bVector := r.polyvecDecompress(inputBytes[:p.PolyvecCompressedBytes()], p.K, p.Du)
+	vPolynomial := r.polyDecompress(inputBytes[p.PolyvecCompressedBytes():], p.Dv)
+	return bVector, vPolynomial
+}
+
+// parameterSetForRank returns the standard parameter set of a given module
+// rank, for the functions that take `kVariant` (2, 3 or 4) as a parameter.
+func parameterSetForRank(kVariant int) *ParameterSet {
 	switch kVariant {
 	case 2:
-		bVector := PolyvecDecompress(inputBytes[:paramsPolyvecCompressedBytesK512], kVariant)
-		vPolynomial := PolyDecompress(inputBytes[paramsPolyvecCompressedBytesK512:], kVariant)
-		return bVector, vPolynomial
+		return Kyber512
 	case 3:
-		bVector := PolyvecDecompress(inputBytes[:paramsPolyvecCompressedBytesK768], kVariant)
-		vPolynomial := PolyDecompress(inputBytes[paramsPolyvecCompressedBytesK768:], kVariant)
-		return bVector, vPolynomial
+		return Kyber768
 	default:
-		bVector := PolyvecDecompress(inputBytes[:paramsPolyvecCompressedBytesK1024], kVariant)
-		vPolynomial := PolyDecompress(inputBytes[paramsPolyvecCompressedBytesK1024:], kVariant)
-		return bVector, vPolynomial
+		return Kyber1024
 	}
 }
 
@@ -101,66 +127,57 @@ func IndcpaRejUniform(inputBytes []byte, inputLength int, numCoefficients int) (
 // from a seed. Entries of the matrix are polynomials that look uniformly random.
 // Performs rejection sampling on the output of an extendable-output function (XOF).
 func IndcpaGenMatrix(seed []byte, transposed bool, kVariant int) ([]PolynomialVector, error) {
+	return indcpaGenMatrix(SymmetricSHAKE, seed, transposed, kVariant)
+}
+
+func indcpaGenMatrix(sym Symmetric, seed []byte, transposed bool, kVariant int) ([]PolynomialVector, error) {
 	resultMatrix := make([]PolynomialVector, kVariant)
-	buffer := make([]byte, 672)
-	xof := sha3.NewShake128()
-	seedWithIndex := make([]byte, len(seed)+2) // Allocate once and reuse
+	buffer := make([]byte, 504)
 
 	for i := 0; i < kVariant; i++ {
 		resultMatrix[i] = PolyvecNew(kVariant)
 		for j := 0; j < kVariant; j++ {
-			xof.Reset()
-			copy(seedWithIndex, seed) // Reset seed prefix
-
+			var xof io.Reader
 			if transposed {
-				seedWithIndex[len(seed)] = byte(i)
-				seedWithIndex[len(seed)+1] = byte(j)
+				xof = sym.Xof(seed, byte(i), byte(j))
 			} else {
-				seedWithIndex[len(seed)] = byte(j)
-				seedWithIndex[len(seed)+1] = byte(i)
+				xof = sym.Xof(seed, byte(j), byte(i))
 			}
 
-			_, err := xof.Write(seedWithIndex)
-			if err != nil {
+			// Three SHAKE-128 blocks are almost always enough; keep
+			// squeezing one block at a time in the rare case they are not.
+			if _, err := io.ReadFull(xof, buffer); err != nil {
 				return []PolynomialVector{}, err
 			}
-			_, err = xof.Read(buffer)
-			if err != nil {
-				return []PolynomialVector{}, err
-			}
-
-			ctr := 0
-			poly, sampled := IndcpaRejUniform(buffer[:504], 504, paramsN)
+			poly, ctr := IndcpaRejUniform(buffer, len(buffer), paramsN)
 			resultMatrix[i][j] = poly
-			ctr = sampled
 
-			if ctr < paramsN {
-				missingCoefficients, numSampled := IndcpaRejUniform(buffer[504:672], 168, paramsN-ctr)
-				for k := 0; k < numSampled; k++ { // Optimized copy loop
+			for ctr < paramsN {
+				if _, err := io.ReadFull(xof, buffer[:168]); err != nil {
+					return []PolynomialVector{}, err
+				}
+				missingCoefficients, numSampled := IndcpaRejUniform(buffer[:168], 168, paramsN-ctr)
+				for k := 0; k < numSampled; k++ {
 					resultMatrix[i][j][ctr+k] = missingCoefficients[k]
 				}
 				ctr += numSampled
 			}
-			// No need for the second loop and manual copy if IndcpaRejUniform handles the count correctly
-			// Original code's second loop was overly complex and potentially inefficient.
-			// Simplified logic based on the assumption that IndcpaRejUniform correctly samples up to the requested count.
-			// We've already sampled 'sampled' coefficients in the first call.
-			// If more are needed, the second call samples up to the remaining amount.
 		}
 	}
 	return resultMatrix, nil
 }
 
+// IndcpaGenMatrix generates the matrix `A` (or its transpose) of the
+// parameter set from a seed, in the NTT domain.
+func (p *ParameterSet) IndcpaGenMatrix(seed []byte, transposed bool) ([]PolynomialVector, error) {
+	return p.PolynomialRing().genMatrix(p.Symmetric, seed, transposed, p.K)
+}
+
 // IndcpaPrf provides a pseudo-random function (PRF) which returns
 // a byte array of length `l`, using the provided key and nonce
 // to instantiate the PRF's underlying hash function.
 func IndcpaPrf(outputLength int, key []byte, nonce byte) []byte {
-	hash := make([]byte, outputLength)
-	keyNonce := make([]byte, len(key)+1)
-	copy(keyNonce, key)
-	keyNonce[len(key)] = nonce
-	sha3.ShakeSum256(hash, keyNonce)
-	return hash
+	return SymmetricSHAKE.Prf(outputLength, key, nonce)
 }
 
 // IndcpaKeypair generates a key pair for the IND-CPA secure encryption scheme.
@@ -194,59 +211,86 @@ func IndcpaPrf(outputLength int, key []byte, nonce byte) []byte {
 //	fmt.Printf("Private Key: %x\n", privateKey)
 //	fmt.Printf("Public Key: %x\n", publicKey)
 func IndcpaKeypair(kVariant int) ([]byte, []byte, error) {
-	privateKeyVector := PolyvecNew(kVariant)
-	publicKeyVector := PolyvecNew(kVariant)
-	errorVector := PolyvecNew(kVariant)
-	randomBytes := make([]byte, 2*paramsSymBytes) // Allocate once
+	return parameterSetForRank(kVariant).IndcpaKeypair()
+}
 
-	_, err := rand.Read(randomBytes[:paramsSymBytes])
-	if err != nil {
+// IndcpaKeypair generates an IND-CPA key pair for the parameter set; see the
+// package-level IndcpaKeypair for a description of the steps.
+func (p *ParameterSet) IndcpaKeypair() ([]byte, []byte, error) {
+	if err := p.Validate(); err != nil {
 		return []byte{}, []byte{}, err
 	}
-
-	hash := sha3.New512()
-	_, err = hash.Write(randomBytes[:paramsSymBytes])
-	if err != nil {
+	randomBytes := make([]byte, paramsSymBytes)
+	if _, err := rand.Read(randomBytes); err != nil {
 		return []byte{}, []byte{}, err
 	}
+	return p.indcpaKeypairDerand(randomBytes)
+}
 
-	// Reuse randomBytes buffer for hash sum
-	randomBytes = randomBytes[:0] // Reset the slice length to reuse the buffer
-	randomBytes = hash.Sum(randomBytes)
+// indcpaKeypairDerand is the deterministic core of IndcpaKeypair: it expands
+// a 32-byte seed into the public and noise seeds with `G`.
+func (p *ParameterSet) indcpaKeypairDerand(seed []byte) ([]byte, []byte, error) {
+	r := p.PolynomialRing()
+	kVariant := p.K
+	privateKeyVector := PolyvecNew(kVariant)
+	publicKeyVector := PolyvecNew(kVariant)
+	errorVector := PolyvecNew(kVariant)
 
+	// ML-KEM appends the module rank to the seed, as domain separation
+	// between the parameter sets.
+	hashInput := seed
+	if p.FIPS203 {
+		hashInput = append(seed[:paramsSymBytes:paramsSymBytes], byte(p.K))
+	}
+	expandedSeed := p.Symmetric.Hash512(hashInput)
 	publicSeed := make([]byte, paramsSymBytes)
 	noiseSeed := make([]byte, paramsSymBytes)
-	copy(publicSeed, randomBytes[:paramsSymBytes])
-	copy(noiseSeed, randomBytes[paramsSymBytes:])
+	copy(publicSeed, expandedSeed[:paramsSymBytes])
+	copy(noiseSeed, expandedSeed[paramsSymBytes:])
+	p.traceBytes("indcpa.keypair.seed", seed)
+	p.traceBytes("indcpa.keypair.publicSeed", publicSeed)
+	p.traceBytes("indcpa.keypair.noiseSeed", noiseSeed)
 
 	// Generate matrix A from public seed.
-	matrixA, err := IndcpaGenMatrix(publicSeed, false, kVariant)
+	matrixA, err := p.IndcpaGenMatrix(publicSeed, false)
 	if err != nil {
 		return []byte{}, []byte{}, err
 	}
+	p.traceMatrix("indcpa.keypair.A.ntt", matrixA)
 
+	// Sample the private key and then the error vector from the noise seed,
+	// with consecutive nonces as in the reference implementation.
 	var nonce byte
-	// Sample private key and error vector from noise seed in combined loop.
 	for i := 0; i < kVariant; i++ {
-		privateKeyVector[i] = PolyGetNoise(noiseSeed, nonce, kVariant)
-		nonce++ // Increment nonce after private key
-		errorVector[i] = PolyGetNoise(noiseSeed, nonce, kVariant)
-		nonce++ // Increment nonce after error vector
+		privateKeyVector[i] = r.polyGetNoise(p.Symmetric, noiseSeed, nonce, p.Eta1)
+		nonce++
+	}
+	for i := 0; i < kVariant; i++ {
+		errorVector[i] = r.polyGetNoise(p.Symmetric, noiseSeed, nonce, p.Eta1)
+		nonce++
 	}
+	p.tracePolyvec("indcpa.keypair.s", privateKeyVector)
+	p.tracePolyvec("indcpa.keypair.e", errorVector)
 
 	// Convert private key and error vector to NTT domain.
-	PolyvecNtt(privateKeyVector, kVariant)
-	PolyvecReduce(privateKeyVector, kVariant) // Reduce private key modulo q.
-	PolyvecNtt(errorVector, kVariant)
+	r.polyvecNtt(privateKeyVector)
+	r.polyvecReduce(privateKeyVector) // Reduce private key modulo q.
+	r.polyvecNtt(errorVector)
 
 	// Calculate public key: A*s + e.
 	for i := 0; i < kVariant; i++ {
-		publicKeyVector[i] = PolyToMont(PolyvecPointWiseAccMontgomery(matrixA[i], privateKeyVector, kVariant))
+		publicKeyVector[i] = r.polyToMont(r.polyvecPointWiseAccMontgomery(matrixA[i], privateKeyVector))
 	}
 	PolyvecAdd(publicKeyVector, errorVector, kVariant)
-	PolyvecReduce(publicKeyVector, kVariant) // Reduce public key modulo q.
-
-	return IndcpaPackPrivateKey(privateKeyVector, kVariant), IndcpaPackPublicKey(publicKeyVector, publicSeed, kVariant), nil
+	r.polyvecReduce(publicKeyVector) // Reduce public key modulo q.
+	p.tracePolyvec("indcpa.keypair.s.ntt", privateKeyVector)
+	p.tracePolyvec("indcpa.keypair.t.ntt", publicKeyVector)
+
+	privateKey := p.IndcpaPackPrivateKey(privateKeyVector)
+	publicKey := p.indcpaPackPublicKey(publicKeyVector, publicSeed)
+	p.traceBytes("indcpa.keypair.privateKey", privateKey)
+	p.traceBytes("indcpa.keypair.publicKey", publicKey)
+	return privateKey, publicKey, nil
 }
 
 // IndcpaEncrypt encrypts a given message using the provided public key and coins.
@@ -277,49 +321,80 @@ func IndcpaKeypair(kVariant int) ([]byte, []byte, error) {
 // - A byte slice containing the ciphertext.
 // - An error if any step in the encryption process fails.
 func IndcpaEncrypt(message []byte, publicKey []byte, coins []byte, kVariant int) ([]byte, error) {
+	return parameterSetForRank(kVariant).IndcpaEncrypt(message, publicKey, coins)
+}
+
+// IndcpaEncrypt encrypts a message of MessageBytes bytes under the parameter
+// set; see the package-level IndcpaEncrypt for a description of the steps.
+func (p *ParameterSet) IndcpaEncrypt(message []byte, publicKey []byte, coins []byte) ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return []byte{}, err
+	}
+	if len(publicKey) != p.IndcpaPublicKeyBytes() {
+		return []byte{}, errors.New("invalid public key length")
+	}
+	if len(message) != p.MessageBytes() {
+		return []byte{}, errors.New("invalid message length")
+	}
+	r := p.PolynomialRing()
+	kVariant := p.K
 	sPrimeVector := PolyvecNew(kVariant)
 	ePrimeVector := PolyvecNew(kVariant)
 	bPrimeVector := PolyvecNew(kVariant)
 
-	publicKeyVector, seed := IndcpaUnpackPublicKey(publicKey, kVariant)
-	kPolynomial := PolyFromMsg(message)
+	publicKeyVector, seed := p.IndcpaUnpackPublicKey(publicKey)
+	kPolynomial := r.polyFromMsg(message)
+	p.traceBytes("indcpa.encrypt.message", message)
+	p.traceBytes("indcpa.encrypt.coins", coins)
+	p.tracePoly("indcpa.encrypt.m", &kPolynomial)
 
 	// Generate transposed matrix A from seed.
-	matrixATransposed, err := IndcpaGenMatrix(seed[:paramsSymBytes], true, kVariant)
+	matrixATransposed, err := p.IndcpaGenMatrix(seed[:paramsSymBytes], true)
 	if err != nil {
 		return []byte{}, err
 	}
+	p.traceMatrix("indcpa.encrypt.At.ntt", matrixATransposed)
 
 	// Sample s' and e' from coins in a combined loop.
 	for i := 0; i < kVariant; i++ {
-		sPrimeVector[i] = PolyGetNoise(coins, byte(i), kVariant)
-		ePrimeVector[i] = PolyGetNoise(coins, byte(i+kVariant), 3)
+		sPrimeVector[i] = r.polyGetNoise(p.Symmetric, coins, byte(i), p.Eta1)
+		ePrimeVector[i] = r.polyGetNoise(p.Symmetric, coins, byte(i+kVariant), p.Eta2)
 	}
 
 	// Sample e''.
-	ePrimePrimePolynomial := PolyGetNoise(coins, byte(kVariant*2), 3)
+	ePrimePrimePolynomial := r.polyGetNoise(p.Symmetric, coins, byte(kVariant*2), p.Eta2)
+	p.tracePolyvec("indcpa.encrypt.r", sPrimeVector)
+	p.tracePolyvec("indcpa.encrypt.e1", ePrimeVector)
+	p.tracePoly("indcpa.encrypt.e2", &ePrimePrimePolynomial)
 
 	// Convert s' to NTT domain.
-	PolyvecNtt(sPrimeVector, kVariant)
-	PolyvecReduce(sPrimeVector, kVariant)
+	r.polyvecNtt(sPrimeVector)
+	r.polyvecReduce(sPrimeVector)
 
 	// Calculate b' = A^T * s' + e'.
 	for i := 0; i < kVariant; i++ {
-		bPrimeVector[i] = PolyvecPointWiseAccMontgomery(matrixATransposed[i], sPrimeVector, kVariant)
+		bPrimeVector[i] = r.polyvecPointWiseAccMontgomery(matrixATransposed[i], sPrimeVector)
 	}
 	// Calculate v = p^T * s' + e'' + K.
-	vPolynomial := PolyvecPointWiseAccMontgomery(publicKeyVector, sPrimeVector, kVariant)
+	vPolynomial := r.polyvecPointWiseAccMontgomery(publicKeyVector, sPrimeVector)
 
 	// Convert b' and v to standard domain.
-	PolyvecInvNttToMont(bPrimeVector, kVariant)
-	vPolynomial = PolyInvNttToMont(vPolynomial)
+	r.polyvecInvNttToMont(bPrimeVector)
+	vPolynomial = r.invNttToMont(vPolynomial)
 
 	// Add error vectors and message to b' and v.
 	PolyvecAdd(bPrimeVector, ePrimeVector, kVariant)
 	vPolynomial = PolyAdd(PolyAdd(vPolynomial, ePrimePrimePolynomial), kPolynomial)
 
-	PolyvecReduce(bPrimeVector, kVariant)
-	return IndcpaPackCiphertext(bPrimeVector, PolyReduce(vPolynomial), kVariant), nil
+	r.polyvecReduce(bPrimeVector)
+	vPolynomial = r.polyReduce(vPolynomial)
+	p.tracePolyvec("indcpa.encrypt.u", bPrimeVector)
+	p.tracePoly("indcpa.encrypt.v", &vPolynomial)
+
+	ciphertext := p.IndcpaPackCiphertext(bPrimeVector, vPolynomial)
+	p.traceBytes("indcpa.encrypt.ciphertext.u", ciphertext[:p.PolyvecCompressedBytes()])
+	p.traceBytes("indcpa.encrypt.ciphertext.v", ciphertext[p.PolyvecCompressedBytes():])
+	return ciphertext, nil
 }
 
 // IndcpaDecrypt decrypts the given ciphertext using the provided private key and Kyber variant.
@@ -341,17 +416,47 @@ func IndcpaEncrypt(message []byte, publicKey []byte, coins []byte, kVariant int)
 //
 //	decryptedMessage := IndcpaDecrypt(ciphertext, privateKey, 3)
 func IndcpaDecrypt(ciphertext []byte, privateKey []byte, kVariant int) []byte {
-	bPrimeVector, vPolynomial := IndcpaUnpackCiphertext(ciphertext, kVariant)
-	privateKeyVector := IndcpaUnpackPrivateKey(privateKey, kVariant)
+	return parameterSetForRank(kVariant).indcpaDecrypt(ciphertext, privateKey)
+}
+
+// IndcpaDecrypt decrypts an IND-CPA ciphertext under the parameter set; see
+// the package-level IndcpaDecrypt for a description of the steps.
+func (p *ParameterSet) IndcpaDecrypt(ciphertext []byte, privateKey []byte) ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if len(ciphertext) != p.CiphertextBytes() {
+		return nil, errors.New("invalid ciphertext length")
+	}
+	if len(privateKey) != p.IndcpaSecretKeyBytes() {
+		return nil, errors.New("invalid private key length")
+	}
+	return p.indcpaDecrypt(ciphertext, privateKey), nil
+}
+
+func (p *ParameterSet) indcpaDecrypt(ciphertext []byte, privateKey []byte) []byte {
+	message := p.PolynomialRing().polyToMsg(p.indcpaDecryptPolynomial(ciphertext, privateKey))
+	p.traceBytes("indcpa.decrypt.message", message)
+	return message
+}
+
+// indcpaDecryptPolynomial returns the noisy encoding of the message,
+// `w = v - sᵀu`, which decryption rounds to bits.
+func (p *ParameterSet) indcpaDecryptPolynomial(ciphertext []byte, privateKey []byte) Polynomial {
+	r := p.PolynomialRing()
+	bPrimeVector, vPolynomial := p.indcpaUnpackCiphertext(ciphertext)
+	privateKeyVector := p.IndcpaUnpackPrivateKey(privateKey)
+	p.tracePolyvec("indcpa.decrypt.u", bPrimeVector)
+	p.tracePoly("indcpa.decrypt.v", &vPolynomial)
 
 	// Convert b' to NTT domain.
-	PolyvecNtt(bPrimeVector, kVariant)
+	r.polyvecNtt(bPrimeVector)
 
 	// Calculate m' = v - b' * s.
-	mPrimePolynomial := PolyvecPointWiseAccMontgomery(privateKeyVector, bPrimeVector, kVariant)
-	mPrimePolynomial = PolyInvNttToMont(mPrimePolynomial)
+	mPrimePolynomial := r.polyvecPointWiseAccMontgomery(privateKeyVector, bPrimeVector)
+	mPrimePolynomial = r.invNttToMont(mPrimePolynomial)
 	mPrimePolynomial = PolySub(vPolynomial, mPrimePolynomial)
-	mPrimePolynomial = PolyReduce(mPrimePolynomial)
-
-	return PolyToMsg(mPrimePolynomial)
+	mPrimePolynomial = r.polyReduce(mPrimePolynomial)
+	p.tracePoly("indcpa.decrypt.w", &mPrimePolynomial)
+	return mPrimePolynomial
 }
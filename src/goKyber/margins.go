@@ -0,0 +1,98 @@
+package gokyber
+
+import "errors"
+
+// NoiseMargins describes how close each coefficient of a decryption came to
+// being decoded to the wrong bit. Decryption computes `w = v - sᵀu`, whose
+// coefficient i is `round(Q/2) * m_i` plus noise, and rounds it to the
+// nearest of 0 and Q/2; it fails when the noise of a coefficient crosses
+// Q/4.
+type NoiseMargins struct {
+	// Message is the decrypted message.
+	Message []byte
+	// Noise[i] is coefficient i of `w` minus the encoding of the decoded
+	// bit, centered in (-Q/2, Q/2]. If decryption failed it is measured from
+	// the wrong bit.
+	Noise []int
+	// Margin[i] is the largest amount of additional noise, in either
+	// direction, that coefficient i tolerates before its bit flips.
+	Margin []int
+}
+
+// NoiseMargins decrypts a ciphertext and reports the noise left in every
+// coefficient and its distance from the decision boundary. The private key
+// is either an IND-CPA private key or a KEM private key, whose first bytes
+// are the IND-CPA private key. It is a research tool: the noise depends on
+// the secret, so it must never be exposed by a deployed decapsulation.
+func (p *ParameterSet) NoiseMargins(ciphertext []byte, privateKey []byte) (*NoiseMargins, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if len(ciphertext) != p.CiphertextBytes() {
+		return nil, errors.New("invalid ciphertext length")
+	}
+	switch len(privateKey) {
+	case p.IndcpaSecretKeyBytes():
+	case p.PrivateKeyBytes():
+		privateKey = privateKey[:p.IndcpaSecretKeyBytes()]
+	default:
+		return nil, errors.New("invalid private key length")
+	}
+
+	r := p.PolynomialRing()
+	w := p.indcpaDecryptPolynomial(ciphertext, privateKey)
+	margins := &NoiseMargins{
+		Message: make([]byte, r.MessageBytes()),
+		Noise:   make([]int, r.N),
+		Margin:  make([]int, r.N),
+	}
+	for i := 0; i < r.N; i++ {
+		bit, noise, margin := decodeWithMargin(r.Q, (int(w[i])%r.Q+r.Q)%r.Q)
+		margins.Message[i/8] |= byte(bit << (i % 8))
+		margins.Noise[i] = noise
+		margins.Margin[i] = margin
+	}
+	return margins, nil
+}
+
+// decodeWithMargin decodes a coefficient x in [0, q) of `w` to a bit, as
+// polyToMsg does, and returns its noise and margin.
+func decodeWithMargin(q, x int) (bit, noise, margin int) {
+	// Coefficients in [lower, upper] decode to one.
+	lower := (q - q/2 + 1) / 2
+	upper := (2*q - q/2 - 1) / 2
+	encoded := 0
+	switch {
+	case x >= lower && x <= upper:
+		bit, encoded = 1, (q+1)/2
+		margin = min(x-lower, upper-x)
+	case x < lower:
+		margin = lower - 1 - x
+	default:
+		margin = x - upper - 1
+	}
+	noise = (x - encoded + q) % q
+	if noise > q/2 {
+		noise -= q
+	}
+	return bit, noise, margin
+}
+
+// MinMargin returns the smallest margin over all coefficients: how much
+// additional noise the decryption as a whole tolerates.
+func (m *NoiseMargins) MinMargin() int {
+	result := m.Margin[0]
+	for _, margin := range m.Margin[1:] {
+		result = min(result, margin)
+	}
+	return result
+}
+
+// MaxNoise returns the largest absolute value of the noise.
+func (m *NoiseMargins) MaxNoise() int {
+	result := 0
+	for _, noise := range m.Noise {
+		result = max(result, noise, -noise)
+	}
+	return result
+}
@@ -0,0 +1,76 @@
+package gokyber
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestDecodeWithMargin(t *testing.T) {
+	for _, q := range []int{17, 97, 257, 3329} {
+		decode := func(x int) int {
+			x = (x%q + q) % q
+			return ((x << 1) + q/2) / q & 1
+		}
+		for x := 0; x < q; x++ {
+			bit, noise, margin := decodeWithMargin(q, x)
+			if bit != decode(x) {
+				t.Fatalf("q=%d x=%d: bit %d, want %d", q, x, bit, decode(x))
+			}
+			if (x-noise-bit*((q+1)/2))%q != 0 || 2*noise > q || 2*noise <= -q {
+				t.Fatalf("q=%d x=%d: noise %d is not centered x - encoding", q, x, noise)
+			}
+			if decode(x+margin) != bit || decode(x-margin) != bit {
+				t.Fatalf("q=%d x=%d: margin %d flips the bit", q, x, margin)
+			}
+			if decode(x+margin+1) == bit && decode(x-margin-1) == bit {
+				t.Fatalf("q=%d x=%d: margin %d is not tight", q, x, margin)
+			}
+		}
+	}
+}
+
+func TestNoiseMargins(t *testing.T) {
+	for _, p := range []*ParameterSet{Kyber512, Kyber768, Kyber1024} {
+		privateKey, publicKey, err := p.KemKeypair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		message := make([]byte, p.MessageBytes())
+		coins := make([]byte, paramsSymBytes)
+		rand.Read(message)
+		rand.Read(coins)
+		ciphertext, err := p.IndcpaEncrypt(message, publicKey, coins)
+		if err != nil {
+			t.Fatal(err)
+		}
+		margins, err := p.NoiseMargins(ciphertext, privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(margins.Message, message) {
+			t.Errorf("%s: decrypted message differs", p.Name)
+		}
+		if got := margins.MinMargin() + margins.MaxNoise(); got > paramsQ/4 || got < paramsQ/4-1 {
+			t.Errorf("%s: min margin %d and max noise %d do not add up to q/4", p.Name, margins.MinMargin(), margins.MaxNoise())
+		}
+		// The noise is far below q/4 for honest ciphertexts.
+		if margins.MaxNoise() > paramsQ/8 {
+			t.Errorf("%s: noise %d unexpectedly large", p.Name, margins.MaxNoise())
+		}
+
+		indcpaMargins, err := p.NoiseMargins(ciphertext, privateKey[:p.IndcpaSecretKeyBytes()])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if indcpaMargins.MinMargin() != margins.MinMargin() {
+			t.Errorf("%s: IND-CPA and KEM private keys give different margins", p.Name)
+		}
+	}
+	if _, err := Kyber768.NoiseMargins(make([]byte, 10), make([]byte, Kyber768.PrivateKeyBytes())); err == nil {
+		t.Error("short ciphertext accepted")
+	}
+	if _, err := Kyber768.NoiseMargins(make([]byte, Kyber768.CiphertextBytes()), make([]byte, 10)); err == nil {
+		t.Error("short private key accepted")
+	}
+}
@@ -0,0 +1,586 @@
+package gokyber
+
+import (
+	"crypto/rand"
+	"errors"
+	"sync"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// maskedAdderWidth is the word size, in bits, of the Boolean-masked adder
+// used for arithmetic-to-Boolean conversions. Shared coefficients are always
+// smaller than 2*Q, so 16 bits leave room for the sign of a comparison.
+const maskedAdderWidth int = 16
+
+// MaskedPrivateKey is a Kyber private key whose IND-CPA secret vector `s` is
+// kept as two arithmetic shares `s = s0 + s1 mod Q`. Decapsulating through a
+// MaskedPrivateKey runs a first-order masked IND-CPA decryption and a masked
+// re-encryption, so that no intermediate value of the secret-dependent part
+// of the computation depends on both shares at once. The shares are refreshed
+// on every call.
+//
+// The masked path is meant for devices exposed to power and electromagnetic
+// analysis. It is considerably slower than KemDecrypt and produces exactly
+// the same shared secrets. Only the SHAKE instantiation is supported, since
+// the masked hashing is built on the Keccak permutation.
+type MaskedPrivateKey struct {
+	mu            sync.Mutex
+	params        *ParameterSet
+	secretShares  [2]PolynomialVector
+	publicKey     []byte
+	publicKeyHash []byte
+	rejectionKey  []byte
+}
+
+// maskRand is the source of fresh masking randomness. It is a SHAKE-256
+// stream seeded from crypto/rand once per operation, so that the many small
+// random values needed by the masking gadgets do not each cost a read from
+// the operating system.
+type maskRand struct {
+	xof sha3.ShakeHash
+	buf [1024]byte
+	pos int
+}
+
+// newMaskRand seeds a new masking randomness stream from crypto/rand.
+func newMaskRand() (*maskRand, error) {
+	seed := make([]byte, paramsSymBytes)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	xof := sha3.NewShake256()
+	if _, err := xof.Write(seed); err != nil {
+		return nil, err
+	}
+	r := &maskRand{xof: xof}
+	r.pos = len(r.buf)
+	return r, nil
+}
+
+func (r *maskRand) byte() byte {
+	if r.pos == len(r.buf) {
+		r.xof.Read(r.buf[:])
+		r.pos = 0
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *maskRand) uint16() uint16 {
+	return uint16(r.byte()) | uint16(r.byte())<<8
+}
+
+func (r *maskRand) uint64() uint64 {
+	return uint64(r.uint16()) | uint64(r.uint16())<<16 | uint64(r.uint16())<<32 | uint64(r.uint16())<<48
+}
+
+// modQ returns a uniformly random integer in [0, Q).
+func (r *maskRand) modQ() int16 {
+	for {
+		v := r.uint16() & 0xFFF
+		if v < uint16(paramsQ) {
+			return int16(v)
+		}
+	}
+}
+
+// secAnd is the first-order ISW AND gadget: given Boolean shares of `x` and
+// `y` and a fresh random word, it returns Boolean shares of `x & y`. The
+// order of the operations matters: the cross products are only ever combined
+// with the random word before being combined with each other.
+func secAnd[T uint16 | uint64](x, y [2]T, rnd T) [2]T {
+	z0 := (x[0] & y[0]) ^ rnd
+	z1 := (x[1] & y[1]) ^ ((rnd ^ (x[0] & y[1])) ^ (x[1] & y[0]))
+	return [2]T{z0, z1}
+}
+
+// secAdd adds two Boolean-shared 16-bit words modulo 2^16 with a masked
+// ripple-carry adder built from secAnd.
+func secAdd(x, y [2]uint16, rng *maskRand) [2]uint16 {
+	p := [2]uint16{x[0] ^ y[0], x[1] ^ y[1]}
+	g := secAnd(x, y, rng.uint16())
+	var c [2]uint16
+	for i := 0; i < maskedAdderWidth; i++ {
+		t := secAnd(p, c, rng.uint16())
+		c = [2]uint16{(g[0] ^ t[0]) << 1, (g[1] ^ t[1]) << 1}
+	}
+	return [2]uint16{p[0] ^ c[0], p[1] ^ c[1]}
+}
+
+// secAddConst adds a public 16-bit constant to a Boolean-shared word modulo
+// 2^16. The generate term is linear in the shares since the constant is known.
+func secAddConst(x [2]uint16, constant uint16, rng *maskRand) [2]uint16 {
+	p := [2]uint16{x[0] ^ constant, x[1]}
+	g := [2]uint16{x[0] & constant, x[1] & constant}
+	var c [2]uint16
+	for i := 0; i < maskedAdderWidth; i++ {
+		t := secAnd(p, c, rng.uint16())
+		c = [2]uint16{(g[0] ^ t[0]) << 1, (g[1] ^ t[1]) << 1}
+	}
+	return [2]uint16{p[0] ^ c[0], p[1] ^ c[1]}
+}
+
+// maskedA2B converts two arithmetic shares in [0, Q) into Boolean shares of
+// their integer sum, which lies in [0, 2Q). Each arithmetic share is first
+// re-masked with a fresh Boolean mask before entering the adder.
+func maskedA2B(a0, a1 int16, rng *maskRand) [2]uint16 {
+	r0 := rng.uint16()
+	r1 := rng.uint16()
+	return secAdd([2]uint16{uint16(a0) ^ r0, r0}, [2]uint16{uint16(a1) ^ r1, r1}, rng)
+}
+
+// maskedLessThan returns Boolean shares of the bit `y < threshold` for a
+// Boolean-shared `y` in [0, 2Q), by reading the sign of `y - threshold`.
+func maskedLessThan(y [2]uint16, threshold uint16, rng *maskRand) [2]uint16 {
+	d := secAddConst(y, -threshold, rng)
+	return [2]uint16{d[0] >> (maskedAdderWidth - 1), d[1] >> (maskedAdderWidth - 1)}
+}
+
+// maskedB2ABit converts a Boolean-shared bit `x0 ^ x1` into two arithmetic
+// shares modulo Q. It uses the identity `x0 ^ x1 = x0*(1 - 2*x1) + x1` where
+// `x0` is first hidden behind a uniformly random arithmetic mask.
+func maskedB2ABit(x0, x1 byte, rng *maskRand) [2]int16 {
+	r := int32(rng.modQ())
+	a := (int32(x0) - r + int32(paramsQ)) % int32(paramsQ)
+	sign := 1 - 2*int32(x1)
+	a0 := (a*sign + int32(x1) + int32(paramsQ)) % int32(paramsQ)
+	a1 := (r*sign + int32(paramsQ)) % int32(paramsQ)
+	return [2]int16{int16(a0), int16(a1)}
+}
+
+// maskedThresholds describes the set of shared sums `y` in [0, 2Q) whose
+// reduction modulo Q compresses to a given value. Membership of `y` is the
+// XOR of `constant` and of the bits `y < t` for each threshold `t`.
+type maskedThresholds struct {
+	constant   uint16
+	thresholds []uint16
+}
+
+var maskedCompressTables [12]struct {
+	once    sync.Once
+	entries []maskedThresholds
+}
+
+// maskedCompressTable returns, for every possible compressed value on `d`
+// bits, the thresholds describing its pre-image. The table is derived from
+// the very functions used by the unmasked code (PolyToMsg, PolyCompress and
+// PolyvecCompress), so that the masked and unmasked paths cannot disagree.
+func maskedCompressTable(d int) []maskedThresholds {
+	t := &maskedCompressTables[d]
+	t.once.Do(func() {
+		compressed := make([]uint16, paramsQ)
+		for start := 0; start < paramsQ; start += paramsN {
+			var p Polynomial
+			for i := 0; i < paramsN; i++ {
+				p[i] = int16((start + i) % paramsQ)
+			}
+			values := compressCoefficients(p, d)
+			for i := 0; i < paramsN && start+i < paramsQ; i++ {
+				compressed[start+i] = values[i]
+			}
+		}
+
+		// Every run of equal compressed values [low, high] contributes the
+		// ranges [low, high] and [low+Q, high+Q] in the space of shared sums.
+		counts := make([]map[int]int, 1<<d)
+		for c := range counts {
+			counts[c] = map[int]int{}
+		}
+		low := 0
+		for x := 1; x <= paramsQ; x++ {
+			if x < paramsQ && compressed[x] == compressed[low] {
+				continue
+			}
+			c := compressed[low]
+			for _, shift := range []int{0, paramsQ} {
+				counts[c][low+shift]++
+				counts[c][x+shift]++
+			}
+			low = x
+		}
+
+		t.entries = make([]maskedThresholds, 1<<d)
+		for c := range t.entries {
+			for threshold, n := range counts[c] {
+				switch {
+				case n%2 == 0 || threshold == 0:
+					// Toggling twice is a no-op and `y < 0` never holds.
+				case threshold >= 2*paramsQ:
+					// `y < 2Q` always holds.
+					t.entries[c].constant ^= 1
+				default:
+					t.entries[c].thresholds = append(t.entries[c].thresholds, uint16(threshold))
+				}
+			}
+		}
+	})
+	return t.entries
+}
+
+// compressCoefficients returns the `d`-bit compressed value of each
+// coefficient of a polynomial with coefficients in [0, Q), as produced by the
+// unmasked message decoding (d = 1) or ciphertext compression (any other d).
+func compressCoefficients(p Polynomial, d int) []uint16 {
+	if d == 1 {
+		return unpackBits(PolyToMsg(p), 1, paramsN)
+	}
+	return unpackBits(polyCompress(p, d), d, paramsN)
+}
+
+// unpackBits reads `count` little-endian `d`-bit integers from a byte array.
+func unpackBits(inputBytes []byte, d int, count int) []uint16 {
+	values := make([]uint16, count)
+	for i := 0; i < count; i++ {
+		for b := 0; b < d; b++ {
+			pos := i*d + b
+			values[i] |= uint16((inputBytes[pos/8]>>(pos%8))&1) << b
+		}
+	}
+	return values
+}
+
+// maskedMembership returns Boolean shares of the bit telling whether the
+// shared sum `y` belongs to the set described by `entry`.
+func maskedMembership(y [2]uint16, entry *maskedThresholds, rng *maskRand) [2]uint16 {
+	result := [2]uint16{entry.constant, 0}
+	for _, threshold := range entry.thresholds {
+		lt := maskedLessThan(y, threshold, rng)
+		result[0] ^= lt[0]
+		result[1] ^= lt[1]
+	}
+	return result
+}
+
+// maskedPolyToMsg is the masked counterpart of PolyToMsg. It takes two
+// arithmetic shares of a polynomial, with coefficients in [0, Q), and returns
+// two Boolean shares of the decoded message.
+func maskedPolyToMsg(shares [2]Polynomial, rng *maskRand) [2][]byte {
+	table := maskedCompressTable(1)
+	msg := [2][]byte{make([]byte, paramsSymBytes), make([]byte, paramsSymBytes)}
+	for i := 0; i < paramsN; i++ {
+		y := maskedA2B(shares[0][i], shares[1][i], rng)
+		bit := maskedMembership(y, &table[1], rng)
+		msg[0][i/8] |= byte(bit[0]&1) << (i % 8)
+		msg[1][i/8] |= byte(bit[1]&1) << (i % 8)
+	}
+	return msg
+}
+
+// maskedPolyFromMsg is the masked counterpart of PolyFromMsg. It takes two
+// Boolean shares of a message and returns two arithmetic shares of its
+// polynomial representation.
+func maskedPolyFromMsg(msg [2][]byte, rng *maskRand) [2]Polynomial {
+	var shares [2]Polynomial
+	for i := 0; i < paramsN; i++ {
+		a := maskedB2ABit((msg[0][i/8]>>(i%8))&1, (msg[1][i/8]>>(i%8))&1, rng)
+		for s := 0; s < 2; s++ {
+			shares[s][i] = int16(int32(a[s]) * int32((paramsQ+1)/2) % int32(paramsQ))
+		}
+	}
+	return shares
+}
+
+// maskedPolyGetNoise is the masked counterpart of PolyGetNoise. The PRF runs
+// on Boolean-shared coins through the masked Keccak permutation, and every
+// bit of its output is converted to arithmetic shares before the centered
+// binomial sum is taken.
+func maskedPolyGetNoise(coins [2][]byte, nonce byte, eta int, rng *maskRand) [2]Polynomial {
+	input := [2][]byte{
+		append(append([]byte{}, coins[0]...), nonce),
+		append(append([]byte{}, coins[1]...), 0),
+	}
+	uniform := maskedSponge(input, 136, 0x1f, eta*paramsN/4, rng)
+
+	var shares [2]Polynomial
+	for i := 0; i < paramsN; i++ {
+		var acc [2]int32
+		for j := 0; j < 2*eta; j++ {
+			pos := 2*eta*i + j
+			a := maskedB2ABit((uniform[0][pos/8]>>(pos%8))&1, (uniform[1][pos/8]>>(pos%8))&1, rng)
+			for s := 0; s < 2; s++ {
+				if j < eta {
+					acc[s] += int32(a[s])
+				} else {
+					acc[s] += int32(paramsQ) - int32(a[s])
+				}
+			}
+		}
+		for s := 0; s < 2; s++ {
+			shares[s][i] = int16(acc[s] % int32(paramsQ))
+		}
+	}
+	return shares
+}
+
+// maskedNormalize reduces every coefficient of a share to [0, Q).
+func maskedNormalize(p Polynomial) Polynomial {
+	return PolyCSubQ(PolyReduce(p))
+}
+
+// IndcpaMaskPrivateKey splits a packed IND-CPA private key into two fresh
+// arithmetic shares `s0` and `s1` such that `s = s0 + s1 mod Q`. The shares
+// stay in the NTT domain, like the unpacked private key.
+func IndcpaMaskPrivateKey(privateKey []byte, kVariant int) ([2]PolynomialVector, error) {
+	rng, err := newMaskRand()
+	if err != nil {
+		return [2]PolynomialVector{}, err
+	}
+	privateKeyVector := IndcpaUnpackPrivateKey(privateKey, kVariant)
+	shares := [2]PolynomialVector{PolyvecNew(kVariant), PolyvecNew(kVariant)}
+	for i := 0; i < kVariant; i++ {
+		for j := 0; j < paramsN; j++ {
+			r := rng.modQ()
+			shares[0][i][j] = r
+			shares[1][i][j] = int16((int32(privateKeyVector[i][j]) - int32(r) + int32(paramsQ)) % int32(paramsQ))
+		}
+	}
+	return shares, nil
+}
+
+// IndcpaDecryptMasked is the first-order masked counterpart of IndcpaDecrypt.
+//
+// Parameters:
+//   - ciphertext: The encrypted data to be decrypted.
+//   - secretShares: Two arithmetic shares of the private key vector, as returned by IndcpaMaskPrivateKey.
+//   - kVariant: The Kyber variant (e.g., 2, 3, or 4) which determines the security level and parameters.
+//
+// Returns:
+//   - Two Boolean shares of the decrypted message; the message is their XOR.
+//   - An error if the masking randomness cannot be obtained.
+//
+// The linear part of the decryption, `v - b'ᵀs`, is computed on each share
+// separately. The rounding of every coefficient to a message bit is the only
+// non-linear step; it converts the arithmetic shares to Boolean shares with a
+// masked adder (A2B) and compares them against the decision boundaries
+// without ever recombining them.
+func IndcpaDecryptMasked(ciphertext []byte, secretShares [2]PolynomialVector, kVariant int) ([2][]byte, error) {
+	rng, err := newMaskRand()
+	if err != nil {
+		return [2][]byte{}, err
+	}
+	return indcpaDecryptMasked(ciphertext, secretShares, parameterSetForRank(kVariant), rng), nil
+}
+
+func indcpaDecryptMasked(ciphertext []byte, secretShares [2]PolynomialVector, p *ParameterSet, rng *maskRand) [2][]byte {
+	bPrimeVector, vPolynomial := p.indcpaUnpackCiphertext(ciphertext)
+	PolyvecNtt(bPrimeVector, p.K)
+
+	// Calculate m' = v - b' * s on each share: m'0 = v - b' * s0, m'1 = -b' * s1.
+	var mShares [2]Polynomial
+	for s := 0; s < 2; s++ {
+		w := PolyvecPointWiseAccMontgomery(secretShares[s], bPrimeVector, p.K)
+		w = PolyInvNttToMont(w)
+		if s == 0 {
+			mShares[s] = PolySub(vPolynomial, w)
+		} else {
+			mShares[s] = PolySub(Polynomial{}, w)
+		}
+		mShares[s] = maskedNormalize(mShares[s])
+	}
+	return maskedPolyToMsg(mShares, rng)
+}
+
+// indcpaReencryptMasked re-encrypts a Boolean-shared message with
+// Boolean-shared coins and compares the result with `ciphertext` without
+// unmasking the re-encryption. Only the final comparison bit is revealed:
+// it returns 1 if the ciphertexts are equal and 0 otherwise.
+func indcpaReencryptMasked(ciphertext []byte, message [2][]byte, publicKey []byte, coins [2][]byte, p *ParameterSet, rng *maskRand) (int, error) {
+	kVariant := p.K
+	publicKeyVector, seed := IndcpaUnpackPublicKey(publicKey, kVariant)
+	matrixATransposed, err := indcpaGenMatrix(p.Symmetric, seed[:paramsSymBytes], true, kVariant)
+	if err != nil {
+		return 0, err
+	}
+	kShares := maskedPolyFromMsg(message, rng)
+
+	sPrimeShares := [2]PolynomialVector{PolyvecNew(kVariant), PolyvecNew(kVariant)}
+	ePrimeShares := [2]PolynomialVector{PolyvecNew(kVariant), PolyvecNew(kVariant)}
+	for i := 0; i < kVariant; i++ {
+		sPrime := maskedPolyGetNoise(coins, byte(i), p.Eta1, rng)
+		ePrime := maskedPolyGetNoise(coins, byte(i+kVariant), p.Eta2, rng)
+		for s := 0; s < 2; s++ {
+			sPrimeShares[s][i] = sPrime[s]
+			ePrimeShares[s][i] = ePrime[s]
+		}
+	}
+	ePrimePrimeShares := maskedPolyGetNoise(coins, byte(kVariant*2), p.Eta2, rng)
+
+	// Everything up to the compression is linear and runs on each share.
+	bPrimeShares := [2]PolynomialVector{PolyvecNew(kVariant), PolyvecNew(kVariant)}
+	var vShares [2]Polynomial
+	for s := 0; s < 2; s++ {
+		PolyvecNtt(sPrimeShares[s], kVariant)
+		PolyvecReduce(sPrimeShares[s], kVariant)
+		for i := 0; i < kVariant; i++ {
+			bPrimeShares[s][i] = PolyvecPointWiseAccMontgomery(matrixATransposed[i], sPrimeShares[s], kVariant)
+		}
+		vShares[s] = PolyvecPointWiseAccMontgomery(publicKeyVector, sPrimeShares[s], kVariant)
+		PolyvecInvNttToMont(bPrimeShares[s], kVariant)
+		vShares[s] = PolyInvNttToMont(vShares[s])
+		PolyvecAdd(bPrimeShares[s], ePrimeShares[s], kVariant)
+		vShares[s] = PolyAdd(PolyAdd(vShares[s], ePrimePrimeShares[s]), kShares[s])
+		for i := 0; i < kVariant; i++ {
+			bPrimeShares[s][i] = maskedNormalize(bPrimeShares[s][i])
+		}
+		vShares[s] = maskedNormalize(vShares[s])
+	}
+
+	return maskedCompareCiphertext(ciphertext, bPrimeShares, vShares, p, rng), nil
+}
+
+// maskedCompareCiphertext checks, coefficient by coefficient, that the
+// compression of the shared re-encryption matches the public ciphertext, and
+// ANDs all the shared equality bits together before unmasking the result.
+func maskedCompareCiphertext(ciphertext []byte, bShares [2]PolynomialVector, vShares [2]Polynomial, p *ParameterSet, rng *maskRand) int {
+	kVariant, du, dv := p.K, p.Du, p.Dv
+	polyvecCompressedBytes := p.PolyvecCompressedBytes()
+	cb := unpackBits(ciphertext[:polyvecCompressedBytes], du, kVariant*paramsN)
+	cv := unpackBits(ciphertext[polyvecCompressedBytes:], dv, paramsN)
+	tableB := maskedCompressTable(du)
+	tableV := maskedCompressTable(dv)
+
+	count := (kVariant + 1) * paramsN
+	equal := [2][]uint64{make([]uint64, (count+63)/64), make([]uint64, (count+63)/64)}
+	index := 0
+	push := func(bit [2]uint16) {
+		equal[0][index/64] |= uint64(bit[0]&1) << (index % 64)
+		equal[1][index/64] |= uint64(bit[1]&1) << (index % 64)
+		index++
+	}
+	for i := 0; i < kVariant; i++ {
+		for j := 0; j < paramsN; j++ {
+			y := maskedA2B(bShares[0][i][j], bShares[1][i][j], rng)
+			push(maskedMembership(y, &tableB[cb[i*paramsN+j]], rng))
+		}
+	}
+	for j := 0; j < paramsN; j++ {
+		y := maskedA2B(vShares[0][j], vShares[1][j], rng)
+		push(maskedMembership(y, &tableV[cv[j]], rng))
+	}
+	// Pad the last word with public ones so that it does not affect the AND.
+	if count%64 != 0 {
+		equal[0][len(equal[0])-1] |= ^uint64(0) << (count % 64)
+	}
+
+	acc := [2]uint64{^uint64(0), 0}
+	for w := range equal[0] {
+		acc = secAnd(acc, [2]uint64{equal[0][w], equal[1][w]}, rng.uint64())
+	}
+	for shift := 32; shift >= 1; shift >>= 1 {
+		// Refresh the shifted operand so that both inputs of the gadget
+		// are independently shared.
+		r := rng.uint64()
+		acc = secAnd(acc, [2]uint64{(acc[0] >> shift) ^ r, (acc[1] >> shift) ^ r}, rng.uint64())
+	}
+	return int((acc[0] ^ acc[1]) & 1)
+}
+
+// NewMaskedPrivateKey prepares a Kyber KEM private key for masked decapsulation.
+//
+// Parameters:
+//   - privateKey: A private key as returned by KemKeypair.
+//   - kyberVariant: An integer representing the Kyber variant. It can be one of the following:
+//   - 512: For Kyber512 mode
+//   - 768: For Kyber768 mode
+//   - 1024: For Kyber1024 mode
+//
+// Returns:
+//   - A MaskedPrivateKey holding the IND-CPA secret vector as two arithmetic shares.
+//   - An error if the private key has the wrong length or an invalid Kyber variant is provided.
+//
+// Splitting the key necessarily touches the unmasked secret once; it should
+// be done when the key is provisioned, not for every decapsulation.
+func NewMaskedPrivateKey(privateKey []byte, kyberVariant int) (*MaskedPrivateKey, error) {
+	params, err := ParameterSetForVariant(kyberVariant)
+	if err != nil {
+		return nil, err
+	}
+	if len(privateKey) != params.PrivateKeyBytes() {
+		return nil, errors.New("invalid private key length")
+	}
+	paramsIndcpaSecretKeyBytes := params.IndcpaSecretKeyBytes()
+	paramsIndcpaPublicKeyBytes := params.IndcpaPublicKeyBytes()
+
+	secretShares, err := IndcpaMaskPrivateKey(privateKey[:paramsIndcpaSecretKeyBytes], params.K)
+	if err != nil {
+		return nil, err
+	}
+	publicKeyEnd := paramsIndcpaSecretKeyBytes + paramsIndcpaPublicKeyBytes
+	return &MaskedPrivateKey{
+		params:        params,
+		secretShares:  secretShares,
+		publicKey:     append([]byte{}, privateKey[paramsIndcpaSecretKeyBytes:publicKeyEnd]...),
+		publicKeyHash: append([]byte{}, privateKey[publicKeyEnd:publicKeyEnd+paramsSymBytes]...),
+		rejectionKey:  append([]byte{}, privateKey[publicKeyEnd+paramsSymBytes:]...),
+	}, nil
+}
+
+// refresh re-randomizes the secret shares without changing their sum.
+func (key *MaskedPrivateKey) refresh(rng *maskRand) {
+	for i := 0; i < key.params.K; i++ {
+		for j := 0; j < paramsN; j++ {
+			r := int32(rng.modQ())
+			key.secretShares[0][i][j] = int16((int32(key.secretShares[0][i][j]) + r) % int32(paramsQ))
+			key.secretShares[1][i][j] = int16((int32(key.secretShares[1][i][j]) - r + int32(paramsQ)) % int32(paramsQ))
+		}
+	}
+}
+
+// KemDecrypt decapsulates a ciphertext like KemDecrypt, but with the
+// IND-CPA decryption, the hashing of the recovered message, the
+// re-encryption and the ciphertext comparison all running on masked values.
+//
+// Parameters:
+//   - ciphertext: The encrypted data to be decrypted.
+//
+// Returns:
+//   - []byte: The decrypted shared secret, identical to the one KemDecrypt returns.
+//   - error: An error if the ciphertext has the wrong length or masking randomness cannot be obtained.
+//
+// Only two values are unmasked: the result of the ciphertext comparison,
+// which an attacker can already observe through the protocol, and the
+// pre-key `K̄` when the ciphertext is valid.
+func (key *MaskedPrivateKey) KemDecrypt(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) != key.params.CiphertextBytes() {
+		return nil, errors.New("invalid ciphertext length")
+	}
+
+	key.mu.Lock()
+	defer key.mu.Unlock()
+
+	rng, err := newMaskRand()
+	if err != nil {
+		return nil, err
+	}
+	key.refresh(rng)
+
+	buf := indcpaDecryptMasked(ciphertext, key.secretShares, key.params, rng)
+
+	// kr = G(m' || H(pk)), with H(pk) public.
+	kr := maskedSponge([2][]byte{
+		append(buf[0], key.publicKeyHash...),
+		append(buf[1], make([]byte, paramsSymBytes)...),
+	}, 72, 0x06, 2*paramsSymBytes, rng)
+	coins := [2][]byte{kr[0][paramsSymBytes:], kr[1][paramsSymBytes:]}
+
+	ok, err := indcpaReencryptMasked(ciphertext, buf, key.publicKey, coins, key.params, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	preKey := make([]byte, paramsSymBytes)
+	if ok == 1 {
+		for i := range preKey {
+			preKey[i] = kr[0][i] ^ kr[1][i]
+		}
+	} else {
+		copy(preKey, key.rejectionKey)
+	}
+
+	krh := sha3.Sum256(ciphertext)
+	sharedSecret := make([]byte, KyberSSBytes)
+	sha3.ShakeSum256(sharedSecret, append(preKey, krh[:]...))
+	return sharedSecret, nil
+}
@@ -0,0 +1,113 @@
+package gokyber
+
+import "math/bits"
+
+// keccakRoundConstants are the iota round constants of Keccak-f[1600].
+var keccakRoundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808A, 0x8000000080008000,
+	0x000000000000808B, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008A, 0x0000000000000088, 0x0000000080008009, 0x000000008000000A,
+	0x000000008000808B, 0x800000000000008B, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800A, 0x800000008000000A,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// keccakRotations are the rho rotation offsets, indexed by x+5*y.
+var keccakRotations = [25]int{
+	0, 1, 62, 28, 27,
+	36, 44, 6, 55, 20,
+	3, 10, 43, 25, 39,
+	41, 45, 15, 21, 8,
+	18, 2, 61, 56, 14,
+}
+
+// maskedKeccakF1600 applies the Keccak-f[1600] permutation to a state that is
+// split into two Boolean shares, so that the actual state is the XOR of both.
+// Theta, rho, pi and iota are linear and are applied to each share
+// independently; the only non-linear step, chi, uses the SecAnd gadget so that
+// no intermediate value ever depends on both shares of the same lane.
+func maskedKeccakF1600(state *[2][25]uint64, rng *maskRand) {
+	var c, d [5]uint64
+	var b [2][25]uint64
+	for round := 0; round < 24; round++ {
+		for s := 0; s < 2; s++ {
+			a := &state[s]
+			// Theta.
+			for x := 0; x < 5; x++ {
+				c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+			}
+			for x := 0; x < 5; x++ {
+				d[x] = c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+			}
+			for i := 0; i < 25; i++ {
+				a[i] ^= d[i%5]
+			}
+			// Rho and pi.
+			for x := 0; x < 5; x++ {
+				for y := 0; y < 5; y++ {
+					b[s][y+5*((2*x+3*y)%5)] = bits.RotateLeft64(a[x+5*y], keccakRotations[x+5*y])
+				}
+			}
+		}
+		// Chi: a = b ^ (^b[x+1] & b[x+2]). Negating a shared value only
+		// requires negating one of its shares.
+		for y := 0; y < 5; y++ {
+			for x := 0; x < 5; x++ {
+				i := x + 5*y
+				j := (x+1)%5 + 5*y
+				k := (x+2)%5 + 5*y
+				t := secAnd([2]uint64{^b[0][j], b[1][j]}, [2]uint64{b[0][k], b[1][k]}, rng.uint64())
+				state[0][i] = b[0][i] ^ t[0]
+				state[1][i] = b[1][i] ^ t[1]
+			}
+		}
+		// Iota only touches the first share.
+		state[0][0] ^= keccakRoundConstants[round]
+	}
+}
+
+// maskedSponge computes a Keccak sponge over a Boolean-shared input and
+// returns the Boolean-shared output. Both input shares must have the same
+// length; public data can be passed by placing it in the first share and
+// zeros in the second one. The rate and domain separation byte select the
+// instance, e.g. 72 and 0x06 for SHA3-512 or 136 and 0x1f for SHAKE-256.
+func maskedSponge(input [2][]byte, rate int, dsByte byte, outputLength int, rng *maskRand) [2][]byte {
+	var state [2][25]uint64
+	xorByte := func(s int, pos int, v byte) {
+		state[s][pos/8] ^= uint64(v) << (8 * (pos % 8))
+	}
+
+	// Absorb the full blocks.
+	offset := 0
+	for ; len(input[0])-offset >= rate; offset += rate {
+		for s := 0; s < 2; s++ {
+			for i := 0; i < rate; i++ {
+				xorByte(s, i, input[s][offset+i])
+			}
+		}
+		maskedKeccakF1600(&state, rng)
+	}
+
+	// Absorb the remaining bytes and the padding, which is public.
+	for s := 0; s < 2; s++ {
+		for i := 0; i < len(input[s])-offset; i++ {
+			xorByte(s, i, input[s][offset+i])
+		}
+	}
+	xorByte(0, len(input[0])-offset, dsByte)
+	xorByte(0, rate-1, 0x80)
+	maskedKeccakF1600(&state, rng)
+
+	// Squeeze.
+	output := [2][]byte{make([]byte, outputLength), make([]byte, outputLength)}
+	for pos := 0; pos < outputLength; pos++ {
+		if pos > 0 && pos%rate == 0 {
+			maskedKeccakF1600(&state, rng)
+		}
+		i := pos % rate
+		for s := 0; s < 2; s++ {
+			output[s][pos] = byte(state[s][i/8] >> (8 * (i % 8)))
+		}
+	}
+	return output
+}
@@ -0,0 +1,145 @@
+package gokyber
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func TestMaskedSpongeMatchesSHA3(t *testing.T) {
+	rng, err := newMaskRand()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, length := range []int{0, 33, 64, 71, 72, 135, 136, 300} {
+		message := make([]byte, length)
+		mask := make([]byte, length)
+		rand.Read(message)
+		rand.Read(mask)
+		shares := [2][]byte{make([]byte, length), mask}
+		for i := range message {
+			shares[0][i] = message[i] ^ mask[i]
+		}
+
+		out := maskedSponge(shares, 72, 0x06, 64, rng)
+		want := sha3.Sum512(message)
+		for i := range out[0] {
+			out[0][i] ^= out[1][i]
+		}
+		if !bytes.Equal(out[0], want[:]) {
+			t.Errorf("masked SHA3-512 mismatch for length %d", length)
+		}
+
+		out = maskedSponge(shares, 136, 0x1f, 200, rng)
+		wantShake := make([]byte, 200)
+		sha3.ShakeSum256(wantShake, message)
+		for i := range out[0] {
+			out[0][i] ^= out[1][i]
+		}
+		if !bytes.Equal(out[0], wantShake) {
+			t.Errorf("masked SHAKE-256 mismatch for length %d", length)
+		}
+	}
+}
+
+func TestIndcpaDecryptMaskedMatchesIndcpaDecrypt(t *testing.T) {
+	for _, k := range []int{2, 3, 4} {
+		privateKey, publicKey, err := IndcpaKeypair(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares, err := IndcpaMaskPrivateKey(privateKey, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		message := make([]byte, paramsSymBytes)
+		coins := make([]byte, paramsSymBytes)
+		rand.Read(message)
+		rand.Read(coins)
+		ciphertext, err := IndcpaEncrypt(message, publicKey, coins, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		masked, err := IndcpaDecryptMasked(ciphertext, shares, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range masked[0] {
+			masked[0][i] ^= masked[1][i]
+		}
+		if !bytes.Equal(masked[0], IndcpaDecrypt(ciphertext, privateKey, k)) {
+			t.Errorf("k=%d: masked and unmasked decryption differ", k)
+		}
+		if !bytes.Equal(masked[0], message) {
+			t.Errorf("k=%d: masked decryption did not recover the message", k)
+		}
+	}
+}
+
+func TestMaskedKemDecryptMatchesKemDecrypt(t *testing.T) {
+	for _, variant := range []int{512, 768, 1024} {
+		privateKey, publicKey, err := KemKeypair(variant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		maskedKey, err := NewMaskedPrivateKey(privateKey, variant)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 3; i++ {
+			ciphertext, sharedSecret, err := KemEncrypt(publicKey, variant)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := maskedKey.KemDecrypt(ciphertext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, sharedSecret) {
+				t.Fatalf("%d: masked decapsulation returned a different shared secret", variant)
+			}
+
+			// Tampered ciphertexts must be implicitly rejected exactly
+			// like the unmasked path does.
+			for _, pos := range []int{0, len(ciphertext) / 2, len(ciphertext) - 1} {
+				tampered := append([]byte{}, ciphertext...)
+				tampered[pos] ^= 1 << i
+				got, err := maskedKey.KemDecrypt(tampered)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want, err := KemDecrypt(tampered, privateKey, variant)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Fatalf("%d: masked rejection differs from unmasked rejection", variant)
+				}
+				if bytes.Equal(got, sharedSecret) {
+					t.Fatalf("%d: tampered ciphertext was accepted", variant)
+				}
+			}
+		}
+	}
+}
+
+func TestMaskedKemDecryptRejectsWrongLength(t *testing.T) {
+	privateKey, _, err := KemKeypair(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	maskedKey, err := NewMaskedPrivateKey(privateKey, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := maskedKey.KemDecrypt(make([]byte, Kyber512CTBytes-1)); err == nil {
+		t.Error("expected an error for a short ciphertext")
+	}
+	if _, err := NewMaskedPrivateKey(privateKey, 768); err == nil {
+		t.Error("expected an error for a private key of the wrong variant")
+	}
+}
@@ -0,0 +1,73 @@
+package gokyber
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+)
+
+// ML-KEM (FIPS 203) shares the IND-CPA scheme of round-3 Kyber, with the
+// seed of the key pair bound to the module rank, and differs in the
+// Fujisaki-Okamoto transform: the encapsulated message is used as is rather
+// than hashed, the shared secret is the first half of `G(m || H(pk))`
+// without a hash of the ciphertext, and implicit rejection returns
+// `J(z || c)`. It also mandates checks on keys that round-3 Kyber leaves
+// out.
+
+// mlkemEncapsulate is ML-KEM.Encaps_internal: it encapsulates the 32-byte
+// message m, truncated to MessageBytes for toy rings.
+func (p *ParameterSet) mlkemEncapsulate(publicKey []byte, m []byte) ([]byte, []byte, error) {
+	m = m[:p.MessageBytes():p.MessageBytes()]
+	pkh := p.Symmetric.Hash256(publicKey)
+	kr := p.Symmetric.Hash512(append(m, pkh[:]...))
+	p.traceBytes("kem.encaps.message", m)
+	p.traceBytes("kem.encaps.publicKeyHash", pkh[:])
+	p.traceBytes("kem.encaps.coins", kr[paramsSymBytes:])
+
+	ciphertext, err := p.IndcpaEncrypt(m, publicKey, kr[paramsSymBytes:])
+	if err != nil {
+		return nil, nil, err
+	}
+	sharedSecret := bytes.Clone(kr[:paramsSymBytes])
+	p.traceBytes("kem.encaps.sharedSecret", sharedSecret)
+	return ciphertext, sharedSecret, nil
+}
+
+// mlkemDecapsulate is ML-KEM.Decaps, including the check of the hash of
+// the public key stored in the private key. The lengths have been checked.
+func (p *ParameterSet) mlkemDecapsulate(ciphertext, privateKey []byte) ([]byte, error) {
+	indcpaPrivateKey := privateKey[:p.IndcpaSecretKeyBytes()]
+	publicKey := privateKey[p.IndcpaSecretKeyBytes() : p.IndcpaSecretKeyBytes()+p.IndcpaPublicKeyBytes()]
+	pkh := privateKey[len(privateKey)-2*paramsSymBytes : len(privateKey)-paramsSymBytes]
+	z := privateKey[len(privateKey)-paramsSymBytes:]
+	if h := p.Symmetric.Hash256(publicKey); !bytes.Equal(h[:], pkh) {
+		return nil, errors.New("invalid private key: public key hash mismatch")
+	}
+
+	m := p.indcpaDecrypt(ciphertext, indcpaPrivateKey)
+	kr := p.Symmetric.Hash512(append(m, pkh...))
+	p.traceBytes("kem.decaps.message", m)
+	p.traceBytes("kem.decaps.coins", kr[paramsSymBytes:])
+	cmp, err := p.IndcpaEncrypt(m, publicKey, kr[paramsSymBytes:])
+	if err != nil {
+		return nil, err
+	}
+	rejection := p.Symmetric.Kdf(append(bytes.Clone(z), ciphertext...))
+
+	valid := subtle.ConstantTimeCompare(ciphertext, cmp)
+	p.traceBool("kem.decaps.valid", valid == 1)
+	sharedSecret := make([]byte, paramsSymBytes)
+	subtle.ConstantTimeCopy(1-valid, sharedSecret, rejection[:])
+	subtle.ConstantTimeCopy(valid, sharedSecret, kr[:paramsSymBytes])
+	p.traceBytes("kem.decaps.sharedSecret", sharedSecret)
+	return sharedSecret, nil
+}
+
+// publicKeyReduced is the modulus check of FIPS 203: every coefficient of
+// the encoded vector `t` must be below Q, so that decoding and re-encoding
+// gives back the same bytes.
+func (p *ParameterSet) publicKeyReduced(publicKey []byte) bool {
+	r := p.PolynomialRing()
+	encoded := publicKey[:p.PolyvecBytes()]
+	return bytes.Equal(r.polyvecToBytes(r.polyvecFromBytes(encoded, p.K)), encoded)
+}
@@ -0,0 +1,148 @@
+package gokyber
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// mlkemVectors were computed with an independent FIPS 203 implementation.
+// The seed is `d || z`, m is the encapsulated message; keys and ciphertexts
+// are given as their SHA3-256 digests. rejection is the shared secret
+// returned for the ciphertext with its first bit flipped.
+var mlkemVectors = []struct {
+	params                *ParameterSet
+	seed, m               string
+	publicKey, ciphertext string
+	sharedSecret          string
+	rejection             string
+}{
+	{
+		MLKEM768,
+		"eeb58c55e712f239b154140f278433f03aa200df738a39033666b6d99d9a8b849488400835811de2197cd17c44084f887a857996ff869961ecc5f0e19b0e9414",
+		"2d15129b42c220a98fa0552aebead4e1d0736b47195f2c56edd5ab15048b20d5",
+		"f97ef4ff8c34924fdf55decf4cc61022b27498c792c361fb1d0061c935e09334",
+		"dad291beee417e8bd4a6a0d3f678312ee3abab5c38bc065afb3cef080b72acea",
+		"699337d1a090892cc6ad29d9e4fcb7f7b012ff1d0a5a0f6c592b10b78c2a1bde",
+		"8729c2d50da1ca93b7010597461c8801ae5edd80b498f32b96071b676de68e86",
+	},
+	{
+		MLKEM1024,
+		"fc6fb1998e61a68c9471dba08635e813bf681b793ff9a2f04b2a3185a88d7d7b8864303061303d2c6084cce819ed1aa2256ba2e36c790b94217f66d2fbe46dcf",
+		"4e9a26fddc7458b0e86d791e103ae1919d6fb5d6167d9ecd7d2a4d85b694f467",
+		"d1328b29deb982bb094f41ea00d059d3a1ef4476d9e3de1c041b5805aeee4a6f",
+		"515afa7e44efc898ee8a14a05e48c25c965a61c74d5884cc5e1d5df7fe8ad11d",
+		"6fe108df2bf11c7c2d5d93c23578ac5ba9d3384ce8e3e46c47a31aa98924f6ba",
+		"e6cc0e0f9dfd7a94bba8f94a7865ba8d7fcf2458abdd1bf793e935e5a9c81f83",
+	},
+}
+
+func digest(b []byte) string {
+	d := sha3.Sum256(b)
+	return hex.EncodeToString(d[:])
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestMLKEMVectors(t *testing.T) {
+	for _, v := range mlkemVectors {
+		p := v.params
+		privateKey, publicKey, err := p.KemKeypairFromSeed(mustDecodeHex(t, v.seed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := digest(publicKey); got != v.publicKey {
+			t.Errorf("%s: public key digest %s, want %s", p.Name, got, v.publicKey)
+		}
+		ciphertext, sharedSecret, err := p.KemEncryptFromSeed(publicKey, mustDecodeHex(t, v.m))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := digest(ciphertext); got != v.ciphertext {
+			t.Errorf("%s: ciphertext digest %s, want %s", p.Name, got, v.ciphertext)
+		}
+		if got := hex.EncodeToString(sharedSecret); got != v.sharedSecret {
+			t.Errorf("%s: shared secret %s, want %s", p.Name, got, v.sharedSecret)
+		}
+		decapsulated, err := p.KemDecrypt(ciphertext, privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decapsulated, sharedSecret) {
+			t.Errorf("%s: decapsulation gives a different shared secret", p.Name)
+		}
+		ciphertext[0] ^= 1
+		rejected, err := p.KemDecrypt(ciphertext, privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(rejected); got != v.rejection {
+			t.Errorf("%s: implicit rejection %s, want %s", p.Name, got, v.rejection)
+		}
+	}
+}
+
+func TestMLKEMRoundTrip(t *testing.T) {
+	for _, p := range []*ParameterSet{MLKEM512, MLKEM768, MLKEM1024} {
+		privateKey, publicKey, err := p.KemKeypair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertext, sharedSecret, err := p.KemEncrypt(publicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decapsulated, err := p.KemDecrypt(ciphertext, privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decapsulated, sharedSecret) {
+			t.Errorf("%s: shared secrets differ", p.Name)
+		}
+	}
+}
+
+func TestMLKEMDiffersFromKyber(t *testing.T) {
+	seed := make([]byte, 64)
+	_, kyberPublicKey, _ := Kyber768.KemKeypairFromSeed(seed)
+	_, mlkemPublicKey, _ := MLKEM768.KemKeypairFromSeed(seed)
+	if bytes.Equal(kyberPublicKey, mlkemPublicKey) {
+		t.Error("ML-KEM and Kyber derive the same key pair from a seed")
+	}
+}
+
+func TestMLKEMKeyChecks(t *testing.T) {
+	p := MLKEM768
+	privateKey, publicKey, err := p.KemKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Set the first coefficient of `t` to 4095.
+	bad := bytes.Clone(publicKey)
+	bad[0] = 0xff
+	bad[1] |= 0x0f
+	if _, _, err := p.KemEncrypt(bad); err == nil {
+		t.Error("public key with an unreduced coefficient accepted")
+	}
+	ciphertext, _, err := p.KemEncrypt(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	badPrivateKey := bytes.Clone(privateKey)
+	badPrivateKey[p.IndcpaSecretKeyBytes()] ^= 1
+	if _, err := p.KemDecrypt(ciphertext, badPrivateKey); err == nil {
+		t.Error("private key with a mismatched public key hash accepted")
+	}
+	if _, _, err := p.KemKeypairFromSeed(make([]byte, 32)); err == nil {
+		t.Error("short seed accepted")
+	}
+}
@@ -0,0 +1,218 @@
+package gokyber
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ParameterSet describes an instance of Kyber: the module rank, the widths
+// of the centered binomial noise, the number of bits ciphertexts are
+// compressed to and the symmetric primitives. Every size follows from these
+// values and from the ring, which is KyberRing unless a toy ring is set.
+//
+// The three standard parameter sets, and their Kyber-90s counterparts, are
+// predefined. Other combinations can be used for research; Validate only
+// checks that a combination can be encoded, not that it is secure or that
+// its decryption failure rate is low enough to be usable.
+type ParameterSet struct {
+	// Name is a human-readable identifier, e.g. "Kyber768".
+	Name string
+	// K is the module rank: the number of polynomials in a vector.
+	K int
+	// Eta1 is the noise width of the secret and of `r` during encryption.
+	Eta1 int
+	// Eta2 is the noise width of the errors added during encryption.
+	Eta2 int
+	// Du is the number of bits each coefficient of `u` is compressed to.
+	Du int
+	// Dv is the number of bits each coefficient of `v` is compressed to.
+	Dv int
+	// Symmetric holds the hash functions, PRF, XOF and KDF.
+	Symmetric Symmetric
+	// Ring is the polynomial ring; nil stands for KyberRing.
+	Ring *Ring
+	// FIPS203 selects ML-KEM, the standardized form of Kyber, instead of
+	// the round-3 submission. It changes how seeds, messages and shared
+	// secrets are derived, but not the sizes of keys and ciphertexts.
+	FIPS203 bool
+	// tracer, if set, receives the intermediate values of every operation.
+	// It is only set by WithTracer, on a copy, so that the shared predefined
+	// sets are never traced.
+	tracer Tracer
+}
+
+var (
+	// Kyber512 is the standard parameter set targeting NIST security level 1.
+	Kyber512 = &ParameterSet{Name: "Kyber512", K: 2, Eta1: paramsETAK512, Eta2: paramsETAK768K1024, Du: 10, Dv: 4, Symmetric: SymmetricSHAKE}
+	// Kyber768 is the standard parameter set targeting NIST security level 3.
+	Kyber768 = &ParameterSet{Name: "Kyber768", K: 3, Eta1: paramsETAK768K1024, Eta2: paramsETAK768K1024, Du: 10, Dv: 4, Symmetric: SymmetricSHAKE}
+	// Kyber1024 is the standard parameter set targeting NIST security level 5.
+	Kyber1024 = &ParameterSet{Name: "Kyber1024", K: 4, Eta1: paramsETAK768K1024, Eta2: paramsETAK768K1024, Du: 11, Dv: 5, Symmetric: SymmetricSHAKE}
+
+	// Kyber512_90s is Kyber512 instantiated with AES-256-CTR and SHA-2.
+	Kyber512_90s = &ParameterSet{Name: "Kyber512-90s", K: 2, Eta1: paramsETAK512, Eta2: paramsETAK768K1024, Du: 10, Dv: 4, Symmetric: Symmetric90s}
+	// Kyber768_90s is Kyber768 instantiated with AES-256-CTR and SHA-2.
+	Kyber768_90s = &ParameterSet{Name: "Kyber768-90s", K: 3, Eta1: paramsETAK768K1024, Eta2: paramsETAK768K1024, Du: 10, Dv: 4, Symmetric: Symmetric90s}
+	// Kyber1024_90s is Kyber1024 instantiated with AES-256-CTR and SHA-2.
+	Kyber1024_90s = &ParameterSet{Name: "Kyber1024-90s", K: 4, Eta1: paramsETAK768K1024, Eta2: paramsETAK768K1024, Du: 11, Dv: 5, Symmetric: Symmetric90s}
+
+	// MLKEM512 is ML-KEM-512 as specified in FIPS 203.
+	MLKEM512 = &ParameterSet{Name: "ML-KEM-512", K: 2, Eta1: paramsETAK512, Eta2: paramsETAK768K1024, Du: 10, Dv: 4, Symmetric: SymmetricSHAKE, FIPS203: true}
+	// MLKEM768 is ML-KEM-768 as specified in FIPS 203.
+	MLKEM768 = &ParameterSet{Name: "ML-KEM-768", K: 3, Eta1: paramsETAK768K1024, Eta2: paramsETAK768K1024, Du: 10, Dv: 4, Symmetric: SymmetricSHAKE, FIPS203: true}
+	// MLKEM1024 is ML-KEM-1024 as specified in FIPS 203.
+	MLKEM1024 = &ParameterSet{Name: "ML-KEM-1024", K: 4, Eta1: paramsETAK768K1024, Eta2: paramsETAK768K1024, Du: 11, Dv: 5, Symmetric: SymmetricSHAKE, FIPS203: true}
+)
+
+// predefinedSets lists the predefined parameter sets. Each has an entry in
+// algorithmIDs and in publicKeyHRPs.
+var predefinedSets = []*ParameterSet{
+	Kyber512, Kyber768, Kyber1024,
+	Kyber512_90s, Kyber768_90s, Kyber1024_90s,
+	MLKEM512, MLKEM768, MLKEM1024,
+}
+
+// Predefined returns the predefined parameter set with the parameters of p,
+// or nil if there is none. Copies of a predefined set, such as those made by
+// WithTracer, resolve to it; a custom set does not, even under the name of
+// a predefined one.
+func (p *ParameterSet) Predefined() *ParameterSet {
+	for _, q := range predefinedSets {
+		if p.K == q.K && p.Eta1 == q.Eta1 && p.Eta2 == q.Eta2 && p.Du == q.Du && p.Dv == q.Dv &&
+			p.Symmetric == q.Symmetric && p.PolynomialRing() == q.PolynomialRing() && p.FIPS203 == q.FIPS203 {
+			return q
+		}
+	}
+	return nil
+}
+
+// ParameterSetForVariant returns the standard parameter set for a Kyber
+// variant (512, 768 or 1024), as accepted by KemKeypair.
+func ParameterSetForVariant(kyberVariant int) (*ParameterSet, error) {
+	switch kyberVariant {
+	case 512:
+		return Kyber512, nil
+	case 768:
+		return Kyber768, nil
+	case 1024:
+		return Kyber1024, nil
+	default:
+		return nil, errors.New("invalid Kyber variant")
+	}
+}
+
+// ParameterSetByName returns the predefined parameter set with a name, such
+// as "Kyber768" or "ML-KEM-768".
+func ParameterSetByName(name string) (*ParameterSet, error) {
+	for _, p := range predefinedSets {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown parameter set %q", name)
+}
+
+// parameterSet90sForVariant returns the Kyber-90s parameter set for a Kyber variant.
+func parameterSet90sForVariant(kyberVariant int) (*ParameterSet, error) {
+	switch kyberVariant {
+	case 512:
+		return Kyber512_90s, nil
+	case 768:
+		return Kyber768_90s, nil
+	case 1024:
+		return Kyber1024_90s, nil
+	default:
+		return nil, errors.New("invalid Kyber variant")
+	}
+}
+
+// Validate reports whether the parameter set can be used by the IND-CPA and
+// KEM layers. The rank is limited by the one-byte nonces of the PRF, and the
+// compression widths by the 12-bit size of the coefficients.
+func (p *ParameterSet) Validate() error {
+	switch {
+	case p.K < 1 || 2*p.K+1 > 255:
+		return fmt.Errorf("invalid module rank %d", p.K)
+	case p.Eta1 < 1 || p.Eta1 > 16:
+		return fmt.Errorf("invalid noise width eta1 = %d", p.Eta1)
+	case p.Eta2 < 1 || p.Eta2 > 16:
+		return fmt.Errorf("invalid noise width eta2 = %d", p.Eta2)
+	case p.Du < 1 || p.Du > 11:
+		return fmt.Errorf("invalid compression width du = %d", p.Du)
+	case p.Dv < 1 || p.Dv > 11:
+		return fmt.Errorf("invalid compression width dv = %d", p.Dv)
+	case p.Symmetric == nil:
+		return errors.New("missing symmetric primitives")
+	case p.Ring != nil && p.Ring.zetas == nil:
+		return errors.New("ring not created with NewRing")
+	case p.FIPS203 && p.Symmetric != SymmetricSHAKE:
+		return errors.New("ML-KEM is only defined with SHAKE and SHA-3")
+	}
+	return nil
+}
+
+// PolynomialRing returns the ring of the parameter set.
+func (p *ParameterSet) PolynomialRing() *Ring {
+	if p.Ring == nil {
+		return KyberRing
+	}
+	return p.Ring
+}
+
+// PolyvecBytes returns the byte length of a serialized vector of polynomials.
+func (p *ParameterSet) PolyvecBytes() int {
+	return p.K * p.PolynomialRing().PolyBytes()
+}
+
+// PolyCompressedBytes returns the byte length of the compressed polynomial `v`.
+func (p *ParameterSet) PolyCompressedBytes() int {
+	return p.Dv * p.PolynomialRing().N / 8
+}
+
+// PolyvecCompressedBytes returns the byte length of the compressed vector `u`.
+func (p *ParameterSet) PolyvecCompressedBytes() int {
+	return p.K * p.Du * p.PolynomialRing().N / 8
+}
+
+// IndcpaPublicKeyBytes returns the byte length of IND-CPA public keys.
+func (p *ParameterSet) IndcpaPublicKeyBytes() int {
+	return p.PolyvecBytes() + paramsSymBytes
+}
+
+// IndcpaSecretKeyBytes returns the byte length of IND-CPA private keys.
+func (p *ParameterSet) IndcpaSecretKeyBytes() int {
+	return p.PolyvecBytes()
+}
+
+// PublicKeyBytes returns the byte length of KEM public keys.
+func (p *ParameterSet) PublicKeyBytes() int {
+	return p.IndcpaPublicKeyBytes()
+}
+
+// PrivateKeyBytes returns the byte length of KEM private keys, which hold the
+// IND-CPA private key, the public key, its hash and the rejection value `z`.
+func (p *ParameterSet) PrivateKeyBytes() int {
+	return p.IndcpaSecretKeyBytes() + p.IndcpaPublicKeyBytes() + 2*paramsSymBytes
+}
+
+// CiphertextBytes returns the byte length of ciphertexts.
+func (p *ParameterSet) CiphertextBytes() int {
+	return p.PolyvecCompressedBytes() + p.PolyCompressedBytes()
+}
+
+// MessageBytes returns the byte length of the messages of the IND-CPA
+// scheme, which is 32 except for toy rings.
+func (p *ParameterSet) MessageBytes() int {
+	return p.PolynomialRing().MessageBytes()
+}
+
+// SeedBytes returns the byte length of the seeds accepted by
+// KemKeypairFromSeed.
+func (p *ParameterSet) SeedBytes() int {
+	return 2 * paramsSymBytes
+}
+
+// SharedSecretBytes returns the byte length of shared secrets.
+func (p *ParameterSet) SharedSecretBytes() int {
+	return KyberSSBytes
+}
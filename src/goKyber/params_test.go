@@ -0,0 +1,191 @@
+package gokyber
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestParameterSetSizes(t *testing.T) {
+	for _, v := range []struct {
+		params                                          *ParameterSet
+		publicKey, privateKey, ciphertext, kyberVariant int
+	}{
+		{Kyber512, Kyber512PKBytes, Kyber512SKBytes, Kyber512CTBytes, 512},
+		{Kyber768, Kyber768PKBytes, Kyber768SKBytes, Kyber768CTBytes, 768},
+		{Kyber1024, Kyber1024PKBytes, Kyber1024SKBytes, Kyber1024CTBytes, 1024},
+	} {
+		if got := v.params.PublicKeyBytes(); got != v.publicKey {
+			t.Errorf("%s: public key length %d, want %d", v.params.Name, got, v.publicKey)
+		}
+		if got := v.params.PrivateKeyBytes(); got != v.privateKey {
+			t.Errorf("%s: private key length %d, want %d", v.params.Name, got, v.privateKey)
+		}
+		if got := v.params.CiphertextBytes(); got != v.ciphertext {
+			t.Errorf("%s: ciphertext length %d, want %d", v.params.Name, got, v.ciphertext)
+		}
+		if got, err := ParameterSetForVariant(v.kyberVariant); err != nil || got != v.params {
+			t.Errorf("ParameterSetForVariant(%d) = %v, %v", v.kyberVariant, got, err)
+		}
+	}
+}
+
+func TestParameterSetByName(t *testing.T) {
+	for _, p := range []*ParameterSet{Kyber512, Kyber768_90s, MLKEM1024} {
+		if got, err := ParameterSetByName(p.Name); err != nil || got != p {
+			t.Errorf("ParameterSetByName(%q) = %v, %v", p.Name, got, err)
+		}
+	}
+	if _, err := ParameterSetByName("kyber768"); err == nil {
+		t.Error("names are case sensitive")
+	}
+}
+
+func TestPredefined(t *testing.T) {
+	for _, p := range predefinedSets {
+		if got := p.WithTracer(nil).Predefined(); got != p {
+			t.Errorf("%s: traced copy resolves to %v", p.Name, got)
+		}
+	}
+	custom := *Kyber768
+	custom.Name = "custom"
+	if custom.Predefined() != Kyber768 {
+		t.Error("renamed copy of Kyber768 does not resolve to it")
+	}
+	for _, change := range []func(p *ParameterSet){
+		func(p *ParameterSet) { p.K = 5 },
+		func(p *ParameterSet) { p.Eta1 = 3 },
+		func(p *ParameterSet) { p.Eta2 = 3 },
+		func(p *ParameterSet) { p.Du = 11 },
+		func(p *ParameterSet) { p.Dv = 5 },
+		func(p *ParameterSet) { p.Symmetric = Symmetric90s },
+		func(p *ParameterSet) { p.FIPS203 = true },
+	} {
+		custom := *Kyber768
+		change(&custom)
+		if custom.Predefined() == Kyber768 {
+			t.Errorf("changed copy of Kyber768 resolves to it: %+v", custom)
+		}
+	}
+}
+
+func TestCustomParameterSetsRoundTrip(t *testing.T) {
+	for _, params := range []*ParameterSet{
+		{Name: "K1", K: 1, Eta1: 3, Eta2: 2, Du: 10, Dv: 4, Symmetric: SymmetricSHAKE},
+		{Name: "K5", K: 5, Eta1: 2, Eta2: 2, Du: 11, Dv: 5, Symmetric: SymmetricSHAKE},
+		{Name: "K3-eta1", K: 3, Eta1: 1, Eta2: 1, Du: 9, Dv: 3, Symmetric: SymmetricSHAKE},
+		{Name: "K2-wide", K: 2, Eta1: 2, Eta2: 2, Du: 11, Dv: 7, Symmetric: Symmetric90s},
+		{Name: "K4-eta4", K: 4, Eta1: 4, Eta2: 1, Du: 11, Dv: 6, Symmetric: SymmetricSHAKE},
+	} {
+		privateKey, publicKey, err := params.KemKeypair()
+		if err != nil {
+			t.Fatalf("%s: %v", params.Name, err)
+		}
+		if len(privateKey) != params.PrivateKeyBytes() || len(publicKey) != params.PublicKeyBytes() {
+			t.Fatalf("%s: unexpected key lengths %d and %d", params.Name, len(privateKey), len(publicKey))
+		}
+		ciphertext, sharedSecret, err := params.KemEncrypt(publicKey)
+		if err != nil {
+			t.Fatalf("%s: %v", params.Name, err)
+		}
+		if len(ciphertext) != params.CiphertextBytes() {
+			t.Fatalf("%s: ciphertext length %d, want %d", params.Name, len(ciphertext), params.CiphertextBytes())
+		}
+		decrypted, err := params.KemDecrypt(ciphertext, privateKey)
+		if err != nil {
+			t.Fatalf("%s: %v", params.Name, err)
+		}
+		if !bytes.Equal(decrypted, sharedSecret) {
+			t.Errorf("%s: shared secrets do not match", params.Name)
+		}
+
+		if _, _, err := params.KemEncrypt(publicKey[1:]); err == nil {
+			t.Errorf("%s: short public key accepted", params.Name)
+		}
+		if _, err := params.KemDecrypt(ciphertext[1:], privateKey); err == nil {
+			t.Errorf("%s: short ciphertext accepted", params.Name)
+		}
+	}
+}
+
+func TestParameterSetValidate(t *testing.T) {
+	valid := *Kyber768
+	for _, tweak := range []func(p *ParameterSet){
+		func(p *ParameterSet) { p.K = 0 },
+		func(p *ParameterSet) { p.K = 128 },
+		func(p *ParameterSet) { p.Eta1 = 0 },
+		func(p *ParameterSet) { p.Eta2 = 17 },
+		func(p *ParameterSet) { p.Du = 12 },
+		func(p *ParameterSet) { p.Dv = 0 },
+		func(p *ParameterSet) { p.Symmetric = nil },
+	} {
+		params := valid
+		tweak(&params)
+		if err := params.Validate(); err == nil {
+			t.Errorf("Validate accepted %+v", params)
+		}
+		if _, _, err := params.KemKeypair(); err == nil {
+			t.Errorf("KemKeypair accepted %+v", params)
+		}
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate rejected Kyber768: %v", err)
+	}
+	if _, err := ParameterSetForVariant(640); err == nil {
+		t.Error("ParameterSetForVariant accepted 640")
+	}
+}
+
+// TestCompressBitsMatchesFastPaths checks the generic compression against
+// the unrolled routines on every coefficient value.
+func TestCompressBitsMatchesFastPaths(t *testing.T) {
+	for _, d := range []int{4, 5, 10, 11} {
+		for start := 0; start < paramsQ; start += paramsN {
+			var p Polynomial
+			for i := 0; i < paramsN; i++ {
+				p[i] = int16((start + i) % paramsQ)
+			}
+			var fast []byte
+			if d < 10 {
+				fast = polyCompress(p, d)
+			} else {
+				fast = polyvecCompress(PolynomialVector{p}, 1, d)
+			}
+			generic := compressBits(p[:paramsN], paramsQ, d)
+			if !bytes.Equal(fast, generic) {
+				t.Fatalf("d = %d: compression of [%d, %d) differs", d, start, start+paramsN)
+			}
+
+			var fastPoly, genericPoly Polynomial
+			if d < 10 {
+				fastPoly = polyDecompress(fast, d)
+			} else {
+				fastPoly = polyvecDecompress(fast, 1, d)[0]
+			}
+			decompressBits(genericPoly[:paramsN], generic, paramsQ, d)
+			if fastPoly != genericPoly {
+				t.Fatalf("d = %d: decompression of [%d, %d) differs", d, start, start+paramsN)
+			}
+		}
+	}
+}
+
+// TestByteopsCbd checks every noise width against a bit-by-bit centered
+// binomial sampler.
+func TestByteopsCbd(t *testing.T) {
+	for eta := 1; eta <= 5; eta++ {
+		uniformBytes := make([]byte, eta*paramsN/4)
+		rand.Read(uniformBytes)
+		got := byteopsCbd(uniformBytes, eta)
+		bit := func(pos int) int16 { return int16(uniformBytes[pos/8]>>(pos%8)) & 1 }
+		for i := 0; i < paramsN; i++ {
+			var want int16
+			for j := 0; j < eta; j++ {
+				want += bit(2*eta*i+j) - bit(2*eta*i+eta+j)
+			}
+			if got[i] != want {
+				t.Fatalf("eta = %d: coefficient %d is %d, want %d", eta, i, got[i], want)
+			}
+		}
+	}
+}
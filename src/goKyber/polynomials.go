@@ -20,11 +20,23 @@ type PolynomialVector []Polynomial
 // by iterating over its coefficients and applying bitwise operations to pack them
 // into the output byte slice.
 func PolyCompress(inputPoly Polynomial, kVariant int) []byte {
+	switch kVariant {
+	case 2, 3:
+		return polyCompress(inputPoly, 4)
+	default:
+		return polyCompress(inputPoly, 5)
+	}
+}
+
+// polyCompress compresses every coefficient of a polynomial to `d` bits and
+// packs them little-endian. The widths used by the standard parameter sets
+// have dedicated unrolled implementations.
+func polyCompress(inputPoly Polynomial, d int) []byte {
 	temp := make([]byte, 8)
 	inputPoly = PolyCSubQ(inputPoly)
 	outputByteIndex := 0
-	switch kVariant {
-	case 2, 3:
+	switch d {
+	case 4:
 		outputBytes := make([]byte, paramsPolyCompressedBytesK768) // 128
 		for i := 0; i < paramsN/8; i++ {
 			for j := 0; j < 8; j++ {
@@ -37,7 +49,7 @@ func PolyCompress(inputPoly Polynomial, kVariant int) []byte {
 			outputByteIndex = outputByteIndex + 4
 		}
 		return outputBytes
-	default:
+	case 5:
 		outputBytes := make([]byte, paramsPolyCompressedBytesK1024) // 160
 		for i := 0; i < paramsN/8; i++ {
 			for j := 0; j < 8; j++ {
@@ -51,6 +63,8 @@ func PolyCompress(inputPoly Polynomial, kVariant int) []byte {
 			outputByteIndex = outputByteIndex + 5
 		}
 		return outputBytes
+	default:
+		return compressBits(inputPoly[:paramsN], paramsQ, d)
 	}
 }
 
@@ -59,17 +73,27 @@ func PolyCompress(inputPoly Polynomial, kVariant int) []byte {
 // Note that compression is lossy, and thus decompression will not match the
 // original input.
 func PolyDecompress(inputBytes []byte, kVariant int) Polynomial {
+	switch kVariant {
+	case 2, 3:
+		return polyDecompress(inputBytes, 4)
+	default:
+		return polyDecompress(inputBytes, 5)
+	}
+}
+
+// polyDecompress is the approximate inverse of polyCompress.
+func polyDecompress(inputBytes []byte, d int) Polynomial {
 	var resultPoly Polynomial
 	temp := make([]byte, 8)
 	inputByteIndex := 0
-	switch kVariant {
-	case 2, 3:
+	switch d {
+	case 4:
 		for i := 0; i < paramsN/2; i++ {
 			resultPoly[2*i+0] = int16(((uint16(inputBytes[inputByteIndex]&15) * uint16(paramsQ)) + 8) >> 4)
 			resultPoly[2*i+1] = int16(((uint16(inputBytes[inputByteIndex]>>4) * uint16(paramsQ)) + 8) >> 4)
 			inputByteIndex = inputByteIndex + 1
 		}
-	case 4:
+	case 5:
 		for i := range paramsN / 8 {
 			temp[0] = (inputBytes[inputByteIndex+0] >> 0)
 			temp[1] = (inputBytes[inputByteIndex+0] >> 5) | (inputBytes[inputByteIndex+1] << 3)
@@ -84,6 +108,8 @@ func PolyDecompress(inputBytes []byte, kVariant int) Polynomial {
 				resultPoly[8*i+j] = int16(((uint32(temp[j]&31) * uint32(paramsQ)) + 16) >> 5)
 			}
 		}
+	default:
+		decompressBits(resultPoly[:paramsN], inputBytes, paramsQ, d)
 	}
 	return resultPoly
 }
@@ -206,16 +232,19 @@ func PolyToMsg(inputPoly Polynomial) []byte {
 func PolyGetNoise(seed []byte, nonce byte, kVariant int) Polynomial {
 	switch kVariant {
 	case 2:
-		l := paramsETAK512 * paramsN / 4
-		p := IndcpaPrf(l, seed, nonce)
-		return ByteopsCbd(p, kVariant)
+		return polyGetNoise(SymmetricSHAKE, seed, nonce, paramsETAK512)
 	default:
-		l := paramsETAK768K1024 * paramsN / 4
-		p := IndcpaPrf(l, seed, nonce)
-		return ByteopsCbd(p, kVariant)
+		return polyGetNoise(SymmetricSHAKE, seed, nonce, paramsETAK768K1024)
 	}
 }
 
+// polyGetNoise samples a polynomial from the centered binomial distribution
+// of width `eta`, using `eta * N / 4` bytes of PRF output.
+func polyGetNoise(sym Symmetric, seed []byte, nonce byte, eta int) Polynomial {
+	p := sym.Prf(eta*paramsN/4, seed, nonce)
+	return byteopsCbd(p, eta)
+}
+
 // PolyNtt computes a negacyclic number-theoretic transform (NTT) of
 // a polynomial in-place; the input is assumed to be in normal order,
 // while the output is in bit-reversed order.
@@ -326,19 +355,22 @@ func PolyvecNew(kVariant int) PolynomialVector {
 // Returns:
 //   - A byte array containing the compressed polynomial vector.
 func PolyvecCompress(polyVec PolynomialVector, kVariant int) []byte {
-	var resultBytes []byte
-	PolyvecCSubQ(polyVec, kVariant)
-	resultByteIndex := 0
-	switch kVariant {
-	case 2:
-		resultBytes = make([]byte, paramsPolyvecCompressedBytesK512)
-	case 3:
-		resultBytes = make([]byte, paramsPolyvecCompressedBytesK768)
-	case 4:
-		resultBytes = make([]byte, paramsPolyvecCompressedBytesK1024)
-	}
 	switch kVariant {
 	case 2, 3:
+		return polyvecCompress(polyVec, kVariant, 10)
+	default:
+		return polyvecCompress(polyVec, kVariant, 11)
+	}
+}
+
+// polyvecCompress compresses every coefficient of a vector of `kVariant`
+// polynomials to `d` bits and packs them little-endian.
+func polyvecCompress(polyVec PolynomialVector, kVariant int, d int) []byte {
+	PolyvecCSubQ(polyVec, kVariant)
+	resultBytes := make([]byte, kVariant*d*paramsN/8)
+	resultByteIndex := 0
+	switch d {
+	case 10:
 		temp := make([]uint16, 4)
 		for i := 0; i < kVariant; i++ {
 			for j := 0; j < paramsN/4; j++ {
@@ -354,7 +386,7 @@ func PolyvecCompress(polyVec PolynomialVector, kVariant int) []byte {
 			}
 		}
 		return resultBytes
-	default:
+	case 11:
 		temp := make([]uint16, 8)
 		for i := 0; i < kVariant; i++ {
 			for j := 0; j < paramsN/8; j++ {
@@ -376,6 +408,11 @@ func PolyvecCompress(polyVec PolynomialVector, kVariant int) []byte {
 			}
 		}
 		return resultBytes
+	default:
+		for i := 0; i < kVariant; i++ {
+			copy(resultBytes[i*d*paramsN/8:], compressBits(polyVec[i][:paramsN], paramsQ, d))
+		}
+		return resultBytes
 	}
 }
 
@@ -394,10 +431,20 @@ func PolyvecCompress(polyVec PolynomialVector, kVariant int) []byte {
 //
 // The decompression process involves reading specific bits from the inputBytes and converting them into polynomial coefficients.
 func PolyvecDecompress(inputBytes []byte, kVariant int) PolynomialVector {
-	resultPolyVec := PolyvecNew(kVariant)
-	inputByteIndex := 0
 	switch kVariant {
 	case 2, 3:
+		return polyvecDecompress(inputBytes, kVariant, 10)
+	default:
+		return polyvecDecompress(inputBytes, kVariant, 11)
+	}
+}
+
+// polyvecDecompress is the approximate inverse of polyvecCompress.
+func polyvecDecompress(inputBytes []byte, kVariant int, d int) PolynomialVector {
+	resultPolyVec := PolyvecNew(kVariant)
+	inputByteIndex := 0
+	switch d {
+	case 10:
 		temp := make([]uint16, 4)
 		for i := 0; i < kVariant; i++ {
 			for j := 0; j < paramsN/4; j++ {
@@ -411,7 +458,7 @@ func PolyvecDecompress(inputBytes []byte, kVariant int) PolynomialVector {
 				}
 			}
 		}
-	case 4:
+	case 11:
 		temp := make([]uint16, 8)
 		for i := 0; i < kVariant; i++ {
 			for j := 0; j < paramsN/8; j++ {
@@ -429,10 +476,55 @@ func PolyvecDecompress(inputBytes []byte, kVariant int) PolynomialVector {
 				}
 			}
 		}
+	default:
+		for i := 0; i < kVariant; i++ {
+			decompressBits(resultPolyVec[i][:paramsN], inputBytes[i*d*paramsN/8:], paramsQ, d)
+		}
 	}
 	return resultPolyVec
 }
 
+// compressBits maps each coefficient `x` in [0, q) to `round(2^d * x / q) mod 2^d`
+// and packs the results little-endian on `d` bits each. It is the generic
+// counterpart of the unrolled compression routines.
+func compressBits(coefficients []int16, q int, d int) []byte {
+	outputBytes := make([]byte, len(coefficients)*d/8)
+	var acc uint32
+	accBits := 0
+	outputByteIndex := 0
+	for _, c := range coefficients {
+		t := ((uint32(c) << d) + uint32(q/2)) / uint32(q)
+		acc |= (t & (1<<d - 1)) << accBits
+		accBits += d
+		for accBits >= 8 {
+			outputBytes[outputByteIndex] = byte(acc)
+			outputByteIndex++
+			acc >>= 8
+			accBits -= 8
+		}
+	}
+	return outputBytes
+}
+
+// decompressBits reads `len(coefficients)` little-endian `d`-bit values and
+// maps each of them back to `round(q * t / 2^d)`.
+func decompressBits(coefficients []int16, inputBytes []byte, q int, d int) {
+	var acc uint32
+	accBits := 0
+	inputByteIndex := 0
+	for i := range coefficients {
+		for accBits < d {
+			acc |= uint32(inputBytes[inputByteIndex]) << accBits
+			inputByteIndex++
+			accBits += 8
+		}
+		t := acc & (1<<d - 1)
+		acc >>= d
+		accBits -= d
+		coefficients[i] = int16((t*uint32(q) + (1 << (d - 1))) >> d)
+	}
+}
+
 // SerializePolyVector takes a PolynomialVector and an integer kVariant, and returns a byte slice.
 // It serializes each polynomial in the vector up to the kVariant length.
 //
@@ -0,0 +1,540 @@
+package gokyber
+
+import (
+	"errors"
+	"io"
+)
+
+// Ring is the polynomial ring `Z_Q[X]/(X^N + 1)` the scheme computes in,
+// together with the twiddle factors of its number-theoretic transform.
+//
+// Kyber uses N = 256 and Q = 3329 (KyberRing). Smaller rings, built with
+// NewRing, make toy instances for teaching: with N = 16 or 32 the underlying
+// lattice problem is small enough to be broken by lattice reduction on a
+// laptop. Toy rings must never be used to protect anything.
+//
+// A Ring must be created with NewRing and must not be modified afterwards.
+type Ring struct {
+	// N is the degree of the ring, a power of two.
+	N int
+	// Q is the prime modulus, with Q = 1 mod N.
+	Q int
+
+	// zetas holds the powers of a primitive N-th root of unity in the
+	// Montgomery domain and bit-reversed order, as used by the forward NTT
+	// and the base multiplication. zetasInv holds the twiddle factors of
+	// the inverse NTT; its last entry is the final scaling factor.
+	zetas    []int16
+	zetasInv []int16
+	qInv     int32 // Q^-1 mod 2^16
+	barrett  int64 // round(2^26 / Q)
+	toMont   int16 // 2^32 mod Q
+
+	// fast is set for the standard ring, for which the unrolled
+	// implementations of the rest of the package are used.
+	fast bool
+}
+
+// KyberRing is the ring of every standard parameter set: N = 256, Q = 3329.
+var KyberRing = mustNewRing(paramsN, paramsQ)
+
+// NewRing builds the ring `Z_q[X]/(X^n + 1)` and generates the twiddle
+// factors of its NTT. The degree n must be a power of two between 8 and 256
+// and q a prime with q = 1 mod n, so that the ring has a primitive n-th root
+// of unity. Coefficients are serialized on 12 bits and kept in 16-bit
+// integers, which bounds q: the NTT is computed without intermediate
+// reductions, so q times the number of its layers must fit in an int16.
+//
+// The smallest root of unity ζ with ζ^(n/2) = -1 is used; for the standard
+// ring this is 17, as in the Kyber specification.
+func NewRing(n, q int) (*Ring, error) {
+	if n < 8 || n > paramsN || n&(n-1) != 0 {
+		return nil, errors.New("ring degree must be a power of two between 8 and 256")
+	}
+	if q >= 1<<12 || !isPrime(q) || q%n != 1 {
+		return nil, errors.New("ring modulus must be a prime below 4096 equal to 1 modulo the degree")
+	}
+	layers := 0
+	for l := n / 2; l >= 2; l >>= 1 {
+		layers++
+	}
+	if (layers+1)*q >= 1<<15 {
+		return nil, errors.New("ring modulus too large for the degree")
+	}
+
+	zeta := 2
+	for ; zeta < q; zeta++ {
+		if powMod(zeta, n/2, q) == q-1 {
+			break
+		}
+	}
+
+	r := &Ring{
+		N:       n,
+		Q:       q,
+		qInv:    int32(inverseMod(q, 1<<16)),
+		barrett: int64(((1 << 26) + q/2) / q),
+		toMont:  int16((1 << 32) % q),
+		fast:    n == paramsN && q == paramsQ,
+	}
+
+	// tmp[i] = R * ζ^i mod q, with R = 2^16 the Montgomery factor.
+	montgomery := (1 << 16) % q
+	tmp := make([]int, n/2)
+	for i := range tmp {
+		tmp[i] = montgomery * powMod(zeta, i, q) % q
+	}
+	bits := layers
+	r.zetas = make([]int16, n/2)
+	for i := range r.zetas {
+		r.zetas[i] = int16(tmp[bitReverse(i, bits)])
+	}
+	r.zetasInv = make([]int16, 0, n/2)
+	for i := n / 4; i >= 1; i >>= 1 {
+		for j := i; j < 2*i; j++ {
+			// -R * ζ^(n/2 - br(j)) = R * ζ^-br(j)
+			r.zetasInv = append(r.zetasInv, int16((q-tmp[n/2-bitReverse(j, bits)])%q))
+		}
+	}
+	// The final scaling by R^2 / (n/2) leaves the output of the inverse NTT
+	// multiplied by R, like the standard implementation.
+	r.zetasInv = append(r.zetasInv, int16(montgomery*montgomery%q*inverseMod(n/2, q)%q))
+	return r, nil
+}
+
+func mustNewRing(n, q int) *Ring {
+	r, err := NewRing(n, q)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// PolyBytes returns the byte length of a serialized polynomial.
+func (r *Ring) PolyBytes() int {
+	return 12 * r.N / 8
+}
+
+// MessageBytes returns the byte length of the messages encrypted by the
+// IND-CPA scheme: one bit per coefficient.
+func (r *Ring) MessageBytes() int {
+	return r.N / 8
+}
+
+// NTT returns the number-theoretic transform of a polynomial with
+// coefficients in the normal domain, with coefficients in [0, Q). It is the
+// representation in which keys are serialized and matrix `A` is sampled.
+func (r *Ring) NTT(p Polynomial) Polynomial {
+	return r.polyCSubQ(r.polyReduce(r.ntt(p)))
+}
+
+// InvNTT is the exact inverse of NTT: it returns the polynomial, with
+// coefficients in [0, Q), whose transform is p.
+func (r *Ring) InvNTT(p Polynomial) Polynomial {
+	p = r.invNttToMont(p)
+	for i := 0; i < r.N; i++ {
+		p[i] = r.montgomeryReduce(int32(p[i]))
+	}
+	return r.polyCSubQ(r.polyReduce(p))
+}
+
+// MulNTT returns the product of two polynomials given by their transforms,
+// as a transform with coefficients in [0, Q). The coefficients of a and b
+// must be in [0, Q).
+func (r *Ring) MulNTT(a, b Polynomial) Polynomial {
+	return r.polyCSubQ(r.polyReduce(r.polyToMont(r.polyBaseMulMontgomery(a, b))))
+}
+
+// Encode serializes the coefficients of a polynomial, which must be in
+// [0, Q), on 12 bits each, as keys are serialized.
+func (r *Ring) Encode(p Polynomial) []byte {
+	return r.polyToBytes(p)
+}
+
+// Decode is the inverse of Encode. inputBytes must be PolyBytes long.
+func (r *Ring) Decode(inputBytes []byte) Polynomial {
+	return r.polyFromBytes(inputBytes)
+}
+
+// Compress rounds every coefficient of a polynomial, in [0, Q), to d bits
+// and packs them, as ciphertexts are compressed. d must be between 1 and 11.
+func (r *Ring) Compress(p Polynomial, d int) []byte {
+	return r.polyCompress(p, d)
+}
+
+// Decompress is the approximate inverse of Compress. inputBytes must be
+// d*N/8 bytes long.
+func (r *Ring) Decompress(inputBytes []byte, d int) Polynomial {
+	return r.polyDecompress(inputBytes, d)
+}
+
+// SampleUniform samples a polynomial with coefficients uniform in [0, Q) by
+// rejection from an XOF stream, as the entries of matrix `A` are sampled.
+func (r *Ring) SampleUniform(xof io.Reader) (Polynomial, error) {
+	var p Polynomial
+	buffer := make([]byte, 168)
+	for ctr := 0; ctr < r.N; {
+		if _, err := io.ReadFull(xof, buffer); err != nil {
+			return Polynomial{}, err
+		}
+		ctr += r.rejUniform(p[ctr:r.N], buffer)
+	}
+	return p, nil
+}
+
+// SampleCBD samples a polynomial with coefficients drawn from the centered
+// binomial distribution of parameter eta, in [-eta, eta], from the PRF of
+// sym keyed with seed, as the noise of the scheme is sampled.
+func (r *Ring) SampleCBD(sym Symmetric, seed []byte, nonce byte, eta int) Polynomial {
+	return r.polyGetNoise(sym, seed, nonce, eta)
+}
+
+func (r *Ring) montgomeryReduce(a int32) int16 {
+	u := a * r.qInv
+	t := a - (u&0xFFFF)*int32(r.Q)
+	return int16(t >> 16)
+}
+
+func (r *Ring) fqmul(a, b int16) int16 {
+	return r.montgomeryReduce(int32(a) * int32(b))
+}
+
+func (r *Ring) barrettReduce(a int16) int16 {
+	t := int16((r.barrett * int64(a)) >> 26)
+	return a - t*int16(r.Q)
+}
+
+func (r *Ring) csubq(a int16) int16 {
+	a = a - int16(r.Q)
+	return a + ((a >> 15) & int16(r.Q))
+}
+
+func (r *Ring) ntt(p Polynomial) Polynomial {
+	if r.fast {
+		return Ntt(p)
+	}
+	k := 1
+	for l := r.N / 2; l >= 2; l >>= 1 {
+		for start := 0; start < r.N; start += 2 * l {
+			zeta := r.zetas[k]
+			k++
+			for j := start; j < start+l; j++ {
+				t := r.fqmul(zeta, p[j+l])
+				p[j+l] = p[j] - t
+				p[j] = p[j] + t
+			}
+		}
+	}
+	return p
+}
+
+func (r *Ring) invNttToMont(p Polynomial) Polynomial {
+	if r.fast {
+		return NttInv(p)
+	}
+	k := 0
+	for l := 2; l <= r.N/2; l <<= 1 {
+		for start := 0; start < r.N; start += 2 * l {
+			zeta := r.zetasInv[k]
+			k++
+			for j := start; j < start+l; j++ {
+				t := p[j]
+				p[j] = r.barrettReduce(t + p[j+l])
+				p[j+l] = t - p[j+l]
+				p[j+l] = r.fqmul(zeta, p[j+l])
+			}
+		}
+	}
+	f := r.zetasInv[len(r.zetasInv)-1]
+	for j := 0; j < r.N; j++ {
+		p[j] = r.fqmul(p[j], f)
+	}
+	return p
+}
+
+func (r *Ring) polyBaseMulMontgomery(a, b Polynomial) Polynomial {
+	if r.fast {
+		return PolyBaseMulMontgomery(a, b)
+	}
+	for i := 0; i < r.N/4; i++ {
+		zeta := r.zetas[r.N/4+i]
+		a[4*i+0], a[4*i+1] = r.fqmul(r.fqmul(a[4*i+1], b[4*i+1]), zeta)+r.fqmul(a[4*i+0], b[4*i+0]),
+			r.fqmul(a[4*i+0], b[4*i+1])+r.fqmul(a[4*i+1], b[4*i+0])
+		a[4*i+2], a[4*i+3] = r.fqmul(r.fqmul(a[4*i+3], b[4*i+3]), -zeta)+r.fqmul(a[4*i+2], b[4*i+2]),
+			r.fqmul(a[4*i+2], b[4*i+3])+r.fqmul(a[4*i+3], b[4*i+2])
+	}
+	return a
+}
+
+func (r *Ring) polyToMont(p Polynomial) Polynomial {
+	if r.fast {
+		return PolyToMont(p)
+	}
+	for i := 0; i < r.N; i++ {
+		p[i] = r.montgomeryReduce(int32(p[i]) * int32(r.toMont))
+	}
+	return p
+}
+
+func (r *Ring) polyReduce(p Polynomial) Polynomial {
+	if r.fast {
+		return PolyReduce(p)
+	}
+	for i := 0; i < r.N; i++ {
+		p[i] = r.barrettReduce(p[i])
+	}
+	return p
+}
+
+func (r *Ring) polyCSubQ(p Polynomial) Polynomial {
+	if r.fast {
+		return PolyCSubQ(p)
+	}
+	for i := 0; i < r.N; i++ {
+		p[i] = r.csubq(p[i])
+	}
+	return p
+}
+
+func (r *Ring) polyToBytes(p Polynomial) []byte {
+	if r.fast {
+		return PolyToBytes(p)
+	}
+	outputBytes := make([]byte, r.PolyBytes())
+	p = r.polyCSubQ(p)
+	for i := 0; i < r.N/2; i++ {
+		t0, t1 := uint16(p[2*i]), uint16(p[2*i+1])
+		outputBytes[3*i+0] = byte(t0)
+		outputBytes[3*i+1] = byte(t0>>8) | byte(t1<<4)
+		outputBytes[3*i+2] = byte(t1 >> 4)
+	}
+	return outputBytes
+}
+
+func (r *Ring) polyFromBytes(inputBytes []byte) Polynomial {
+	if r.fast {
+		return PolyFromBytes(inputBytes)
+	}
+	var resultPoly Polynomial
+	for i := 0; i < r.N/2; i++ {
+		resultPoly[2*i] = int16((uint16(inputBytes[3*i]) | uint16(inputBytes[3*i+1])<<8) & 0xFFF)
+		resultPoly[2*i+1] = int16((uint16(inputBytes[3*i+1])>>4 | uint16(inputBytes[3*i+2])<<4) & 0xFFF)
+	}
+	return resultPoly
+}
+
+func (r *Ring) polyFromMsg(msg []byte) Polynomial {
+	if r.fast {
+		return PolyFromMsg(msg)
+	}
+	var resultPoly Polynomial
+	for i := 0; i < r.N; i++ {
+		mask := -int16((msg[i/8] >> (i % 8)) & 1)
+		resultPoly[i] = mask & int16((r.Q+1)/2)
+	}
+	return resultPoly
+}
+
+func (r *Ring) polyToMsg(p Polynomial) []byte {
+	if r.fast {
+		return PolyToMsg(p)
+	}
+	msg := make([]byte, r.MessageBytes())
+	p = r.polyCSubQ(p)
+	for i := 0; i < r.N; i++ {
+		t := ((int(p[i]) << 1) + r.Q/2) / r.Q & 1
+		msg[i/8] |= byte(t << (i % 8))
+	}
+	return msg
+}
+
+func (r *Ring) polyCompress(p Polynomial, d int) []byte {
+	if r.fast {
+		return polyCompress(p, d)
+	}
+	p = r.polyCSubQ(p)
+	return compressBits(p[:r.N], r.Q, d)
+}
+
+func (r *Ring) polyDecompress(inputBytes []byte, d int) Polynomial {
+	if r.fast {
+		return polyDecompress(inputBytes, d)
+	}
+	var resultPoly Polynomial
+	decompressBits(resultPoly[:r.N], inputBytes, r.Q, d)
+	return resultPoly
+}
+
+func (r *Ring) polyGetNoise(sym Symmetric, seed []byte, nonce byte, eta int) Polynomial {
+	if r.fast {
+		return polyGetNoise(sym, seed, nonce, eta)
+	}
+	var resultPoly Polynomial
+	cbdBits(resultPoly[:r.N], sym.Prf(eta*r.N/4, seed, nonce), eta)
+	return resultPoly
+}
+
+// rejUniform parses 12-bit values from inputBytes and keeps those below Q,
+// like IndcpaRejUniform.
+func (r *Ring) rejUniform(coefficients []int16, inputBytes []byte) int {
+	ctr := 0
+	for pos := 0; ctr < len(coefficients) && pos+3 <= len(inputBytes); pos += 3 {
+		val0 := uint16(inputBytes[pos]) | uint16(inputBytes[pos+1])<<8&0xFFF
+		val1 := uint16(inputBytes[pos+1])>>4 | uint16(inputBytes[pos+2])<<4
+		if val0 < uint16(r.Q) {
+			coefficients[ctr] = int16(val0)
+			ctr++
+		}
+		if ctr < len(coefficients) && val1 < uint16(r.Q) {
+			coefficients[ctr] = int16(val1)
+			ctr++
+		}
+	}
+	return ctr
+}
+
+func (r *Ring) genMatrix(sym Symmetric, seed []byte, transposed bool, kVariant int) ([]PolynomialVector, error) {
+	if r.fast {
+		return indcpaGenMatrix(sym, seed, transposed, kVariant)
+	}
+	resultMatrix := make([]PolynomialVector, kVariant)
+	buffer := make([]byte, 168)
+	for i := 0; i < kVariant; i++ {
+		resultMatrix[i] = PolyvecNew(kVariant)
+		for j := 0; j < kVariant; j++ {
+			var xof io.Reader
+			if transposed {
+				xof = sym.Xof(seed, byte(i), byte(j))
+			} else {
+				xof = sym.Xof(seed, byte(j), byte(i))
+			}
+			for ctr := 0; ctr < r.N; {
+				if _, err := io.ReadFull(xof, buffer); err != nil {
+					return []PolynomialVector{}, err
+				}
+				ctr += r.rejUniform(resultMatrix[i][j][ctr:r.N], buffer)
+			}
+		}
+	}
+	return resultMatrix, nil
+}
+
+func (r *Ring) polyvecNtt(v PolynomialVector) {
+	for i := range v {
+		v[i] = r.ntt(v[i])
+	}
+}
+
+func (r *Ring) polyvecInvNttToMont(v PolynomialVector) {
+	for i := range v {
+		v[i] = r.invNttToMont(v[i])
+	}
+}
+
+func (r *Ring) polyvecReduce(v PolynomialVector) {
+	for i := range v {
+		v[i] = r.polyReduce(v[i])
+	}
+}
+
+// polyvecPointWiseAccMontgomery is PolyvecPointWiseAccMontgomery for any
+// ring and rank. Beyond rank 4 the accumulator is reduced as it goes, since
+// the sum of the unreduced products would overflow 16 bits.
+func (r *Ring) polyvecPointWiseAccMontgomery(a, b PolynomialVector) Polynomial {
+	if r.fast && len(a) <= 4 {
+		return PolyvecPointWiseAccMontgomery(a, b, len(a))
+	}
+	resultPoly := r.polyBaseMulMontgomery(a[0], b[0])
+	for i := 1; i < len(a); i++ {
+		resultPoly = PolyAdd(resultPoly, r.polyBaseMulMontgomery(a[i], b[i]))
+		if i%4 == 3 {
+			resultPoly = r.polyReduce(resultPoly)
+		}
+	}
+	return r.polyReduce(resultPoly)
+}
+
+func (r *Ring) polyvecToBytes(v PolynomialVector) []byte {
+	resultBytes := make([]byte, 0, len(v)*r.PolyBytes())
+	for i := range v {
+		resultBytes = append(resultBytes, r.polyToBytes(v[i])...)
+	}
+	return resultBytes
+}
+
+func (r *Ring) polyvecFromBytes(inputBytes []byte, kVariant int) PolynomialVector {
+	resultPolyVec := PolyvecNew(kVariant)
+	for i := range resultPolyVec {
+		resultPolyVec[i] = r.polyFromBytes(inputBytes[i*r.PolyBytes():])
+	}
+	return resultPolyVec
+}
+
+func (r *Ring) polyvecCompress(v PolynomialVector, d int) []byte {
+	if r.fast {
+		return polyvecCompress(v, len(v), d)
+	}
+	resultBytes := make([]byte, 0, len(v)*d*r.N/8)
+	for i := range v {
+		resultBytes = append(resultBytes, r.polyCompress(v[i], d)...)
+	}
+	return resultBytes
+}
+
+func (r *Ring) polyvecDecompress(inputBytes []byte, kVariant int, d int) PolynomialVector {
+	if r.fast {
+		return polyvecDecompress(inputBytes, kVariant, d)
+	}
+	resultPolyVec := PolyvecNew(kVariant)
+	for i := range resultPolyVec {
+		resultPolyVec[i] = r.polyDecompress(inputBytes[i*d*r.N/8:], d)
+	}
+	return resultPolyVec
+}
+
+func isPrime(q int) bool {
+	if q < 2 {
+		return false
+	}
+	for f := 2; f*f <= q; f++ {
+		if q%f == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func powMod(base, exponent, modulus int) int {
+	result := 1
+	base %= modulus
+	for ; exponent > 0; exponent >>= 1 {
+		if exponent&1 == 1 {
+			result = result * base % modulus
+		}
+		base = base * base % modulus
+	}
+	return result
+}
+
+// inverseMod returns the inverse of a modulo m, for a and m coprime.
+func inverseMod(a, m int) int {
+	t, newT := 0, 1
+	r, newR := m, a%m
+	for newR != 0 {
+		quotient := r / newR
+		t, newT = newT, t-quotient*newT
+		r, newR = newR, r-quotient*newR
+	}
+	if t < 0 {
+		t += m
+	}
+	return t
+}
+
+func bitReverse(x, bits int) int {
+	y := 0
+	for i := 0; i < bits; i++ {
+		y = y<<1 | (x>>i)&1
+	}
+	return y
+}
@@ -0,0 +1,190 @@
+package gokyber
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// standardZetas and standardZetasInv are the twiddle factors of the
+// reference implementation, which NewRing must reproduce for KyberRing.
+var standardZetas = [128]int16{
+	2285, 2571, 2970, 1812, 1493, 1422, 287, 202, 3158, 622, 1577, 182, 962,
+	2127, 1855, 1468, 573, 2004, 264, 383, 2500, 1458, 1727, 3199, 2648, 1017,
+	732, 608, 1787, 411, 3124, 1758, 1223, 652, 2777, 1015, 2036, 1491, 3047,
+	1785, 516, 3321, 3009, 2663, 1711, 2167, 126, 1469, 2476, 3239, 3058, 830,
+	107, 1908, 3082, 2378, 2931, 961, 1821, 2604, 448, 2264, 677, 2054, 2226,
+	430, 555, 843, 2078, 871, 1550, 105, 422, 587, 177, 3094, 3038, 2869, 1574,
+	1653, 3083, 778, 1159, 3182, 2552, 1483, 2727, 1119, 1739, 644, 2457, 349,
+	418, 329, 3173, 3254, 817, 1097, 603, 610, 1322, 2044, 1864, 384, 2114, 3193,
+	1218, 1994, 2455, 220, 2142, 1670, 2144, 1799, 2051, 794, 1819, 2475, 2459,
+	478, 3221, 3021, 996, 991, 958, 1869, 1522, 1628,
+}
+
+var standardZetasInv = [128]int16{
+	1701, 1807, 1460, 2371, 2338, 2333, 308, 108, 2851, 870, 854, 1510, 2535,
+	1278, 1530, 1185, 1659, 1187, 3109, 874, 1335, 2111, 136, 1215, 2945, 1465,
+	1285, 2007, 2719, 2726, 2232, 2512, 75, 156, 3000, 2911, 2980, 872, 2685,
+	1590, 2210, 602, 1846, 777, 147, 2170, 2551, 246, 1676, 1755, 460, 291, 235,
+	3152, 2742, 2907, 3224, 1779, 2458, 1251, 2486, 2774, 2899, 1103, 1275, 2652,
+	1065, 2881, 725, 1508, 2368, 398, 951, 247, 1421, 3222, 2499, 271, 90, 853,
+	1860, 3203, 1162, 1618, 666, 320, 8, 2813, 1544, 282, 1838, 1293, 2314, 552,
+	2677, 2106, 1571, 205, 2918, 1542, 2721, 2597, 2312, 681, 130, 1602, 1871,
+	829, 2946, 3065, 1325, 2756, 1861, 1474, 1202, 2367, 3147, 1752, 2707, 171,
+	3127, 3042, 1907, 1836, 1517, 359, 758, 1441,
+}
+
+func TestKyberRingTables(t *testing.T) {
+	if nttZetas != standardZetas {
+		t.Error("generated NTT twiddle factors differ from the reference tables")
+	}
+	if nttZetasInv != standardZetasInv {
+		t.Error("generated inverse NTT twiddle factors differ from the reference tables")
+	}
+}
+
+func TestNewRingRejectsInvalidParameters(t *testing.T) {
+	for _, v := range []struct{ n, q int }{
+		{24, 97},    // not a power of two
+		{4, 17},     // too small
+		{512, 7681}, // too large
+		{32, 96},    // not prime
+		{32, 101},   // not 1 mod n
+		{16, 4129},  // above 12 bits
+	} {
+		if _, err := NewRing(v.n, v.q); err == nil {
+			t.Errorf("NewRing(%d, %d) succeeded", v.n, v.q)
+		}
+	}
+}
+
+func randomPolynomial(rng *rand.Rand, r *Ring) Polynomial {
+	var p Polynomial
+	for i := 0; i < r.N; i++ {
+		p[i] = int16(rng.Intn(r.Q))
+	}
+	return p
+}
+
+// TestRingGenericMatchesFastPaths runs the generic ring code on the
+// standard ring and checks it against the unrolled implementations.
+func TestRingGenericMatchesFastPaths(t *testing.T) {
+	generic := *KyberRing
+	generic.fast = false
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 20; i++ {
+		a, b := randomPolynomial(rng, KyberRing), randomPolynomial(rng, KyberRing)
+		if generic.ntt(a) != Ntt(a) {
+			t.Fatal("NTT differs")
+		}
+		if generic.invNttToMont(a) != NttInv(a) {
+			t.Fatal("inverse NTT differs")
+		}
+		if generic.polyBaseMulMontgomery(a, b) != PolyBaseMulMontgomery(a, b) {
+			t.Fatal("base multiplication differs")
+		}
+		if generic.polyToMont(a) != PolyToMont(a) {
+			t.Fatal("conversion to the Montgomery domain differs")
+		}
+		if !bytes.Equal(generic.polyToBytes(a), PolyToBytes(a)) {
+			t.Fatal("serialization differs")
+		}
+		if generic.polyFromBytes(PolyToBytes(a)) != PolyFromBytes(PolyToBytes(a)) {
+			t.Fatal("deserialization differs")
+		}
+		if !bytes.Equal(generic.polyToMsg(a), PolyToMsg(a)) {
+			t.Fatal("message decoding differs")
+		}
+		msg := PolyToMsg(a)
+		if generic.polyFromMsg(msg) != PolyFromMsg(msg) {
+			t.Fatal("message encoding differs")
+		}
+	}
+
+	// The whole KEM computed with the generic ring matches the vectors.
+	params := *Kyber768
+	params.Ring = &generic
+	seed := make([]byte, 3*paramsSymBytes)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	privateKey, publicKey, err := params.kemKeypairDerand(seed[:32], seed[32:64])
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPrivateKey, wantPublicKey, _ := Kyber768.kemKeypairDerand(seed[:32], seed[32:64])
+	if !bytes.Equal(privateKey, wantPrivateKey) || !bytes.Equal(publicKey, wantPublicKey) {
+		t.Fatal("key pairs differ")
+	}
+	ciphertext, sharedSecret, err := params.kemEncryptDerand(publicKey, seed[64:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCiphertext, wantSharedSecret, _ := Kyber768.kemEncryptDerand(publicKey, seed[64:])
+	if !bytes.Equal(ciphertext, wantCiphertext) || !bytes.Equal(sharedSecret, wantSharedSecret) {
+		t.Fatal("encapsulations differ")
+	}
+}
+
+// TestToyRingMultiplication checks NTT-based multiplication against the
+// schoolbook negacyclic product.
+func TestToyRingMultiplication(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, v := range []struct{ n, q int }{{8, 17}, {16, 97}, {32, 257}, {64, 193}, {128, 3329}} {
+		r, err := NewRing(v.n, v.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, b := randomPolynomial(rng, r), randomPolynomial(rng, r)
+
+		var want Polynomial
+		for i := 0; i < r.N; i++ {
+			for j := 0; j < r.N; j++ {
+				product := int(a[i]) * int(b[j])
+				if i+j < r.N {
+					want[i+j] = int16((int(want[i+j]) + product) % r.Q)
+				} else {
+					want[i+j-r.N] = int16(((int(want[i+j-r.N])-product)%r.Q + r.Q) % r.Q)
+				}
+			}
+		}
+
+		got := r.invNttToMont(r.polyBaseMulMontgomery(r.ntt(a), r.ntt(b)))
+		got = r.polyCSubQ(r.polyReduce(got))
+		if got != want {
+			t.Errorf("n = %d, q = %d: product differs", v.n, v.q)
+		}
+		if r.InvNTT(r.NTT(a)) != a {
+			t.Errorf("n = %d, q = %d: InvNTT is not the inverse of NTT", v.n, v.q)
+		}
+	}
+}
+
+func TestToyRingKemRoundTrip(t *testing.T) {
+	for _, v := range []struct{ n, q, k, eta int }{{16, 97, 1, 1}, {32, 257, 2, 1}, {64, 769, 2, 2}} {
+		r, err := NewRing(v.n, v.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		params := &ParameterSet{Name: "toy", K: v.k, Eta1: v.eta, Eta2: v.eta, Du: 10, Dv: 10, Symmetric: SymmetricSHAKE, Ring: r}
+		privateKey, publicKey, err := params.KemKeypair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertext, sharedSecret, err := params.KemEncrypt(publicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decrypted, err := params.KemDecrypt(ciphertext, privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decrypted, sharedSecret) {
+			t.Errorf("n = %d, q = %d: shared secrets do not match", v.n, v.q)
+		}
+		if len(publicKey) != params.PublicKeyBytes() || len(ciphertext) != params.CiphertextBytes() {
+			t.Errorf("n = %d, q = %d: unexpected sizes", v.n, v.q)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package gokyber
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Sealed messages are the KEM-DEM combination of a KEM and AES-256-GCM, for
+// encrypting a message to a public key without writing any glue code. Their
+// wire format is
+//
+//	version (1 byte) || algorithm (2 bytes) || KEM ciphertext || nonce (12 bytes) || AES-256-GCM ciphertext
+//
+// The AES key is derived from the shared secret with HKDF-SHA256, taking
+// the version and algorithm as info, and the AES-256-GCM additional data is
+// everything before the AES-256-GCM ciphertext followed by the caller's
+// additional data. Changing any byte of a sealed message makes Open fail.
+const (
+	sealVersion     = 1
+	sealHeaderBytes = 3
+	sealNonceBytes  = 12
+	sealKeyBytes    = 32
+	sealInfo        = "goKyber seal"
+)
+
+// Seal encrypts and authenticates a message to a public key of the parameter
+// set, and authenticates additional data that Open must be given again. It
+// is supported by the parameter sets with an AlgorithmID only.
+func (p *ParameterSet) Seal(publicKey, plaintext, aad []byte) ([]byte, error) {
+	return SealKEM(p, publicKey, plaintext, aad)
+}
+
+// Open decrypts a message sealed with Seal to the public key matching the
+// private key, checking the additional data.
+func (p *ParameterSet) Open(privateKey, sealed, aad []byte) ([]byte, error) {
+	return OpenKEM(p, privateKey, sealed, aad)
+}
+
+// SealKEM is Seal for any KEM with an AlgorithmID.
+func SealKEM(kem KEM, publicKey, plaintext, aad []byte) ([]byte, error) {
+	algorithm, err := AlgorithmID(kem)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, sharedSecret, err := kem.KemEncrypt(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	header := binary.BigEndian.AppendUint16([]byte{sealVersion}, algorithm)
+	aead, err := sealCipher(sharedSecret, header)
+	if err != nil {
+		return nil, err
+	}
+	sealed := append(header, ciphertext...)
+	nonce := make([]byte, sealNonceBytes)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed = append(sealed, nonce...)
+	return aead.Seal(sealed, nonce, plaintext, append(sealed[:len(sealed):len(sealed)], aad...)), nil
+}
+
+// OpenKEM is Open for any KEM accepted by SealKEM.
+func OpenKEM(kem KEM, privateKey, sealed, aad []byte) ([]byte, error) {
+	algorithm, err := AlgorithmID(kem)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < sealHeaderBytes {
+		return nil, errors.New("sealed message too short")
+	}
+	if sealed[0] != sealVersion {
+		return nil, errors.New("unsupported sealed message version")
+	}
+	if binary.BigEndian.Uint16(sealed[1:sealHeaderBytes]) != algorithm {
+		return nil, errors.New("sealed message is for another algorithm")
+	}
+	bodyStart := sealHeaderBytes + kem.CiphertextBytes() + sealNonceBytes
+	if len(sealed) < bodyStart {
+		return nil, errors.New("sealed message too short")
+	}
+	ciphertext := sealed[sealHeaderBytes : sealHeaderBytes+kem.CiphertextBytes()]
+	nonce := sealed[sealHeaderBytes+kem.CiphertextBytes() : bodyStart]
+	sharedSecret, err := kem.KemDecrypt(ciphertext, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := sealCipher(sharedSecret, sealed[:sealHeaderBytes])
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nonce, sealed[bodyStart:], append(sealed[:bodyStart:bodyStart], aad...))
+	if err != nil {
+		return nil, errors.New("sealed message authentication failed")
+	}
+	return plaintext, nil
+}
+
+// sealCipher returns AES-256-GCM keyed with HKDF-SHA256 of the shared
+// secret, bound to the header.
+func sealCipher(sharedSecret, header []byte) (cipher.AEAD, error) {
+	key := make([]byte, sealKeyBytes)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecret, nil, append([]byte(sealInfo), header...)), key); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
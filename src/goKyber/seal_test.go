@@ -0,0 +1,124 @@
+package gokyber
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSealRoundTrip(t *testing.T) {
+	kems := []KEM{Kyber512, Kyber768, Kyber1024, Kyber768_90s, MLKEM768, XWing, MLKEM768P256, Kyber768.WithTracer(nil)}
+	for _, kem := range kems {
+		privateKey, publicKey, err := kem.KemKeypair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, plaintext := range [][]byte{nil, []byte("attack at dawn"), bytes.Repeat([]byte{0xaa}, 10000)} {
+			sealed, err := SealKEM(kem, publicKey, plaintext, []byte("aad"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := sealHeaderBytes + kem.CiphertextBytes() + sealNonceBytes + len(plaintext) + 16; len(sealed) != want {
+				t.Errorf("sealed length %d, want %d", len(sealed), want)
+			}
+			opened, err := OpenKEM(kem, privateKey, sealed, []byte("aad"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(opened, plaintext) {
+				t.Error("opened message differs")
+			}
+		}
+	}
+}
+
+func TestSealTampering(t *testing.T) {
+	p := Kyber768
+	privateKey, publicKey, err := p.KemKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	aad := []byte("header")
+	sealed, err := p.Seal(publicKey, []byte("attack at dawn"), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Open(privateKey, sealed, aad); err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertextStart := sealHeaderBytes
+	nonceStart := ciphertextStart + p.CiphertextBytes()
+	bodyStart := nonceStart + sealNonceBytes
+	for _, field := range []struct {
+		name   string
+		offset int
+	}{
+		{"version", 0},
+		{"algorithm", 2},
+		{"KEM ciphertext", ciphertextStart},
+		{"KEM ciphertext end", nonceStart - 1},
+		{"nonce", nonceStart},
+		{"AEAD ciphertext", bodyStart},
+		{"tag", len(sealed) - 1},
+	} {
+		modified := bytes.Clone(sealed)
+		modified[field.offset] ^= 1
+		if _, err := p.Open(privateKey, modified, aad); err == nil {
+			t.Errorf("modified %s accepted", field.name)
+		}
+	}
+	if _, err := p.Open(privateKey, sealed, []byte("Header")); err == nil {
+		t.Error("modified additional data accepted")
+	}
+	for _, n := range []int{0, 2, bodyStart, len(sealed) - 1} {
+		if _, err := p.Open(privateKey, sealed[:n], aad); err == nil {
+			t.Errorf("message truncated to %d bytes accepted", n)
+		}
+	}
+	if _, err := p.Open(privateKey, append(bytes.Clone(sealed), 0), aad); err == nil {
+		t.Error("extended message accepted")
+	}
+	// Kyber768 and ML-KEM-768 keys have the same sizes; the algorithm
+	// identifier keeps them apart.
+	if _, err := MLKEM768.Open(privateKey, sealed, aad); err == nil {
+		t.Error("message opened with another algorithm")
+	}
+}
+
+func TestSealUnknownKEM(t *testing.T) {
+	toy := &ParameterSet{Name: "toy", K: 2, Eta1: 2, Eta2: 2, Du: 10, Dv: 4, Symmetric: SymmetricSHAKE}
+	_, publicKey, err := toy.KemKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := toy.Seal(publicKey, nil, nil); err == nil {
+		t.Error("sealed with a parameter set without identifier")
+	}
+}
+
+func TestAlgorithmID(t *testing.T) {
+	for _, kem := range []KEM{Kyber512, Kyber768_90s, MLKEM1024, XWing, MLKEM768P256, MLKEM1024P384} {
+		id, err := AlgorithmID(kem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := KEMForAlgorithmID(id); err != nil || got != kem {
+			t.Errorf("identifier %#04x maps back to %v, %v", id, got, err)
+		}
+	}
+	if id, err := AlgorithmID(Kyber768.WithTracer(nil)); err != nil || id != algorithmIDs[Kyber768.Name] {
+		t.Error("traced parameter set has another identifier")
+	}
+	// A custom set has no identifier, even under a predefined name.
+	custom := &ParameterSet{Name: "Kyber768", K: 2, Eta1: 3, Eta2: 2, Du: 10, Dv: 4, Symmetric: SymmetricSHAKE, FIPS203: true}
+	if id, err := AlgorithmID(custom); err != nil || id != algorithmIDs[MLKEM512.Name] {
+		t.Error("copy of ML-KEM-512 does not have its identifier")
+	}
+	custom.Du = 11
+	if _, err := AlgorithmID(custom); err == nil {
+		t.Error("custom parameter set named Kyber768 has an identifier")
+	}
+	if _, err := KEMForAlgorithmID(0xffff); err == nil {
+		t.Error("unknown identifier accepted")
+	}
+}
@@ -0,0 +1,123 @@
+package gokyber
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/sha512"
+	"io"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Symmetric is the set of symmetric primitives Kyber is instantiated with.
+// The IND-CPA and KEM layers only ever call hash functions, the PRF and the
+// XOF through this interface, so that the same lattice code can run on top
+// of SHAKE (standard Kyber) or AES and SHA-2 (Kyber-90s).
+type Symmetric interface {
+	// Hash256 is the hash function `H`, used on public keys, messages and ciphertexts.
+	Hash256(input []byte) [32]byte
+	// Hash512 is the hash function `G`, used to derive seeds and coins.
+	Hash512(input []byte) [64]byte
+	// Prf returns `outputLength` pseudo-random bytes derived from a 32-byte
+	// key and a one-byte nonce; it feeds the noise sampling.
+	Prf(outputLength int, key []byte, nonce byte) []byte
+	// Xof returns the extendable output stream used to sample the matrix
+	// entry indexed by `x` and `y` from the public seed.
+	Xof(seed []byte, x byte, y byte) io.Reader
+	// Kdf derives the final shared secret.
+	Kdf(input []byte) [32]byte
+}
+
+// SymmetricSHAKE instantiates Kyber with SHA3-256, SHA3-512, SHAKE-128 and
+// SHAKE-256, as in the standard parameter sets.
+var SymmetricSHAKE Symmetric = shakeSymmetric{}
+
+// Symmetric90s instantiates Kyber with SHA-256, SHA-512 and AES-256 in
+// counter mode, as in the Kyber-90s parameter sets. It is meant for hardware
+// with AES and SHA-2 acceleration.
+var Symmetric90s Symmetric = aesSymmetric{}
+
+type shakeSymmetric struct{}
+
+func (shakeSymmetric) Hash256(input []byte) [32]byte {
+	return sha3.Sum256(input)
+}
+
+func (shakeSymmetric) Hash512(input []byte) [64]byte {
+	return sha3.Sum512(input)
+}
+
+func (shakeSymmetric) Prf(outputLength int, key []byte, nonce byte) []byte {
+	hash := make([]byte, outputLength)
+	keyNonce := make([]byte, len(key)+1)
+	copy(keyNonce, key)
+	keyNonce[len(key)] = nonce
+	sha3.ShakeSum256(hash, keyNonce)
+	return hash
+}
+
+func (shakeSymmetric) Xof(seed []byte, x byte, y byte) io.Reader {
+	xof := sha3.NewShake128()
+	xof.Write(seed)
+	xof.Write([]byte{x, y})
+	return xof
+}
+
+func (shakeSymmetric) Kdf(input []byte) [32]byte {
+	var sharedSecret [32]byte
+	sha3.ShakeSum256(sharedSecret[:], input)
+	return sharedSecret
+}
+
+type aesSymmetric struct{}
+
+func (aesSymmetric) Hash256(input []byte) [32]byte {
+	return sha256.Sum256(input)
+}
+
+func (aesSymmetric) Hash512(input []byte) [64]byte {
+	return sha512.Sum512(input)
+}
+
+// Prf runs AES-256-CTR keyed with `key`, with the nonce in the first byte of
+// the 96-bit IV and a 32-bit big-endian block counter starting at zero.
+func (aesSymmetric) Prf(outputLength int, key []byte, nonce byte) []byte {
+	output := make([]byte, outputLength)
+	aes256Ctr(key, []byte{nonce}).XORKeyStream(output, output)
+	return output
+}
+
+// Xof runs AES-256-CTR keyed with the seed, with `x` and `y` in the first two
+// bytes of the IV.
+func (aesSymmetric) Xof(seed []byte, x byte, y byte) io.Reader {
+	return aesCtrReader{aes256Ctr(seed, []byte{x, y})}
+}
+
+func (aesSymmetric) Kdf(input []byte) [32]byte {
+	return sha256.Sum256(input)
+}
+
+// aes256Ctr returns an AES-256-CTR key stream whose IV starts with `nonce`
+// and is zero elsewhere.
+func aes256Ctr(key []byte, nonce []byte) cipher.Stream {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		// Keys are always 32 bytes long: they are seeds or hash outputs.
+		panic("gokyber: invalid AES-256 key length")
+	}
+	iv := make([]byte, aes.BlockSize)
+	copy(iv, nonce)
+	return cipher.NewCTR(block, iv)
+}
+
+// aesCtrReader exposes an AES-CTR key stream as an io.Reader.
+type aesCtrReader struct {
+	stream cipher.Stream
+}
+
+func (r aesCtrReader) Read(p []byte) (int, error) {
+	clear(p)
+	r.stream.XORKeyStream(p, p)
+	return len(p), nil
+}
@@ -0,0 +1,167 @@
+package gokyber
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// symmetricVectors are known-answer vectors for both instantiations. The
+// seed of the key pair is 0x00..0x1f, `z` is 0x20..0x3f and the encapsulation
+// randomness is 0x40..0x5f; keys and ciphertexts are given as their SHA3-256
+// digests to keep the table short.
+var symmetricVectors = []struct {
+	params       *ParameterSet
+	publicKey    string
+	privateKey   string
+	ciphertext   string
+	sharedSecret string
+}{
+	{
+		Kyber512,
+		"b0448d37ecbcf2918a73bcc1b3d8174e322df9863ce92f5ea0f6bd6bca7da05e",
+		"fce230daa8dd4f465ddcc2b8dc864d0c604983f97447e91524dde80ac913f522",
+		"961cdaa90d7f63ad4f72c696fc5e2d8d0abbf27bda1a3c6609d0789e1dfb3877",
+		"484c65aa18a6955f7a9f70137c882fcdbf0bd732d15ccf204a250bd17bf3fc4f",
+	},
+	{
+		Kyber768,
+		"0df97a95f3e9fcaa29e04ffc0b246f17b50a19e74c90f88dadc41837dddcb6d1",
+		"157699f1afdbe4acff52ab00e6ef075bb6c267280d4c1e24fd907b43c9bf0ce5",
+		"3950acf029976ea4c229215284b32b6f4c3d75faea76c53912ce38ef59569604",
+		"7973130dd759b854824a18a0e046afd26cdd02ec874734200bc98d387965de7c",
+	},
+	{
+		Kyber1024,
+		"8db2bf117dfa02aaa1994384bbf77a1054cc601b1d3c6fb6b215823f55e6e7bf",
+		"ff10fb5eaff0ddf9bb5eaf2db484da4b33f918f025883ba98295372f2f54a18d",
+		"03b3120cada88f7882ae7fd1ee1383131765cc14cede25293bf2384d4e200ead",
+		"66cd15c09e372fe64522aea8c8086844999ce7f16565b4a043680bf0bc95083b",
+	},
+	{
+		Kyber512_90s,
+		"4f48bf478c07b48e493cc16e6e10cb85e5dd5c62e9ec1caf79d586b4ce793d0e",
+		"7d4ddb79b498a5c2dbc4e2703a4f55c5a9ff30b0be1e91e5e393a8986dc6fa08",
+		"6441b7ea9e15c7b4ba3841b6bc500aa3d5366541c490083e10081e82e1193405",
+		"a66a40f483dfd75e2331d0106ccc4877bcede7cb42560a9f4f836b8cf4f6722c",
+	},
+	{
+		Kyber768_90s,
+		"eded7de96ea95b6c625bdf1ab4c1e6cdcdce86c8648f09864422c3328d0d390d",
+		"0988a4d3001adf1d5c2ce74d90cd73cff5cd5cc8ae85482c1fce8c2787729a3a",
+		"5ec8b08ad39e259db7098fd69b32f0f19e67cd4229eb3c090e1c53a2a494bed3",
+		"ed5e28b65b0ba5c4d18f05f4dce888b91f2ff3684c8f4b2f095e4ee6ffeb8fcd",
+	},
+	{
+		Kyber1024_90s,
+		"f2a0f0750a6287da78399d673d9ea71a8bde230f20d1502ac95eae89a50b02ae",
+		"ec2f4c4d058335a42526a808b88c64b9f8ecc29ceb1421df2e0bf01cfb370037",
+		"2e48abfa0e353ff524d49335f86fd77552c620ba6941d7fc18cdf3df4c65201e",
+		"507b6137378fac0188cb04c3a7c94f7ddfbc2b0cb577095f2e538d076ee677d3",
+	},
+}
+
+func TestSymmetricVectors(t *testing.T) {
+	seed := make([]byte, 3*paramsSymBytes)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	digest := func(b []byte) string {
+		d := sha3.Sum256(b)
+		return hex.EncodeToString(d[:])
+	}
+
+	for _, v := range symmetricVectors {
+		privateKey, publicKey, err := v.params.kemKeypairDerand(seed[:32], seed[32:64])
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertext, sharedSecret, err := v.params.kemEncryptDerand(publicKey, seed[64:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		decrypted, err := v.params.KemDecrypt(ciphertext, privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := digest(publicKey); got != v.publicKey {
+			t.Errorf("%s: public key digest %s, want %s", v.params.Name, got, v.publicKey)
+		}
+		if got := digest(privateKey); got != v.privateKey {
+			t.Errorf("%s: private key digest %s, want %s", v.params.Name, got, v.privateKey)
+		}
+		if got := digest(ciphertext); got != v.ciphertext {
+			t.Errorf("%s: ciphertext digest %s, want %s", v.params.Name, got, v.ciphertext)
+		}
+		if got := hex.EncodeToString(sharedSecret); got != v.sharedSecret {
+			t.Errorf("%s: shared secret %s, want %s", v.params.Name, got, v.sharedSecret)
+		}
+		if !bytes.Equal(decrypted, sharedSecret) {
+			t.Errorf("%s: decapsulation does not match encapsulation", v.params.Name)
+		}
+	}
+}
+
+func TestKem90sRoundTrip(t *testing.T) {
+	for _, variant := range []int{512, 768, 1024} {
+		privateKey, publicKey, err := KemKeypair90s(variant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertext, sharedSecret, err := KemEncrypt90s(publicKey, variant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decrypted, err := KemDecrypt90s(ciphertext, privateKey, variant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decrypted, sharedSecret) {
+			t.Errorf("90s-%d: shared secrets do not match", variant)
+		}
+
+		// The instantiations are not interchangeable: decapsulating a
+		// Kyber-90s ciphertext with the SHAKE code path is a rejection.
+		other, err := KemDecrypt(ciphertext, privateKey, variant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(other, sharedSecret) {
+			t.Errorf("90s-%d: SHAKE decapsulation accepted a 90s ciphertext", variant)
+		}
+	}
+}
+
+func TestSymmetric90sPrimitives(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	// The PRF and the XOF are the same AES-256-CTR stream, with the nonce
+	// bytes at the start of the IV.
+	prf := Symmetric90s.Prf(100, key, 7)
+	stream := make([]byte, 100)
+	if _, err := Symmetric90s.Xof(key, 7, 0).Read(stream); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(prf, stream) {
+		t.Error("PRF and XOF disagree on the same nonce")
+	}
+
+	// Reading the XOF in pieces yields the same stream.
+	xof := Symmetric90s.Xof(key, 7, 0)
+	pieces := make([]byte, 100)
+	xof.Read(pieces[:13])
+	xof.Read(pieces[13:])
+	if !bytes.Equal(pieces, stream) {
+		t.Error("XOF stream depends on the read sizes")
+	}
+
+	if bytes.Equal(Symmetric90s.Prf(32, key, 1), Symmetric90s.Prf(32, key, 2)) {
+		t.Error("PRF output does not depend on the nonce")
+	}
+}
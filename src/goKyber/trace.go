@@ -0,0 +1,130 @@
+package gokyber
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// Tracer receives the intermediate values of the IND-CPA and KEM operations
+// of a parameter set, for teaching and for debugging interoperability
+// problems. Names are dotted paths such as "indcpa.keypair.A.ntt" or
+// "kem.encaps.sharedSecret"; names ending in ".ntt" hold values in the NTT
+// domain.
+//
+// The value is one of:
+//   - []byte: seeds, hashes, messages and serialized keys and ciphertexts,
+//   - []int16: a polynomial, as its N coefficients reduced to [0, Q),
+//   - [][]int16: a vector of polynomials,
+//   - [][][]int16: a matrix of polynomials, row by row,
+//   - bool: the outcome of a check.
+//
+// Values are copies that the tracer may keep. Tracing exposes secret values
+// and must never be enabled on keys that protect real data.
+type Tracer interface {
+	Trace(name string, value any)
+}
+
+// WithTracer returns a copy of the parameter set whose operations report
+// their intermediate values to the tracer. Only the methods of the returned
+// parameter set are traced; the package-level functions taking a variant
+// never are. A nil tracer turns tracing off.
+func (p *ParameterSet) WithTracer(t Tracer) *ParameterSet {
+	traced := *p
+	traced.tracer = t
+	return &traced
+}
+
+// When tracing is off, the trace helpers return after a nil check. They hand
+// the tracer copies, which keeps the traced values themselves from escaping
+// to the heap, so an untraced operation makes no extra allocation.
+
+func (p *ParameterSet) traceBytes(name string, b []byte) {
+	if p.tracer != nil {
+		p.tracer.Trace(name, bytes.Clone(b))
+	}
+}
+
+func (p *ParameterSet) traceBool(name string, b bool) {
+	if p.tracer != nil {
+		p.tracer.Trace(name, b)
+	}
+}
+
+func (p *ParameterSet) tracePoly(name string, a *Polynomial) {
+	if p.tracer != nil {
+		p.tracer.Trace(name, p.traceVector(PolynomialVector{*a})[0])
+	}
+}
+
+func (p *ParameterSet) tracePolyvec(name string, v PolynomialVector) {
+	if p.tracer != nil {
+		p.tracer.Trace(name, p.traceVector(v))
+	}
+}
+
+func (p *ParameterSet) traceMatrix(name string, m []PolynomialVector) {
+	if p.tracer != nil {
+		rows := make([][][]int16, len(m))
+		for i, v := range m {
+			rows[i] = p.traceVector(v)
+		}
+		p.tracer.Trace(name, rows)
+	}
+}
+
+// traceVector returns the coefficients of a vector of polynomials, reduced
+// to [0, Q).
+func (p *ParameterSet) traceVector(v PolynomialVector) [][]int16 {
+	r := p.PolynomialRing()
+	out := make([][]int16, len(v))
+	for i := range v {
+		out[i] = make([]int16, r.N)
+		for j := range out[i] {
+			out[i][j] = int16((int(v[i][j])%r.Q + r.Q) % r.Q)
+		}
+	}
+	return out
+}
+
+// JSONTracer is a Tracer that writes one JSON object per value, of the form
+// {"name": "...", "value": ...}, followed by a newline. Byte strings are
+// hex-encoded; polynomials are arrays of coefficients. It is safe for
+// concurrent use, although values of concurrent operations interleave.
+type JSONTracer struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewJSONTracer returns a JSONTracer writing to w.
+func NewJSONTracer(w io.Writer) *JSONTracer {
+	return &JSONTracer{enc: json.NewEncoder(w)}
+}
+
+type traceRecord struct {
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
+
+// Trace writes a value. Write errors are kept and reported by Err.
+func (t *JSONTracer) Trace(name string, value any) {
+	if b, ok := value.([]byte); ok {
+		value = hex.EncodeToString(b)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return
+	}
+	t.err = t.enc.Encode(traceRecord{Name: name, Value: value})
+}
+
+// Err returns the first error met while writing, if any.
+func (t *JSONTracer) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
@@ -0,0 +1,179 @@
+package gokyber
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+type recordingTracer struct {
+	names  []string
+	values map[string][]any
+}
+
+func (t *recordingTracer) Trace(name string, value any) {
+	if t.values == nil {
+		t.values = make(map[string][]any)
+	}
+	t.names = append(t.names, name)
+	t.values[name] = append(t.values[name], value)
+}
+
+func TestTracerRecordsKemValues(t *testing.T) {
+	tracer := &recordingTracer{}
+	p := Kyber768.WithTracer(tracer)
+	if Kyber768.tracer != nil {
+		t.Fatal("WithTracer modified the original parameter set")
+	}
+
+	seed := make([]byte, 64)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	privateKey, publicKey, err := p.kemKeypairDerand(seed[:32], seed[32:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, sharedSecret, err := p.kemEncryptDerand(publicKey, seed[:32])
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := p.KemDecrypt(ciphertext, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Tracing must not change the results.
+	wantPrivateKey, wantPublicKey, _ := Kyber768.kemKeypairDerand(seed[:32], seed[32:])
+	wantCiphertext, _, _ := Kyber768.kemEncryptDerand(wantPublicKey, seed[:32])
+	if !bytes.Equal(privateKey, wantPrivateKey) || !bytes.Equal(ciphertext, wantCiphertext) {
+		t.Fatal("tracing changed the output")
+	}
+
+	first := func(name string) any {
+		values := tracer.values[name]
+		if len(values) == 0 {
+			t.Fatalf("%s was not traced", name)
+		}
+		return values[0]
+	}
+	if got := first("kem.encaps.sharedSecret").([]byte); !bytes.Equal(got, sharedSecret) {
+		t.Error("traced shared secret differs from the returned one")
+	}
+	if got := first("kem.decaps.sharedSecret").([]byte); !bytes.Equal(got, decrypted) {
+		t.Error("traced decapsulated secret differs from the returned one")
+	}
+	if !first("kem.decaps.valid").(bool) {
+		t.Error("decapsulation of an honest ciphertext traced as invalid")
+	}
+	if !bytes.Equal(first("kem.decaps.message").([]byte), first("kem.encaps.message").([]byte)) {
+		t.Error("decrypted message differs from the encapsulated one")
+	}
+	if got := first("indcpa.keypair.publicKey").([]byte); !bytes.Equal(got, publicKey) {
+		t.Error("traced public key differs from the returned one")
+	}
+
+	matrix := first("indcpa.keypair.A.ntt").([][][]int16)
+	if len(matrix) != 3 || len(matrix[0]) != 3 || len(matrix[0][0]) != paramsN {
+		t.Errorf("matrix A traced with shape %dx%dx%d", len(matrix), len(matrix[0]), len(matrix[0][0]))
+	}
+	for _, s := range first("indcpa.keypair.s").([][]int16) {
+		for _, c := range s {
+			if c > 2 && int(c) < paramsQ-2 {
+				t.Fatalf("secret coefficient %d outside [-eta1, eta1] mod q", c)
+			}
+		}
+	}
+	u := first("indcpa.encrypt.ciphertext.u").([]byte)
+	v := first("indcpa.encrypt.ciphertext.v").([]byte)
+	if !bytes.Equal(append(append([]byte{}, u...), v...), ciphertext) {
+		t.Error("traced compressed u and v do not form the ciphertext")
+	}
+}
+
+func TestTracerRecordsRejection(t *testing.T) {
+	tracer := &recordingTracer{}
+	p := Kyber512.WithTracer(tracer)
+	privateKey, publicKey, err := p.KemKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, _, err := p.KemEncrypt(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext[0] ^= 1
+	if _, err := p.KemDecrypt(ciphertext, privateKey); err != nil {
+		t.Fatal(err)
+	}
+	if tracer.values["kem.decaps.valid"][0].(bool) {
+		t.Error("decapsulation of a modified ciphertext traced as valid")
+	}
+}
+
+func TestJSONTracer(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := NewJSONTracer(&buf)
+	p := Kyber512.WithTracer(tracer)
+	privateKey, publicKey, err := p.KemKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, _, err := p.KemEncrypt(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.KemDecrypt(ciphertext, privateKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracer.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := 0
+	scanner := bufio.NewScanner(&buf)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var record struct {
+			Name  string          `json:"name"`
+			Value json.RawMessage `json:"value"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line %d: %v", lines+1, err)
+		}
+		if record.Name == "" || len(record.Value) == 0 {
+			t.Fatalf("line %d is incomplete: %s", lines+1, scanner.Text())
+		}
+		if record.Name == "kem.encaps.sharedSecret" {
+			var s string
+			if err := json.Unmarshal(record.Value, &s); err != nil || len(s) != 64 {
+				t.Errorf("shared secret not traced as 32 hex-encoded bytes: %s", record.Value)
+			}
+		}
+		lines++
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if lines < 30 {
+		t.Errorf("only %d values traced", lines)
+	}
+}
+
+func TestTracingOffDoesNotAllocate(t *testing.T) {
+	p := Kyber768
+	v := PolyvecNew(3)
+	var a Polynomial
+	b := make([]byte, 32)
+	allocs := testing.AllocsPerRun(100, func() {
+		p.traceBytes("b", b)
+		p.traceBool("ok", true)
+		p.tracePoly("a", &a)
+		p.tracePolyvec("v", v)
+		p.traceMatrix("m", []PolynomialVector{v})
+	})
+	if allocs != 0 {
+		t.Errorf("untraced helpers made %v allocations", allocs)
+	}
+}
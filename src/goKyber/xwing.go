@@ -0,0 +1,175 @@
+package gokyber
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// xwingLabel is the domain separator of the X-Wing combiner, the ASCII
+// drawing `\./` over `/^\`.
+const xwingLabel = "\\./" + "/^\\"
+
+const (
+	xwingSeedBytes = 32
+	x25519Bytes    = 32
+)
+
+// XWingKEM is X-Wing (draft-connolly-cfrg-xwing-kem), a hybrid of ML-KEM-768
+// and X25519: the shared secret stays secret as long as either of them is
+// unbroken. Private keys are 32-byte seeds, from which both component key
+// pairs are expanded with SHAKE-256; public keys are the ML-KEM-768 public
+// key followed by the X25519 public key, and ciphertexts likewise.
+type XWingKEM struct{}
+
+// XWing is the X-Wing KEM.
+var XWing = &XWingKEM{}
+
+var _ KEM = XWing
+
+// PublicKeyBytes returns the byte length of X-Wing public keys.
+func (*XWingKEM) PublicKeyBytes() int {
+	return MLKEM768.PublicKeyBytes() + x25519Bytes
+}
+
+// PrivateKeyBytes returns the byte length of X-Wing private keys: a seed.
+func (*XWingKEM) PrivateKeyBytes() int {
+	return xwingSeedBytes
+}
+
+// CiphertextBytes returns the byte length of X-Wing ciphertexts.
+func (*XWingKEM) CiphertextBytes() int {
+	return MLKEM768.CiphertextBytes() + x25519Bytes
+}
+
+// SharedSecretBytes returns the byte length of X-Wing shared secrets.
+func (*XWingKEM) SharedSecretBytes() int {
+	return 32
+}
+
+// KemKeypair generates an X-Wing key pair.
+func (x *XWingKEM) KemKeypair() ([]byte, []byte, error) {
+	seed := make([]byte, xwingSeedBytes)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, nil, err
+	}
+	return x.KemKeypairFromSeed(seed)
+}
+
+// KemKeypairFromSeed returns the X-Wing key pair of a 32-byte seed. The
+// private key is the seed itself.
+func (x *XWingKEM) KemKeypairFromSeed(seed []byte) ([]byte, []byte, error) {
+	if len(seed) != xwingSeedBytes {
+		return nil, nil, errors.New("invalid seed length")
+	}
+	_, mlkemPublicKey, x25519PrivateKey, err := x.expandPrivateKey(seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	publicKey := append(mlkemPublicKey, x25519PrivateKey.PublicKey().Bytes()...)
+	return append([]byte{}, seed...), publicKey, nil
+}
+
+// expandPrivateKey derives the component key pairs from a seed.
+func (*XWingKEM) expandPrivateKey(seed []byte) ([]byte, []byte, *ecdh.PrivateKey, error) {
+	expanded := make([]byte, 96)
+	sha3.ShakeSum256(expanded, seed)
+	mlkemPrivateKey, mlkemPublicKey, err := MLKEM768.KemKeypairFromSeed(expanded[:64])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	x25519PrivateKey, err := ecdh.X25519().NewPrivateKey(expanded[64:])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return mlkemPrivateKey, mlkemPublicKey, x25519PrivateKey, nil
+}
+
+// KemEncrypt encapsulates a fresh shared secret to an X-Wing public key.
+func (x *XWingKEM) KemEncrypt(publicKey []byte) ([]byte, []byte, error) {
+	eseed := make([]byte, 64)
+	if _, err := rand.Read(eseed); err != nil {
+		return nil, nil, err
+	}
+	return x.kemEncryptDerand(publicKey, eseed)
+}
+
+// kemEncryptDerand is the deterministic core of KemEncrypt. The first half
+// of the 64-byte eseed is the ML-KEM message, the second half the
+// ephemeral X25519 private key.
+func (x *XWingKEM) kemEncryptDerand(publicKey []byte, eseed []byte) ([]byte, []byte, error) {
+	if len(publicKey) != x.PublicKeyBytes() {
+		return nil, nil, errors.New("invalid public key length")
+	}
+	mlkemPublicKey := publicKey[:MLKEM768.PublicKeyBytes()]
+	x25519PublicKeyBytes := publicKey[MLKEM768.PublicKeyBytes():]
+	x25519PublicKey, err := ecdh.X25519().NewPublicKey(x25519PublicKeyBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !MLKEM768.publicKeyReduced(mlkemPublicKey) {
+		return nil, nil, errors.New("invalid public key: coefficient out of range")
+	}
+
+	ephemeral, err := ecdh.X25519().NewPrivateKey(eseed[32:64])
+	if err != nil {
+		return nil, nil, err
+	}
+	x25519Ciphertext := ephemeral.PublicKey().Bytes()
+	x25519SharedSecret, err := ephemeral.ECDH(x25519PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	mlkemCiphertext, mlkemSharedSecret, err := MLKEM768.kemEncryptDerand(mlkemPublicKey, eseed[:32])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sharedSecret := xwingCombiner(mlkemSharedSecret, x25519SharedSecret, x25519Ciphertext, x25519PublicKeyBytes)
+	return append(mlkemCiphertext, x25519Ciphertext...), sharedSecret, nil
+}
+
+// KemDecrypt decapsulates an X-Wing ciphertext. Like ML-KEM, it implicitly
+// rejects invalid ciphertexts of the right length.
+func (x *XWingKEM) KemDecrypt(ciphertext []byte, privateKey []byte) ([]byte, error) {
+	if len(ciphertext) != x.CiphertextBytes() {
+		return nil, errors.New("invalid ciphertext length")
+	}
+	if len(privateKey) != x.PrivateKeyBytes() {
+		return nil, errors.New("invalid private key length")
+	}
+	mlkemPrivateKey, _, x25519PrivateKey, err := x.expandPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	mlkemCiphertext := ciphertext[:MLKEM768.CiphertextBytes()]
+	x25519Ciphertext := ciphertext[MLKEM768.CiphertextBytes():]
+
+	mlkemSharedSecret, err := MLKEM768.KemDecrypt(mlkemCiphertext, mlkemPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(x25519Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	x25519SharedSecret, err := x25519PrivateKey.ECDH(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	return xwingCombiner(mlkemSharedSecret, x25519SharedSecret, x25519Ciphertext, x25519PrivateKey.PublicKey().Bytes()), nil
+}
+
+// xwingCombiner is the X-Wing combiner: SHA3-256 over both shared secrets,
+// the X25519 ciphertext and public key, and the label.
+func xwingCombiner(mlkemSharedSecret, x25519SharedSecret, x25519Ciphertext, x25519PublicKey []byte) []byte {
+	h := sha3.New256()
+	h.Write(mlkemSharedSecret)
+	h.Write(x25519SharedSecret)
+	h.Write(x25519Ciphertext)
+	h.Write(x25519PublicKey)
+	h.Write([]byte(xwingLabel))
+	return h.Sum(nil)
+}
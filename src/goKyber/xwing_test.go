@@ -0,0 +1,113 @@
+package gokyber
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// xwingVectors are the test vectors of draft-connolly-cfrg-xwing-kem, with
+// the public key and ciphertext given as their SHA3-256 digests.
+var xwingVectors = []struct {
+	seed, eseed           string
+	publicKey, ciphertext string
+	sharedSecret          string
+}{
+	{
+		"7f9c2ba4e88f827d616045507605853ed73b8093f6efbc88eb1a6eacfa66ef26",
+		"3cb1eea988004b93103cfb0aeefd2a686e01fa4a58e8a3639ca8a1e3f9ae57e235b8cc873c23dc62b8d260169afa2f75ab916a58d974918835d25e6a435085b2",
+		"5121745904643ad9dfacca7869292c19a8a69533b53e60666b7db910b4ad6367",
+		"c0abd149f83f45324ac3a7ddc7606c71f257e5ea86113522834a0ee1bcb34e3e",
+		"d2df0522128f09dd8e2c92b1e905c793d8f57a54c3da25861f10bf4ca613e384",
+	},
+	{
+		"badfd6dfaac359a5efbb7bcc4b59d538df9a04302e10c8bc1cbf1a0b3a5120ea",
+		"17cda7cfad765f5623474d368ccca8af0007cd9f5e4c849f167a580b14aabdefaee7eef47cb0fca9767be1fda69419dfb927e9df07348b196691abaeb580b32d",
+		"799b6016e5daa56ffa1b5e79f7caf73413ceecd6df428642404cac41ddee4853",
+		"7680b7ba47ae09bac4b43001edcef9d98e50df20026e70ba6a424447e1f2b961",
+		"f2e86241c64d60f6649fbc6c5b7d17180b780a3f34355e64a85749949c45f150",
+	},
+	{
+		"ef58538b8d23f87732ea63b02b4fa0f4873360e2841928cd60dd4cee8cc0d4c9",
+		"22a96188d032675c8ac850933c7aff1533b94c834adbb69c6115bad4692d8619f90b0cdf8a7b9c264029ac185b70b83f2801f2f4b3f70c593ea3aeeb613a7f1b",
+		"1ef0c99a06026450564957a5402a788feffbbefdcce55d25de254d0a49eb095a",
+		"3088688d63201d5d844170b79f148b2791c15f346ff6f8bd559807fbd442f91f",
+		"953f7f4e8c5b5049bdc771d1dffada0dd961477d1a2ae0988baa7ea6898d893f",
+	},
+}
+
+func TestXWingVectors(t *testing.T) {
+	for i, v := range xwingVectors {
+		privateKey, publicKey, err := XWing.KemKeypairFromSeed(mustDecodeHex(t, v.seed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := digest(publicKey); got != v.publicKey {
+			t.Errorf("vector %d: public key digest %s, want %s", i, got, v.publicKey)
+		}
+		ciphertext, sharedSecret, err := XWing.kemEncryptDerand(publicKey, mustDecodeHex(t, v.eseed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := digest(ciphertext); got != v.ciphertext {
+			t.Errorf("vector %d: ciphertext digest %s, want %s", i, got, v.ciphertext)
+		}
+		if got := hex.EncodeToString(sharedSecret); got != v.sharedSecret {
+			t.Errorf("vector %d: shared secret %s, want %s", i, got, v.sharedSecret)
+		}
+		decapsulated, err := XWing.KemDecrypt(ciphertext, privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decapsulated, sharedSecret) {
+			t.Errorf("vector %d: decapsulation gives a different shared secret", i)
+		}
+	}
+}
+
+func TestXWingThroughKEMInterface(t *testing.T) {
+	for _, kem := range []KEM{XWing, MLKEM768, Kyber768} {
+		privateKey, publicKey, err := kem.KemKeypair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(privateKey) != kem.PrivateKeyBytes() || len(publicKey) != kem.PublicKeyBytes() {
+			t.Fatalf("%T: key sizes %d and %d", kem, len(privateKey), len(publicKey))
+		}
+		ciphertext, sharedSecret, err := kem.KemEncrypt(publicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ciphertext) != kem.CiphertextBytes() || len(sharedSecret) != kem.SharedSecretBytes() {
+			t.Fatalf("%T: ciphertext and shared secret sizes %d and %d", kem, len(ciphertext), len(sharedSecret))
+		}
+		decapsulated, err := kem.KemDecrypt(ciphertext, privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decapsulated, sharedSecret) {
+			t.Errorf("%T: shared secrets differ", kem)
+		}
+		// Flipping a bit of either component changes the shared secret.
+		for _, i := range []int{0, len(ciphertext) - 1} {
+			modified := bytes.Clone(ciphertext)
+			modified[i] ^= 1
+			rejected, err := kem.KemDecrypt(modified, privateKey)
+			if err == nil && bytes.Equal(rejected, sharedSecret) {
+				t.Errorf("%T: modified ciphertext gives the same shared secret", kem)
+			}
+		}
+	}
+}
+
+func TestXWingRejectsInvalidInput(t *testing.T) {
+	if _, _, err := XWing.KemEncrypt(make([]byte, 10)); err == nil {
+		t.Error("short public key accepted")
+	}
+	if _, err := XWing.KemDecrypt(make([]byte, 10), make([]byte, 32)); err == nil {
+		t.Error("short ciphertext accepted")
+	}
+	if _, err := XWing.KemDecrypt(make([]byte, XWing.CiphertextBytes()), make([]byte, 31)); err == nil {
+		t.Error("short private key accepted")
+	}
+}
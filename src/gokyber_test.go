@@ -33,3 +33,29 @@ func benchmarkKyber(b *testing.B, securityLevel int) {
 func BenchmarkKyber512(b *testing.B)  { benchmarkKyber(b, 512) }
 func BenchmarkKyber768(b *testing.B)  { benchmarkKyber(b, 768) }
 func BenchmarkKyber1024(b *testing.B) { benchmarkKyber(b, 1024) }
+
+func benchmarkMaskedDecrypt(b *testing.B, securityLevel int) {
+	privateKey, publicKey, err := gokyber.KemKeypair(securityLevel)
+	if err != nil {
+		b.Fatalf("Failed to generate key pair: %v", err)
+	}
+	maskedKey, err := gokyber.NewMaskedPrivateKey(privateKey, securityLevel)
+	if err != nil {
+		b.Fatalf("Failed to mask private key: %v", err)
+	}
+	ciphertext, _, err := gokyber.KemEncrypt(publicKey, securityLevel)
+	if err != nil {
+		b.Fatalf("Failed to encrypt: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := maskedKey.KemDecrypt(ciphertext); err != nil {
+			b.Fatalf("Failed to decrypt: %v", err)
+		}
+	}
+}
+
+func BenchmarkMaskedDecrypt512(b *testing.B)  { benchmarkMaskedDecrypt(b, 512) }
+func BenchmarkMaskedDecrypt768(b *testing.B)  { benchmarkMaskedDecrypt(b, 768) }
+func BenchmarkMaskedDecrypt1024(b *testing.B) { benchmarkMaskedDecrypt(b, 1024) }
@@ -0,0 +1,52 @@
+package hpke
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// AEAD is the authenticated encryption algorithm of a suite.
+type AEAD struct {
+	// ID is the HPKE AEAD identifier.
+	ID uint16
+	// Name is the name of the AEAD in the HPKE registry.
+	Name string
+	// KeyBytes and NonceBytes are Nk and Nn, the key and nonce lengths.
+	KeyBytes, NonceBytes int
+	// New returns the AEAD keyed with a key of KeyBytes bytes. It is nil for
+	// ExportOnly.
+	New func(key []byte) (cipher.AEAD, error)
+}
+
+var (
+	// AES128GCM is AES-128 in GCM mode.
+	AES128GCM = &AEAD{ID: 0x0001, Name: "AES-128-GCM", KeyBytes: 16, NonceBytes: 12, New: newGCM}
+	// AES256GCM is AES-256 in GCM mode.
+	AES256GCM = &AEAD{ID: 0x0002, Name: "AES-256-GCM", KeyBytes: 32, NonceBytes: 12, New: newGCM}
+	// ChaCha20Poly1305 is ChaCha20-Poly1305 (RFC 8439).
+	ChaCha20Poly1305 = &AEAD{ID: 0x0003, Name: "ChaCha20Poly1305", KeyBytes: 32, NonceBytes: 12, New: chacha20poly1305.New}
+	// ExportOnly is the AEAD of suites that only use the secret export
+	// interface: Seal and Open always fail.
+	ExportOnly = &AEAD{ID: 0xffff, Name: "Export-only"}
+)
+
+// AEADByID returns the AEAD with an HPKE identifier.
+func AEADByID(id uint16) (*AEAD, error) {
+	for _, aead := range []*AEAD{AES128GCM, AES256GCM, ChaCha20Poly1305, ExportOnly} {
+		if aead.ID == id {
+			return aead, nil
+		}
+	}
+	return nil, errors.New("unsupported AEAD")
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
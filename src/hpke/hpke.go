@@ -0,0 +1,319 @@
+// Package hpke implements Hybrid Public Key Encryption (RFC 9180), which
+// turns the shared secret of a KEM into authenticated encryption of any
+// number of messages, and into exported secrets, for the holder of a
+// private key.
+//
+// All four modes are supported: Base, PSK (with a pre-shared key), Auth
+// (authenticating the sender with a static DHKEM key) and AuthPSK. Besides
+// the DHKEMs of RFC 9180, suites can use ML-KEM and its hybrids with ECDH as
+// specified by draft-ietf-hpke-pq, or any other KEM of package gokyber
+// through CustomKEM.
+//
+// A suite is a KEM, a KDF and an AEAD:
+//
+//	suite := hpke.NewSuite(hpke.MLKEM768X25519, hpke.HKDFSHA256, hpke.AES128GCM)
+//	enc, sender, err := suite.SetupBaseS(publicKey, info)
+//	ciphertext, err := sender.Seal(aad, plaintext)
+//
+//	recipient, err := suite.SetupBaseR(enc, privateKey, info)
+//	plaintext, err := recipient.Open(aad, ciphertext)
+package hpke
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// versionLabel prefixes every labeled KDF input.
+const versionLabel = "HPKE-v1"
+
+// The modes of RFC 9180, section 5.
+const (
+	modeBase    byte = 0x00
+	modePSK     byte = 0x01
+	modeAuth    byte = 0x02
+	modeAuthPSK byte = 0x03
+)
+
+// minPSKBytes is the minimum length of pre-shared keys: RFC 9180 requires
+// at least 32 bytes of entropy.
+const minPSKBytes = 32
+
+// Suite is an HPKE ciphersuite.
+type Suite struct {
+	KEM  KEM
+	KDF  *KDF
+	AEAD *AEAD
+}
+
+// NewSuite returns the suite of a KEM, a KDF and an AEAD.
+func NewSuite(kem KEM, kdf *KDF, aead *AEAD) *Suite {
+	return &Suite{KEM: kem, KDF: kdf, AEAD: aead}
+}
+
+// SuiteByID returns the suite of built-in algorithms with HPKE identifiers.
+func SuiteByID(kemID, kdfID, aeadID uint16) (*Suite, error) {
+	kem, err := KEMByID(kemID)
+	if err != nil {
+		return nil, err
+	}
+	kdf, err := KDFByID(kdfID)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := AEADByID(aeadID)
+	if err != nil {
+		return nil, err
+	}
+	return NewSuite(kem, kdf, aead), nil
+}
+
+// id returns the suite_id of the key schedule.
+func (s *Suite) id() []byte {
+	id := binary.BigEndian.AppendUint16([]byte("HPKE"), s.KEM.ID())
+	id = binary.BigEndian.AppendUint16(id, s.KDF.ID)
+	return binary.BigEndian.AppendUint16(id, s.AEAD.ID)
+}
+
+// context is the encryption context shared by senders and recipients.
+type context struct {
+	suite          *Suite
+	aead           cipher.AEAD
+	baseNonce      []byte
+	seq            uint64
+	exporterSecret []byte
+}
+
+// Sender is the encryption context of a sender.
+type Sender struct {
+	context
+}
+
+// Recipient is the encryption context of a recipient.
+type Recipient struct {
+	context
+}
+
+// SetupBaseS encapsulates a shared secret to a recipient public key and
+// returns the encapsulated key and the sender context.
+func (s *Suite) SetupBaseS(publicKey, info []byte) ([]byte, *Sender, error) {
+	return s.setupS(modeBase, publicKey, info, nil, nil, nil)
+}
+
+// SetupBaseR returns the recipient context of an encapsulated key.
+func (s *Suite) SetupBaseR(enc, privateKey, info []byte) (*Recipient, error) {
+	return s.setupR(modeBase, enc, privateKey, info, nil, nil, nil)
+}
+
+// SetupPSKS is SetupBaseS with a pre-shared key of at least 32 bytes and
+// its identifier.
+func (s *Suite) SetupPSKS(publicKey, info, psk, pskID []byte) ([]byte, *Sender, error) {
+	return s.setupS(modePSK, publicKey, info, psk, pskID, nil)
+}
+
+// SetupPSKR is SetupBaseR with a pre-shared key and its identifier.
+func (s *Suite) SetupPSKR(enc, privateKey, info, psk, pskID []byte) (*Recipient, error) {
+	return s.setupR(modePSK, enc, privateKey, info, psk, pskID, nil)
+}
+
+// SetupAuthS is SetupBaseS authenticated with the private key of the
+// sender. The KEM must be an AuthKEM.
+func (s *Suite) SetupAuthS(publicKey, info, senderPrivateKey []byte) ([]byte, *Sender, error) {
+	return s.setupS(modeAuth, publicKey, info, nil, nil, senderPrivateKey)
+}
+
+// SetupAuthR is SetupBaseR checking that the sender holds the private key
+// of a public key. The KEM must be an AuthKEM.
+func (s *Suite) SetupAuthR(enc, privateKey, info, senderPublicKey []byte) (*Recipient, error) {
+	return s.setupR(modeAuth, enc, privateKey, info, nil, nil, senderPublicKey)
+}
+
+// SetupAuthPSKS combines SetupAuthS and SetupPSKS.
+func (s *Suite) SetupAuthPSKS(publicKey, info, psk, pskID, senderPrivateKey []byte) ([]byte, *Sender, error) {
+	return s.setupS(modeAuthPSK, publicKey, info, psk, pskID, senderPrivateKey)
+}
+
+// SetupAuthPSKR combines SetupAuthR and SetupPSKR.
+func (s *Suite) SetupAuthPSKR(enc, privateKey, info, psk, pskID, senderPublicKey []byte) (*Recipient, error) {
+	return s.setupR(modeAuthPSK, enc, privateKey, info, psk, pskID, senderPublicKey)
+}
+
+func (s *Suite) setupS(mode byte, publicKey, info, psk, pskID, senderPrivateKey []byte) ([]byte, *Sender, error) {
+	var enc, sharedSecret []byte
+	var err error
+	if mode == modeAuth || mode == modeAuthPSK {
+		kem, ok := s.KEM.(AuthKEM)
+		if !ok {
+			return nil, nil, errors.New("KEM does not support sender authentication")
+		}
+		enc, sharedSecret, err = kem.AuthEncap(publicKey, senderPrivateKey)
+	} else {
+		enc, sharedSecret, err = s.KEM.Encap(publicKey)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, err := s.keySchedule(mode, sharedSecret, info, psk, pskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, &Sender{*ctx}, nil
+}
+
+func (s *Suite) setupR(mode byte, enc, privateKey, info, psk, pskID, senderPublicKey []byte) (*Recipient, error) {
+	var sharedSecret []byte
+	var err error
+	if mode == modeAuth || mode == modeAuthPSK {
+		kem, ok := s.KEM.(AuthKEM)
+		if !ok {
+			return nil, errors.New("KEM does not support sender authentication")
+		}
+		sharedSecret, err = kem.AuthDecap(enc, privateKey, senderPublicKey)
+	} else {
+		sharedSecret, err = s.KEM.Decap(enc, privateKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ctx, err := s.keySchedule(mode, sharedSecret, info, psk, pskID)
+	if err != nil {
+		return nil, err
+	}
+	return &Recipient{*ctx}, nil
+}
+
+// keySchedule is KeySchedule of RFC 9180, section 5.1.
+func (s *Suite) keySchedule(mode byte, sharedSecret, info, psk, pskID []byte) (*context, error) {
+	if err := verifyPSKInputs(mode, psk, pskID); err != nil {
+		return nil, err
+	}
+	suiteID := s.id()
+	pskIDHash := s.KDF.labeledExtract(suiteID, nil, "psk_id_hash", pskID)
+	infoHash := s.KDF.labeledExtract(suiteID, nil, "info_hash", info)
+	keyScheduleContext := append(append([]byte{mode}, pskIDHash...), infoHash...)
+	secret := s.KDF.labeledExtract(suiteID, sharedSecret, "secret", psk)
+
+	ctx := &context{suite: s}
+	var err error
+	if s.AEAD.New != nil {
+		key, err := s.KDF.labeledExpand(suiteID, secret, "key", keyScheduleContext, s.AEAD.KeyBytes)
+		if err != nil {
+			return nil, err
+		}
+		if ctx.aead, err = s.AEAD.New(key); err != nil {
+			return nil, err
+		}
+		if ctx.baseNonce, err = s.KDF.labeledExpand(suiteID, secret, "base_nonce", keyScheduleContext, s.AEAD.NonceBytes); err != nil {
+			return nil, err
+		}
+	}
+	if ctx.exporterSecret, err = s.KDF.labeledExpand(suiteID, secret, "exp", keyScheduleContext, s.KDF.Size()); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// verifyPSKInputs checks that a pre-shared key and its identifier are given
+// exactly in the PSK modes.
+func verifyPSKInputs(mode byte, psk, pskID []byte) error {
+	gotPSK, gotPSKID := len(psk) != 0, len(pskID) != 0
+	if gotPSK != gotPSKID {
+		return errors.New("inconsistent PSK inputs")
+	}
+	withPSK := mode == modePSK || mode == modeAuthPSK
+	if gotPSK && !withPSK {
+		return errors.New("PSK input provided when not needed")
+	}
+	if !gotPSK && withPSK {
+		return errors.New("missing required PSK input")
+	}
+	if gotPSK && len(psk) < minPSKBytes {
+		return errors.New("PSK too short")
+	}
+	return nil
+}
+
+// nonce returns the nonce of the current message, the base nonce XORed with
+// the sequence number.
+func (c *context) nonce() ([]byte, error) {
+	if c.aead == nil {
+		return nil, errors.New("export-only suite cannot encrypt")
+	}
+	// RFC 9180 allows 2^96 - 1 messages; a uint64 counter is plenty.
+	if c.seq == math.MaxUint64 {
+		return nil, errors.New("message limit reached")
+	}
+	nonce := make([]byte, len(c.baseNonce))
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], c.seq)
+	subtle.XORBytes(nonce, nonce, c.baseNonce)
+	return nonce, nil
+}
+
+func (c *context) export(exporterContext []byte, length int) ([]byte, error) {
+	return c.suite.KDF.labeledExpand(c.suite.id(), c.exporterSecret, "sec", exporterContext, length)
+}
+
+// Seal encrypts and authenticates the next message and authenticates the
+// additional data.
+func (c *Sender) Seal(aad, plaintext []byte) ([]byte, error) {
+	nonce, err := c.nonce()
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := c.aead.Seal(nil, nonce, plaintext, aad)
+	c.seq++
+	return ciphertext, nil
+}
+
+// Export derives a secret of the given length from the context, the same
+// for the sender and the recipient.
+func (c *Sender) Export(exporterContext []byte, length int) ([]byte, error) {
+	return c.export(exporterContext, length)
+}
+
+// Open decrypts the next message. Messages must be opened in the order they
+// were sealed; a message that fails to open does not advance the context.
+func (c *Recipient) Open(aad, ciphertext []byte) ([]byte, error) {
+	nonce, err := c.nonce()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, err
+	}
+	c.seq++
+	return plaintext, nil
+}
+
+// Export derives a secret of the given length from the context, the same
+// for the sender and the recipient.
+func (c *Recipient) Export(exporterContext []byte, length int) ([]byte, error) {
+	return c.export(exporterContext, length)
+}
+
+// Seal encrypts a single message to a public key in the Base mode and
+// returns the encapsulated key and the ciphertext.
+func (s *Suite) Seal(publicKey, info, aad, plaintext []byte) ([]byte, []byte, error) {
+	enc, sender, err := s.SetupBaseS(publicKey, info)
+	if err != nil {
+		return nil, nil, err
+	}
+	ciphertext, err := sender.Seal(aad, plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, ciphertext, nil
+}
+
+// Open decrypts a single message sealed with Seal.
+func (s *Suite) Open(enc, privateKey, info, aad, ciphertext []byte) ([]byte, error) {
+	recipient, err := s.SetupBaseR(enc, privateKey, info)
+	if err != nil {
+		return nil, err
+	}
+	return recipient.Open(aad, ciphertext)
+}
@@ -0,0 +1,529 @@
+package hpke
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+	"golang.org/x/crypto/sha3"
+)
+
+// hpkeVector is a test vector. testdata/rfc9180.json holds the Base mode
+// DHKEM vectors of RFC 9180, appendix A, condensed as in the Go standard
+// library: instead of a list of encryptions and exports, they hold a digest
+// of 1000 of each with pseudo-random inputs. testdata/rfc9180-modes.json
+// holds the PSK, Auth and AuthPSK mode vectors of the RFC for the DHKEMs
+// implemented here, from the test-vectors.json of the CFRG draft repository
+// cut to their first five encryptions. testdata/hpke-pq.json holds the
+// vectors of draft-ietf-hpke-pq.
+type hpkeVector struct {
+	Mode           byte   `json:"mode"`
+	KEM            uint16 `json:"kem_id"`
+	KDF            uint16 `json:"kdf_id"`
+	AEAD           uint16 `json:"aead_id"`
+	Info           string `json:"info"`
+	IkmE           string `json:"ikmE"`
+	IkmR           string `json:"ikmR"`
+	IkmS           string `json:"ikmS"`
+	SkRm           string `json:"skRm"`
+	PkRm           string `json:"pkRm"`
+	PkSm           string `json:"pkSm"`
+	PSK            string `json:"psk"`
+	PSKID          string `json:"psk_id"`
+	Enc            string `json:"enc"`
+	SharedSecret   string `json:"shared_secret"`
+	Key            string `json:"key"`
+	BaseNonce      string `json:"base_nonce"`
+	ExporterSecret string `json:"exporter_secret"`
+	Encryptions    []struct {
+		Aad string `json:"aad"`
+		Ct  string `json:"ct"`
+		Pt  string `json:"pt"`
+	} `json:"encryptions"`
+	Exports []struct {
+		Context string `json:"exporter_context"`
+		L       int    `json:"L"`
+		Value   string `json:"exported_value"`
+	} `json:"exports"`
+	AccEncryptions string `json:"encryptions_accumulated"`
+	AccExports     string `json:"exports_accumulated"`
+}
+
+// fixedEphemeral is a KEM whose encapsulation uses the ephemeral input
+// keying material of a test vector.
+type fixedEphemeral struct {
+	KEM
+	ikmE []byte
+}
+
+func (k fixedEphemeral) Encap(publicKey []byte) ([]byte, []byte, error) {
+	return k.KEM.(derandomized).encapDerand(publicKey, k.ikmE)
+}
+
+func (k fixedEphemeral) AuthEncap(publicKey, senderPrivateKey []byte) ([]byte, []byte, error) {
+	return k.KEM.(*DHKEM).authEncapDerand(publicKey, senderPrivateKey, k.ikmE)
+}
+
+func (k fixedEphemeral) AuthDecap(enc, privateKey, senderPublicKey []byte) ([]byte, error) {
+	return k.KEM.(AuthKEM).AuthDecap(enc, privateKey, senderPublicKey)
+}
+
+func TestVectors(t *testing.T) {
+	for _, name := range []string{"rfc9180", "rfc9180-modes", "hpke-pq"} {
+		data, err := os.ReadFile("testdata/" + name + ".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var vectors []hpkeVector
+		if err := json.Unmarshal(data, &vectors); err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range vectors {
+			t.Run(fmt.Sprintf("%s/mode=%d,kem=%04x,kdf=%04x,aead=%04x", name, v.Mode, v.KEM, v.KDF, v.AEAD), func(t *testing.T) {
+				testVector(t, v)
+			})
+		}
+	}
+}
+
+func testVector(t *testing.T, v hpkeVector) {
+	suite, err := SuiteByID(v.KEM, v.KDF, v.AEAD)
+	if err != nil {
+		// X448 and the SHAKE and TurboSHAKE KDFs are not implemented.
+		t.Skip(err)
+	}
+	kem := suite.KEM
+	privateKey, publicKey, err := kem.DeriveKeyPair(mustDecodeHex(t, v.IkmR))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(publicKey); got != v.PkRm {
+		t.Fatalf("derived public key %s, want %s", got, v.PkRm)
+	}
+	// X25519 private keys are compared through their public keys only, as
+	// the vectors may hold them clamped.
+	if kem != DHKEMX25519 && hex.EncodeToString(privateKey) != v.SkRm {
+		t.Errorf("derived private key %x, want %s", privateKey, v.SkRm)
+	}
+	if len(publicKey) != kem.PublicKeyBytes() || len(privateKey) != kem.PrivateKeyBytes() {
+		t.Errorf("key sizes %d and %d", len(publicKey), len(privateKey))
+	}
+
+	var senderPrivateKey, senderPublicKey []byte
+	if v.Mode == modeAuth || v.Mode == modeAuthPSK {
+		senderPrivateKey, senderPublicKey, err = kem.DeriveKeyPair(mustDecodeHex(t, v.IkmS))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(senderPublicKey); got != v.PkSm {
+			t.Fatalf("derived sender public key %s, want %s", got, v.PkSm)
+		}
+	}
+
+	info, psk, pskID := mustDecodeHex(t, v.Info), mustDecodeHex(t, v.PSK), mustDecodeHex(t, v.PSKID)
+	senderSuite := NewSuite(fixedEphemeral{kem, mustDecodeHex(t, v.IkmE)}, suite.KDF, suite.AEAD)
+	enc, sender, err := senderSuite.setupS(v.Mode, publicKey, info, psk, pskID, senderPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(enc); got != v.Enc {
+		t.Fatalf("enc %s, want %s", got, v.Enc)
+	}
+	if len(enc) != kem.EncBytes() {
+		t.Errorf("enc size %d, want %d", len(enc), kem.EncBytes())
+	}
+	recipient, err := suite.setupR(v.Mode, enc, mustDecodeHex(t, v.SkRm), info, psk, pskID, senderPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.SharedSecret != "" {
+		checkSharedSecret(t, v, senderSuite.KEM, enc, senderPrivateKey, senderPublicKey)
+	}
+	if v.Key != "" {
+		checkKeySchedule(t, v, &sender.context, &recipient.context)
+	}
+
+	switch {
+	case suite.AEAD == ExportOnly:
+		if _, err := sender.Seal(nil, nil); err == nil {
+			t.Error("export-only suite sealed a message")
+		}
+		if _, err := recipient.Open(nil, nil); err == nil {
+			t.Error("export-only suite opened a message")
+		}
+	case v.AccEncryptions != "":
+		source, sink := sha3.NewShake128(), sha3.NewShake128()
+		for i := 0; i < 1000; i++ {
+			aad, plaintext := drawRandomInput(t, source), drawRandomInput(t, source)
+			sealAndOpen(t, sender, recipient, aad, plaintext, sink)
+		}
+		if got := readHex(sink); got != v.AccEncryptions {
+			t.Errorf("accumulated encryptions %s, want %s", got, v.AccEncryptions)
+		}
+	default:
+		for _, e := range v.Encryptions {
+			var ciphertext bytes.Buffer
+			sealAndOpen(t, sender, recipient, mustDecodeHex(t, e.Aad), mustDecodeHex(t, e.Pt), &ciphertext)
+			if got := hex.EncodeToString(ciphertext.Bytes()); got != e.Ct {
+				t.Errorf("ciphertext %s, want %s", got, e.Ct)
+			}
+		}
+	}
+
+	if v.AccExports != "" {
+		source, sink := sha3.NewShake128(), sha3.NewShake128()
+		for length := 0; length < 1000; length++ {
+			sink.Write(exportBoth(t, sender, recipient, drawRandomInput(t, source), length))
+		}
+		if got := readHex(sink); got != v.AccExports {
+			t.Errorf("accumulated exports %s, want %s", got, v.AccExports)
+		}
+	}
+	for _, e := range v.Exports {
+		value := exportBoth(t, sender, recipient, mustDecodeHex(t, e.Context), e.L)
+		if got := hex.EncodeToString(value); got != e.Value {
+			t.Errorf("exported value %s, want %s", got, e.Value)
+		}
+	}
+}
+
+// checkSharedSecret checks the shared secret of both sides of the KEM,
+// authenticated in the Auth modes.
+func checkSharedSecret(t *testing.T, v hpkeVector, kem KEM, enc, senderPrivateKey, senderPublicKey []byte) {
+	t.Helper()
+	publicKey, privateKey := mustDecodeHex(t, v.PkRm), mustDecodeHex(t, v.SkRm)
+	var sent, received []byte
+	var err error
+	if senderPrivateKey != nil {
+		auth := kem.(AuthKEM)
+		if _, sent, err = auth.AuthEncap(publicKey, senderPrivateKey); err != nil {
+			t.Fatal(err)
+		}
+		received, err = auth.AuthDecap(enc, privateKey, senderPublicKey)
+	} else {
+		if _, sent, err = kem.Encap(publicKey); err != nil {
+			t.Fatal(err)
+		}
+		received, err = kem.Decap(enc, privateKey)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(sent); got != v.SharedSecret {
+		t.Errorf("encapsulated shared secret %s, want %s", got, v.SharedSecret)
+	}
+	if got := hex.EncodeToString(received); got != v.SharedSecret {
+		t.Errorf("decapsulated shared secret %s, want %s", got, v.SharedSecret)
+	}
+}
+
+// checkKeySchedule checks the base nonce, exporter secret and AEAD key of
+// the contexts. The key is not kept, so it is checked through the tag of
+// an empty message.
+func checkKeySchedule(t *testing.T, v hpkeVector, contexts ...*context) {
+	t.Helper()
+	for _, c := range contexts {
+		if got := hex.EncodeToString(c.exporterSecret); got != v.ExporterSecret {
+			t.Errorf("exporter secret %s, want %s", got, v.ExporterSecret)
+		}
+		if c.suite.AEAD == ExportOnly {
+			continue
+		}
+		if got := hex.EncodeToString(c.baseNonce); got != v.BaseNonce {
+			t.Errorf("base nonce %s, want %s", got, v.BaseNonce)
+		}
+		want, err := c.suite.AEAD.New(mustDecodeHex(t, v.Key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(c.aead.Seal(nil, c.baseNonce, nil, nil), want.Seal(nil, c.baseNonce, nil, nil)) {
+			t.Errorf("AEAD key differs from %s", v.Key)
+		}
+	}
+}
+
+// sealAndOpen seals a message, writes the ciphertext to w and checks that
+// the recipient opens it.
+func sealAndOpen(t *testing.T, sender *Sender, recipient *Recipient, aad, plaintext []byte, w io.Writer) {
+	t.Helper()
+	ciphertext, err := sender.Seal(aad, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(ciphertext)
+	opened, err := recipient.Open(aad, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Fatalf("opened %x, want %x", opened, plaintext)
+	}
+}
+
+// exportBoth exports a secret from both contexts and checks they agree.
+func exportBoth(t *testing.T, sender *Sender, recipient *Recipient, exporterContext []byte, length int) []byte {
+	t.Helper()
+	value, err := sender.Export(exporterContext, length)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := recipient.Export(exporterContext, length)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(value, other) {
+		t.Fatal("sender and recipient export different secrets")
+	}
+	return value
+}
+
+// drawRandomInput reads a length byte and that many bytes.
+func drawRandomInput(t *testing.T, r io.Reader) []byte {
+	t.Helper()
+	b := make([]byte, 1)
+	if _, err := io.ReadFull(r, b); err != nil {
+		t.Fatal(err)
+	}
+	b = make([]byte, b[0])
+	if _, err := io.ReadFull(r, b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func readHex(r io.Reader) string {
+	b := make([]byte, 16)
+	io.ReadFull(r, b)
+	return hex.EncodeToString(b)
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+var allKEMs = []KEM{
+	DHKEMP256, DHKEMP384, DHKEMP521, DHKEMX25519,
+	MLKEM512, MLKEM768, MLKEM1024,
+	MLKEM768P256, MLKEM1024P384, MLKEM768X25519,
+	NewCustomKEM(0xff00, gokyber.Kyber768),
+	NewCustomKEM(0xff01, gokyber.MLKEM768P256),
+}
+
+var (
+	testPSK   = []byte("0123456789abcdef0123456789abcdef")
+	testPSKID = []byte("psk id")
+)
+
+func TestModes(t *testing.T) {
+	info, aad, plaintext := []byte("info"), []byte("aad"), []byte("plaintext")
+	for _, kem := range allKEMs {
+		for _, aead := range []*AEAD{AES128GCM, AES256GCM, ChaCha20Poly1305} {
+			suite := NewSuite(kem, HKDFSHA384, aead)
+			privateKey, publicKey, err := kem.GenerateKeyPair()
+			if err != nil {
+				t.Fatal(err)
+			}
+			senderPrivateKey, senderPublicKey, _ := kem.GenerateKeyPair()
+			_, auth := kem.(AuthKEM)
+
+			type setup struct {
+				name string
+				s    func() ([]byte, *Sender, error)
+				r    func(enc []byte) (*Recipient, error)
+			}
+			setups := []setup{
+				{"base", func() ([]byte, *Sender, error) { return suite.SetupBaseS(publicKey, info) },
+					func(enc []byte) (*Recipient, error) { return suite.SetupBaseR(enc, privateKey, info) }},
+				{"psk", func() ([]byte, *Sender, error) { return suite.SetupPSKS(publicKey, info, testPSK, testPSKID) },
+					func(enc []byte) (*Recipient, error) {
+						return suite.SetupPSKR(enc, privateKey, info, testPSK, testPSKID)
+					}},
+				{"auth", func() ([]byte, *Sender, error) { return suite.SetupAuthS(publicKey, info, senderPrivateKey) },
+					func(enc []byte) (*Recipient, error) { return suite.SetupAuthR(enc, privateKey, info, senderPublicKey) }},
+				{"authpsk", func() ([]byte, *Sender, error) {
+					return suite.SetupAuthPSKS(publicKey, info, testPSK, testPSKID, senderPrivateKey)
+				}, func(enc []byte) (*Recipient, error) {
+					return suite.SetupAuthPSKR(enc, privateKey, info, testPSK, testPSKID, senderPublicKey)
+				}},
+			}
+			for _, setup := range setups {
+				name := fmt.Sprintf("kem=%04x/aead=%04x/%s", kem.ID(), aead.ID, setup.name)
+				enc, sender, err := setup.s()
+				if !auth && (setup.name == "auth" || setup.name == "authpsk") {
+					if err == nil {
+						t.Errorf("%s: sender authentication without an AuthKEM", name)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+				recipient, err := setup.r(enc)
+				if err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+				for i := 0; i < 3; i++ {
+					ciphertext, err := sender.Seal(aad, plaintext)
+					if err != nil {
+						t.Fatal(err)
+					}
+					opened, err := recipient.Open(aad, ciphertext)
+					if err != nil || !bytes.Equal(opened, plaintext) {
+						t.Fatalf("%s: message %d does not open: %v", name, i, err)
+					}
+				}
+				exportBoth(t, sender, recipient, []byte("context"), 42)
+			}
+		}
+	}
+}
+
+func TestModeMismatch(t *testing.T) {
+	suite := NewSuite(DHKEMX25519, HKDFSHA256, AES128GCM)
+	privateKey, publicKey, _ := suite.KEM.GenerateKeyPair()
+	senderPrivateKey, senderPublicKey, _ := suite.KEM.GenerateKeyPair()
+	_, otherPublicKey, _ := suite.KEM.GenerateKeyPair()
+	info, plaintext := []byte("info"), []byte("plaintext")
+
+	enc, sender, err := suite.SetupAuthPSKS(publicKey, info, testPSK, testPSKID, senderPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, _ := sender.Seal(nil, plaintext)
+	otherPSK := bytes.Clone(testPSK)
+	otherPSK[0] ^= 1
+	for name, open := range map[string]func() (*Recipient, error){
+		"base": func() (*Recipient, error) { return suite.SetupBaseR(enc, privateKey, info) },
+		"auth": func() (*Recipient, error) { return suite.SetupAuthR(enc, privateKey, info, senderPublicKey) },
+		"wrong info": func() (*Recipient, error) {
+			return suite.SetupAuthPSKR(enc, privateKey, nil, testPSK, testPSKID, senderPublicKey)
+		},
+		"wrong psk": func() (*Recipient, error) {
+			return suite.SetupAuthPSKR(enc, privateKey, info, otherPSK, testPSKID, senderPublicKey)
+		},
+		"wrong psk id": func() (*Recipient, error) {
+			return suite.SetupAuthPSKR(enc, privateKey, info, testPSK, []byte("x"), senderPublicKey)
+		},
+		"wrong sender": func() (*Recipient, error) {
+			return suite.SetupAuthPSKR(enc, privateKey, info, testPSK, testPSKID, otherPublicKey)
+		},
+	} {
+		recipient, err := open()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, err := recipient.Open(nil, ciphertext); err == nil {
+			t.Errorf("%s: message opened", name)
+		}
+	}
+
+	if _, _, err := suite.SetupPSKS(publicKey, info, testPSK[:16], testPSKID); err == nil {
+		t.Error("short PSK accepted")
+	}
+	if _, _, err := suite.SetupPSKS(publicKey, info, testPSK, nil); err == nil {
+		t.Error("PSK without identifier accepted")
+	}
+	if _, _, err := suite.SetupPSKS(publicKey, info, nil, nil); err == nil {
+		t.Error("PSK mode without PSK accepted")
+	}
+	if _, _, err := suite.SetupAuthS(publicKey, info, nil); err == nil {
+		t.Error("Auth mode without sender key accepted")
+	}
+}
+
+func TestOrdering(t *testing.T) {
+	suite := NewSuite(MLKEM768X25519, HKDFSHA256, ChaCha20Poly1305)
+	privateKey, publicKey, _ := suite.KEM.GenerateKeyPair()
+	enc, sender, err := suite.SetupBaseS(publicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient, err := suite.SetupBaseR(enc, privateKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := sender.Seal(nil, []byte("first"))
+	second, _ := sender.Seal(nil, []byte("second"))
+	if _, err := recipient.Open(nil, second); err == nil {
+		t.Fatal("second message opened first")
+	}
+	// The failure did not advance the context.
+	if _, err := recipient.Open(nil, first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recipient.Open(nil, second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSingleShot(t *testing.T) {
+	suite, err := SuiteByID(0x0051, 0x0002, 0x0002)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey, publicKey, _ := suite.KEM.GenerateKeyPair()
+	enc, ciphertext, err := suite.Seal(publicKey, []byte("info"), []byte("aad"), []byte("plaintext"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := suite.Open(enc, privateKey, []byte("info"), []byte("aad"), ciphertext)
+	if err != nil || string(plaintext) != "plaintext" {
+		t.Fatalf("Open: %q, %v", plaintext, err)
+	}
+	enc[0] ^= 1
+	if _, err := suite.Open(enc, privateKey, []byte("info"), []byte("aad"), ciphertext); err == nil {
+		t.Error("modified encapsulated key accepted")
+	}
+}
+
+func TestXWingInterop(t *testing.T) {
+	seed := bytes.Repeat([]byte{7}, 32)
+	privateKey, publicKey, err := gokyber.XWing.KemKeypairFromSeed(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hpkePrivateKey, hpkePublicKey, err := MLKEM768X25519.keyPair(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(privateKey, hpkePrivateKey) || !bytes.Equal(publicKey, hpkePublicKey) {
+		t.Fatal("key pairs differ")
+	}
+	ciphertext, sharedSecret, err := gokyber.XWing.KemEncrypt(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decapsulated, err := MLKEM768X25519.Decap(ciphertext, hpkePrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decapsulated, sharedSecret) {
+		t.Error("shared secrets differ")
+	}
+}
+
+func TestCustomKEMDeriveKeyPair(t *testing.T) {
+	ikm := bytes.Repeat([]byte{1}, 32)
+	kyber := NewCustomKEM(0xff00, gokyber.Kyber768)
+	a, _, err := kyber.DeriveKeyPair(ikm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _, _ := kyber.DeriveKeyPair(ikm)
+	if !bytes.Equal(a, b) {
+		t.Error("key pair derivation is not deterministic")
+	}
+	if _, _, err := NewCustomKEM(0xff01, gokyber.MLKEM768P256).DeriveKeyPair(ikm); err == nil {
+		t.Error("derived a key pair without seeds")
+	}
+}
@@ -0,0 +1,88 @@
+package hpke
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"hash"
+
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/sha3"
+)
+
+// KDF is the key derivation function of a suite, HKDF with a given hash.
+type KDF struct {
+	// ID is the HPKE KDF identifier.
+	ID uint16
+	// Name is the name of the KDF in the HPKE registry.
+	Name string
+	// Hash is the hash function of HKDF.
+	Hash func() hash.Hash
+}
+
+var (
+	// HKDFSHA256 is HKDF with SHA-256.
+	HKDFSHA256 = &KDF{ID: 0x0001, Name: "HKDF-SHA256", Hash: sha256.New}
+	// HKDFSHA384 is HKDF with SHA-384.
+	HKDFSHA384 = &KDF{ID: 0x0002, Name: "HKDF-SHA384", Hash: sha512.New384}
+	// HKDFSHA512 is HKDF with SHA-512.
+	HKDFSHA512 = &KDF{ID: 0x0003, Name: "HKDF-SHA512", Hash: sha512.New}
+)
+
+// KDFByID returns the KDF with an HPKE identifier.
+func KDFByID(id uint16) (*KDF, error) {
+	for _, kdf := range []*KDF{HKDFSHA256, HKDFSHA384, HKDFSHA512} {
+		if kdf.ID == id {
+			return kdf, nil
+		}
+	}
+	return nil, errors.New("unsupported KDF")
+}
+
+// Size returns Nh, the output length of the hash function.
+func (kdf *KDF) Size() int {
+	return kdf.Hash().Size()
+}
+
+// labeledExtract is LabeledExtract of RFC 9180, section 4.
+func (kdf *KDF) labeledExtract(suiteID, salt []byte, label string, ikm []byte) []byte {
+	labeledIKM := append([]byte(versionLabel), suiteID...)
+	labeledIKM = append(labeledIKM, label...)
+	labeledIKM = append(labeledIKM, ikm...)
+	return hkdf.Extract(kdf.Hash, labeledIKM, salt)
+}
+
+// labeledExpand is LabeledExpand of RFC 9180, section 4.
+func (kdf *KDF) labeledExpand(suiteID, prk []byte, label string, info []byte, length int) ([]byte, error) {
+	if length > 255*kdf.Size() || length > 0xffff {
+		return nil, errors.New("requested output too long")
+	}
+	labeledInfo := binary.BigEndian.AppendUint16(nil, uint16(length))
+	labeledInfo = append(labeledInfo, versionLabel...)
+	labeledInfo = append(labeledInfo, suiteID...)
+	labeledInfo = append(labeledInfo, label...)
+	labeledInfo = append(labeledInfo, info...)
+	out := make([]byte, length)
+	if _, err := hkdf.Expand(kdf.Hash, prk, labeledInfo).Read(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// shakeDerive is LabeledDerive with SHAKE-256, the one-stage KDF that
+// draft-ietf-hpke-pq uses to derive post-quantum key pairs, independently of
+// the KDF of the suite.
+func shakeDerive(suiteID, ikm []byte, label string, context []byte, length int) []byte {
+	h := sha3.NewShake256()
+	h.Write(ikm)
+	h.Write([]byte(versionLabel))
+	h.Write(suiteID)
+	h.Write(binary.BigEndian.AppendUint16(nil, uint16(len(label))))
+	h.Write([]byte(label))
+	h.Write(binary.BigEndian.AppendUint16(nil, uint16(length)))
+	h.Write(context)
+	out := make([]byte, length)
+	h.Read(out)
+	return out
+}
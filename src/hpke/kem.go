@@ -0,0 +1,274 @@
+package hpke
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+)
+
+// KEM is the key encapsulation mechanism of a suite. Keys are byte strings
+// in the serialization of the KEM, as in package gokyber: the encapsulated
+// key `enc` is the KEM ciphertext.
+type KEM interface {
+	// ID returns the HPKE KEM identifier.
+	ID() uint16
+	// PublicKeyBytes returns Npk, the length of public keys.
+	PublicKeyBytes() int
+	// PrivateKeyBytes returns Nsk, the length of private keys.
+	PrivateKeyBytes() int
+	// EncBytes returns Nenc, the length of encapsulated keys.
+	EncBytes() int
+	// SharedSecretBytes returns Nsecret, the length of shared secrets.
+	SharedSecretBytes() int
+	// GenerateKeyPair returns a fresh private key and its public key.
+	GenerateKeyPair() (privateKey, publicKey []byte, err error)
+	// DeriveKeyPair deterministically derives a key pair from input keying
+	// material, which must have at least Nsk bytes of entropy.
+	DeriveKeyPair(ikm []byte) (privateKey, publicKey []byte, err error)
+	// Encap encapsulates a fresh shared secret to a public key.
+	Encap(publicKey []byte) (enc, sharedSecret []byte, err error)
+	// Decap returns the shared secret encapsulated in enc.
+	Decap(enc, privateKey []byte) ([]byte, error)
+}
+
+// AuthKEM is a KEM that can also authenticate the sender, as the DHKEMs
+// can. Post-quantum KEMs cannot, so the Auth and AuthPSK modes require a
+// DHKEM.
+type AuthKEM interface {
+	KEM
+	// AuthEncap encapsulates a fresh shared secret to a public key that only
+	// the holder of the sender private key could have produced.
+	AuthEncap(publicKey, senderPrivateKey []byte) (enc, sharedSecret []byte, err error)
+	// AuthDecap returns the shared secret encapsulated in enc by the holder
+	// of the private key matching the sender public key.
+	AuthDecap(enc, privateKey, senderPublicKey []byte) ([]byte, error)
+}
+
+// derandomized is implemented by the KEMs whose encapsulation is a
+// deterministic function of ephemeral input keying material, as in the
+// test vectors. Encap draws ephemeralBytes random bytes.
+type derandomized interface {
+	ephemeralBytes() int
+	encapDerand(publicKey, ikmE []byte) (enc, sharedSecret []byte, err error)
+}
+
+// KEMByID returns the built-in KEM with an HPKE identifier.
+func KEMByID(id uint16) (KEM, error) {
+	for _, kem := range []KEM{
+		DHKEMP256, DHKEMP384, DHKEMP521, DHKEMX25519,
+		MLKEM512, MLKEM768, MLKEM1024,
+		MLKEM768P256, MLKEM1024P384, MLKEM768X25519,
+	} {
+		if kem.ID() == id {
+			return kem, nil
+		}
+	}
+	return nil, errors.New("unsupported KEM")
+}
+
+// kemSuiteID is the suite_id of the KEM functions, "KEM" followed by the
+// KEM identifier.
+func kemSuiteID(id uint16) []byte {
+	return binary.BigEndian.AppendUint16([]byte("KEM"), id)
+}
+
+// DHKEM is the Diffie-Hellman based KEM of RFC 9180, section 4.1, on a
+// curve of crypto/ecdh. Public keys are encoded as by crypto/ecdh (an
+// uncompressed point for the NIST curves) and private keys are scalars.
+type DHKEM struct {
+	id    uint16
+	curve ecdh.Curve
+	kdf   *KDF
+	// secretBytes is Nsecret, privateKeyBytes Nsk and publicKeyBytes Npk.
+	secretBytes, privateKeyBytes, publicKeyBytes int
+	// bitmask is applied to the first byte of candidate scalars in
+	// DeriveKeyPair; zero for X25519, which takes any string.
+	bitmask byte
+}
+
+var (
+	// DHKEMP256 is DHKEM(P-256, HKDF-SHA256).
+	DHKEMP256 = &DHKEM{id: 0x0010, curve: ecdh.P256(), kdf: HKDFSHA256, secretBytes: 32, privateKeyBytes: 32, publicKeyBytes: 65, bitmask: 0xff}
+	// DHKEMP384 is DHKEM(P-384, HKDF-SHA384).
+	DHKEMP384 = &DHKEM{id: 0x0011, curve: ecdh.P384(), kdf: HKDFSHA384, secretBytes: 48, privateKeyBytes: 48, publicKeyBytes: 97, bitmask: 0xff}
+	// DHKEMP521 is DHKEM(P-521, HKDF-SHA512).
+	DHKEMP521 = &DHKEM{id: 0x0012, curve: ecdh.P521(), kdf: HKDFSHA512, secretBytes: 64, privateKeyBytes: 66, publicKeyBytes: 133, bitmask: 0x01}
+	// DHKEMX25519 is DHKEM(X25519, HKDF-SHA256).
+	DHKEMX25519 = &DHKEM{id: 0x0020, curve: ecdh.X25519(), kdf: HKDFSHA256, secretBytes: 32, privateKeyBytes: 32, publicKeyBytes: 32}
+)
+
+var _ AuthKEM = DHKEMP256
+
+// ID returns the HPKE KEM identifier.
+func (k *DHKEM) ID() uint16 { return k.id }
+
+// PublicKeyBytes returns the length of public keys.
+func (k *DHKEM) PublicKeyBytes() int { return k.publicKeyBytes }
+
+// PrivateKeyBytes returns the length of private keys.
+func (k *DHKEM) PrivateKeyBytes() int { return k.privateKeyBytes }
+
+// EncBytes returns the length of encapsulated keys, ephemeral public keys.
+func (k *DHKEM) EncBytes() int { return k.publicKeyBytes }
+
+// SharedSecretBytes returns the length of shared secrets.
+func (k *DHKEM) SharedSecretBytes() int { return k.secretBytes }
+
+// GenerateKeyPair returns a fresh key pair.
+func (k *DHKEM) GenerateKeyPair() ([]byte, []byte, error) {
+	privateKey, err := k.curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return privateKey.Bytes(), privateKey.PublicKey().Bytes(), nil
+}
+
+// DeriveKeyPair derives a key pair as in RFC 9180, section 7.1.3.
+func (k *DHKEM) DeriveKeyPair(ikm []byte) ([]byte, []byte, error) {
+	privateKey, err := k.derivePrivateKey(ikm)
+	if err != nil {
+		return nil, nil, err
+	}
+	return privateKey.Bytes(), privateKey.PublicKey().Bytes(), nil
+}
+
+func (k *DHKEM) derivePrivateKey(ikm []byte) (*ecdh.PrivateKey, error) {
+	suiteID := kemSuiteID(k.id)
+	prk := k.kdf.labeledExtract(suiteID, nil, "dkp_prk", ikm)
+	if k.bitmask == 0 {
+		sk, err := k.kdf.labeledExpand(suiteID, prk, "sk", nil, k.privateKeyBytes)
+		if err != nil {
+			return nil, err
+		}
+		return k.curve.NewPrivateKey(sk)
+	}
+	// Rejection sampling of a scalar in [1, n).
+	for counter := 0; counter < 256; counter++ {
+		candidate, err := k.kdf.labeledExpand(suiteID, prk, "candidate", []byte{byte(counter)}, k.privateKeyBytes)
+		if err != nil {
+			return nil, err
+		}
+		candidate[0] &= k.bitmask
+		if privateKey, err := k.curve.NewPrivateKey(candidate); err == nil {
+			return privateKey, nil
+		}
+	}
+	return nil, errors.New("key pair derivation failed")
+}
+
+// Encap encapsulates a fresh shared secret to a public key.
+func (k *DHKEM) Encap(publicKey []byte) ([]byte, []byte, error) {
+	return k.encap(publicKey, nil)
+}
+
+// AuthEncap encapsulates a fresh shared secret to a public key, from the
+// holder of a sender private key.
+func (k *DHKEM) AuthEncap(publicKey, senderPrivateKey []byte) ([]byte, []byte, error) {
+	if senderPrivateKey == nil {
+		return nil, nil, errors.New("missing sender private key")
+	}
+	return k.encap(publicKey, senderPrivateKey)
+}
+
+func (k *DHKEM) encap(publicKey, senderPrivateKey []byte) ([]byte, []byte, error) {
+	ikmE := make([]byte, k.ephemeralBytes())
+	if _, err := rand.Read(ikmE); err != nil {
+		return nil, nil, err
+	}
+	return k.authEncapDerand(publicKey, senderPrivateKey, ikmE)
+}
+
+func (k *DHKEM) ephemeralBytes() int {
+	return k.privateKeyBytes
+}
+
+func (k *DHKEM) encapDerand(publicKey, ikmE []byte) ([]byte, []byte, error) {
+	return k.authEncapDerand(publicKey, nil, ikmE)
+}
+
+// authEncapDerand derives the ephemeral key pair from ikmE. Without a sender
+// private key, it is Encap; with one, AuthEncap.
+func (k *DHKEM) authEncapDerand(publicKey, senderPrivateKey, ikmE []byte) ([]byte, []byte, error) {
+	recipient, err := k.curve.NewPublicKey(publicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	ephemeral, err := k.derivePrivateKey(ikmE)
+	if err != nil {
+		return nil, nil, err
+	}
+	dh, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return nil, nil, err
+	}
+	enc := ephemeral.PublicKey().Bytes()
+	kemContext := append(append([]byte{}, enc...), publicKey...)
+	if senderPrivateKey != nil {
+		sender, err := k.curve.NewPrivateKey(senderPrivateKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		dhStatic, err := sender.ECDH(recipient)
+		if err != nil {
+			return nil, nil, err
+		}
+		dh = append(dh, dhStatic...)
+		kemContext = append(kemContext, sender.PublicKey().Bytes()...)
+	}
+	sharedSecret, err := k.extractAndExpand(dh, kemContext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, sharedSecret, nil
+}
+
+// Decap returns the shared secret encapsulated in enc.
+func (k *DHKEM) Decap(enc, privateKey []byte) ([]byte, error) {
+	return k.decap(enc, privateKey, nil)
+}
+
+// AuthDecap returns the shared secret encapsulated in enc by the holder of
+// the private key matching a sender public key.
+func (k *DHKEM) AuthDecap(enc, privateKey, senderPublicKey []byte) ([]byte, error) {
+	if senderPublicKey == nil {
+		return nil, errors.New("missing sender public key")
+	}
+	return k.decap(enc, privateKey, senderPublicKey)
+}
+
+func (k *DHKEM) decap(enc, privateKey, senderPublicKey []byte) ([]byte, error) {
+	ephemeral, err := k.curve.NewPublicKey(enc)
+	if err != nil {
+		return nil, err
+	}
+	recipient, err := k.curve.NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	dh, err := recipient.ECDH(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	kemContext := append(append([]byte{}, enc...), recipient.PublicKey().Bytes()...)
+	if senderPublicKey != nil {
+		sender, err := k.curve.NewPublicKey(senderPublicKey)
+		if err != nil {
+			return nil, err
+		}
+		dhStatic, err := recipient.ECDH(sender)
+		if err != nil {
+			return nil, err
+		}
+		dh = append(dh, dhStatic...)
+		kemContext = append(kemContext, senderPublicKey...)
+	}
+	return k.extractAndExpand(dh, kemContext)
+}
+
+// extractAndExpand is ExtractAndExpand of RFC 9180, section 4.1.
+func (k *DHKEM) extractAndExpand(dh, kemContext []byte) ([]byte, error) {
+	suiteID := kemSuiteID(k.id)
+	prk := k.kdf.labeledExtract(suiteID, nil, "eae_prk", dh)
+	return k.kdf.labeledExpand(suiteID, prk, "shared_secret", kemContext, k.secretBytes)
+}
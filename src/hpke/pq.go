@@ -0,0 +1,336 @@
+package hpke
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+	"golang.org/x/crypto/sha3"
+)
+
+// MLKEM is ML-KEM used directly as an HPKE KEM, as in draft-ietf-hpke-pq.
+// Private keys are the 64-byte seeds of gokyber's KemKeypairFromSeed, which
+// are expanded on every decapsulation; public keys and encapsulated keys
+// are ML-KEM public keys and ciphertexts.
+type MLKEM struct {
+	id     uint16
+	params *gokyber.ParameterSet
+}
+
+var (
+	// MLKEM512 is ML-KEM-512.
+	MLKEM512 = &MLKEM{id: 0x0040, params: gokyber.MLKEM512}
+	// MLKEM768 is ML-KEM-768.
+	MLKEM768 = &MLKEM{id: 0x0041, params: gokyber.MLKEM768}
+	// MLKEM1024 is ML-KEM-1024.
+	MLKEM1024 = &MLKEM{id: 0x0042, params: gokyber.MLKEM1024}
+)
+
+// ID returns the HPKE KEM identifier.
+func (k *MLKEM) ID() uint16 { return k.id }
+
+// PublicKeyBytes returns the length of public keys.
+func (k *MLKEM) PublicKeyBytes() int { return k.params.PublicKeyBytes() }
+
+// PrivateKeyBytes returns the length of private keys, seeds.
+func (k *MLKEM) PrivateKeyBytes() int { return k.params.SeedBytes() }
+
+// EncBytes returns the length of encapsulated keys, ML-KEM ciphertexts.
+func (k *MLKEM) EncBytes() int { return k.params.CiphertextBytes() }
+
+// SharedSecretBytes returns the length of shared secrets.
+func (k *MLKEM) SharedSecretBytes() int { return k.params.SharedSecretBytes() }
+
+// GenerateKeyPair returns a fresh key pair.
+func (k *MLKEM) GenerateKeyPair() ([]byte, []byte, error) {
+	seed := make([]byte, k.params.SeedBytes())
+	if _, err := rand.Read(seed); err != nil {
+		return nil, nil, err
+	}
+	return k.keyPair(seed)
+}
+
+// DeriveKeyPair derives the seed of a key pair with SHAKE-256.
+func (k *MLKEM) DeriveKeyPair(ikm []byte) ([]byte, []byte, error) {
+	return k.keyPair(shakeDerive(kemSuiteID(k.id), ikm, "DeriveKeyPair", nil, k.params.SeedBytes()))
+}
+
+func (k *MLKEM) keyPair(seed []byte) ([]byte, []byte, error) {
+	_, publicKey, err := k.params.KemKeypairFromSeed(seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return seed, publicKey, nil
+}
+
+// Encap encapsulates a fresh shared secret to a public key.
+func (k *MLKEM) Encap(publicKey []byte) ([]byte, []byte, error) {
+	m := make([]byte, k.ephemeralBytes())
+	if _, err := rand.Read(m); err != nil {
+		return nil, nil, err
+	}
+	return k.encapDerand(publicKey, m)
+}
+
+func (k *MLKEM) ephemeralBytes() int {
+	return 32
+}
+
+func (k *MLKEM) encapDerand(publicKey, ikmE []byte) ([]byte, []byte, error) {
+	return k.params.KemEncryptFromSeed(publicKey, ikmE)
+}
+
+// Decap returns the shared secret encapsulated in enc.
+func (k *MLKEM) Decap(enc, privateKey []byte) ([]byte, error) {
+	expanded, _, err := k.params.KemKeypairFromSeed(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return k.params.KemDecrypt(enc, expanded)
+}
+
+// HybridKEM is one of the hybrids of ML-KEM and ECDH of draft-ietf-hpke-pq,
+// MLKEM768-P256, MLKEM1024-P384 and MLKEM768-X25519 (X-Wing). Private keys
+// are 32-byte seeds, expanded with SHAKE-256 into an ML-KEM seed followed by
+// candidate ECDH scalars; public keys are the ML-KEM public key followed by
+// the ECDH public key, and encapsulated keys likewise. The shared secret is
+//
+//	SHA3-256(ss_mlkem || ss_ecdh || ct_ecdh || pk_ecdh || label)
+//
+// This is not the combiner of gokyber.HybridKEM, which also binds the ML-KEM
+// ciphertext and public key, so the two do not interoperate.
+type HybridKEM struct {
+	id    uint16
+	label string
+	mlkem *gokyber.ParameterSet
+	curve ecdh.Curve
+	// seedBytes is the length of candidate scalars and publicKeyBytes that
+	// of ECDH public keys.
+	seedBytes, publicKeyBytes int
+}
+
+var (
+	// MLKEM768P256 is ML-KEM-768 with ECDH on P-256.
+	MLKEM768P256 = &HybridKEM{id: 0x0050, label: "MLKEM768-P256", mlkem: gokyber.MLKEM768, curve: ecdh.P256(), seedBytes: 32, publicKeyBytes: 65}
+	// MLKEM1024P384 is ML-KEM-1024 with ECDH on P-384.
+	MLKEM1024P384 = &HybridKEM{id: 0x0051, label: "MLKEM1024-P384", mlkem: gokyber.MLKEM1024, curve: ecdh.P384(), seedBytes: 48, publicKeyBytes: 97}
+	// MLKEM768X25519 is X-Wing, ML-KEM-768 with X25519. It interoperates
+	// with gokyber.XWing.
+	MLKEM768X25519 = &HybridKEM{id: 0x647a, label: "\\./" + "/^\\", mlkem: gokyber.MLKEM768, curve: ecdh.X25519(), seedBytes: 32, publicKeyBytes: 32}
+)
+
+const hybridSeedBytes = 32
+
+// errNoCandidate is returned when no candidate ECDH scalar is valid.
+var errNoCandidate = errors.New("no valid ECDH scalar")
+
+// ID returns the HPKE KEM identifier.
+func (k *HybridKEM) ID() uint16 { return k.id }
+
+// PublicKeyBytes returns the length of public keys.
+func (k *HybridKEM) PublicKeyBytes() int { return k.mlkem.PublicKeyBytes() + k.publicKeyBytes }
+
+// PrivateKeyBytes returns the length of private keys, seeds.
+func (k *HybridKEM) PrivateKeyBytes() int { return hybridSeedBytes }
+
+// EncBytes returns the length of encapsulated keys.
+func (k *HybridKEM) EncBytes() int { return k.mlkem.CiphertextBytes() + k.publicKeyBytes }
+
+// SharedSecretBytes returns the length of shared secrets.
+func (k *HybridKEM) SharedSecretBytes() int { return 32 }
+
+// GenerateKeyPair returns a fresh key pair.
+func (k *HybridKEM) GenerateKeyPair() ([]byte, []byte, error) {
+	seed := make([]byte, hybridSeedBytes)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, nil, err
+	}
+	return k.keyPair(seed)
+}
+
+// DeriveKeyPair derives the seed of a key pair with SHAKE-256.
+func (k *HybridKEM) DeriveKeyPair(ikm []byte) ([]byte, []byte, error) {
+	return k.keyPair(shakeDerive(kemSuiteID(k.id), ikm, "DeriveKeyPair", nil, hybridSeedBytes))
+}
+
+func (k *HybridKEM) keyPair(seed []byte) ([]byte, []byte, error) {
+	_, mlkemPublicKey, ecdhPrivateKey, err := k.expandPrivateKey(seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return seed, append(mlkemPublicKey, ecdhPrivateKey.PublicKey().Bytes()...), nil
+}
+
+// expandPrivateKey derives the component key pairs from a seed.
+func (k *HybridKEM) expandPrivateKey(seed []byte) ([]byte, []byte, *ecdh.PrivateKey, error) {
+	if len(seed) != hybridSeedBytes {
+		return nil, nil, nil, errors.New("invalid private key length")
+	}
+	xof := sha3.NewShake256()
+	xof.Write(seed)
+	mlkemSeed := make([]byte, k.mlkem.SeedBytes())
+	xof.Read(mlkemSeed)
+	mlkemPrivateKey, mlkemPublicKey, err := k.mlkem.KemKeypairFromSeed(mlkemSeed)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	candidate := make([]byte, k.seedBytes)
+	for {
+		xof.Read(candidate)
+		if ecdhPrivateKey, err := k.curve.NewPrivateKey(candidate); err == nil {
+			return mlkemPrivateKey, mlkemPublicKey, ecdhPrivateKey, nil
+		}
+	}
+}
+
+// Encap encapsulates a fresh shared secret to a public key.
+func (k *HybridKEM) Encap(publicKey []byte) ([]byte, []byte, error) {
+	ikmE := make([]byte, k.ephemeralBytes())
+	for {
+		if _, err := rand.Read(ikmE); err != nil {
+			return nil, nil, err
+		}
+		enc, sharedSecret, err := k.encapDerand(publicKey, ikmE)
+		if err != errNoCandidate {
+			return enc, sharedSecret, err
+		}
+	}
+}
+
+// ephemeralBytes is the length of the ML-KEM randomness and one candidate
+// scalar, which is almost always valid.
+func (k *HybridKEM) ephemeralBytes() int {
+	return 32 + k.seedBytes
+}
+
+// encapDerand takes the 32 bytes of ML-KEM randomness followed by candidate
+// ephemeral scalars, the first valid of which is used.
+func (k *HybridKEM) encapDerand(publicKey, ikmE []byte) ([]byte, []byte, error) {
+	if len(publicKey) != k.PublicKeyBytes() {
+		return nil, nil, errors.New("invalid public key length")
+	}
+	if len(ikmE) < 32 {
+		return nil, nil, errors.New("invalid ephemeral input length")
+	}
+	mlkemPublicKey, ecdhPublicKey := publicKey[:k.mlkem.PublicKeyBytes()], publicKey[k.mlkem.PublicKeyBytes():]
+	recipient, err := k.curve.NewPublicKey(ecdhPublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	var ephemeral *ecdh.PrivateKey
+	for candidates := ikmE[32:]; ephemeral == nil; candidates = candidates[k.seedBytes:] {
+		if len(candidates) < k.seedBytes {
+			return nil, nil, errNoCandidate
+		}
+		ephemeral, _ = k.curve.NewPrivateKey(candidates[:k.seedBytes])
+	}
+	mlkemCiphertext, mlkemSharedSecret, err := k.mlkem.KemEncryptFromSeed(mlkemPublicKey, ikmE[:32])
+	if err != nil {
+		return nil, nil, err
+	}
+	ecdhSharedSecret, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return nil, nil, err
+	}
+	ecdhCiphertext := ephemeral.PublicKey().Bytes()
+	sharedSecret := k.combine(mlkemSharedSecret, ecdhSharedSecret, ecdhCiphertext, ecdhPublicKey)
+	return append(mlkemCiphertext, ecdhCiphertext...), sharedSecret, nil
+}
+
+// Decap returns the shared secret encapsulated in enc.
+func (k *HybridKEM) Decap(enc, privateKey []byte) ([]byte, error) {
+	if len(enc) != k.EncBytes() {
+		return nil, errors.New("invalid encapsulated key length")
+	}
+	mlkemPrivateKey, _, ecdhPrivateKey, err := k.expandPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	mlkemCiphertext, ecdhCiphertext := enc[:k.mlkem.CiphertextBytes()], enc[k.mlkem.CiphertextBytes():]
+	mlkemSharedSecret, err := k.mlkem.KemDecrypt(mlkemCiphertext, mlkemPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	ephemeral, err := k.curve.NewPublicKey(ecdhCiphertext)
+	if err != nil {
+		return nil, err
+	}
+	ecdhSharedSecret, err := ecdhPrivateKey.ECDH(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	return k.combine(mlkemSharedSecret, ecdhSharedSecret, ecdhCiphertext, ecdhPrivateKey.PublicKey().Bytes()), nil
+}
+
+func (k *HybridKEM) combine(mlkemSharedSecret, ecdhSharedSecret, ecdhCiphertext, ecdhPublicKey []byte) []byte {
+	h := sha3.New256()
+	h.Write(mlkemSharedSecret)
+	h.Write(ecdhSharedSecret)
+	h.Write(ecdhCiphertext)
+	h.Write(ecdhPublicKey)
+	h.Write([]byte(k.label))
+	return h.Sum(nil)
+}
+
+// CustomKEM uses any KEM of package gokyber, such as a round-3 Kyber
+// parameter set or a gokyber.HybridKEM, under an identifier of the caller's
+// choice. These KEMs have no registered HPKE identifier, so both parties
+// must agree on one out of band, preferably from the private-use range.
+// DeriveKeyPair is only supported by KEMs with key pair seeds, such as
+// parameter sets.
+type CustomKEM struct {
+	id  uint16
+	kem gokyber.KEM
+}
+
+// seededKEM is a gokyber KEM whose key pairs derive from seeds.
+type seededKEM interface {
+	SeedBytes() int
+	KemKeypairFromSeed(seed []byte) ([]byte, []byte, error)
+}
+
+// NewCustomKEM returns a gokyber KEM under an HPKE KEM identifier.
+func NewCustomKEM(id uint16, kem gokyber.KEM) *CustomKEM {
+	return &CustomKEM{id: id, kem: kem}
+}
+
+// ID returns the HPKE KEM identifier.
+func (k *CustomKEM) ID() uint16 { return k.id }
+
+// PublicKeyBytes returns the length of public keys.
+func (k *CustomKEM) PublicKeyBytes() int { return k.kem.PublicKeyBytes() }
+
+// PrivateKeyBytes returns the length of private keys.
+func (k *CustomKEM) PrivateKeyBytes() int { return k.kem.PrivateKeyBytes() }
+
+// EncBytes returns the length of encapsulated keys, KEM ciphertexts.
+func (k *CustomKEM) EncBytes() int { return k.kem.CiphertextBytes() }
+
+// SharedSecretBytes returns the length of shared secrets.
+func (k *CustomKEM) SharedSecretBytes() int { return k.kem.SharedSecretBytes() }
+
+// GenerateKeyPair returns a fresh key pair.
+func (k *CustomKEM) GenerateKeyPair() ([]byte, []byte, error) {
+	return k.kem.KemKeypair()
+}
+
+// DeriveKeyPair derives the seed of a key pair with SHAKE-256, if the KEM
+// supports seeds.
+func (k *CustomKEM) DeriveKeyPair(ikm []byte) ([]byte, []byte, error) {
+	seeded, ok := k.kem.(seededKEM)
+	if !ok {
+		return nil, nil, errors.New("KEM does not support key pair derivation")
+	}
+	return seeded.KemKeypairFromSeed(shakeDerive(kemSuiteID(k.id), ikm, "DeriveKeyPair", nil, seeded.SeedBytes()))
+}
+
+// Encap encapsulates a fresh shared secret to a public key.
+func (k *CustomKEM) Encap(publicKey []byte) ([]byte, []byte, error) {
+	return k.kem.KemEncrypt(publicKey)
+}
+
+// Decap returns the shared secret encapsulated in enc.
+func (k *CustomKEM) Decap(enc, privateKey []byte) ([]byte, error) {
+	return k.kem.KemDecrypt(enc, privateKey)
+}
@@ -0,0 +1,35 @@
+package aeskw
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	// RFC 3394, sections 4.1, 4.3 and 4.6.
+	for _, tc := range []struct{ kek, key, wrapped string }{
+		{"000102030405060708090A0B0C0D0E0F", "00112233445566778899AABBCCDDEEFF", "1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5"},
+		{"000102030405060708090A0B0C0D0E0F1011121314151617", "00112233445566778899AABBCCDDEEFF0001020304050607", "031D33264E15D33268F24EC260743EDCE1C6C7DDEE725A936BA814915C6762D2"},
+		{"000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F", "00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F", "28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21"},
+	} {
+		kek, _ := hex.DecodeString(tc.kek)
+		key, _ := hex.DecodeString(tc.key)
+		want, _ := hex.DecodeString(tc.wrapped)
+		wrapped, err := Wrap(kek, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(wrapped, want) {
+			t.Errorf("wrapped %X, want %X", wrapped, want)
+		}
+		unwrapped, err := Unwrap(kek, wrapped)
+		if err != nil || !bytes.Equal(unwrapped, key) {
+			t.Errorf("unwrapped %X, %v", unwrapped, err)
+		}
+		wrapped[len(wrapped)-1] ^= 1
+		if _, err := Unwrap(kek, wrapped); err == nil {
+			t.Error("modified wrapped key accepted")
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Package bech32 implements the bech32 encoding of BIP 173, as used by age
+// for its recipients and identities, and its bech32m variant of BIP 350,
+// which fixes the insertion weakness of the bech32 checksum. Unlike BIP 173,
+// it does not limit the length of strings: Kyber keys are far longer than 90
+// characters, and the checksum still detects most errors in them, if with
+// weaker guarantees.
+package bech32
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+// The constants the checksums of the variants make polymod return.
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+func polymod(values []byte) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i, g := range generator {
+			if (top>>i)&1 == 1 {
+				chk ^= g
+			}
+		}
+	}
+	return chk
+}
+
+func hrpExpand(hrp string) []byte {
+	out := make([]byte, 0, 2*len(hrp)+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+func checksum(hrp string, data []byte, constant uint32) []byte {
+	values := append(hrpExpand(hrp), data...)
+	mod := polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ constant
+	out := make([]byte, 6)
+	for i := range out {
+		out[i] = byte(mod>>(5*(5-i))) & 31
+	}
+	return out
+}
+
+// convertBits regroups a sequence of frombits-bit values into tobits-bit
+// values. When decoding, padding must be at most 4 zero bits.
+func convertBits(data []byte, frombits, tobits uint, pad bool) ([]byte, error) {
+	var out []byte
+	acc, bits := uint32(0), uint(0)
+	maxv := uint32(1)<<tobits - 1
+	for _, v := range data {
+		if uint32(v)>>frombits != 0 {
+			return nil, errors.New("invalid data range")
+		}
+		acc = acc<<frombits | uint32(v)
+		bits += frombits
+		for bits >= tobits {
+			bits -= tobits
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(tobits-bits)&maxv))
+		}
+	} else if bits >= frombits || acc<<(tobits-bits)&maxv != 0 {
+		return nil, errors.New("invalid padding")
+	}
+	return out, nil
+}
+
+// Encode returns the bech32 encoding of data with a human-readable part,
+// in lower case unless hrp is in upper case.
+func Encode(hrp string, data []byte) (string, error) {
+	return encode(hrp, data, bech32Const)
+}
+
+// EncodeM is Encode with the bech32m checksum.
+func EncodeM(hrp string, data []byte) (string, error) {
+	return encode(hrp, data, bech32mConst)
+}
+
+func encode(hrp string, data []byte, constant uint32) (string, error) {
+	lower := strings.ToLower(hrp)
+	if hrp != lower && hrp != strings.ToUpper(hrp) {
+		return "", errors.New("mixed case human-readable part")
+	}
+	if len(hrp) == 0 {
+		return "", errors.New("empty human-readable part")
+	}
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", fmt.Errorf("invalid character %q in human-readable part", hrp[i])
+		}
+	}
+	values, err := convertBits(data, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(lower)
+	b.WriteByte('1')
+	for _, v := range append(values, checksum(lower, values, constant)...) {
+		b.WriteByte(charset[v])
+	}
+	if hrp != lower {
+		return strings.ToUpper(b.String()), nil
+	}
+	return b.String(), nil
+}
+
+// Decode returns the human-readable part, in lower case, and the data of a
+// bech32 string.
+func Decode(s string) (string, []byte, error) {
+	return decodeData(s, bech32Const)
+}
+
+// DecodeM is Decode for bech32m strings.
+func DecodeM(s string) (string, []byte, error) {
+	return decodeData(s, bech32mConst)
+}
+
+func decodeData(s string, constant uint32) (string, []byte, error) {
+	hrp, values, err := decode(s, constant)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := convertBits(values, 5, 8, false)
+	if err != nil {
+		return "", nil, err
+	}
+	return hrp, data, nil
+}
+
+// decode returns the human-readable part and the 5-bit values of a bech32
+// or bech32m string, checking its checksum.
+func decode(s string, constant uint32) (string, []byte, error) {
+	lower := strings.ToLower(s)
+	if s != lower && s != strings.ToUpper(s) {
+		return "", nil, errors.New("mixed case string")
+	}
+	pos := strings.LastIndexByte(lower, '1')
+	if pos < 1 || pos+7 > len(lower) {
+		return "", nil, errors.New("invalid separator position")
+	}
+	hrp := lower[:pos]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, fmt.Errorf("invalid character %q in human-readable part", hrp[i])
+		}
+	}
+	values := make([]byte, 0, len(lower)-pos-1)
+	for i := pos + 1; i < len(lower); i++ {
+		v := strings.IndexByte(charset, lower[i])
+		if v < 0 {
+			return "", nil, fmt.Errorf("invalid character %q in data part", lower[i])
+		}
+		values = append(values, byte(v))
+	}
+	if polymod(append(hrpExpand(hrp), values...)) != constant {
+		return "", nil, errors.New("invalid checksum")
+	}
+	return hrp, values[:len(values)-6], nil
+}
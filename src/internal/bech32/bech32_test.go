@@ -0,0 +1,116 @@
+package bech32
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestValidChecksums(t *testing.T) {
+	// BIP 173 test vectors.
+	for _, s := range []string{
+		"A12UEL5L",
+		"a12uel5l",
+		"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+		"?1ezyfcl",
+	} {
+		if _, _, err := decode(s, bech32Const); err != nil {
+			t.Errorf("%s: %v", s, err)
+		}
+		if _, _, err := decode(s, bech32mConst); err == nil {
+			t.Errorf("%s: accepted as bech32m", s)
+		}
+	}
+}
+
+func TestValidChecksumsM(t *testing.T) {
+	// BIP 350 test vectors.
+	for _, s := range []string{
+		"A1LQFN3A",
+		"a1lqfn3a",
+		"an83characterlonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11sg7hg6",
+		"abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx",
+		"11llllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllludsr8",
+		"split1checkupstagehandshakeupstreamerranterredcaperredlc445v",
+		"?1v759aa",
+	} {
+		if _, _, err := decode(s, bech32mConst); err != nil {
+			t.Errorf("%s: %v", s, err)
+		}
+		if _, _, err := decode(s, bech32Const); err == nil {
+			t.Errorf("%s: accepted as bech32", s)
+		}
+	}
+}
+
+func TestInvalidChecksums(t *testing.T) {
+	for _, s := range []string{
+		"pzry9x0s0muk",  // no separator
+		"1pzry9x0s0muk", // empty human-readable part
+		"x1b4n0q5v",     // invalid data character
+		"li1dgmt3",      // checksum too short
+		"A1G7SGD8",      // checksum computed with an upper case human-readable part
+		"10a06t8",       // empty human-readable part
+		"1qzzfhee",      // empty human-readable part
+		"a12UEL5L",      // mixed case
+		"\x201nwldj5",   // invalid human-readable part character
+	} {
+		if _, _, err := decode(s, bech32Const); err == nil {
+			t.Errorf("%q accepted", s)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, n := range []int{0, 1, 4, 5, 32, 1184} {
+		data := bytes.Repeat([]byte{0xa5, 0x3c, 0xff}, n)[:n]
+		for _, hrp := range []string{"age", "age1kyber768", "AGE-SECRET-KEY-KYBER768-", "kyber768pub"} {
+			for _, variant := range []struct {
+				encode        func(string, []byte) (string, error)
+				decode, other func(string) (string, []byte, error)
+			}{
+				{Encode, Decode, DecodeM},
+				{EncodeM, DecodeM, Decode},
+			} {
+				s, err := variant.encode(hrp, data)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if hrp == strings.ToUpper(hrp) && s != strings.ToUpper(s) {
+					t.Errorf("%s: not in upper case", s)
+				}
+				gotHRP, got, err := variant.decode(s)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if gotHRP != strings.ToLower(hrp) || !bytes.Equal(got, data) {
+					t.Errorf("%s: decoded to %q, %x", s, gotHRP, got)
+				}
+				if _, _, err := variant.other(s); err == nil {
+					t.Errorf("%s: accepted by the other variant", s)
+				}
+				modified := []byte(s)
+				modified[len(modified)/2+len(hrp)/2] ^= 'q' ^ 'p'
+				if _, _, err := variant.decode(string(modified)); err == nil {
+					t.Errorf("%s: modified string accepted", s)
+				}
+			}
+		}
+	}
+	if _, err := Encode("Age", nil); err == nil {
+		t.Error("mixed case human-readable part accepted")
+	}
+}
+
+func TestAgeIdentity(t *testing.T) {
+	// The identity from the age documentation, a key of 32 bytes of 0x42.
+	hrp, data, err := Decode("AGE-SECRET-KEY-1GFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPQ4EGAEX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hrp != "age-secret-key-" || !bytes.Equal(data, bytes.Repeat([]byte{0x42}, 32)) {
+		t.Errorf("decoded to %q, %x", hrp, data)
+	}
+}
@@ -0,0 +1,293 @@
+package jose
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func generate(t *testing.T, alg string) *JWK {
+	t.Helper()
+	key, err := GenerateJWK(alg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestJWK(t *testing.T) {
+	for _, a := range algorithms {
+		key := generate(t, a.name)
+		key.KeyID = "key-1"
+		data, err := json.Marshal(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fields map[string]string
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatal(err)
+		}
+		if fields["kty"] != "AKP" || fields["alg"] != a.name || fields["kid"] != "key-1" || fields["priv"] == "" {
+			t.Errorf("%s: JWK %s", a.name, data)
+		}
+		var parsed JWK
+		if err := json.Unmarshal(data, &parsed); err != nil {
+			t.Fatal(err)
+		}
+		if parsed.Algorithm != a.name || parsed.KeyID != "key-1" || !bytes.Equal(parsed.PublicKey, key.PublicKey) || !bytes.Equal(parsed.Seed, key.Seed) {
+			t.Errorf("%s: JWK does not round trip", a.name)
+		}
+
+		public, err := json.Marshal(key.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(public), "priv") {
+			t.Errorf("%s: public JWK %s", a.name, public)
+		}
+		if err := json.Unmarshal(public, &parsed); err != nil || parsed.Seed != nil {
+			t.Errorf("%s: public JWK: %v", a.name, err)
+		}
+	}
+}
+
+func TestMalformedJWK(t *testing.T) {
+	key := generate(t, MLKEM768)
+	other := generate(t, MLKEM768)
+	data, err := json.Marshal(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]string
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for name, modify := range map[string]func(map[string]string){
+		"key type":            func(m map[string]string) { m["kty"] = "OKP" },
+		"algorithm":           func(m map[string]string) { m["alg"] = "ECDH-ES" },
+		"algorithm of a size": func(m map[string]string) { m["alg"] = MLKEM1024 },
+		"missing public key":  func(m map[string]string) { delete(m, "pub") },
+		"short public key":    func(m map[string]string) { m["pub"] = m["pub"][:100] },
+		"padded private key":  func(m map[string]string) { m["priv"] += "==" },
+		"short private key":   func(m map[string]string) { m["priv"] = m["priv"][:80] },
+		"mismatched key pair": func(m map[string]string) { m["pub"] = b64.EncodeToString(other.PublicKey) },
+		"standard base64 key": func(m map[string]string) { m["pub"] = strings.NewReplacer("-", "+", "_", "/").Replace(m["pub"]) + "+" },
+	} {
+		modified := map[string]string{}
+		for k, v := range fields {
+			modified[k] = v
+		}
+		modify(modified)
+		data, _ := json.Marshal(modified)
+		var parsed JWK
+		if err := json.Unmarshal(data, &parsed); err == nil {
+			t.Errorf("%s accepted", name)
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	for _, a := range algorithms {
+		key := generate(t, a.name)
+		plaintext := []byte("attack at dawn")
+		token, err := Encrypt(plaintext, key.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts := strings.Split(token, ".")
+		if (parts[1] == "") != (a.kekBytes == 0) {
+			t.Errorf("%s: encrypted key %q", a.name, parts[1])
+		}
+		decrypted, err := Decrypt(token, key)
+		if err != nil {
+			t.Fatalf("%s: %v", a.name, err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("%s: decrypted plaintext differs", a.name)
+		}
+		if _, err := Decrypt(token, generate(t, a.name)); err == nil {
+			t.Errorf("%s: decrypted with another key", a.name)
+		}
+		if _, err := Decrypt(token, key.Public()); err == nil {
+			t.Errorf("%s: decrypted with a public key", a.name)
+		}
+		for i := 1; i < 5; i++ {
+			modified := strings.Split(token, ".")
+			if modified[i] == "" {
+				continue
+			}
+			b, _ := b64.DecodeString(modified[i])
+			b[0] ^= 1
+			modified[i] = b64.EncodeToString(b)
+			if _, err := Decrypt(strings.Join(modified, "."), key); err == nil {
+				t.Errorf("%s: modified part %d accepted", a.name, i+1)
+			}
+		}
+	}
+}
+
+// compactWithHeader encrypts with a header changed by modify after key
+// management, so that only the header checks can reject it.
+func compactWithHeader(t *testing.T, key *JWK, encryptedKey []byte, modify func(map[string]any)) string {
+	t.Helper()
+	cek := make([]byte, cekBytes)
+	rand.Read(cek)
+	rk, cek, err := encapsulate(key.Public(), cek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rk.header.Encryption = A256GCM
+	data, _ := json.Marshal(rk.header)
+	var h map[string]any
+	json.Unmarshal(data, &h)
+	modify(h)
+	protected, _ := json.Marshal(h)
+	encodedProtected := b64.EncodeToString(protected)
+	if encryptedKey == nil {
+		encryptedKey = rk.encryptedKey
+	}
+	iv, ciphertext, tag, err := sealContent(cek, []byte("payload"), []byte(encodedProtected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Join([]string{encodedProtected, b64.EncodeToString(encryptedKey), b64.EncodeToString(iv), b64.EncodeToString(ciphertext), b64.EncodeToString(tag)}, ".")
+}
+
+func TestMalformedCompact(t *testing.T) {
+	key := generate(t, MLKEM768)
+	if _, err := Decrypt(compactWithHeader(t, key, nil, func(map[string]any) {}), key); err != nil {
+		t.Fatal(err)
+	}
+	for name, modify := range map[string]func(map[string]any){
+		"content encryption":  func(h map[string]any) { h["enc"] = "A128GCM" },
+		"missing enc":         func(h map[string]any) { delete(h, "enc") },
+		"missing ek":          func(h map[string]any) { delete(h, "ek") },
+		"short ek":            func(h map[string]any) { h["ek"] = h["ek"].(string)[:64] },
+		"ek of wrong type":    func(h map[string]any) { h["ek"] = 42 },
+		"other algorithm":     func(h map[string]any) { h["alg"] = MLKEM768A192KW },
+		"unknown algorithm":   func(h map[string]any) { h["alg"] = "RSA-OAEP" },
+		"critical parameters": func(h map[string]any) { h["crit"] = []string{"exp"}; h["exp"] = 0 },
+		"compression":         func(h map[string]any) { h["zip"] = "DEF" },
+	} {
+		if _, err := Decrypt(compactWithHeader(t, key, nil, modify), key); err == nil {
+			t.Errorf("%s accepted", name)
+		}
+	}
+	if _, err := Decrypt(compactWithHeader(t, key, []byte("unexpected"), func(map[string]any) {}), key); err == nil {
+		t.Error("encrypted key for direct key agreement accepted")
+	}
+
+	token, err := Encrypt([]byte("payload"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+	for name, s := range map[string]string{
+		"four parts":        strings.Join(parts[:4], "."),
+		"six parts":         token + ".",
+		"padded part":       strings.Join(append([]string{parts[0] + "="}, parts[1:]...), "."),
+		"header not JSON":   strings.Join(append([]string{b64.EncodeToString([]byte("alg"))}, parts[1:]...), "."),
+		"header not object": strings.Join(append([]string{b64.EncodeToString([]byte("[]"))}, parts[1:]...), "."),
+		"short IV":          strings.Join([]string{parts[0], parts[1], parts[2][:8], parts[3], parts[4]}, "."),
+		"missing tag":       strings.Join([]string{parts[0], parts[1], parts[2], parts[3], ""}, "."),
+	} {
+		if _, err := Decrypt(s, key); err == nil {
+			t.Errorf("%s accepted", name)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	keys := []*JWK{generate(t, MLKEM512A128KW), generate(t, MLKEM768A192KW), generate(t, MLKEM1024A256KW), generate(t, MLKEM768A192KW)}
+	keys[1].KeyID, keys[3].KeyID = "one", "three"
+	var public []*JWK
+	for _, k := range keys {
+		public = append(public, k.Public())
+	}
+	plaintext := []byte("attack at dawn")
+	data, err := EncryptJSON(plaintext, []byte("header"), public...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, key := range keys {
+		decrypted, aad, err := DecryptJSON(data, key)
+		if err != nil {
+			t.Fatalf("recipient %d: %v", i, err)
+		}
+		if !bytes.Equal(decrypted, plaintext) || string(aad) != "header" {
+			t.Errorf("recipient %d: decrypted %q, %q", i, decrypted, aad)
+		}
+	}
+	if _, _, err := DecryptJSON(data, generate(t, MLKEM768A192KW)); err == nil {
+		t.Error("decrypted with an unrelated key")
+	}
+
+	var in map[string]any
+	json.Unmarshal(data, &in)
+	in["aad"] = b64.EncodeToString([]byte("Header"))
+	modified, _ := json.Marshal(in)
+	if _, _, err := DecryptJSON(modified, keys[0]); err == nil {
+		t.Error("modified additional data accepted")
+	}
+
+	// Direct key agreement, in the flattened serialization.
+	direct := generate(t, MLKEM1024)
+	if _, err := EncryptJSON(plaintext, nil, direct.Public(), public[0]); err == nil {
+		t.Error("direct key agreement with two recipients")
+	}
+	data, err = EncryptJSON(plaintext, nil, direct.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	in = nil
+	json.Unmarshal(data, &in)
+	recipient := in["recipients"].([]any)[0].(map[string]any)
+	delete(in, "recipients")
+	in["header"] = recipient["header"]
+	flattened, _ := json.Marshal(in)
+	decrypted, aad, err := DecryptJSON(flattened, direct)
+	if err != nil || !bytes.Equal(decrypted, plaintext) || aad != nil {
+		t.Errorf("flattened serialization: %q, %q, %v", decrypted, aad, err)
+	}
+}
+
+func TestMalformedJSON(t *testing.T) {
+	key := generate(t, MLKEM768A192KW)
+	data, err := EncryptJSON([]byte("payload"), nil, key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, modify := range map[string]func(map[string]any){
+		"parameter in two headers": func(in map[string]any) { in["unprotected"] = map[string]any{"enc": A256GCM} },
+		"enc in unprotected header": func(in map[string]any) {
+			in["protected"] = b64.EncodeToString([]byte("{}"))
+			in["unprotected"] = map[string]any{"enc": A256GCM}
+		},
+		"mixed serializations": func(in map[string]any) { in["encrypted_key"] = "AAAA" },
+		"unprotected crit": func(in map[string]any) {
+			in["recipients"].([]any)[0].(map[string]any)["header"].(map[string]any)["crit"] = []string{"b64"}
+		},
+		"padded IV":            func(in map[string]any) { in["iv"] = in["iv"].(string) + "=" },
+		"protected not base64": func(in map[string]any) { in["protected"] = "!" },
+	} {
+		var in map[string]any
+		json.Unmarshal(data, &in)
+		modify(in)
+		modified, _ := json.Marshal(in)
+		if _, _, err := DecryptJSON(modified, key); err == nil {
+			t.Errorf("%s accepted", name)
+		}
+	}
+	// Removing the protected header and moving enc to the shared
+	// unprotected header still parses, but fails authentication.
+	var in map[string]any
+	json.Unmarshal(data, &in)
+	delete(in, "protected")
+	in["unprotected"] = map[string]any{"enc": A256GCM}
+	modified, _ := json.Marshal(in)
+	if _, _, err := DecryptJSON(modified, key); err == nil {
+		t.Error("unprotected content encryption accepted")
+	}
+}
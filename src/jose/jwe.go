@@ -0,0 +1,382 @@
+package jose
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Rohith04MVK/goKyber/internal/aeskw"
+)
+
+const (
+	cekBytes = 32
+	ivBytes  = 12
+	tagBytes = 16
+)
+
+// header holds the JWE header parameters this package understands.
+type header struct {
+	Algorithm       string   `json:"alg,omitempty"`
+	Encryption      string   `json:"enc,omitempty"`
+	KeyID           string   `json:"kid,omitempty"`
+	EncapsulatedKey string   `json:"ek,omitempty"`
+	Critical        []string `json:"crit,omitempty"`
+	Compression     string   `json:"zip,omitempty"`
+}
+
+// recipientKey is the key management output for one recipient.
+type recipientKey struct {
+	header       header
+	encryptedKey []byte
+}
+
+// concatKDF is the Concat KDF of NIST SP 800-56A as profiled by RFC 7518,
+// section 4.6.2, with SHA-256 and empty PartyUInfo and PartyVInfo. One
+// round is enough for keys of up to 32 bytes.
+func concatKDF(z []byte, algorithmID string, keyBytes int) []byte {
+	h := sha256.New()
+	h.Write([]byte{0, 0, 0, 1})
+	h.Write(z)
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(algorithmID))))
+	h.Write([]byte(algorithmID))
+	h.Write(make([]byte, 8)) // PartyUInfo and PartyVInfo
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(8*keyBytes)))
+	return h.Sum(nil)[:keyBytes]
+}
+
+// encapsulate runs the key management algorithm of a recipient. For direct
+// key agreement it returns the content encryption key, otherwise it wraps
+// cek.
+func encapsulate(recipient *JWK, cek []byte) (*recipientKey, []byte, error) {
+	a, err := algorithmByName(recipient.Algorithm)
+	if err != nil {
+		return nil, nil, err
+	}
+	ciphertext, sharedSecret, err := a.params.KemEncrypt(recipient.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	rk := &recipientKey{header: header{Algorithm: a.name, KeyID: recipient.KeyID, EncapsulatedKey: b64.EncodeToString(ciphertext)}}
+	if a.kekBytes == 0 {
+		return rk, concatKDF(sharedSecret, A256GCM, cekBytes), nil
+	}
+	rk.encryptedKey, err = aeskw.Wrap(concatKDF(sharedSecret, a.name, a.kekBytes), cek)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rk, cek, nil
+}
+
+// decapsulate recovers the content encryption key with a private key from
+// the merged header of a recipient and its encrypted key.
+func decapsulate(key *JWK, h *header, encryptedKey []byte) ([]byte, error) {
+	a, privateKey, err := key.decapsulationKey()
+	if err != nil {
+		return nil, err
+	}
+	if h.Algorithm != a.name {
+		return nil, fmt.Errorf("JWE algorithm %q does not match the key", h.Algorithm)
+	}
+	ciphertext, err := b64.DecodeString(h.EncapsulatedKey)
+	if err != nil || len(ciphertext) != a.params.CiphertextBytes() {
+		return nil, errors.New("invalid JWE ek header parameter")
+	}
+	sharedSecret, err := a.params.KemDecrypt(ciphertext, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if a.kekBytes == 0 {
+		if len(encryptedKey) != 0 {
+			return nil, errors.New("JWE encrypted key must be empty for direct key agreement")
+		}
+		return concatKDF(sharedSecret, A256GCM, cekBytes), nil
+	}
+	cek, err := aeskw.Unwrap(concatKDF(sharedSecret, a.name, a.kekBytes), encryptedKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(cek) != cekBytes {
+		return nil, errors.New("invalid JWE content encryption key length")
+	}
+	return cek, nil
+}
+
+// checkHeader checks the merged header of a recipient.
+func checkHeader(h *header) error {
+	if len(h.Critical) > 0 {
+		return fmt.Errorf("unsupported critical JWE header parameters %q", h.Critical)
+	}
+	if h.Compression != "" {
+		return errors.New("JWE compression is not supported")
+	}
+	if h.Encryption != A256GCM {
+		return fmt.Errorf("unsupported JWE content encryption %q", h.Encryption)
+	}
+	if h.Algorithm == "" || h.EncapsulatedKey == "" {
+		return errors.New("JWE header lacks alg or ek")
+	}
+	return nil
+}
+
+// mergeHeaders parses the JSON objects of the protected and unprotected
+// headers of a recipient, whose parameter names must be disjoint, and
+// returns the merged header.
+func mergeHeaders(objects ...[]byte) (*header, error) {
+	merged := map[string]json.RawMessage{}
+	for _, object := range objects {
+		if object == nil {
+			continue
+		}
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(object, &m); err != nil || m == nil {
+			return nil, errors.New("JWE header is not a JSON object")
+		}
+		for name, value := range m {
+			if _, ok := merged[name]; ok {
+				return nil, fmt.Errorf("duplicate JWE header parameter %q", name)
+			}
+			merged[name] = value
+		}
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	h := &header{}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, fmt.Errorf("invalid JWE header: %v", err)
+	}
+	return h, checkHeader(h)
+}
+
+func newGCM(cek []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealContent encrypts the plaintext with A256GCM, returning the IV, the
+// ciphertext and the tag.
+func sealContent(cek, plaintext, aad []byte) ([]byte, []byte, []byte, error) {
+	aead, err := newGCM(cek)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	iv := make([]byte, ivBytes)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, nil, nil, err
+	}
+	sealed := aead.Seal(nil, iv, plaintext, aad)
+	return iv, sealed[:len(sealed)-tagBytes], sealed[len(sealed)-tagBytes:], nil
+}
+
+func openContent(cek, iv, ciphertext, tag, aad []byte) ([]byte, error) {
+	if len(iv) != ivBytes || len(tag) != tagBytes {
+		return nil, errors.New("invalid JWE IV or tag length")
+	}
+	aead, err := newGCM(cek)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, iv, append(ciphertext[:len(ciphertext):len(ciphertext)], tag...), aad)
+	if err != nil {
+		return nil, errors.New("JWE decryption failed")
+	}
+	return plaintext, nil
+}
+
+// Encrypt encrypts a plaintext to a public key in the JWE compact
+// serialization, with all the header parameters protected.
+func Encrypt(plaintext []byte, recipient *JWK) (string, error) {
+	cek := make([]byte, cekBytes)
+	if _, err := rand.Read(cek); err != nil {
+		return "", err
+	}
+	rk, cek, err := encapsulate(recipient, cek)
+	if err != nil {
+		return "", err
+	}
+	rk.header.Encryption = A256GCM
+	protected, err := json.Marshal(rk.header)
+	if err != nil {
+		return "", err
+	}
+	encodedProtected := b64.EncodeToString(protected)
+	iv, ciphertext, tag, err := sealContent(cek, plaintext, []byte(encodedProtected))
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{
+		encodedProtected,
+		b64.EncodeToString(rk.encryptedKey),
+		b64.EncodeToString(iv),
+		b64.EncodeToString(ciphertext),
+		b64.EncodeToString(tag),
+	}, "."), nil
+}
+
+// Decrypt decrypts a JWE in the compact serialization with a private key.
+func Decrypt(compact string, key *JWK) ([]byte, error) {
+	parts := strings.Split(compact, ".")
+	if len(parts) != 5 {
+		return nil, errors.New("JWE compact serialization must have five parts")
+	}
+	var decoded [5][]byte
+	for i, part := range parts {
+		var err error
+		if decoded[i], err = b64.DecodeString(part); err != nil {
+			return nil, fmt.Errorf("invalid base64url in JWE part %d", i+1)
+		}
+	}
+	h, err := mergeHeaders(decoded[0])
+	if err != nil {
+		return nil, err
+	}
+	cek, err := decapsulate(key, h, decoded[1])
+	if err != nil {
+		return nil, err
+	}
+	return openContent(cek, decoded[2], decoded[3], decoded[4], []byte(parts[0]))
+}
+
+type recipientJSON struct {
+	Header       json.RawMessage `json:"header,omitempty"`
+	EncryptedKey string          `json:"encrypted_key,omitempty"`
+}
+
+// jweJSON is the general JWE JSON serialization, or the flattened one when
+// Recipients is empty.
+type jweJSON struct {
+	Protected   string          `json:"protected,omitempty"`
+	Unprotected json.RawMessage `json:"unprotected,omitempty"`
+	Recipients  []recipientJSON `json:"recipients,omitempty"`
+	recipientJSON
+	AAD        string `json:"aad,omitempty"`
+	IV         string `json:"iv"`
+	Ciphertext string `json:"ciphertext"`
+	Tag        string `json:"tag"`
+}
+
+// EncryptJSON encrypts a plaintext to one or more public keys in the
+// general JWE JSON serialization, authenticating the additional data aad,
+// which may be nil. The content encryption algorithm is protected, and the
+// per-recipient parameters are in the recipients' headers. Direct key
+// agreement allows a single recipient only.
+func EncryptJSON(plaintext, aad []byte, recipients ...*JWK) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients")
+	}
+	cek := make([]byte, cekBytes)
+	if _, err := rand.Read(cek); err != nil {
+		return nil, err
+	}
+	var out jweJSON
+	for _, r := range recipients {
+		rk, derived, err := encapsulate(r, cek)
+		if err != nil {
+			return nil, err
+		}
+		if len(rk.encryptedKey) == 0 {
+			if len(recipients) > 1 {
+				return nil, errors.New("direct key agreement allows a single recipient")
+			}
+			cek = derived
+		}
+		h, err := json.Marshal(rk.header)
+		if err != nil {
+			return nil, err
+		}
+		out.Recipients = append(out.Recipients, recipientJSON{Header: h, EncryptedKey: b64.EncodeToString(rk.encryptedKey)})
+	}
+	protected, err := json.Marshal(header{Encryption: A256GCM})
+	if err != nil {
+		return nil, err
+	}
+	out.Protected = b64.EncodeToString(protected)
+	contentAAD := out.Protected
+	if aad != nil {
+		out.AAD = b64.EncodeToString(aad)
+		contentAAD += "." + out.AAD
+	}
+	iv, ciphertext, tag, err := sealContent(cek, plaintext, []byte(contentAAD))
+	if err != nil {
+		return nil, err
+	}
+	out.IV, out.Ciphertext, out.Tag = b64.EncodeToString(iv), b64.EncodeToString(ciphertext), b64.EncodeToString(tag)
+	return json.Marshal(out)
+}
+
+// DecryptJSON decrypts a JWE in the general or flattened JSON serialization
+// with a private key, trying the recipients whose algorithm, and key ID if
+// both have one, match the key. It returns the plaintext and the additional
+// data, which is nil if absent.
+func DecryptJSON(data []byte, key *JWK) ([]byte, []byte, error) {
+	var in jweJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, nil, err
+	}
+	recipients := in.Recipients
+	if len(recipients) == 0 {
+		recipients = []recipientJSON{in.recipientJSON}
+	} else if in.Header != nil || in.EncryptedKey != "" {
+		return nil, nil, errors.New("JWE mixes the general and flattened serializations")
+	}
+
+	var protected []byte
+	if in.Protected != "" {
+		var err error
+		if protected, err = b64.DecodeString(in.Protected); err != nil {
+			return nil, nil, errors.New("invalid base64url in JWE protected header")
+		}
+	}
+	var aad []byte
+	contentAAD := in.Protected
+	if in.AAD != "" {
+		var err error
+		if aad, err = b64.DecodeString(in.AAD); err != nil {
+			return nil, nil, errors.New("invalid base64url in JWE aad")
+		}
+		contentAAD += "." + in.AAD
+	}
+	iv, err1 := b64.DecodeString(in.IV)
+	ciphertext, err2 := b64.DecodeString(in.Ciphertext)
+	tag, err3 := b64.DecodeString(in.Tag)
+	if err := errors.Join(err1, err2, err3); err != nil {
+		return nil, nil, errors.New("invalid base64url in JWE")
+	}
+
+	err := errors.New("no JWE recipient matches the key")
+	for _, r := range recipients {
+		h, herr := mergeHeaders(protected, in.Unprotected, r.Header)
+		if herr != nil {
+			return nil, nil, herr
+		}
+		if h.Algorithm != key.Algorithm || (h.KeyID != "" && key.KeyID != "" && h.KeyID != key.KeyID) {
+			continue
+		}
+		encryptedKey, derr := b64.DecodeString(r.EncryptedKey)
+		if derr != nil {
+			return nil, nil, errors.New("invalid base64url in JWE encrypted key")
+		}
+		cek, derr := decapsulate(key, h, encryptedKey)
+		if derr != nil {
+			err = derr
+			continue
+		}
+		plaintext, derr := openContent(cek, iv, ciphertext, tag, []byte(contentAAD))
+		if derr != nil {
+			err = derr
+			continue
+		}
+		return plaintext, aad, nil
+	}
+	return nil, nil, err
+}
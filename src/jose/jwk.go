@@ -0,0 +1,190 @@
+// Package jose implements JSON Web Keys and JSON Web Encryption with ML-KEM,
+// after the JOSE post-quantum KEM drafts.
+//
+// Keys are JWKs of the "AKP" (algorithm key pair) key type, whose "alg"
+// names the algorithm they are for, "pub" holds the public key and "priv"
+// the 64-byte seed of the key pair. The algorithms are the KEM used for
+// key agreement, like ECDH-ES:
+//
+//   - MLKEM512, MLKEM768 and MLKEM1024 derive the content encryption key
+//     directly from the shared secret (direct key agreement).
+//   - MLKEM512+A128KW, MLKEM768+A192KW and MLKEM1024+A256KW derive a key
+//     encryption key, which wraps a random content encryption key with AES
+//     Key Wrap (key agreement with key wrapping).
+//
+// The key is derived with the Concat KDF of RFC 7518, section 4.6, as for
+// ECDH-ES, and the KEM ciphertext is carried in the "ek" header parameter.
+// The content is encrypted with A256GCM.
+package jose
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+// The key management algorithms.
+const (
+	MLKEM512        = "MLKEM512"
+	MLKEM768        = "MLKEM768"
+	MLKEM1024       = "MLKEM1024"
+	MLKEM512A128KW  = "MLKEM512+A128KW"
+	MLKEM768A192KW  = "MLKEM768+A192KW"
+	MLKEM1024A256KW = "MLKEM1024+A256KW"
+)
+
+// A256GCM is the content encryption algorithm.
+const A256GCM = "A256GCM"
+
+// algorithm is a key management algorithm. Direct key agreement has no
+// key encryption key.
+type algorithm struct {
+	name     string
+	params   *gokyber.ParameterSet
+	kekBytes int
+}
+
+var algorithms = []algorithm{
+	{MLKEM512, gokyber.MLKEM512, 0},
+	{MLKEM768, gokyber.MLKEM768, 0},
+	{MLKEM1024, gokyber.MLKEM1024, 0},
+	{MLKEM512A128KW, gokyber.MLKEM512, 16},
+	{MLKEM768A192KW, gokyber.MLKEM768, 24},
+	{MLKEM1024A256KW, gokyber.MLKEM1024, 32},
+}
+
+func algorithmByName(name string) (*algorithm, error) {
+	for i := range algorithms {
+		if algorithms[i].name == name {
+			return &algorithms[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported algorithm %q", name)
+}
+
+var b64 = base64.RawURLEncoding.Strict()
+
+// JWK is an ML-KEM key of the AKP key type.
+type JWK struct {
+	// Algorithm is the key management algorithm the key is for.
+	Algorithm string
+	KeyID     string
+	PublicKey []byte
+	// Seed is the seed of the key pair, or nil for public keys.
+	Seed []byte
+}
+
+// GenerateJWK generates a private key for a key management algorithm.
+func GenerateJWK(alg string) (*JWK, error) {
+	a, err := algorithmByName(alg)
+	if err != nil {
+		return nil, err
+	}
+	seed := make([]byte, a.params.SeedBytes())
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	return NewJWK(alg, seed)
+}
+
+// NewJWK returns the private key of a key management algorithm derived from
+// a seed.
+func NewJWK(alg string, seed []byte) (*JWK, error) {
+	a, err := algorithmByName(alg)
+	if err != nil {
+		return nil, err
+	}
+	_, publicKey, err := a.params.KemKeypairFromSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+	return &JWK{Algorithm: alg, PublicKey: publicKey, Seed: bytes.Clone(seed)}, nil
+}
+
+// Public returns the public key of k.
+func (k *JWK) Public() *JWK {
+	return &JWK{Algorithm: k.Algorithm, KeyID: k.KeyID, PublicKey: k.PublicKey}
+}
+
+// decapsulationKey returns the parameter set and expanded private key of k.
+func (k *JWK) decapsulationKey() (*algorithm, []byte, error) {
+	a, err := algorithmByName(k.Algorithm)
+	if err != nil {
+		return nil, nil, err
+	}
+	if k.Seed == nil {
+		return nil, nil, errors.New("JWK is not a private key")
+	}
+	privateKey, _, err := a.params.KemKeypairFromSeed(k.Seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, privateKey, nil
+}
+
+type jwkJSON struct {
+	KeyType   string `json:"kty"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+	Public    string `json:"pub"`
+	Private   string `json:"priv,omitempty"`
+}
+
+// MarshalJSON returns the JSON encoding of the key, with "priv" for private
+// keys only.
+func (k *JWK) MarshalJSON() ([]byte, error) {
+	a, err := algorithmByName(k.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if len(k.PublicKey) != a.params.PublicKeyBytes() {
+		return nil, errors.New("invalid JWK public key length")
+	}
+	j := jwkJSON{KeyType: "AKP", Algorithm: k.Algorithm, KeyID: k.KeyID, Public: b64.EncodeToString(k.PublicKey)}
+	if k.Seed != nil {
+		j.Private = b64.EncodeToString(k.Seed)
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON parses an AKP JWK of an ML-KEM algorithm. The public key of
+// a private key must match its seed.
+func (k *JWK) UnmarshalJSON(data []byte) error {
+	var j jwkJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if j.KeyType != "AKP" {
+		return fmt.Errorf("unsupported JWK key type %q", j.KeyType)
+	}
+	a, err := algorithmByName(j.Algorithm)
+	if err != nil {
+		return err
+	}
+	publicKey, err := b64.DecodeString(j.Public)
+	if err != nil || len(publicKey) != a.params.PublicKeyBytes() {
+		return errors.New("invalid JWK public key")
+	}
+	key := JWK{Algorithm: j.Algorithm, KeyID: j.KeyID, PublicKey: publicKey}
+	if j.Private != "" {
+		seed, err := b64.DecodeString(j.Private)
+		if err != nil || len(seed) != a.params.SeedBytes() {
+			return errors.New("invalid JWK private key")
+		}
+		derived, err := NewJWK(j.Algorithm, seed)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(derived.PublicKey, publicKey) {
+			return errors.New("JWK public key does not match the private key")
+		}
+		key.Seed = seed
+	}
+	*k = key
+	return nil
+}
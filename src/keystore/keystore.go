@@ -0,0 +1,181 @@
+// Package keystore stores private keys encrypted under a passphrase.
+//
+// The key encryption key is derived from the passphrase with Argon2id (RFC
+// 9106) and a random salt, and the private key is encrypted with
+// XChaCha20-Poly1305. A key file is a JSON object holding the format
+// version, the name of the key, the KDF parameters, the cipher, the nonce
+// and the ciphertext; everything but the ciphertext is authenticated as
+// additional data, so neither the parameters nor the name can be altered
+// without the passphrase. Changing the passphrase re-wraps the key under a
+// fresh salt.
+package keystore
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Rohith04MVK/goKyber/securemem"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Version is the version of the key file format.
+const Version = 1
+
+const (
+	kdfArgon2id       = "argon2id"
+	cipherXChaCha20   = "xchacha20-poly1305"
+	saltBytes         = 16
+	maxArgon2idMemory = 4 * 1024 * 1024 // KiB, 4 GiB
+	maxArgon2idTime   = 64
+)
+
+var (
+	// ErrIncorrectPassphrase is returned when a key file fails to decrypt,
+	// because of a wrong passphrase or a corrupted file.
+	ErrIncorrectPassphrase = errors.New("keystore: incorrect passphrase or corrupted key file")
+	// ErrNotFound is returned for keys that are not in a store.
+	ErrNotFound = errors.New("keystore: key not found")
+)
+
+// KDFParams are the Argon2id parameters of a key file. Memory is in KiB.
+type KDFParams struct {
+	Algorithm string `json:"algorithm"`
+	Salt      []byte `json:"salt,omitempty"`
+	Time      uint32 `json:"time"`
+	Memory    uint32 `json:"memory"`
+	Threads   uint8  `json:"threads"`
+}
+
+// DefaultKDFParams are the second recommended Argon2id parameters of RFC
+// 9106: three passes over 64 MiB with four lanes.
+var DefaultKDFParams = KDFParams{Algorithm: kdfArgon2id, Time: 3, Memory: 64 * 1024, Threads: 4}
+
+func (p *KDFParams) validate() error {
+	if p.Algorithm != kdfArgon2id {
+		return fmt.Errorf("keystore: unsupported KDF %q", p.Algorithm)
+	}
+	if p.Time == 0 || p.Time > maxArgon2idTime || p.Threads == 0 ||
+		p.Memory < 8*uint32(p.Threads) || p.Memory > maxArgon2idMemory {
+		return errors.New("keystore: invalid Argon2id parameters")
+	}
+	return nil
+}
+
+// header is the authenticated part of a key file.
+type header struct {
+	Version int       `json:"version"`
+	Name    string    `json:"name"`
+	KDF     KDFParams `json:"kdf"`
+	Cipher  string    `json:"cipher"`
+	Nonce   []byte    `json:"nonce"`
+}
+
+type keyFile struct {
+	header
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Wrap encrypts a private key named name under a passphrase and returns
+// the key file.
+func Wrap(name string, privateKey, passphrase []byte, params KDFParams) ([]byte, error) {
+	params.Salt = make([]byte, saltBytes)
+	if _, err := rand.Read(params.Salt); err != nil {
+		return nil, err
+	}
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	h := header{Version: Version, Name: name, KDF: params, Cipher: cipherXChaCha20, Nonce: make([]byte, chacha20poly1305.NonceSizeX)}
+	if _, err := rand.Read(h.Nonce); err != nil {
+		return nil, err
+	}
+	additionalData, err := json.Marshal(&h)
+	if err != nil {
+		return nil, err
+	}
+	key := deriveKey(passphrase, &params)
+	defer clear(key)
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	f := keyFile{header: h, Ciphertext: aead.Seal(nil, h.Nonce, privateKey, additionalData)}
+	return json.MarshalIndent(&f, "", "  ")
+}
+
+// Unwrap decrypts the private key of a key file, which must be named name.
+func Unwrap(name string, data, passphrase []byte) ([]byte, error) {
+	return unwrap(name, data, passphrase, func(n int) ([]byte, error) { return make([]byte, n), nil })
+}
+
+// UnwrapBuffer is Unwrap decrypting straight into a secure buffer, so the
+// private key never lives on the Go heap where locking is available.
+func UnwrapBuffer(name string, data, passphrase []byte) (*securemem.Buffer, error) {
+	var b *securemem.Buffer
+	_, err := unwrap(name, data, passphrase, func(n int) ([]byte, error) {
+		var err error
+		b, err = securemem.New(n)
+		if err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	})
+	if err != nil {
+		if b != nil {
+			b.Destroy()
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+// unwrap decrypts the private key of a key file into a slice of its length
+// from alloc.
+func unwrap(name string, data, passphrase []byte, alloc func(n int) ([]byte, error)) ([]byte, error) {
+	var f keyFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("keystore: malformed key file: %w", err)
+	}
+	if f.Version != Version {
+		return nil, fmt.Errorf("keystore: unsupported key file version %d", f.Version)
+	}
+	if f.Name != name {
+		return nil, fmt.Errorf("keystore: key file is for %q, not %q", f.Name, name)
+	}
+	if err := f.KDF.validate(); err != nil {
+		return nil, err
+	}
+	if f.Cipher != cipherXChaCha20 {
+		return nil, fmt.Errorf("keystore: unsupported cipher %q", f.Cipher)
+	}
+	if len(f.KDF.Salt) != saltBytes || len(f.Nonce) != chacha20poly1305.NonceSizeX || len(f.Ciphertext) < chacha20poly1305.Overhead {
+		return nil, errors.New("keystore: malformed key file")
+	}
+	additionalData, err := json.Marshal(&f.header)
+	if err != nil {
+		return nil, err
+	}
+	key := deriveKey(passphrase, &f.KDF)
+	defer clear(key)
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := alloc(len(f.Ciphertext) - chacha20poly1305.Overhead)
+	if err != nil {
+		return nil, err
+	}
+	// Open decrypts in place into privateKey, which has the exact capacity.
+	if _, err := aead.Open(privateKey[:0], f.Nonce, f.Ciphertext, additionalData); err != nil {
+		return nil, ErrIncorrectPassphrase
+	}
+	return privateKey, nil
+}
+
+// deriveKey derives the key encryption key from a passphrase.
+func deriveKey(passphrase []byte, params *KDFParams) []byte {
+	return argon2.IDKey(passphrase, params.Salt, params.Time, params.Memory, params.Threads, chacha20poly1305.KeySize)
+}
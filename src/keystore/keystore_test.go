@@ -0,0 +1,199 @@
+package keystore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// testKDF keeps the tests fast; it is far too weak for real keys.
+var testKDF = KDFParams{Algorithm: kdfArgon2id, Time: 1, Memory: 64, Threads: 1}
+
+var privateKey = bytes.Repeat([]byte("private key "), 200)
+
+func TestWrap(t *testing.T) {
+	data, err := Wrap("alice", privateKey, []byte("correct horse"), testKDF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, privateKey[:32]) {
+		t.Error("key file contains the private key")
+	}
+	got, err := Unwrap("alice", data, []byte("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, privateKey) {
+		t.Error("private key does not round trip")
+	}
+	if _, err := Unwrap("alice", data, []byte("battery staple")); !errors.Is(err, ErrIncorrectPassphrase) {
+		t.Errorf("wrong passphrase: %v", err)
+	}
+	if _, err := Unwrap("bob", data, []byte("correct horse")); err == nil {
+		t.Error("unwrapped a key file under another name")
+	}
+
+	// Every field but the ciphertext is authenticated.
+	for name, modify := range map[string]func(f map[string]any){
+		"name":    func(f map[string]any) { f["name"] = "bob" },
+		"version": func(f map[string]any) { f["version"] = 2 },
+		"time":    func(f map[string]any) { f["kdf"].(map[string]any)["time"] = 2 },
+		"memory":  func(f map[string]any) { f["kdf"].(map[string]any)["memory"] = 128 },
+		"salt":    func(f map[string]any) { f["kdf"].(map[string]any)["salt"] = "AAAAAAAAAAAAAAAAAAAAAA==" },
+		"kdf":     func(f map[string]any) { f["kdf"].(map[string]any)["algorithm"] = "scrypt" },
+		"cipher":  func(f map[string]any) { f["cipher"] = "aes-256-gcm" },
+		"nonce":   func(f map[string]any) { f["nonce"] = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA" },
+		"huge memory": func(f map[string]any) {
+			f["kdf"].(map[string]any)["memory"] = 1 << 30
+		},
+	} {
+		var f map[string]any
+		if err := json.Unmarshal(data, &f); err != nil {
+			t.Fatal(err)
+		}
+		modify(f)
+		modified, err := json.Marshal(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Unwrap("alice", modified, []byte("correct horse")); err == nil {
+			t.Errorf("%s: unwrapped a modified key file", name)
+		}
+	}
+
+	if _, err := Unwrap("alice", []byte("not json"), []byte("correct horse")); err == nil {
+		t.Error("unwrapped a malformed key file")
+	}
+	if _, err := Wrap("alice", privateKey, nil, KDFParams{Algorithm: kdfArgon2id}); err == nil {
+		t.Error("wrapped with invalid KDF parameters")
+	}
+}
+
+func newStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := New(filepath.Join(t.TempDir(), "keys"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.KDF = testKDF
+	return s
+}
+
+func TestStore(t *testing.T) {
+	s := newStore(t)
+	if s.Exists("alice") {
+		t.Error("empty store holds alice")
+	}
+	if _, err := s.Unlock("alice", []byte("pw")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing key: %v", err)
+	}
+	if err := s.Create("alice", privateKey, []byte("pw")); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Exists("alice") {
+		t.Error("store does not hold alice")
+	}
+	if err := s.Create("alice", privateKey, []byte("other")); err == nil {
+		t.Error("overwrote an existing key")
+	}
+	info, err := os.Stat(filepath.Join(s.dir, "alice"+fileExtension))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("key file mode %v", info.Mode())
+	}
+
+	if err := s.ChangePassphrase("alice", []byte("wrong"), []byte("new")); !errors.Is(err, ErrIncorrectPassphrase) {
+		t.Errorf("change with the wrong passphrase: %v", err)
+	}
+	if err := s.ChangePassphrase("alice", []byte("pw"), []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Unlock("alice", []byte("pw")); !errors.Is(err, ErrIncorrectPassphrase) {
+		t.Errorf("old passphrase: %v", err)
+	}
+	got, err := s.Unlock("alice", []byte("new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, privateKey) {
+		t.Error("private key changed with the passphrase")
+	}
+	buf, err := s.UnlockBuffer("alice", []byte("new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), privateKey) {
+		t.Error("private key differs in a secure buffer")
+	}
+	buf.Destroy()
+	if _, err := s.UnlockBuffer("alice", []byte("pw")); !errors.Is(err, ErrIncorrectPassphrase) {
+		t.Errorf("old passphrase into a buffer: %v", err)
+	}
+
+	// No temporary files are left behind.
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != "alice"+fileExtension && e.Name() != lockFileName {
+			t.Errorf("unexpected file %s", e.Name())
+		}
+	}
+
+	for _, name := range []string{"", ".hidden", "../alice", "a/b", `a\b`, "a:b", "a\x00b"} {
+		if err := s.Create(name, privateKey, []byte("pw")); err == nil {
+			t.Errorf("created key %q", name)
+		}
+	}
+}
+
+func TestConcurrentPassphraseChanges(t *testing.T) {
+	s := newStore(t)
+	if err := s.Create("alice", privateKey, []byte("0")); err != nil {
+		t.Fatal(err)
+	}
+	// Each change succeeds only with the passphrase before it, so the
+	// changes must serialize into a chain without losing any.
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	current := "0"
+	changes := 0
+	for i := 1; i <= 8; i++ {
+		wg.Add(1)
+		go func(next string) {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				old := current
+				mu.Unlock()
+				err := s.ChangePassphrase("alice", []byte(old), []byte(next))
+				if errors.Is(err, ErrIncorrectPassphrase) {
+					continue
+				}
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				current = next
+				changes++
+				mu.Unlock()
+				return
+			}
+		}(string(rune('0' + i)))
+	}
+	wg.Wait()
+	if changes != 8 {
+		t.Errorf("%d changes", changes)
+	}
+	if _, err := s.Unlock("alice", []byte(current)); err != nil {
+		t.Errorf("final passphrase: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build !unix
+
+package keystore
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// lockTimeout bounds the wait for a lock file held by another process.
+const lockTimeout = 10 * time.Second
+
+// lockFile takes a lock by creating a file exclusively, waiting for other
+// holders to remove it, and returns the function releasing it. Unlike
+// flock, the lock survives a crash of its holder, and must then be removed
+// by hand.
+func lockFile(path string) (func(), error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New("keystore: timed out waiting for lock file " + path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,170 @@
+package keystore
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Rohith04MVK/goKyber/securemem"
+)
+
+// fileExtension is the extension of key files in a store.
+const fileExtension = ".keystore"
+
+// lockFileName is the name of the file locked while a store is written.
+const lockFileName = ".lock"
+
+// Store is a directory of key files, one per name. Writes replace files
+// atomically under an exclusive lock on the directory, so concurrent
+// processes never see partial files or lose a passphrase change.
+type Store struct {
+	dir string
+	// KDF holds the parameters of newly wrapped keys, DefaultKDFParams by
+	// default.
+	KDF KDFParams
+}
+
+// New returns the store in a directory, which is created if needed.
+func New(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir, KDF: DefaultKDFParams}, nil
+}
+
+// path returns the key file of a name, which must be usable as a file name.
+func (s *Store) path(name string) (string, error) {
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\:`) || strings.ContainsRune(name, 0) {
+		return "", fmt.Errorf("keystore: invalid key name %q", name)
+	}
+	return filepath.Join(s.dir, name+fileExtension), nil
+}
+
+// Exists reports whether the store holds a key named name.
+func (s *Store) Exists(name string) bool {
+	path, err := s.path(name)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// Create stores a private key under a passphrase. It fails if the store
+// already holds a key of that name.
+func (s *Store) Create(name string, privateKey, passphrase []byte) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	data, err := Wrap(name, privateKey, passphrase, s.KDF)
+	if err != nil {
+		return err
+	}
+	unlock, err := lockFile(filepath.Join(s.dir, lockFileName))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("keystore: key %q already exists", name)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// Unlock returns the private key named name, decrypted with a passphrase.
+func (s *Store) Unlock(name string, passphrase []byte) ([]byte, error) {
+	data, err := s.read(name)
+	if err != nil {
+		return nil, err
+	}
+	return Unwrap(name, data, passphrase)
+}
+
+// UnlockBuffer is Unlock returning the private key in a secure buffer,
+// which the caller must destroy.
+func (s *Store) UnlockBuffer(name string, passphrase []byte) (*securemem.Buffer, error) {
+	data, err := s.read(name)
+	if err != nil {
+		return nil, err
+	}
+	return UnwrapBuffer(name, data, passphrase)
+}
+
+// read returns the key file of a name.
+func (s *Store) read(name string) ([]byte, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// ChangePassphrase re-wraps the private key named name under a new
+// passphrase and the current KDF parameters.
+func (s *Store) ChangePassphrase(name string, oldPassphrase, newPassphrase []byte) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	unlock, err := lockFile(filepath.Join(s.dir, lockFileName))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	privateKey, err := s.UnlockBuffer(name, oldPassphrase)
+	if err != nil {
+		return err
+	}
+	data, err := Wrap(name, privateKey.Bytes(), newPassphrase, s.KDF)
+	privateKey.Destroy()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic replaces a file with data through a temporary file in the
+// same directory, so that readers see either the old or the new contents.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	// Persist the rename; not every platform can sync a directory.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
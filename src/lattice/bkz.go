@@ -0,0 +1,94 @@
+package lattice
+
+import "math"
+
+// enumerate returns the coefficients, with respect to rows start..end-1, of
+// the shortest nonzero vector of the lattice they generate once projected
+// orthogonally to the rows before start, if it is shorter than the
+// projection of row start by a factor of at least `improvement`. It is the
+// Schnorr-Euchner depth-first enumeration, without pruning.
+func (r *reducer) enumerate(start, end int, improvement float64) ([]int64, bool) {
+	size := end - start
+	bound := r.norms[start] * improvement
+	x := make([]int64, size)
+	var best []int64
+
+	var search func(i int, partial float64)
+	search = func(i int, partial float64) {
+		center := 0.0
+		for l := i + 1; l < size; l++ {
+			center -= float64(x[l]) * r.mu[start+l][start+i]
+		}
+		norm := r.norms[start+i]
+		first := math.Round(center)
+		direction := 1.0
+		if center < first {
+			direction = -1
+		}
+		// Visit first, first+d, first-d, first+2d, ... in order of
+		// increasing distance to the center.
+		for step := 0; ; step++ {
+			offset := float64((step + 1) / 2)
+			if step%2 == 0 {
+				offset = -offset
+			}
+			value := first + direction*offset
+			length := partial + (value-center)*(value-center)*norm
+			if length >= bound {
+				// Distances to the center only grow from here on.
+				break
+			}
+			x[i] = int64(value)
+			if i == 0 {
+				if length > 1e-9 {
+					bound = length
+					best = append(best[:0], x...)
+				}
+			} else {
+				search(i-1, length)
+			}
+		}
+		x[i] = 0
+	}
+	search(size-1, 0)
+	return best, best != nil
+}
+
+// BKZ reduces a lattice basis, given by its rows, with the block
+// Korkine-Zolotarev algorithm: for every window of blockSize consecutive
+// rows it finds the shortest vector of the projected sublattice by
+// enumeration and inserts it, then runs LLL again, until a whole tour makes
+// no change or maxTours tours have been run. Block sizes up to about 25 are
+// practical. The returned basis replaces the input.
+func BKZ(basis [][]int64, blockSize int, maxTours int) [][]int64 {
+	const delta = 0.99
+	r := newReducer(basis)
+	r.lll(delta, 0)
+
+	for tour := 0; tour < maxTours; tour++ {
+		changed := false
+		for j := 0; j+1 < len(r.basis); j++ {
+			end := min(j+blockSize, len(r.basis))
+			coefficients, ok := r.enumerate(j, end, 0.999)
+			if !ok {
+				continue
+			}
+			v := make([]int64, len(r.basis[0]))
+			for i, c := range coefficients {
+				for l := range v {
+					v[l] += c * r.basis[j+i][l]
+				}
+			}
+			// Insert v before row j; LLL removes the resulting linear
+			// dependency and leaves v, or something as short, in place.
+			r.basis = append(r.basis[:j], append([][]int64{v}, r.basis[j:]...)...)
+			r.resize()
+			r.lll(delta, j)
+			changed = true
+		}
+		if !changed {
+			break
+		}
+	}
+	return r.basis
+}
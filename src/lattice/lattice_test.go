@@ -0,0 +1,88 @@
+package lattice
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestLLLFindsShortVectors(t *testing.T) {
+	// The four rows are linearly dependent and generate a lattice of rank 3
+	// with a basis of vectors of squared length at most 2.
+	basis := [][]int64{
+		{1, 1, 1},
+		{-1, 0, 2},
+		{3, 5, 6},
+		{4, 6, 9},
+	}
+	reduced := LLL(basis, 0.99)
+	if len(reduced) != 3 {
+		t.Fatalf("LLL kept %d rows, want 3", len(reduced))
+	}
+	for _, v := range reduced {
+		if dot(v, v) > 2 {
+			t.Errorf("LLL returned the long vector %v", v)
+		}
+	}
+}
+
+func randomLWE(rng *rand.Rand, m, n int, q, bound int64) (LWE, []int64, []int64) {
+	instance := LWE{A: make([][]int64, m), T: make([]int64, m), Q: q, Bound: bound}
+	s := make([]int64, n)
+	e := make([]int64, m)
+	for j := range s {
+		s[j] = rng.Int63n(2*bound+1) - bound
+	}
+	for i := range instance.A {
+		instance.A[i] = make([]int64, n)
+		e[i] = rng.Int63n(2*bound+1) - bound
+		sum := e[i]
+		for j := range instance.A[i] {
+			instance.A[i][j] = rng.Int63n(q)
+			sum += instance.A[i][j] * s[j]
+		}
+		instance.T[i] = ((sum % q) + q) % q
+	}
+	return instance, s, e
+}
+
+func TestPrimalAttack(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, blockSize := range []int{2, 10} {
+		instance, s, e := randomLWE(rng, 20, 20, 97, 1)
+		gotS, gotE, err := PrimalAttack(instance, blockSize)
+		if err != nil {
+			t.Fatalf("block size %d: %v", blockSize, err)
+		}
+		for j := range s {
+			if gotS[j] != s[j] {
+				t.Fatalf("block size %d: recovered s = %v, want %v", blockSize, gotS, s)
+			}
+		}
+		for i := range e {
+			if gotE[i] != e[i] {
+				t.Fatalf("block size %d: recovered e = %v, want %v", blockSize, gotE, e)
+			}
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	instance, s, e := randomLWE(rng, 8, 8, 97, 1)
+	if !instance.Verify(s, e) {
+		t.Fatal("Verify rejected the real secret")
+	}
+	s[0] += 97
+	if instance.Verify(s, e) {
+		t.Fatal("Verify accepted a secret outside the bound")
+	}
+	s[0] -= 97
+	if e[0] == 1 {
+		e[0] = 0
+	} else {
+		e[0]++
+	}
+	if instance.Verify(s, e) {
+		t.Fatal("Verify accepted a wrong error")
+	}
+}
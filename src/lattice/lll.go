@@ -0,0 +1,147 @@
+// Package lattice is a small lattice-reduction toolkit for teaching: LLL,
+// a BKZ with exact enumeration in small blocks, and the primal attack on
+// LWE that uses them.
+//
+// It works on integer bases with float64 Gram-Schmidt data, which is
+// accurate enough for the dimensions of toy instances (up to about 100) and
+// entries of a few thousand. It is far too slow for real parameter sets,
+// which is the point: it shows that the security of Kyber rests on the
+// dimension of the lattice, not on any secret in the algorithms.
+package lattice
+
+import "math"
+
+// reducer holds an integer basis, given by its rows, and its Gram-Schmidt
+// orthogonalization: b*_i = b_i - sum_{j<i} mu[i][j] b*_j, with
+// norms[i] = |b*_i|^2.
+type reducer struct {
+	basis [][]int64
+	mu    [][]float64
+	norms []float64
+	// dots[i][j] is <b_i, b*_j>, kept to update mu row by row.
+	dots [][]float64
+}
+
+func newReducer(basis [][]int64) *reducer {
+	r := &reducer{basis: basis}
+	r.resize()
+	return r
+}
+
+func (r *reducer) resize() {
+	n := len(r.basis)
+	r.mu = make([][]float64, n)
+	r.dots = make([][]float64, n)
+	for i := range r.mu {
+		r.mu[i] = make([]float64, n)
+		r.dots[i] = make([]float64, n)
+	}
+	r.norms = make([]float64, n)
+}
+
+func dot(a, b []int64) float64 {
+	var sum int64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return float64(sum)
+}
+
+// updateRow recomputes the Gram-Schmidt coefficients of row k from the
+// exact integer basis, assuming those of the previous rows are up to date.
+func (r *reducer) updateRow(k int) {
+	for j := 0; j < k; j++ {
+		d := dot(r.basis[k], r.basis[j])
+		for i := 0; i < j; i++ {
+			d -= r.mu[j][i] * r.dots[k][i]
+		}
+		r.dots[k][j] = d
+		if r.norms[j] == 0 {
+			r.mu[k][j] = 0
+		} else {
+			r.mu[k][j] = d / r.norms[j]
+		}
+	}
+	norm := dot(r.basis[k], r.basis[k])
+	for j := 0; j < k; j++ {
+		norm -= r.mu[k][j] * r.dots[k][j]
+	}
+	r.norms[k] = math.Max(norm, 0)
+}
+
+// sizeReduce makes |mu[k][j]| <= 1/2 for every j < k.
+func (r *reducer) sizeReduce(k int) {
+	for j := k - 1; j >= 0; j-- {
+		q := math.Round(r.mu[k][j])
+		if q == 0 {
+			continue
+		}
+		c := int64(q)
+		for i := range r.basis[k] {
+			r.basis[k][i] -= c * r.basis[j][i]
+		}
+		for i := 0; i < j; i++ {
+			r.mu[k][i] -= q * r.mu[j][i]
+		}
+		r.mu[k][j] -= q
+	}
+}
+
+func isZero(v []int64) bool {
+	for _, x := range v {
+		if x != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// lll runs LLL with parameter delta on the rows from start on, assuming the
+// rows before start are already reduced. Rows that become zero, which
+// happens when the rows are linearly dependent, are removed.
+func (r *reducer) lll(delta float64, start int) {
+	for k := 0; k < start && k < len(r.basis); k++ {
+		r.updateRow(k)
+	}
+	k := max(start, 1)
+	if len(r.basis) > 0 && start == 0 {
+		r.updateRow(0)
+	}
+	for k < len(r.basis) {
+		r.updateRow(k)
+		r.sizeReduce(k)
+		// Size reduction with large quotients loses precision; recompute
+		// from the reduced integer row.
+		r.updateRow(k)
+
+		if isZero(r.basis[k]) {
+			r.basis = append(r.basis[:k], r.basis[k+1:]...)
+			r.mu = append(r.mu[:k], r.mu[k+1:]...)
+			r.dots = append(r.dots[:k], r.dots[k+1:]...)
+			r.norms = append(r.norms[:k], r.norms[k+1:]...)
+			continue
+		}
+
+		m := r.mu[k][k-1]
+		if r.norms[k] < (delta-m*m)*r.norms[k-1] {
+			r.basis[k], r.basis[k-1] = r.basis[k-1], r.basis[k]
+			if k == 1 {
+				r.updateRow(0)
+			} else {
+				k--
+			}
+		} else {
+			k++
+		}
+	}
+}
+
+// LLL reduces a lattice basis, given by its rows, with the Lenstra, Lenstra
+// and Lovász algorithm. delta in (1/4, 1) trades quality for speed; 0.99 is
+// customary. The rows are modified in place; linearly dependent rows are
+// reduced to zero and dropped, so the returned basis may be shorter.
+func LLL(basis [][]int64, delta float64) [][]int64 {
+	r := newReducer(basis)
+	r.lll(delta, 0)
+	return r.basis
+}
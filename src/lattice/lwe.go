@@ -0,0 +1,91 @@
+package lattice
+
+import "errors"
+
+// LWE is an instance `t = A s + e mod Q` of the learning-with-errors
+// problem, with `A` an m×n matrix and the coefficients of `s` and `e` at most
+// Bound in absolute value.
+type LWE struct {
+	A     [][]int64
+	T     []int64
+	Q     int64
+	Bound int64
+}
+
+// PrimalAttack recovers the secret `s` and the error `e` of an LWE instance
+// by lattice reduction (Kannan's embedding). The vector (e, -s, 1) is an
+// unusually short vector of the lattice generated by the rows of
+//
+//	| q·I_m   0    0 |
+//	|  Aᵀ    I_n   0 |
+//	|  tᵀ     0    1 |
+//
+// and BKZ with a large enough block size finds it. blockSize 2 is plain
+// LLL, which already breaks the smallest toy instances.
+func PrimalAttack(instance LWE, blockSize int) (s []int64, e []int64, err error) {
+	m, n := len(instance.A), len(instance.A[0])
+	d := m + n + 1
+	basis := make([][]int64, 0, d)
+	for i := 0; i < m; i++ {
+		row := make([]int64, d)
+		row[i] = instance.Q
+		basis = append(basis, row)
+	}
+	for j := 0; j < n; j++ {
+		row := make([]int64, d)
+		for i := 0; i < m; i++ {
+			row[i] = instance.A[i][j]
+		}
+		row[m+j] = 1
+		basis = append(basis, row)
+	}
+	row := make([]int64, d)
+	copy(row, instance.T)
+	row[d-1] = 1
+	basis = append(basis, row)
+
+	if blockSize <= 2 {
+		basis = LLL(basis, 0.99)
+	} else {
+		basis = BKZ(basis, blockSize, 16)
+	}
+
+	for _, v := range basis {
+		sign := v[d-1]
+		if sign != 1 && sign != -1 {
+			continue
+		}
+		s = make([]int64, n)
+		e = make([]int64, m)
+		for j := range s {
+			s[j] = -sign * v[m+j]
+		}
+		for i := range e {
+			e[i] = sign * v[i]
+		}
+		if instance.Verify(s, e) {
+			return s, e, nil
+		}
+	}
+	return nil, nil, errors.New("no short vector found: increase the block size")
+}
+
+// Verify reports whether `t = A s + e mod Q` with `s` and `e` within the
+// bound.
+func (instance LWE) Verify(s, e []int64) bool {
+	for _, x := range append(append([]int64{}, s...), e...) {
+		if x > instance.Bound || x < -instance.Bound {
+			return false
+		}
+	}
+	for i, row := range instance.A {
+		sum := e[i] - instance.T[i]
+		for j, a := range row {
+			sum += a * s[j]
+		}
+		if sum%instance.Q != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -6,13 +6,18 @@ import (
 	"encoding/csv"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/Rohith04MVK/goKyber/agent"
 	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+	"github.com/Rohith04MVK/goKyber/keystore"
+	"github.com/Rohith04MVK/goKyber/securemem"
 )
 
 type User struct {
@@ -36,11 +41,23 @@ type ApiResponse struct {
 	Message string `json:"message"`
 }
 
+// privateKeyDir holds the private keys of the users, encrypted with their
+// passwords, and the unencrypted keys of older versions until migrated.
+const privateKeyDir = "private_keys"
+
+// keys is the store of the private keys of the users.
+var keys *keystore.Store
+
 func main() {
 	users := make(map[string]User)
 	reader := bufio.NewReader(os.Stdin)
 
-	os.Mkdir("private_keys", 0700)
+	var err error
+	keys, err = keystore.New(privateKeyDir)
+	if err != nil {
+		fmt.Println("Error opening key store:", err)
+		return
+	}
 	loadUsersFromCSV(users)
 
 	// Start web server in a goroutine
@@ -51,7 +68,7 @@ func main() {
 
 func cliMode(users map[string]User, reader *bufio.Reader) {
 	for {
-		fmt.Println("\nOptions: (1) Create User, (2) Send Message, (3) Decrypt Message, (4) Exit")
+		fmt.Println("\nOptions: (1) Create User, (2) Send Message, (3) Decrypt Message, (4) Exit, (5) List Users, (6) Change Password")
 		fmt.Print("Enter choice: ")
 		choice, _ := reader.ReadString('\n')
 		choice = strings.TrimSpace(choice)
@@ -66,6 +83,10 @@ func cliMode(users map[string]User, reader *bufio.Reader) {
 		case "4":
 			fmt.Println("Exiting.")
 			return
+		case "5":
+			listUsers()
+		case "6":
+			changePassword(users, reader)
 		default:
 			fmt.Println("Invalid choice.")
 		}
@@ -167,9 +188,8 @@ func registerHandler(w http.ResponseWriter, r *http.Request, users map[string]Us
 		return
 	}
 
-	// Save private key
-	privateKeyFilename := filepath.Join("private_keys", req.Username+".key")
-	if err := os.WriteFile(privateKeyFilename, privateKey, 0600); err != nil {
+	// Save private key, encrypted with the password
+	if err := keys.Create(req.Username, privateKey, []byte(req.Password)); err != nil {
 		json.NewEncoder(w).Encode(ApiResponse{Success: false, Message: "Error saving private key"})
 		return
 	}
@@ -178,7 +198,7 @@ func registerHandler(w http.ResponseWriter, r *http.Request, users map[string]Us
 	users[req.Username] = User{Name: req.Username, PublicKey: publicKey, Password: hashedPassword}
 	saveUsersToCSV(users)
 
-	json.NewEncoder(w).Encode(ApiResponse{Success: true, Message: "User registered successfully"})
+	json.NewEncoder(w).Encode(ApiResponse{Success: true, Message: "User registered successfully. Key fingerprint: " + keyFingerprint(publicKey)})
 }
 
 func loginHandler(w http.ResponseWriter, r *http.Request, users map[string]User) {
@@ -236,16 +256,18 @@ func createUser(users map[string]User, reader *bufio.Reader) {
 		return
 	}
 
-	privateKeyFilename := filepath.Join("private_keys", username+".key")
-	err = os.WriteFile(privateKeyFilename, privateKey, 0600)
-	if err != nil {
+	if err := keys.Create(username, privateKey, []byte(password)); err != nil {
 		fmt.Println("Error saving private key:", err)
 		return
 	}
 
 	users[username] = User{Name: username, PublicKey: publicKey, Password: hashedPassword}
 
-	fmt.Println("User created. Public key stored. Private key saved to", privateKeyFilename)
+	fmt.Println("User created. Public key stored. Private key encrypted with your password in", privateKeyDir)
+	fmt.Println("Key fingerprint:", keyFingerprint(publicKey))
+	if encoded, err := gokyber.Kyber768.EncodePublicKey(publicKey); err == nil {
+		fmt.Println("Public key:", encoded)
+	}
 	saveUsersToCSV(users)
 }
 
@@ -264,6 +286,9 @@ func sendMessage(users map[string]User, reader *bufio.Reader) {
 		return
 	}
 
+	// Show the fingerprint so the sender can check it with the receiver.
+	fmt.Println("Receiver key fingerprint:", keyFingerprint(receiver.PublicKey))
+
 	ciphertext, sharedSecret, err := gokyber.KemEncrypt(receiver.PublicKey, 768)
 	if err != nil {
 		fmt.Println("Error encrypting:", err)
@@ -289,23 +314,38 @@ func decryptMessage(reader *bufio.Reader) {
 		return
 	}
 
-	// Ask for password and verify
-	fmt.Print("Enter password: ")
-	password, _ := reader.ReadString('\n')
-	password = strings.TrimSpace(password)
-
-	// Check if password matches
-	hashedPassword := hashPassword(password)
-	if user.Password != hashedPassword {
-		fmt.Println("Invalid password. Access denied.")
-		return
-	}
+	// Use the key agent if one is running, so the private key is never
+	// read here; otherwise unlock the key with the password.
+	var decapsulator agent.Decapsulator
+	if socket := os.Getenv(agent.SocketEnv); socket != "" {
+		client, err := agent.Dial(socket)
+		if err != nil {
+			fmt.Println("Error connecting to key agent:", err)
+			return
+		}
+		defer client.Close()
+		fmt.Println("Using the key agent at", socket)
+		decapsulator = client.Decapsulator(username)
+	} else {
+		// Ask for password and verify
+		fmt.Print("Enter password: ")
+		password, _ := reader.ReadString('\n')
+		password = strings.TrimSpace(password)
+
+		// Check if password matches
+		hashedPassword := hashPassword(password)
+		if user.Password != hashedPassword {
+			fmt.Println("Invalid password. Access denied.")
+			return
+		}
 
-	privateKeyFilename := filepath.Join("private_keys", username+".key")
-	privateKey, err := os.ReadFile(privateKeyFilename)
-	if err != nil {
-		fmt.Println("Error reading private key:", err)
-		return
+		privateKey, err := unlockPrivateKey(username, password)
+		if err != nil {
+			fmt.Println("Error unlocking private key:", err)
+			return
+		}
+		defer privateKey.Destroy()
+		decapsulator = agent.NewLocalDecapsulator(gokyber.Kyber768, privateKey.Bytes())
 	}
 
 	fmt.Print("Enter ciphertext: ")
@@ -318,7 +358,7 @@ func decryptMessage(reader *bufio.Reader) {
 		return
 	}
 
-	sharedSecret, err := gokyber.KemDecrypt(ciphertext, privateKey, 768)
+	sharedSecret, err := decapsulator.Decapsulate(ciphertext)
 	if err != nil {
 		fmt.Println("Error decrypting:", err)
 		return
@@ -327,6 +367,109 @@ func decryptMessage(reader *bufio.Reader) {
 	fmt.Printf("\nShared secret: %x\n", sharedSecret)
 }
 
+// unlockPrivateKey decrypts the private key of a user with their password
+// into locked memory, which the caller must destroy. An unencrypted key left
+// by an older version is moved into the key store first.
+func unlockPrivateKey(username, password string) (*securemem.Buffer, error) {
+	privateKey, err := keys.UnlockBuffer(username, []byte(password))
+	if !errors.Is(err, keystore.ErrNotFound) {
+		return privateKey, err
+	}
+	legacyFilename := filepath.Join(privateKeyDir, username+".key")
+	legacyKey, err := os.ReadFile(legacyFilename)
+	if err != nil {
+		return nil, err
+	}
+	if err := keys.Create(username, legacyKey, []byte(password)); err != nil {
+		clear(legacyKey)
+		return nil, err
+	}
+	if err := os.Remove(legacyFilename); err != nil {
+		clear(legacyKey)
+		return nil, err
+	}
+	fmt.Println("Unencrypted private key moved to the key store and encrypted with your password.")
+	return securemem.NewFromBytes(legacyKey)
+}
+
+// changePassword changes the password of a user and re-encrypts their
+// private key with it. The users map is updated in place, as later saves
+// of it would otherwise write the old password back.
+func changePassword(users map[string]User, reader *bufio.Reader) {
+	fmt.Print("Enter username: ")
+	username, _ := reader.ReadString('\n')
+	username = strings.TrimSpace(username)
+
+	loadUsersFromCSV(users)
+
+	user, exists := users[username]
+	if !exists {
+		fmt.Println("User not found.")
+		return
+	}
+
+	fmt.Print("Enter current password: ")
+	password, _ := reader.ReadString('\n')
+	password = strings.TrimSpace(password)
+	if user.Password != hashPassword(password) {
+		fmt.Println("Invalid password. Access denied.")
+		return
+	}
+
+	fmt.Print("Enter new password: ")
+	newPassword, _ := reader.ReadString('\n')
+	newPassword = strings.TrimSpace(newPassword)
+	if newPassword == "" {
+		fmt.Println("Password cannot be empty.")
+		return
+	}
+
+	// Migrate an unencrypted key before re-encrypting it.
+	if !keys.Exists(username) {
+		privateKey, err := unlockPrivateKey(username, password)
+		if err != nil {
+			fmt.Println("Error unlocking private key:", err)
+			return
+		}
+		privateKey.Destroy()
+	}
+	if err := keys.ChangePassphrase(username, []byte(password), []byte(newPassword)); err != nil {
+		fmt.Println("Error re-encrypting private key:", err)
+		return
+	}
+
+	user.Password = hashPassword(newPassword)
+	users[username] = user
+	saveUsersToCSV(users)
+	fmt.Println("Password changed.")
+}
+
+// keyFingerprint returns the fingerprint of a Kyber-768 public key, which
+// users compare out of band instead of the whole key.
+func keyFingerprint(publicKey []byte) string {
+	fingerprint, err := gokyber.Kyber768.Fingerprint(publicKey)
+	if err != nil {
+		return "(invalid public key)"
+	}
+	return fingerprint
+}
+
+// listUsers prints the users with the fingerprints of their public keys.
+func listUsers() {
+	users := make(map[string]User)
+	loadUsersFromCSV(users)
+
+	names := make([]string, 0, len(users))
+	for name := range users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("%-20s %s\n", name, keyFingerprint(users[name].PublicKey))
+	}
+}
+
 // Update the saveUsersToCSV function to include password
 func saveUsersToCSV(users map[string]User) {
 	file, err := os.Create("users.csv") // Changed to Create to overwrite existing file
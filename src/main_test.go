@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Rohith04MVK/goKyber/keystore"
+)
+
+func TestChangePasswordThenCreateUser(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if keys, err = keystore.New(privateKeyDir); err != nil {
+		t.Fatal(err)
+	}
+	// Weak parameters keep the test fast.
+	keys.KDF = keystore.KDFParams{Algorithm: "argon2id", Time: 1, Memory: 64, Threads: 1}
+
+	users := make(map[string]User)
+	reader := bufio.NewReader(strings.NewReader("alice\nold\n" + "alice\nold\nnew\n" + "bob\nbobpw\n"))
+	createUser(users, reader)
+	changePassword(users, reader)
+	// Creating a user saves the shared map, which must hold the new
+	// password by now.
+	createUser(users, reader)
+
+	saved := make(map[string]User)
+	loadUsersFromCSV(saved)
+	if saved["alice"].Password != hashPassword("new") {
+		t.Error("the old password was saved back")
+	}
+	if _, ok := saved["bob"]; !ok {
+		t.Error("the new user was not saved")
+	}
+	privateKey, err := unlockPrivateKey("alice", "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey.Destroy()
+}
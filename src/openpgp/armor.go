@@ -0,0 +1,90 @@
+package openpgp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// Armor block types.
+const (
+	BlockMessage    = "PGP MESSAGE"
+	BlockPublicKey  = "PGP PUBLIC KEY BLOCK"
+	BlockPrivateKey = "PGP PRIVATE KEY BLOCK"
+)
+
+const armorColumns = 64
+
+// Armor returns the ASCII armor (RFC 9580, section 6) of data. As RFC 9580
+// recommends, no armor headers and no CRC-24 checksum are emitted.
+func Armor(blockType string, data []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("-----BEGIN " + blockType + "-----\n\n")
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > armorColumns {
+		b.WriteString(encoded[:armorColumns] + "\n")
+		encoded = encoded[armorColumns:]
+	}
+	if len(encoded) > 0 {
+		b.WriteString(encoded + "\n")
+	}
+	b.WriteString("-----END " + blockType + "-----\n")
+	return b.Bytes()
+}
+
+// Dearmor decodes the first ASCII armored block of data and returns its
+// type and contents. Text before the block, armor headers and the CRC-24
+// checksum are ignored, the latter as RFC 9580 requires.
+func Dearmor(data []byte) (string, []byte, error) {
+	lines := strings.Split(string(data), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t\r")
+	}
+	for len(lines) > 0 && !(strings.HasPrefix(lines[0], "-----BEGIN ") && strings.HasSuffix(lines[0], "-----")) {
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return "", nil, errors.New("openpgp: no armored block")
+	}
+	blockType := strings.TrimSuffix(strings.TrimPrefix(lines[0], "-----BEGIN "), "-----")
+	lines = lines[1:]
+	for len(lines) > 0 && lines[0] != "" {
+		if !strings.Contains(lines[0], ": ") {
+			return "", nil, errors.New("openpgp: malformed armor header")
+		}
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return "", nil, errors.New("openpgp: truncated armored block")
+	}
+	lines = lines[1:]
+
+	var encoded strings.Builder
+	for {
+		if len(lines) == 0 {
+			return "", nil, errors.New("openpgp: truncated armored block")
+		}
+		line := lines[0]
+		lines = lines[1:]
+		if strings.HasPrefix(line, "=") {
+			// The checksum is the last line before the tail.
+			if len(line) != 5 || len(lines) == 0 || !strings.HasPrefix(lines[0], "-----") {
+				return "", nil, errors.New("openpgp: malformed armor checksum")
+			}
+			line = lines[0]
+		}
+		if strings.HasPrefix(line, "-----") {
+			if line != "-----END "+blockType+"-----" {
+				return "", nil, errors.New("openpgp: mismatched armor tail")
+			}
+			break
+		}
+		encoded.WriteString(line)
+	}
+	body, err := base64.StdEncoding.DecodeString(encoded.String())
+	if err != nil {
+		return "", nil, errors.New("openpgp: malformed armored data")
+	}
+	return blockType, body, nil
+}
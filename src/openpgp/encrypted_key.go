@@ -0,0 +1,174 @@
+package openpgp
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+	"github.com/Rohith04MVK/goKyber/internal/aeskw"
+	"golang.org/x/crypto/sha3"
+)
+
+// combinerLabel is the domain separator of the KEM combiner.
+const combinerLabel = "OpenPGPCompositeKDFv1"
+
+const encryptedKeyVersion = 6
+
+// ErrOtherRecipient is returned when decrypting a session key encrypted to
+// another key.
+var ErrOtherRecipient = errors.New("openpgp: session key is encrypted to another key")
+
+// EncryptedKey is a version 6 Public-Key Encrypted Session Key packet of
+// ML-KEM-768+X25519, which carries the session key of a version 2
+// Symmetrically Encrypted and Integrity Protected Data packet.
+type EncryptedKey struct {
+	// Fingerprint is the fingerprint of the recipient key, or nil for an
+	// anonymous recipient.
+	Fingerprint []byte
+	// EphemeralKey is the X25519 ephemeral public key and MLKEMCiphertext
+	// the ML-KEM-768 ciphertext.
+	EphemeralKey, MLKEMCiphertext []byte
+	// WrappedKey is the session key wrapped with AES-256 Key Wrap.
+	WrappedKey []byte
+}
+
+// combineKeys is the KEM combiner of draft-ietf-openpgp-pqc, which derives
+// the key encryption key from the shares of both components.
+func combineKeys(mlkemShare, x25519Share, ephemeralKey, x25519PublicKey []byte) []byte {
+	h := sha3.New256()
+	h.Write(mlkemShare)
+	h.Write(x25519Share)
+	h.Write(ephemeralKey)
+	h.Write(x25519PublicKey)
+	h.Write([]byte{AlgorithmMLKEM768X25519})
+	h.Write([]byte(combinerLabel))
+	h.Write([]byte{byte(len(combinerLabel))})
+	return h.Sum(nil)
+}
+
+// EncryptSessionKey encrypts a 16, 24 or 32-byte session key to a public
+// key. Clearing the Fingerprint of the result hides the recipient.
+func EncryptSessionKey(recipient *PublicKey, sessionKey []byte) (*EncryptedKey, error) {
+	switch len(sessionKey) {
+	case 16, 24, 32:
+	default:
+		return nil, errors.New("openpgp: invalid session key length")
+	}
+	x25519PublicKey, err := ecdh.X25519().NewPublicKey(recipient.X25519)
+	if err != nil {
+		return nil, err
+	}
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	x25519Share, err := ephemeral.ECDH(x25519PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	mlkemCiphertext, mlkemShare, err := gokyber.MLKEM768.KemEncrypt(recipient.MLKEM)
+	if err != nil {
+		return nil, err
+	}
+	ephemeralKey := ephemeral.PublicKey().Bytes()
+	kek := combineKeys(mlkemShare, x25519Share, ephemeralKey, recipient.X25519)
+	wrappedKey, err := aeskw.Wrap(kek, sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedKey{
+		Fingerprint:     recipient.Fingerprint(),
+		EphemeralKey:    ephemeralKey,
+		MLKEMCiphertext: mlkemCiphertext,
+		WrappedKey:      wrappedKey,
+	}, nil
+}
+
+// DecryptSessionKey decrypts the session key of an encrypted key packet
+// addressed to the key or to an anonymous recipient.
+func (k *PrivateKey) DecryptSessionKey(e *EncryptedKey) ([]byte, error) {
+	if e.Fingerprint != nil && !bytes.Equal(e.Fingerprint, k.Fingerprint()) {
+		return nil, ErrOtherRecipient
+	}
+	ephemeralKey, err := ecdh.X25519().NewPublicKey(e.EphemeralKey)
+	if err != nil {
+		return nil, err
+	}
+	x25519Share, err := k.x25519.ECDH(ephemeralKey)
+	if err != nil {
+		return nil, err
+	}
+	mlkemShare, err := gokyber.MLKEM768.KemDecrypt(e.MLKEMCiphertext, k.mlkem)
+	if err != nil {
+		return nil, err
+	}
+	kek := combineKeys(mlkemShare, x25519Share, e.EphemeralKey, k.PublicKey.X25519)
+	sessionKey, err := aeskw.Unwrap(kek, e.WrappedKey)
+	if err != nil {
+		return nil, errors.New("openpgp: session key unwrap failed")
+	}
+	return sessionKey, nil
+}
+
+// Marshal returns the encrypted key packet.
+func (e *EncryptedKey) Marshal() []byte {
+	b := []byte{encryptedKeyVersion}
+	if e.Fingerprint == nil {
+		b = append(b, 0)
+	} else {
+		b = append(b, byte(1+len(e.Fingerprint)), keyVersion)
+		b = append(b, e.Fingerprint...)
+	}
+	b = append(b, AlgorithmMLKEM768X25519)
+	b = append(b, e.EphemeralKey...)
+	b = append(b, e.MLKEMCiphertext...)
+	b = append(b, byte(len(e.WrappedKey)))
+	return appendPacket(nil, TagEncryptedKey, append(b, e.WrappedKey...))
+}
+
+// ParseEncryptedKey parses a version 6 Public-Key Encrypted Session Key
+// packet.
+func ParseEncryptedKey(p *Packet) (*EncryptedKey, error) {
+	if p.Tag != TagEncryptedKey {
+		return nil, fmt.Errorf("openpgp: packet of tag %d is not an encrypted session key", p.Tag)
+	}
+	body := p.Body
+	if len(body) < 2 {
+		return nil, errors.New("openpgp: truncated encrypted session key packet")
+	}
+	if body[0] != encryptedKeyVersion {
+		return nil, fmt.Errorf("openpgp: unsupported encrypted session key packet version %d", body[0])
+	}
+	e := &EncryptedKey{}
+	switch n := int(body[1]); n {
+	case 0:
+		body = body[2:]
+	case 1 + fingerprintBytes:
+		if len(body) < 2+n || body[2] != keyVersion {
+			return nil, errors.New("openpgp: invalid recipient key version")
+		}
+		e.Fingerprint = bytes.Clone(body[3 : 2+n])
+		body = body[2+n:]
+	default:
+		return nil, errors.New("openpgp: invalid recipient fingerprint length")
+	}
+	mlkemCiphertextBytes := gokyber.MLKEM768.CiphertextBytes()
+	if len(body) < 1+x25519Bytes+mlkemCiphertextBytes+1 {
+		return nil, errors.New("openpgp: truncated encrypted session key packet")
+	}
+	if body[0] != AlgorithmMLKEM768X25519 {
+		return nil, fmt.Errorf("openpgp: unsupported public-key algorithm %d", body[0])
+	}
+	body = body[1:]
+	e.EphemeralKey = bytes.Clone(body[:x25519Bytes])
+	e.MLKEMCiphertext = bytes.Clone(body[x25519Bytes : x25519Bytes+mlkemCiphertextBytes])
+	body = body[x25519Bytes+mlkemCiphertextBytes:]
+	if int(body[0]) != len(body)-1 {
+		return nil, errors.New("openpgp: invalid wrapped session key length")
+	}
+	e.WrappedKey = bytes.Clone(body[1:])
+	return e, nil
+}
@@ -0,0 +1,211 @@
+package openpgp
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+const (
+	// AlgorithmMLKEM768X25519 is the public-key algorithm identifier of
+	// ML-KEM-768+X25519.
+	AlgorithmMLKEM768X25519 = 35
+
+	keyVersion       = 6
+	fingerprintBytes = sha256.Size
+	x25519Bytes      = 32
+)
+
+// PublicKey is a version 6 ML-KEM-768+X25519 public key packet, the
+// encryption subkey of a certificate.
+type PublicKey struct {
+	CreationTime time.Time
+	// IsSubkey selects the Public-Subkey packet tag over the Public-Key one.
+	IsSubkey bool
+	// X25519 and MLKEM are the public keys of the components.
+	X25519, MLKEM []byte
+}
+
+// body returns the packet body of the public key.
+func (k *PublicKey) body() []byte {
+	b := []byte{keyVersion}
+	b = binary.BigEndian.AppendUint32(b, uint32(k.CreationTime.Unix()))
+	b = append(b, AlgorithmMLKEM768X25519)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(k.X25519)+len(k.MLKEM)))
+	b = append(b, k.X25519...)
+	return append(b, k.MLKEM...)
+}
+
+// Marshal returns the public key packet.
+func (k *PublicKey) Marshal() []byte {
+	tag := byte(TagPublicKey)
+	if k.IsSubkey {
+		tag = TagPublicSubkey
+	}
+	return appendPacket(nil, tag, k.body())
+}
+
+// Fingerprint returns the version 6 fingerprint of the key, the SHA-256 hash
+// of its packet body prefixed with 0x9b and its four-octet length.
+func (k *PublicKey) Fingerprint() []byte {
+	body := k.body()
+	h := sha256.New()
+	h.Write([]byte{0x9b})
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(body))))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// KeyID returns the key ID of the key, the first eight octets of its
+// fingerprint.
+func (k *PublicKey) KeyID() uint64 {
+	return binary.BigEndian.Uint64(k.Fingerprint())
+}
+
+// parsePublicKeyBody parses the public key fields at the start of a key
+// packet body and returns the rest.
+func parsePublicKeyBody(body []byte) (*PublicKey, []byte, error) {
+	if len(body) < 10 {
+		return nil, nil, errors.New("openpgp: truncated key packet")
+	}
+	if body[0] != keyVersion {
+		return nil, nil, fmt.Errorf("openpgp: unsupported key packet version %d", body[0])
+	}
+	if body[5] != AlgorithmMLKEM768X25519 {
+		return nil, nil, fmt.Errorf("openpgp: unsupported public-key algorithm %d", body[5])
+	}
+	n := binary.BigEndian.Uint32(body[6:10])
+	if n != x25519Bytes+uint32(gokyber.MLKEM768.PublicKeyBytes()) || uint32(len(body)-10) < n {
+		return nil, nil, errors.New("openpgp: invalid ML-KEM-768+X25519 public key length")
+	}
+	k := &PublicKey{
+		CreationTime: time.Unix(int64(binary.BigEndian.Uint32(body[1:5])), 0),
+		X25519:       bytes.Clone(body[10 : 10+x25519Bytes]),
+		MLKEM:        bytes.Clone(body[10+x25519Bytes : 10+n]),
+	}
+	if _, err := ecdh.X25519().NewPublicKey(k.X25519); err != nil {
+		return nil, nil, err
+	}
+	return k, body[10+n:], nil
+}
+
+// ParsePublicKey parses a Public-Key or Public-Subkey packet.
+func ParsePublicKey(p *Packet) (*PublicKey, error) {
+	if p.Tag != TagPublicKey && p.Tag != TagPublicSubkey {
+		return nil, fmt.Errorf("openpgp: packet of tag %d is not a public key", p.Tag)
+	}
+	k, rest, err := parsePublicKeyBody(p.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("openpgp: trailing data in public key packet")
+	}
+	k.IsSubkey = p.Tag == TagPublicSubkey
+	return k, nil
+}
+
+// PrivateKey is a version 6 ML-KEM-768+X25519 secret key packet. The secret
+// key material is stored unencrypted.
+type PrivateKey struct {
+	PublicKey
+	// X25519PrivateKey is the X25519 private key and MLKEMSeed the 64-byte
+	// ML-KEM-768 seed.
+	X25519PrivateKey, MLKEMSeed []byte
+
+	x25519 *ecdh.PrivateKey
+	mlkem  []byte
+}
+
+// GenerateKey generates an ML-KEM-768+X25519 key created at a time.
+func GenerateKey(creationTime time.Time, isSubkey bool) (*PrivateKey, error) {
+	x25519 := make([]byte, x25519Bytes)
+	seed := make([]byte, gokyber.MLKEM768.SeedBytes())
+	if _, err := rand.Read(x25519); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	return NewPrivateKey(creationTime, isSubkey, x25519, seed)
+}
+
+// NewPrivateKey returns the key of an X25519 private key and an ML-KEM-768
+// seed.
+func NewPrivateKey(creationTime time.Time, isSubkey bool, x25519, mlkemSeed []byte) (*PrivateKey, error) {
+	x25519Key, err := ecdh.X25519().NewPrivateKey(x25519)
+	if err != nil {
+		return nil, err
+	}
+	mlkemPrivateKey, mlkemPublicKey, err := gokyber.MLKEM768.KemKeypairFromSeed(mlkemSeed)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{
+		PublicKey: PublicKey{
+			CreationTime: time.Unix(creationTime.Unix(), 0),
+			IsSubkey:     isSubkey,
+			X25519:       x25519Key.PublicKey().Bytes(),
+			MLKEM:        mlkemPublicKey,
+		},
+		X25519PrivateKey: bytes.Clone(x25519),
+		MLKEMSeed:        bytes.Clone(mlkemSeed),
+		x25519:           x25519Key,
+		mlkem:            mlkemPrivateKey,
+	}, nil
+}
+
+// Public returns the public key.
+func (k *PrivateKey) Public() *PublicKey {
+	public := k.PublicKey
+	return &public
+}
+
+// Marshal returns the secret key packet, with the S2K usage octet zero for
+// unencrypted key material.
+func (k *PrivateKey) Marshal() []byte {
+	tag := byte(TagSecretKey)
+	if k.IsSubkey {
+		tag = TagSecretSubkey
+	}
+	body := append(k.body(), 0)
+	body = append(body, k.X25519PrivateKey...)
+	return appendPacket(nil, tag, append(body, k.MLKEMSeed...))
+}
+
+// ParsePrivateKey parses a Secret-Key or Secret-Subkey packet. Secret key
+// material protected with a passphrase is not supported.
+func ParsePrivateKey(p *Packet) (*PrivateKey, error) {
+	if p.Tag != TagSecretKey && p.Tag != TagSecretSubkey {
+		return nil, fmt.Errorf("openpgp: packet of tag %d is not a secret key", p.Tag)
+	}
+	public, rest, err := parsePublicKeyBody(p.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) == 0 {
+		return nil, errors.New("openpgp: truncated secret key packet")
+	}
+	if rest[0] != 0 {
+		return nil, errors.New("openpgp: encrypted secret keys are not supported")
+	}
+	rest = rest[1:]
+	if len(rest) != x25519Bytes+gokyber.MLKEM768.SeedBytes() {
+		return nil, errors.New("openpgp: invalid ML-KEM-768+X25519 secret key length")
+	}
+	k, err := NewPrivateKey(public.CreationTime, p.Tag == TagSecretSubkey, rest[:x25519Bytes], rest[x25519Bytes:])
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(k.X25519, public.X25519) || !bytes.Equal(k.MLKEM, public.MLKEM) {
+		return nil, errors.New("openpgp: secret key does not match the public key")
+	}
+	return k, nil
+}
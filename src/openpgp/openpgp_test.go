@@ -0,0 +1,413 @@
+package openpgp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+var creationTime = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestCombineKeys(t *testing.T) {
+	// SHA3-256(mlkemShare || x25519Share || ephemeralKey || x25519PublicKey
+	// || 35 || "OpenPGPCompositeKDFv1" || 21) of constant inputs.
+	kek := combineKeys(bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 32), bytes.Repeat([]byte{3}, 32), bytes.Repeat([]byte{4}, 32))
+	if got, want := hex.EncodeToString(kek), "fd2ccfc34013a809badabf7ab7ab5b053557b0a852886049f548d64314cb295d"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+// readSample returns the packets of an armored sample of
+// draft-ietf-openpgp-pqc, with an ML-DSA-65+Ed25519 primary key and an
+// ML-KEM-768+X25519 subkey, as published with the draft.
+func readSample(t *testing.T, name string) []*Packet {
+	t.Helper()
+	data, err := os.ReadFile("testdata/v6-mldsa-65-sample-" + name + ".asc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, data, err = Dearmor(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packets, err := ReadPackets(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return packets
+}
+
+// findPacket returns the only packet of a tag.
+func findPacket(t *testing.T, packets []*Packet, tag byte) *Packet {
+	t.Helper()
+	var found *Packet
+	for _, p := range packets {
+		if p.Tag == tag {
+			if found != nil {
+				t.Fatalf("several packets of tag %d", tag)
+			}
+			found = p
+		}
+	}
+	if found == nil {
+		t.Fatalf("no packet of tag %d", tag)
+	}
+	return found
+}
+
+func TestDraftSample(t *testing.T) {
+	const fingerprint = "7dae8fbce23022607167af72a002e774e0ca379a2d7ae072384e1e8fde3265e4"
+	// The draft does not list the session key of its sample message; this is
+	// the one the go-crypto implementation recovers and decrypts it with.
+	const sessionKey = "adee68618b302d4bfd7ae3d432bc63a1c1ad7f5fd6e7fd7bdedbb0d0b14a5c9a"
+
+	secretPacket := findPacket(t, readSample(t, "sk"), TagSecretSubkey)
+	key, err := ParsePrivateKey(secretPacket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.IsSubkey || !key.CreationTime.Equal(creationTime) {
+		t.Errorf("subkey %v created %v", key.IsSubkey, key.CreationTime)
+	}
+	if got := hex.EncodeToString(key.Fingerprint()); got != fingerprint {
+		t.Errorf("fingerprint %s, want %s", got, fingerprint)
+	}
+	if got := key.KeyID(); got != 0x7dae8fbce2302260 {
+		t.Errorf("key ID %016x", got)
+	}
+	if !bytes.Equal(key.Marshal(), secretPacket.Marshal()) {
+		t.Error("secret subkey packet does not round trip")
+	}
+
+	publicPacket := findPacket(t, readSample(t, "pk"), TagPublicSubkey)
+	public, err := ParsePublicKey(publicPacket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(public.Fingerprint(), key.Fingerprint()) {
+		t.Errorf("certificate subkey fingerprint %x", public.Fingerprint())
+	}
+	if !bytes.Equal(key.Public().Marshal(), publicPacket.Marshal()) {
+		t.Error("public subkey packet differs from the certificate")
+	}
+
+	message := readSample(t, "message")
+	if len(message) != 2 || message[1].Tag != 18 {
+		t.Fatalf("message of %d packets", len(message))
+	}
+	e, err := ParseEncryptedKey(message[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(e.Fingerprint); got != fingerprint {
+		t.Errorf("recipient fingerprint %s", got)
+	}
+	if !bytes.Equal(e.Marshal(), message[0].Marshal()) {
+		t.Error("encrypted session key packet does not round trip")
+	}
+	got, err := key.DecryptSessionKey(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(got) != sessionKey {
+		t.Errorf("session key %x, want %s", got, sessionKey)
+	}
+}
+
+func TestKeyPackets(t *testing.T) {
+	for _, isSubkey := range []bool{false, true} {
+		key, err := GenerateKey(creationTime, isSubkey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		packets, err := ReadPackets(append(key.Public().Marshal(), key.Marshal()...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(packets) != 2 {
+			t.Fatalf("%d packets", len(packets))
+		}
+		wantTags := []byte{TagPublicKey, TagSecretKey}
+		if isSubkey {
+			wantTags = []byte{TagPublicSubkey, TagSecretSubkey}
+		}
+		if packets[0].Tag != wantTags[0] || packets[1].Tag != wantTags[1] {
+			t.Errorf("tags %d and %d", packets[0].Tag, packets[1].Tag)
+		}
+		// Version, creation time, algorithm, key material length, X25519
+		// then ML-KEM-768 public keys.
+		if n := 1 + 4 + 1 + 4 + 32 + gokyber.MLKEM768.PublicKeyBytes(); len(packets[0].Body) != n {
+			t.Errorf("public key packet body of %d bytes, want %d", len(packets[0].Body), n)
+		}
+
+		public, err := ParsePublicKey(packets[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !public.CreationTime.Equal(creationTime) || public.IsSubkey != isSubkey ||
+			!bytes.Equal(public.X25519, key.PublicKey.X25519) || !bytes.Equal(public.MLKEM, key.MLKEM) {
+			t.Error("public key does not round trip")
+		}
+		if fp := public.Fingerprint(); len(fp) != 32 || !bytes.Equal(fp, key.Fingerprint()) {
+			t.Errorf("fingerprint %x", fp)
+		}
+		parsed, err := ParsePrivateKey(packets[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(parsed.X25519PrivateKey, key.X25519PrivateKey) || !bytes.Equal(parsed.MLKEMSeed, key.MLKEMSeed) ||
+			!bytes.Equal(parsed.Fingerprint(), key.Fingerprint()) {
+			t.Error("secret key does not round trip")
+		}
+
+		if _, err := ParsePrivateKey(packets[0]); err == nil {
+			t.Error("parsed a public key packet as a secret key")
+		}
+		encrypted := &Packet{Tag: packets[1].Tag, Body: bytes.Clone(packets[1].Body)}
+		encrypted.Body[len(packets[0].Body)] = 253
+		if _, err := ParsePrivateKey(encrypted); err == nil {
+			t.Error("parsed an encrypted secret key")
+		}
+	}
+}
+
+func TestParseKeyErrors(t *testing.T) {
+	key, err := GenerateKey(creationTime, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := key.Public().body()
+	for name, modify := range map[string]func([]byte) []byte{
+		"version 4":      func(b []byte) []byte { b[0] = 4; return b },
+		"algorithm":      func(b []byte) []byte { b[5] = 25; return b },
+		"length":         func(b []byte) []byte { b[9]++; return b },
+		"truncated":      func(b []byte) []byte { return b[:len(b)-1] },
+		"trailing data":  func(b []byte) []byte { return append(b, 0) },
+		"header":         func(b []byte) []byte { return b[:9] },
+		"short material": func(b []byte) []byte { b[9]--; return b },
+	} {
+		if _, err := ParsePublicKey(&Packet{Tag: TagPublicSubkey, Body: modify(bytes.Clone(body))}); err == nil {
+			t.Errorf("%s: parsed", name)
+		}
+	}
+
+	// The secret key material must match the public key.
+	other, err := GenerateKey(creationTime, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mismatched := append(append(key.Public().body(), 0), other.X25519PrivateKey...)
+	mismatched = append(mismatched, key.MLKEMSeed...)
+	if _, err := ParsePrivateKey(&Packet{Tag: TagSecretSubkey, Body: mismatched}); err == nil {
+		t.Error("parsed a secret key that does not match its public key")
+	}
+}
+
+func TestEncryptSessionKey(t *testing.T) {
+	key, err := GenerateKey(creationTime, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{16, 24, 32} {
+		sessionKey := bytes.Repeat([]byte{byte(n)}, n)
+		e, err := EncryptSessionKey(key.Public(), sessionKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, anonymous := range []bool{false, true} {
+			if anonymous {
+				e.Fingerprint = nil
+			}
+			packets, err := ReadPackets(e.Marshal())
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Version, fingerprint, algorithm, ephemeral key, ML-KEM
+			// ciphertext, wrapped key length and wrapped key.
+			want := 1 + 1 + 1 + 32 + 1 + 32 + gokyber.MLKEM768.CiphertextBytes() + 1 + n + 8
+			if anonymous {
+				want -= 33
+			}
+			if len(packets) != 1 || packets[0].Tag != TagEncryptedKey || len(packets[0].Body) != want {
+				t.Fatalf("encrypted key packet of %d bytes, want %d", len(packets[0].Body), want)
+			}
+			parsed, err := ParseEncryptedKey(packets[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (parsed.Fingerprint == nil) != anonymous {
+				t.Errorf("anonymous %v: fingerprint %x", anonymous, parsed.Fingerprint)
+			}
+			decrypted, err := key.DecryptSessionKey(parsed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(decrypted, sessionKey) {
+				t.Errorf("got session key %x, want %x", decrypted, sessionKey)
+			}
+		}
+	}
+	if _, err := EncryptSessionKey(key.Public(), make([]byte, 20)); err == nil {
+		t.Error("encrypted a 20-byte session key")
+	}
+}
+
+func TestDecryptSessionKeyErrors(t *testing.T) {
+	key, err := GenerateKey(creationTime, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateKey(creationTime, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := EncryptSessionKey(key.Public(), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.DecryptSessionKey(e); !errors.Is(err, ErrOtherRecipient) {
+		t.Errorf("other recipient: %v", err)
+	}
+	anonymous := *e
+	anonymous.Fingerprint = nil
+	if _, err := other.DecryptSessionKey(&anonymous); err == nil {
+		t.Error("decrypted an anonymous session key with the wrong key")
+	}
+
+	// Each component contributes to the key encryption key.
+	for name, field := range map[string][]byte{
+		"ephemeral key":     e.EphemeralKey,
+		"ML-KEM ciphertext": e.MLKEMCiphertext,
+		"wrapped key":       e.WrappedKey,
+	} {
+		field[len(field)/2] ^= 0x01
+		if _, err := key.DecryptSessionKey(e); err == nil {
+			t.Errorf("decrypted with a tampered %s", name)
+		}
+		field[len(field)/2] ^= 0x01
+	}
+	if _, err := key.DecryptSessionKey(e); err != nil {
+		t.Fatal(err)
+	}
+
+	packet := e.Marshal()
+	for name, modify := range map[string]func([]byte) []byte{
+		"version 3":          func(b []byte) []byte { b[3] = 3; return b },
+		"fingerprint length": func(b []byte) []byte { b[4] = 21; return b },
+		"key version":        func(b []byte) []byte { b[5] = 4; return b },
+		"algorithm":          func(b []byte) []byte { b[38] = 18; return b },
+		"wrapped key length": func(b []byte) []byte { b[len(b)-41]++; return b },
+		"truncated":          func(b []byte) []byte { return b[:len(b)-1] },
+	} {
+		packets, err := ReadPackets(modify(bytes.Clone(packet)))
+		if err != nil {
+			continue
+		}
+		if _, err := ParseEncryptedKey(packets[0]); err == nil {
+			t.Errorf("%s: parsed", name)
+		}
+	}
+}
+
+func TestReadPackets(t *testing.T) {
+	for _, n := range []int{0, 1, 191, 192, 8383, 8384, 100000} {
+		body := bytes.Repeat([]byte{0xaa}, n)
+		packets, err := ReadPackets(append(appendPacket(nil, TagPublicKey, body), appendPacket(nil, TagEncryptedKey, []byte{1})...))
+		if err != nil {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+		if len(packets) != 2 || packets[0].Tag != TagPublicKey || !bytes.Equal(packets[0].Body, body) || packets[1].Tag != TagEncryptedKey {
+			t.Errorf("%d bytes: packets do not round trip", n)
+		}
+	}
+
+	// A data packet of partial body lengths of 512 and 1 bytes, then 2 bytes.
+	body := bytes.Repeat([]byte{0xbb}, 515)
+	data := append([]byte{0xd2, 0xe9}, body[:512]...)
+	data = append(append(data, 0xe0), body[512])
+	data = append(append(data, 0x02), body[513:]...)
+	packets, err := ReadPackets(append(data, appendPacket(nil, TagEncryptedKey, []byte{1})...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packets) != 2 || packets[0].Tag != 18 || !bytes.Equal(packets[0].Body, body) || packets[1].Tag != TagEncryptedKey {
+		t.Error("partial body lengths do not round trip")
+	}
+
+	for name, data := range map[string][]byte{
+		"legacy format":           {0x99, 0x00, 0x01, 0x00},
+		"partial length":          {0xc6, 0xe0},
+		"short partial length":    append([]byte{0xd2, 0xe8}, make([]byte, 257)...),
+		"truncated partial":       append([]byte{0xd2, 0xe9}, make([]byte, 512)...),
+		"truncated partial chunk": append([]byte{0xd2, 0xe9}, make([]byte, 511)...),
+		"truncated body":          {0xc6, 0x02, 0x00},
+		"truncated two":           {0xc6, 0xc0},
+		"truncated five":          {0xc6, 0xff, 0x00},
+		"no length":               {0xc6},
+	} {
+		if _, err := ReadPackets(data); err == nil {
+			t.Errorf("%s: read", name)
+		}
+	}
+}
+
+func TestArmor(t *testing.T) {
+	key, err := GenerateKey(creationTime, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := EncryptSessionKey(key.Public(), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := e.Marshal()
+	armored := Armor(BlockMessage, message)
+	lines := strings.Split(strings.TrimSuffix(string(armored), "\n"), "\n")
+	if lines[0] != "-----BEGIN PGP MESSAGE-----" || lines[1] != "" || lines[len(lines)-1] != "-----END PGP MESSAGE-----" {
+		t.Errorf("armor:\n%s", armored)
+	}
+	for _, line := range lines {
+		if len(line) > 64 {
+			t.Errorf("line of %d characters", len(line))
+		}
+	}
+	blockType, decoded, err := Dearmor(armored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blockType != BlockMessage || !bytes.Equal(decoded, message) {
+		t.Error("armor does not round trip")
+	}
+
+	// Surrounding text, headers, CRLF line endings and a checksum, which is
+	// not checked, are accepted.
+	withHeaders := "Some text\r\n" + lines[0] + "\r\nComment: test\r\nVersion: 1\r\n\r\n" +
+		strings.Join(lines[2:len(lines)-1], "\r\n") + "\r\n=AAAA\r\n" + lines[len(lines)-1] + "\r\nmore text\r\n"
+	if _, decoded, err := Dearmor([]byte(withHeaders)); err != nil || !bytes.Equal(decoded, message) {
+		t.Errorf("armor with headers: %v", err)
+	}
+
+	if _, decoded, err := Dearmor(Armor(BlockPublicKey, nil)); err != nil || len(decoded) != 0 {
+		t.Errorf("empty armor: %v", err)
+	}
+	for name, s := range map[string]string{
+		"no block":     "hello",
+		"no tail":      strings.Join(lines[:len(lines)-1], "\n"),
+		"wrong tail":   strings.Join(lines[:len(lines)-1], "\n") + "\n-----END PGP SIGNATURE-----\n",
+		"bad header":   lines[0] + "\nnot a header\n\n" + strings.Join(lines[2:], "\n"),
+		"bad base64":   lines[0] + "\n\n!!!!\n" + lines[len(lines)-1],
+		"bad checksum": lines[0] + "\n\nAAAA\n=AAA\n" + lines[len(lines)-1],
+		"data after =": lines[0] + "\n\nAAAA\n=AAAA\nAAAA\n" + lines[len(lines)-1],
+		"only begin":   lines[0],
+	} {
+		if _, _, err := Dearmor([]byte(s)); err == nil {
+			t.Errorf("%s: decoded", name)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Package openpgp implements the OpenPGP (RFC 9580) packets of the
+// composite ML-KEM-768+X25519 public-key encryption algorithm of
+// draft-ietf-openpgp-pqc, built on gokyber.MLKEM768: version 6 public and
+// secret key packets, version 6 Public-Key Encrypted Session Key packets,
+// and ASCII armor.
+//
+// A session key is encrypted by combining the shared secrets of ML-KEM-768
+// and of an ephemeral X25519 exchange with the KEM combiner of the draft,
+// SHA3-256 over both shares, the X25519 ciphertext and public key, the
+// algorithm identifier and a domain separator, and wrapping the session key
+// with AES-256 Key Wrap under the result. Assembling certificates, which
+// requires signatures, and the encrypted data packets are left to a full
+// OpenPGP implementation.
+package openpgp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Packet tags (RFC 9580, section 5).
+const (
+	TagEncryptedKey = 1
+	TagSecretKey    = 5
+	TagPublicKey    = 6
+	TagSecretSubkey = 7
+	TagPublicSubkey = 14
+)
+
+// Packet is an OpenPGP packet.
+type Packet struct {
+	Tag  byte
+	Body []byte
+}
+
+// appendPacket appends a packet in the OpenPGP packet format, with a
+// length of one, two or five octets.
+func appendPacket(b []byte, tag byte, body []byte) []byte {
+	b = append(b, 0xc0|tag)
+	switch n := len(body); {
+	case n < 192:
+		b = append(b, byte(n))
+	case n < 8384:
+		n -= 192
+		b = append(b, byte(n>>8)+192, byte(n))
+	default:
+		b = append(b, 0xff)
+		b = binary.BigEndian.AppendUint32(b, uint32(n))
+	}
+	return append(b, body...)
+}
+
+// Marshal returns the encoding of the packet.
+func (p *Packet) Marshal() []byte {
+	return appendPacket(nil, p.Tag, p.Body)
+}
+
+// ReadPackets splits a sequence of packets, whose bodies alias data except
+// for those with partial body lengths, which are copied. Only the OpenPGP
+// packet format is supported, and partial body lengths only in the data
+// packets that RFC 9580 allows them in.
+func ReadPackets(data []byte) ([]*Packet, error) {
+	var packets []*Packet
+	for len(data) > 0 {
+		header := data[0]
+		if header&0xc0 != 0xc0 {
+			return nil, errors.New("openpgp: unsupported legacy packet format")
+		}
+		tag := header & 0x3f
+		data = data[1:]
+		var body []byte
+		for first, partial := true, true; partial; first = false {
+			var n, lengthBytes int
+			var err error
+			n, lengthBytes, partial, err = readLength(data)
+			if err != nil {
+				return nil, err
+			}
+			if partial && first && (!partialLengthTags[tag] || n < 512) {
+				return nil, fmt.Errorf("openpgp: invalid partial body length in packet of tag %d", tag)
+			}
+			if len(data)-lengthBytes < n {
+				return nil, fmt.Errorf("openpgp: truncated packet of tag %d", tag)
+			}
+			if first && !partial {
+				body = data[lengthBytes : lengthBytes+n]
+			} else {
+				body = append(body, data[lengthBytes:lengthBytes+n]...)
+			}
+			data = data[lengthBytes+n:]
+		}
+		packets = append(packets, &Packet{Tag: tag, Body: body})
+	}
+	return packets, nil
+}
+
+// partialLengthTags are the tags of the packets that may have partial body
+// lengths: Compressed Data, Symmetrically Encrypted Data, Literal Data and
+// Symmetrically Encrypted and Integrity Protected Data.
+var partialLengthTags = map[byte]bool{8: true, 9: true, 11: true, 18: true}
+
+// readLength reads a body length (RFC 9580, section 4.2.1) and returns it,
+// the number of bytes it takes and whether it is a partial body length.
+func readLength(data []byte) (n, lengthBytes int, partial bool, err error) {
+	if len(data) < 1 {
+		return 0, 0, false, errors.New("openpgp: truncated packet header")
+	}
+	switch l := int(data[0]); {
+	case l < 192:
+		return l, 1, false, nil
+	case l < 224:
+		if len(data) < 2 {
+			return 0, 0, false, errors.New("openpgp: truncated packet header")
+		}
+		return (l-192)<<8 + int(data[1]) + 192, 2, false, nil
+	case l == 255:
+		if len(data) < 5 {
+			return 0, 0, false, errors.New("openpgp: truncated packet header")
+		}
+		return int(binary.BigEndian.Uint32(data[1:5])), 5, false, nil
+	default:
+		return 1 << (l & 0x1f), 1, true, nil
+	}
+}
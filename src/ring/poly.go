@@ -0,0 +1,204 @@
+package ring
+
+import (
+	"fmt"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+// Poly is an element of a ring, in one of the three domains. The zero value
+// is not usable; polynomials are made by the methods of Ring. Polynomials
+// are values: operations return new polynomials and never modify their
+// operands.
+type Poly struct {
+	ring   *Ring
+	domain Domain
+	// c holds the n coefficients in [0, q), in the representation of
+	// domain.
+	c gokyber.Polynomial
+}
+
+// Ring returns the ring of the polynomial.
+func (p Poly) Ring() *Ring {
+	return p.ring
+}
+
+// Domain returns the representation of the polynomial.
+func (p Poly) Domain() Domain {
+	return p.domain
+}
+
+// Coefficients returns the coefficients of the polynomial in its domain,
+// in [0, q).
+func (p Poly) Coefficients() []int {
+	out := make([]int, p.ring.N())
+	for i := range out {
+		out[i] = int(p.c[i])
+	}
+	return out
+}
+
+// Centered returns the coefficients of the polynomial in its domain, in
+// (-q/2, q/2]. It is the natural view of small polynomials such as noise.
+func (p Poly) Centered() []int {
+	out := p.Coefficients()
+	for i, c := range out {
+		if c > p.ring.Q()/2 {
+			out[i] = c - p.ring.Q()
+		}
+	}
+	return out
+}
+
+// Equal reports whether two polynomials of the same ring are equal. They
+// are converted to a common domain first, so a polynomial equals its own
+// transform.
+func (p Poly) Equal(b Poly) bool {
+	p.checkRing(b)
+	if p.domain != b.domain {
+		return p.ToNTT().c == b.ToNTT().c
+	}
+	return p.c == b.c
+}
+
+func (p Poly) checkRing(b Poly) {
+	if p.ring == nil || b.ring == nil {
+		panic("ring: use of an uninitialized polynomial")
+	}
+	if p.ring != b.ring {
+		panic("ring: polynomials of different rings")
+	}
+}
+
+func (p Poly) check(b Poly) {
+	p.checkRing(b)
+	if p.domain != b.domain {
+		panic(fmt.Sprintf("ring: domain mismatch: %v and %v", p.domain, b.domain))
+	}
+}
+
+// Add returns p + b. Both must be in the same domain.
+func (p Poly) Add(b Poly) Poly {
+	p.check(b)
+	q := p.ring.Q()
+	for i := 0; i < p.ring.N(); i++ {
+		p.c[i] = int16((int(p.c[i]) + int(b.c[i])) % q)
+	}
+	return p
+}
+
+// Sub returns p - b. Both must be in the same domain.
+func (p Poly) Sub(b Poly) Poly {
+	return p.Add(b.Neg())
+}
+
+// Neg returns -p.
+func (p Poly) Neg() Poly {
+	p.checkRing(p)
+	q := p.ring.Q()
+	for i := 0; i < p.ring.N(); i++ {
+		p.c[i] = int16((q - int(p.c[i])) % q)
+	}
+	return p
+}
+
+// ScalarMul returns c·p.
+func (p Poly) ScalarMul(c int) Poly {
+	p.checkRing(p)
+	c = p.ring.reduce(c)
+	for i := 0; i < p.ring.N(); i++ {
+		p.c[i] = int16(int(p.c[i]) * c % p.ring.Q())
+	}
+	return p
+}
+
+// Mul returns the product p·b in the ring. Both must be in the same domain,
+// which is the domain of the result. Products in the normal domain go
+// through the NTT and back; keep polynomials in the NTT domain to multiply
+// them repeatedly.
+func (p Poly) Mul(b Poly) Poly {
+	p.check(b)
+	domain := p.domain
+	product := Poly{ring: p.ring, domain: NTT, c: p.ring.r.MulNTT(p.ToNTT().c, b.ToNTT().c)}
+	return product.to(domain)
+}
+
+// ToNormal returns the polynomial in the normal domain.
+func (p Poly) ToNormal() Poly {
+	p.checkRing(p)
+	switch p.domain {
+	case Montgomery:
+		return p.ToNTT().ToNormal()
+	case NTT:
+		return Poly{ring: p.ring, domain: Normal, c: p.ring.r.InvNTT(p.c)}
+	}
+	return p
+}
+
+// ToNTT returns the transform of the polynomial.
+func (p Poly) ToNTT() Poly {
+	p.checkRing(p)
+	switch p.domain {
+	case Normal:
+		return Poly{ring: p.ring, domain: NTT, c: p.ring.r.NTT(p.c)}
+	case Montgomery:
+		p = p.scale(p.ring.montgomeryInv)
+		p.domain = NTT
+	}
+	return p
+}
+
+// ToMontgomery returns the transform of the polynomial multiplied by the
+// Montgomery factor.
+func (p Poly) ToMontgomery() Poly {
+	p.checkRing(p)
+	if p.domain == Montgomery {
+		return p
+	}
+	p = p.ToNTT().scale(p.ring.montgomery)
+	p.domain = Montgomery
+	return p
+}
+
+func (p Poly) to(domain Domain) Poly {
+	switch domain {
+	case Normal:
+		return p.ToNormal()
+	case Montgomery:
+		return p.ToMontgomery()
+	default:
+		return p.ToNTT()
+	}
+}
+
+func (p Poly) scale(c int) Poly {
+	for i := 0; i < p.ring.N(); i++ {
+		p.c[i] = int16(int(p.c[i]) * c % p.ring.Q())
+	}
+	return p
+}
+
+// Encode serializes the coefficients of the polynomial on d bits each,
+// little-endian, as Kyber does. With d = 12 the encoding is exact, as for
+// Kyber keys; with d < 12 the coefficients are first compressed to d bits,
+// as for Kyber ciphertexts, which loses their low-order bits. The domain is
+// not encoded.
+func (p Poly) Encode(d int) []byte {
+	p.checkRing(p)
+	switch {
+	case d == 12:
+		return p.ring.r.Encode(p.c)
+	case d >= 1 && d < 12:
+		return p.ring.r.Compress(p.c, d)
+	default:
+		panic(fmt.Sprintf("ring: invalid bit width %d", d))
+	}
+}
+
+// String formats the polynomial as its domain and centered coefficients.
+func (p Poly) String() string {
+	if p.ring == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%v%v", p.domain, p.Centered())
+}
@@ -0,0 +1,171 @@
+// Package ring is the polynomial arithmetic of Kyber as a standalone
+// library, for prototyping other Module-LWE constructions on the same
+// tested code.
+//
+// It computes in `Z_q[X]/(X^n + 1)`, with the standard Kyber ring (n = 256,
+// q = 3329) or a smaller ring built with New. Every polynomial carries the
+// domain it is represented in, so that the usual mistakes of lattice code,
+// such as adding a polynomial to the transform of another or forgetting a
+// Montgomery factor, are caught instead of silently producing garbage:
+//
+//   - Normal: the coefficients of the polynomial;
+//   - NTT: its number-theoretic transform, in which multiplication is cheap
+//     and in which Kyber samples matrix `A` and serializes its keys;
+//   - Montgomery: the transform with every coefficient multiplied by the
+//     Montgomery factor R = 2^16 mod q, the form Kyber's base multiplication
+//     works in internally.
+//
+// Operations on polynomials of different rings or domains panic, as an
+// out-of-range index does: they are programming errors. Coefficients are
+// always reported reduced to [0, q).
+package ring
+
+import (
+	"fmt"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+// Domain is the representation of a polynomial.
+type Domain int
+
+const (
+	Normal Domain = iota
+	NTT
+	Montgomery
+)
+
+func (d Domain) String() string {
+	switch d {
+	case Normal:
+		return "Normal"
+	case NTT:
+		return "NTT"
+	case Montgomery:
+		return "Montgomery"
+	default:
+		return fmt.Sprintf("Domain(%d)", int(d))
+	}
+}
+
+// Ring is the ring `Z_q[X]/(X^n + 1)`.
+type Ring struct {
+	r *gokyber.Ring
+	// montgomery is R = 2^16 mod q and montgomeryInv its inverse.
+	montgomery    int
+	montgomeryInv int
+}
+
+// Kyber is the ring of the standard Kyber parameter sets.
+var Kyber = newRing(gokyber.KyberRing)
+
+// New returns the ring `Z_q[X]/(X^n + 1)`. n must be a power of two between
+// 8 and 256 and q a prime below 4096 with q = 1 mod n; see gokyber.NewRing.
+func New(n, q int) (*Ring, error) {
+	r, err := gokyber.NewRing(n, q)
+	if err != nil {
+		return nil, err
+	}
+	return newRing(r), nil
+}
+
+func newRing(r *gokyber.Ring) *Ring {
+	montgomery := (1 << 16) % r.Q
+	inverse := 1
+	// q is prime, so R^-1 = R^(q-2) mod q.
+	for e, base := r.Q-2, montgomery; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			inverse = inverse * base % r.Q
+		}
+		base = base * base % r.Q
+	}
+	return &Ring{r: r, montgomery: montgomery, montgomeryInv: inverse}
+}
+
+// N returns the degree of the ring.
+func (r *Ring) N() int {
+	return r.r.N
+}
+
+// Q returns the modulus of the ring.
+func (r *Ring) Q() int {
+	return r.r.Q
+}
+
+// Zero returns the zero polynomial in the given domain.
+func (r *Ring) Zero(domain Domain) Poly {
+	return Poly{ring: r, domain: domain}
+}
+
+// FromCoefficients returns the polynomial in the given domain with the
+// given coefficients, reduced modulo q. It panics unless there are exactly
+// n of them.
+func (r *Ring) FromCoefficients(domain Domain, coefficients []int) Poly {
+	if len(coefficients) != r.N() {
+		panic(fmt.Sprintf("ring: %d coefficients for a ring of degree %d", len(coefficients), r.N()))
+	}
+	p := r.Zero(domain)
+	for i, c := range coefficients {
+		p.c[i] = int16(r.reduce(c))
+	}
+	return p
+}
+
+// Decode is the inverse of Poly.Encode: it reads a polynomial in the given
+// domain from d bits per coefficient. For d < 12 the result only
+// approximates the encoded polynomial. It returns an error if the input
+// does not have the length of an encoding.
+func (r *Ring) Decode(domain Domain, b []byte, d int) (Poly, error) {
+	if d < 1 || d > 12 {
+		return Poly{}, fmt.Errorf("ring: invalid bit width %d", d)
+	}
+	if len(b) != d*r.N()/8 {
+		return Poly{}, fmt.Errorf("ring: invalid encoding length %d for %d bits per coefficient", len(b), d)
+	}
+	p := r.Zero(domain)
+	if d == 12 {
+		p.c = r.r.Decode(b)
+		for i := 0; i < r.N(); i++ {
+			if int(p.c[i]) >= r.Q() {
+				return Poly{}, fmt.Errorf("ring: coefficient %d out of range", i)
+			}
+		}
+	} else {
+		p.c = r.r.Decompress(b, d)
+	}
+	return p, nil
+}
+
+// SampleUniform samples a polynomial uniformly in the NTT domain from the
+// SHAKE-128 stream of a seed and two indices, as Kyber samples its matrix.
+func (r *Ring) SampleUniform(seed []byte, x, y byte) (Poly, error) {
+	c, err := r.r.SampleUniform(gokyber.SymmetricSHAKE.Xof(seed, x, y))
+	if err != nil {
+		return Poly{}, err
+	}
+	return Poly{ring: r, domain: NTT, c: c}, nil
+}
+
+// SampleCBD samples a polynomial in the normal domain with coefficients
+// drawn from the centered binomial distribution of parameter eta, between 1
+// and 16, from the SHAKE-256 PRF of a seed and a nonce, as Kyber samples
+// its noise.
+func (r *Ring) SampleCBD(seed []byte, nonce byte, eta int) Poly {
+	if eta < 1 || eta > 16 {
+		panic(fmt.Sprintf("ring: invalid noise width %d", eta))
+	}
+	c := r.r.SampleCBD(gokyber.SymmetricSHAKE, seed, nonce, eta)
+	p := r.Zero(Normal)
+	for i := 0; i < r.N(); i++ {
+		p.c[i] = int16(r.reduce(int(c[i])))
+	}
+	return p
+}
+
+func (r *Ring) reduce(x int) int {
+	x %= r.Q()
+	if x < 0 {
+		x += r.Q()
+	}
+	return x
+}
@@ -0,0 +1,228 @@
+package ring
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+func randomPoly(r *Ring, rng *rand.Rand, domain Domain) Poly {
+	c := make([]int, r.N())
+	for i := range c {
+		c[i] = rng.Intn(r.Q())
+	}
+	return r.FromCoefficients(domain, c)
+}
+
+// schoolbook multiplies in Z_q[X]/(X^n + 1) by definition.
+func schoolbook(a, b Poly) Poly {
+	r := a.Ring()
+	ac, bc := a.Coefficients(), b.Coefficients()
+	c := make([]int, r.N())
+	for i := range ac {
+		for j := range bc {
+			if k := i + j; k < r.N() {
+				c[k] += ac[i] * bc[j]
+			} else {
+				c[k-r.N()] -= ac[i] * bc[j]
+			}
+		}
+	}
+	return r.FromCoefficients(Normal, c)
+}
+
+func toyRing(t *testing.T) *Ring {
+	r, err := New(32, 257)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestMulMatchesSchoolbook(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, r := range []*Ring{Kyber, toyRing(t)} {
+		for i := 0; i < 10; i++ {
+			a, b := randomPoly(r, rng, Normal), randomPoly(r, rng, Normal)
+			want := schoolbook(a, b)
+			if got := a.Mul(b); got.Domain() != Normal || !got.Equal(want) {
+				t.Fatalf("n=%d: normal-domain product differs from schoolbook", r.N())
+			}
+			if got := a.ToNTT().Mul(b.ToNTT()); got.Domain() != NTT || !got.ToNormal().Equal(want) {
+				t.Fatalf("n=%d: NTT-domain product differs from schoolbook", r.N())
+			}
+			if got := a.ToMontgomery().Mul(b.ToMontgomery()); got.Domain() != Montgomery || !got.ToNormal().Equal(want) {
+				t.Fatalf("n=%d: Montgomery-domain product differs from schoolbook", r.N())
+			}
+		}
+	}
+}
+
+func TestDomainConversions(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	a := randomPoly(Kyber, rng, Normal)
+	for _, p := range []Poly{a.ToNTT(), a.ToMontgomery(), a.ToNTT().ToMontgomery().ToNTT()} {
+		back := p.ToNormal()
+		if back.Domain() != Normal || back.c != a.c {
+			t.Fatalf("%v round trip changed the polynomial", p.Domain())
+		}
+	}
+	// The Montgomery form is the transform times 2^16.
+	ntt, mont := a.ToNTT().Coefficients(), a.ToMontgomery().Coefficients()
+	for i := range ntt {
+		if mont[i] != ntt[i]*(1<<16)%Kyber.Q() {
+			t.Fatalf("coefficient %d: Montgomery form %d, want %d", i, mont[i], ntt[i]*(1<<16)%Kyber.Q())
+		}
+	}
+	// The constant polynomial one is the unit of multiplication.
+	one := make([]int, Kyber.N())
+	one[0] = 1
+	if p := Kyber.FromCoefficients(Normal, one); !p.Mul(a).Equal(a) {
+		t.Error("1·a != a")
+	}
+}
+
+func TestAddSubNeg(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	r := toyRing(t)
+	a, b := randomPoly(r, rng, NTT), randomPoly(r, rng, NTT)
+	if !a.Add(b).Sub(b).Equal(a) {
+		t.Error("a + b - b != a")
+	}
+	if !a.Add(a.Neg()).Equal(r.Zero(NTT)) {
+		t.Error("a + (-a) != 0")
+	}
+	if !a.ScalarMul(-3).Equal(a.Neg().Add(a.Neg()).Add(a.Neg())) {
+		t.Error("-3·a != -a - a - a")
+	}
+	// Addition commutes with the transform.
+	x, y := randomPoly(r, rng, Normal), randomPoly(r, rng, Normal)
+	if !x.Add(y).ToNTT().Equal(x.ToNTT().Add(y.ToNTT())) {
+		t.Error("NTT(x + y) != NTT(x) + NTT(y)")
+	}
+}
+
+func expectPanic(t *testing.T, name string, f func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s did not panic", name)
+		}
+	}()
+	f()
+}
+
+func TestMismatchesPanic(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	a := randomPoly(Kyber, rng, Normal)
+	expectPanic(t, "adding a polynomial to a transform", func() { a.Add(a.ToNTT()) })
+	expectPanic(t, "multiplying NTT and Montgomery forms", func() { a.ToNTT().Mul(a.ToMontgomery()) })
+	expectPanic(t, "mixing rings", func() { a.Add(randomPoly(toyRing(t), rng, Normal)) })
+	expectPanic(t, "using the zero value", func() { Poly{}.Neg() })
+	expectPanic(t, "wrong coefficient count", func() { Kyber.FromCoefficients(Normal, []int{1, 2, 3}) })
+	expectPanic(t, "vectors of different lengths", func() { Vector{a}.Add(Vector{a, a}) })
+}
+
+func TestEncodeDecode(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	for _, r := range []*Ring{Kyber, toyRing(t)} {
+		a := randomPoly(r, rng, NTT)
+		b, err := r.Decode(NTT, a.Encode(12), 12)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !b.Equal(a) {
+			t.Errorf("n=%d: 12-bit encoding is not exact", r.N())
+		}
+		for _, d := range []int{1, 4, 10} {
+			b, err := r.Decode(NTT, a.Encode(d), d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bound := (r.Q() + (1 << d)) / (1 << (d + 1))
+			for i, c := range b.Sub(a).Centered() {
+				if c > bound || c < -bound {
+					t.Fatalf("n=%d d=%d: coefficient %d off by %d, more than %d", r.N(), d, i, c, bound)
+				}
+			}
+		}
+	}
+	if _, err := Kyber.Decode(Normal, make([]byte, 10), 12); err == nil {
+		t.Error("short encoding accepted")
+	}
+	bad := bytes.Repeat([]byte{0xff}, Kyber.N()*12/8)
+	if _, err := Kyber.Decode(Normal, bad, 12); err == nil {
+		t.Error("coefficients above q accepted")
+	}
+}
+
+func TestSampleCBD(t *testing.T) {
+	seed := make([]byte, 32)
+	for eta := 1; eta <= 5; eta++ {
+		p := Kyber.SampleCBD(seed, byte(eta), eta)
+		for _, c := range p.Centered() {
+			if c < -eta || c > eta {
+				t.Fatalf("eta=%d: coefficient %d out of range", eta, c)
+			}
+		}
+	}
+}
+
+// TestKyberEncryption rebuilds Kyber's IND-CPA encryption from the
+// operations of the package and checks it against gokyber byte for byte.
+func TestKyberEncryption(t *testing.T) {
+	params := gokyber.Kyber768
+	k := params.K
+	privateKey, publicKey, err := params.IndcpaKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed := publicKey[len(publicKey)-32:]
+	tHat, err := Kyber.DecodeVector(NTT, publicKey[:len(publicKey)-32], k, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sHat, err := Kyber.DecodeVector(NTT, privateKey, k, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := Kyber.SampleMatrix(seed, k, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The public key is t = A s + e with a small e.
+	e := tHat.Sub(a.MulVector(sHat)).ToNormal()
+	for _, p := range e {
+		for _, c := range p.Centered() {
+			if c < -params.Eta1 || c > params.Eta1 {
+				t.Fatalf("t - A s has coefficient %d, not a small error", c)
+			}
+		}
+	}
+
+	message := make([]byte, 32)
+	coins := make([]byte, 32)
+	rand.New(rand.NewSource(6)).Read(message)
+	copy(coins, "coins for the encryption test!!!")
+	want, err := params.IndcpaEncrypt(message, publicKey, coins)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := Kyber.SampleVectorCBD(coins, 0, k, params.Eta1)
+	e1 := Kyber.SampleVectorCBD(coins, byte(k), k, params.Eta2)
+	e2 := Kyber.SampleCBD(coins, byte(2*k), params.Eta2)
+	m := make([]int, Kyber.N())
+	for i := range m {
+		m[i] = int(message[i/8]>>(i%8)&1) * (Kyber.Q() + 1) / 2
+	}
+	u := a.Transpose().MulVector(r.ToNTT()).ToNormal().Add(e1)
+	v := tHat.Dot(r.ToNTT()).ToNormal().Add(e2).Add(Kyber.FromCoefficients(Normal, m))
+	got := append(u.Encode(params.Du), v.Encode(params.Dv)...)
+	if !bytes.Equal(got, want) {
+		t.Fatal("ciphertext built with the ring package differs from IndcpaEncrypt")
+	}
+}
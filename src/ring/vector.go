@@ -0,0 +1,153 @@
+package ring
+
+import "fmt"
+
+// Vector is a vector of polynomials of the same ring, as the secrets and
+// errors of Module-LWE.
+type Vector []Poly
+
+// Matrix is a matrix of polynomials, as a slice of rows.
+type Matrix []Vector
+
+// SampleVectorCBD samples k polynomials from the centered binomial
+// distribution with consecutive nonces from nonce on, as Kyber samples its
+// secrets and errors.
+func (r *Ring) SampleVectorCBD(seed []byte, nonce byte, k, eta int) Vector {
+	v := make(Vector, k)
+	for i := range v {
+		v[i] = r.SampleCBD(seed, nonce+byte(i), eta)
+	}
+	return v
+}
+
+// SampleMatrix samples a rows×columns matrix uniformly in the NTT domain
+// from a seed. For a square matrix it is Kyber's matrix `A`: entry (i, j)
+// is sampled from the XOF of the seed and the indices j, i.
+func (r *Ring) SampleMatrix(seed []byte, rows, columns int) (Matrix, error) {
+	m := make(Matrix, rows)
+	for i := range m {
+		m[i] = make(Vector, columns)
+		for j := range m[i] {
+			var err error
+			if m[i][j], err = r.SampleUniform(seed, byte(j), byte(i)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return m, nil
+}
+
+func (v Vector) check(b Vector) {
+	if len(v) != len(b) {
+		panic(fmt.Sprintf("ring: vectors of lengths %d and %d", len(v), len(b)))
+	}
+}
+
+// Add returns v + b.
+func (v Vector) Add(b Vector) Vector {
+	v.check(b)
+	out := make(Vector, len(v))
+	for i := range v {
+		out[i] = v[i].Add(b[i])
+	}
+	return out
+}
+
+// Sub returns v - b.
+func (v Vector) Sub(b Vector) Vector {
+	v.check(b)
+	out := make(Vector, len(v))
+	for i := range v {
+		out[i] = v[i].Sub(b[i])
+	}
+	return out
+}
+
+// Dot returns the inner product of v and b, in their common domain. The
+// vectors must not be empty.
+func (v Vector) Dot(b Vector) Poly {
+	v.check(b)
+	if len(v) == 0 {
+		panic("ring: inner product of empty vectors")
+	}
+	sum := v[0].Mul(b[0])
+	for i := 1; i < len(v); i++ {
+		sum = sum.Add(v[i].Mul(b[i]))
+	}
+	return sum
+}
+
+// ToNormal returns the vector with every polynomial in the normal domain.
+func (v Vector) ToNormal() Vector {
+	return v.mapPolys(Poly.ToNormal)
+}
+
+// ToNTT returns the vector with every polynomial in the NTT domain.
+func (v Vector) ToNTT() Vector {
+	return v.mapPolys(Poly.ToNTT)
+}
+
+// ToMontgomery returns the vector with every polynomial in the Montgomery
+// domain.
+func (v Vector) ToMontgomery() Vector {
+	return v.mapPolys(Poly.ToMontgomery)
+}
+
+func (v Vector) mapPolys(f func(Poly) Poly) Vector {
+	out := make(Vector, len(v))
+	for i := range v {
+		out[i] = f(v[i])
+	}
+	return out
+}
+
+// Encode concatenates the encodings of the polynomials on d bits per
+// coefficient; see Poly.Encode.
+func (v Vector) Encode(d int) []byte {
+	var out []byte
+	for _, p := range v {
+		out = append(out, p.Encode(d)...)
+	}
+	return out
+}
+
+// DecodeVector is the inverse of Vector.Encode for a vector of k
+// polynomials.
+func (r *Ring) DecodeVector(domain Domain, b []byte, k, d int) (Vector, error) {
+	size := d * r.N() / 8
+	if len(b) != k*size {
+		return nil, fmt.Errorf("ring: invalid encoding length %d for %d polynomials", len(b), k)
+	}
+	v := make(Vector, k)
+	for i := range v {
+		var err error
+		if v[i], err = r.Decode(domain, b[i*size:(i+1)*size], d); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// MulVector returns the product m·v.
+func (m Matrix) MulVector(v Vector) Vector {
+	out := make(Vector, len(m))
+	for i, row := range m {
+		out[i] = row.Dot(v)
+	}
+	return out
+}
+
+// Transpose returns the transpose of the matrix.
+func (m Matrix) Transpose() Matrix {
+	if len(m) == 0 {
+		return Matrix{}
+	}
+	out := make(Matrix, len(m[0]))
+	for j := range out {
+		out[j] = make(Vector, len(m))
+		for i := range m {
+			out[j][i] = m[i][j]
+		}
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+// Package securemem holds secrets, such as private keys, in memory that is
+// never swapped to disk, left out of core dumps and wiped when freed.
+//
+// On Linux, a Buffer is mapped outside the Go heap with mmap, between two
+// inaccessible guard pages, locked into RAM with mlock and excluded from
+// core dumps with MADV_DONTDUMP; its contents end at the upper guard page,
+// so overflows fault. Where memory cannot be locked, for example because
+// RLIMIT_MEMLOCK is exhausted, and on other platforms, buffers fall back to
+// the Go heap and a warning is logged once.
+package securemem
+
+import (
+	"errors"
+	"log"
+	"sync"
+)
+
+// Buffer is a fixed-size buffer of secret memory. It must be freed with
+// Destroy, which wipes it; buffers are not freed by the garbage collector.
+type Buffer struct {
+	data    []byte
+	mapping []byte // the whole mapping, guard pages included, if any
+	locked  bool
+}
+
+var warnOnce sync.Once
+
+// warnUnlocked logs, once per process, that secrets are kept in ordinary
+// memory.
+func warnUnlocked(reason error) {
+	warnOnce.Do(func() {
+		log.Printf("securemem: secrets are kept in unlocked memory, which may be swapped to disk: %v", reason)
+	})
+}
+
+// New returns a zeroed buffer of size bytes.
+func New(size int) (*Buffer, error) {
+	if size < 0 {
+		return nil, errors.New("securemem: negative buffer size")
+	}
+	if size == 0 {
+		return &Buffer{data: []byte{}}, nil
+	}
+	b, err := newMapped(size)
+	if err != nil {
+		warnUnlocked(err)
+		return &Buffer{data: make([]byte, size)}, nil
+	}
+	return b, nil
+}
+
+// NewFromBytes returns a buffer holding a copy of src, and wipes src.
+func NewFromBytes(src []byte) (*Buffer, error) {
+	b, err := New(len(src))
+	if err != nil {
+		return nil, err
+	}
+	copy(b.data, src)
+	clear(src)
+	return b, nil
+}
+
+// Bytes returns the contents of the buffer, which are valid until Destroy.
+func (b *Buffer) Bytes() []byte {
+	return b.data
+}
+
+// Locked reports whether the buffer is locked into RAM, rather than a heap
+// fallback.
+func (b *Buffer) Locked() bool {
+	return b.locked
+}
+
+// Destroy wipes and frees the buffer. It is safe to call more than once.
+func (b *Buffer) Destroy() {
+	if b.data == nil {
+		return
+	}
+	clear(b.data)
+	if b.mapping != nil {
+		freeMapped(b)
+	}
+	b.data, b.mapping, b.locked = nil, nil, false
+}
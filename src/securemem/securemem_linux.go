@@ -0,0 +1,41 @@
+package securemem
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// newMapped maps a locked buffer of size bytes between guard pages.
+func newMapped(size int) (*Buffer, error) {
+	pageSize := os.Getpagesize()
+	inner := (size + pageSize - 1) / pageSize * pageSize
+	mapping, err := unix.Mmap(-1, 0, inner+2*pageSize, unix.PROT_NONE, unix.MAP_PRIVATE|unix.MAP_ANONYMOUS)
+	if err != nil {
+		return nil, err
+	}
+	// The capacity ends with the buffer too, so that appending to it does
+	// not write into the upper guard page.
+	data := mapping[pageSize : pageSize+inner : pageSize+inner]
+	if err := unix.Mprotect(data, unix.PROT_READ|unix.PROT_WRITE); err != nil {
+		unix.Munmap(mapping)
+		return nil, err
+	}
+	if err := unix.Mlock(data); err != nil {
+		unix.Munmap(mapping)
+		return nil, err
+	}
+	// Core dumps are a leak, not a failure to protect the buffer: kernels
+	// older than 3.4 lack MADV_DONTDUMP.
+	unix.Madvise(data, unix.MADV_DONTDUMP)
+	return &Buffer{data: data[inner-size:], mapping: mapping, locked: true}, nil
+}
+
+// freeMapped unlocks and unmaps a wiped buffer.
+func freeMapped(b *Buffer) {
+	pageSize := os.Getpagesize()
+	inner := b.mapping[pageSize : len(b.mapping)-pageSize]
+	clear(inner)
+	unix.Munlock(inner)
+	unix.Munmap(b.mapping)
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package securemem
+
+import "errors"
+
+func newMapped(size int) (*Buffer, error) {
+	return nil, errors.New("memory locking is not supported on this platform")
+}
+
+func freeMapped(b *Buffer) {}
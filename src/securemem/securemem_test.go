@@ -0,0 +1,87 @@
+package securemem
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"testing"
+	"unsafe"
+)
+
+func TestBuffer(t *testing.T) {
+	for _, size := range []int{0, 1, 32, 2400, os.Getpagesize(), os.Getpagesize() + 1} {
+		b, err := New(size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := b.Bytes()
+		if len(data) != size || !bytes.Equal(data, make([]byte, size)) {
+			t.Fatalf("%d bytes: buffer of %d bytes, not zeroed", size, len(data))
+		}
+		for i := range data {
+			data[i] = byte(i)
+		}
+		if size > 0 && runtime.GOOS == "linux" && !b.Locked() {
+			t.Logf("%d bytes: buffer not locked, RLIMIT_MEMLOCK may be too low", size)
+		}
+		b.Destroy()
+		b.Destroy()
+		if b.Bytes() != nil || b.Locked() {
+			t.Errorf("%d bytes: buffer usable after Destroy", size)
+		}
+	}
+	if _, err := New(-1); err == nil {
+		t.Error("buffer of negative size")
+	}
+}
+
+func TestNewFromBytes(t *testing.T) {
+	src := []byte("private key")
+	b, err := NewFromBytes(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Destroy()
+	if string(b.Bytes()) != "private key" {
+		t.Errorf("got %q", b.Bytes())
+	}
+	if !bytes.Equal(src, make([]byte, len(src))) {
+		t.Error("source not wiped")
+	}
+}
+
+func TestDestroyWipes(t *testing.T) {
+	// A heap fallback buffer is wiped in place.
+	b := &Buffer{data: []byte("secret")}
+	data := b.Bytes()
+	b.Destroy()
+	if !bytes.Equal(data, make([]byte, len(data))) {
+		t.Errorf("heap buffer not wiped: %q", data)
+	}
+}
+
+func TestGuardPage(t *testing.T) {
+	b, err := New(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Destroy()
+	if !b.Locked() {
+		t.Skip("memory locking is not available")
+	}
+	if data := b.Bytes(); cap(data) != len(data) {
+		t.Errorf("capacity %d reaches into the guard page", cap(data))
+	}
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	faulted := func() (faulted bool) {
+		defer func() { faulted = recover() != nil }()
+		// The byte just past the buffer is on the guard page.
+		past := (*byte)(unsafe.Add(unsafe.Pointer(unsafe.SliceData(b.Bytes())), len(b.Bytes())))
+		*past = 1
+		return false
+	}()
+	if !faulted {
+		t.Error("writing past the buffer did not fault")
+	}
+}
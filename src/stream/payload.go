@@ -0,0 +1,220 @@
+package stream
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// The payload follows the age v1 payload design: a 16-byte nonce, from which
+// and from the file key the payload key is derived with HKDF-SHA256, then
+// the plaintext split into chunks of ChunkSize bytes, each encrypted with
+// ChaCha20-Poly1305. The nonce of a chunk is its index as an 11-byte
+// big-endian counter followed by a byte that is 1 for the final chunk and 0
+// for the others (the STREAM construction), so chunks cannot be reordered,
+// dropped or appended, and truncation at a chunk boundary is detected. The
+// final chunk may only be empty if the whole payload is.
+const (
+	// ChunkSize is the length of the plaintext of every chunk but the last.
+	ChunkSize = 64 * 1024
+	// FileKeySize is the length of file keys.
+	FileKeySize = 16
+
+	payloadNonceSize = 16
+	encChunkSize     = ChunkSize + chacha20poly1305.Overhead
+	lastChunkFlag    = 0x01
+)
+
+var (
+	errTruncated  = errors.New("encrypted stream truncated")
+	errChunkAuth  = errors.New("encrypted stream chunk authentication failed")
+	errEmptyFinal = errors.New("encrypted stream has an empty final chunk")
+)
+
+func payloadAEAD(fileKey, nonce []byte) (cipher.AEAD, error) {
+	if len(fileKey) != FileKeySize {
+		return nil, errors.New("invalid file key length")
+	}
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, fileKey, nonce, []byte("payload")), key); err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.New(key)
+}
+
+// chunkNonce is the nonce of the STREAM construction: an 11-byte counter
+// and the final chunk flag.
+type chunkNonce [chacha20poly1305.NonceSize]byte
+
+func (n *chunkNonce) increment() error {
+	for i := len(n) - 2; i >= 0; i-- {
+		n[i]++
+		if n[i] != 0 {
+			return nil
+		}
+	}
+	return errors.New("encrypted stream chunk counter overflow")
+}
+
+func (n *chunkNonce) first() bool {
+	return *n == chunkNonce{} || *n == chunkNonce{len(n) - 1: lastChunkFlag}
+}
+
+// payloadWriter encrypts a payload. It holds back a full chunk until more
+// plaintext arrives, as the final chunk is only known on Close.
+type payloadWriter struct {
+	w     io.Writer
+	aead  cipher.AEAD
+	nonce chunkNonce
+	buf   []byte
+	err   error
+}
+
+// NewPayloadWriter writes a payload nonce to w and returns a writer that
+// encrypts the payload with the file key. Close must be called to write the
+// final chunk; it does not close w.
+func NewPayloadWriter(w io.Writer, fileKey []byte) (io.WriteCloser, error) {
+	nonce := make([]byte, payloadNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	aead, err := payloadAEAD(fileKey, nonce)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(nonce); err != nil {
+		return nil, err
+	}
+	return &payloadWriter{w: w, aead: aead, buf: make([]byte, 0, encChunkSize)}, nil
+}
+
+func (pw *payloadWriter) Write(p []byte) (int, error) {
+	if pw.err != nil {
+		return 0, pw.err
+	}
+	n := 0
+	for len(p) > 0 {
+		if len(pw.buf) == ChunkSize {
+			if pw.err = pw.flush(false); pw.err != nil {
+				return n, pw.err
+			}
+		}
+		k := min(ChunkSize-len(pw.buf), len(p))
+		pw.buf = append(pw.buf, p[:k]...)
+		p = p[k:]
+		n += k
+	}
+	return n, nil
+}
+
+// Close writes the final chunk.
+func (pw *payloadWriter) Close() error {
+	if pw.err != nil {
+		return pw.err
+	}
+	pw.err = pw.flush(true)
+	if pw.err == nil {
+		pw.err = errors.New("encrypted stream writer closed")
+		return nil
+	}
+	return pw.err
+}
+
+func (pw *payloadWriter) flush(last bool) error {
+	if last {
+		pw.nonce[len(pw.nonce)-1] = lastChunkFlag
+	}
+	chunk := pw.aead.Seal(pw.buf[:0], pw.nonce[:], pw.buf, nil)
+	if _, err := pw.w.Write(chunk); err != nil {
+		return err
+	}
+	pw.buf = pw.buf[:0]
+	return pw.nonce.increment()
+}
+
+// payloadReader decrypts a payload chunk by chunk.
+type payloadReader struct {
+	r     *bufio.Reader
+	aead  cipher.AEAD
+	nonce chunkNonce
+	enc   []byte
+	plain []byte
+	done  bool
+	err   error
+}
+
+// NewPayloadReader reads the payload nonce from r and returns a reader of
+// the plaintext decrypted with the file key. No plaintext is returned from
+// a chunk that fails to authenticate, and reaching the end of r before the
+// final chunk is an error.
+func NewPayloadReader(r io.Reader, fileKey []byte) (io.Reader, error) {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReaderSize(r, encChunkSize+1)
+	}
+	nonce := make([]byte, payloadNonceSize)
+	if _, err := io.ReadFull(br, nonce); err != nil {
+		if err == io.EOF {
+			err = errTruncated
+		}
+		return nil, err
+	}
+	aead, err := payloadAEAD(fileKey, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return &payloadReader{r: br, aead: aead, enc: make([]byte, encChunkSize)}, nil
+}
+
+func (pr *payloadReader) Read(p []byte) (int, error) {
+	for len(pr.plain) == 0 {
+		if pr.err != nil {
+			return 0, pr.err
+		}
+		if pr.done {
+			return 0, io.EOF
+		}
+		pr.err = pr.readChunk()
+	}
+	n := copy(p, pr.plain)
+	pr.plain = pr.plain[n:]
+	return n, nil
+}
+
+// readChunk decrypts the next chunk. A chunk shorter than a full one is the
+// final chunk; so is a full one at the end of the stream.
+func (pr *payloadReader) readChunk() error {
+	n, err := io.ReadFull(pr.r, pr.enc)
+	switch {
+	case err == io.EOF:
+		return errTruncated
+	case err == io.ErrUnexpectedEOF:
+		pr.done = true
+	case err != nil:
+		return err
+	default:
+		if _, err := pr.r.Peek(1); err == io.EOF {
+			pr.done = true
+		} else if err != nil {
+			return err
+		}
+	}
+	if pr.done {
+		pr.nonce[len(pr.nonce)-1] = lastChunkFlag
+	}
+	plain, err := pr.aead.Open(pr.enc[:0], pr.nonce[:], pr.enc[:n], nil)
+	if err != nil {
+		return errChunkAuth
+	}
+	if pr.done && len(plain) == 0 && !pr.nonce.first() {
+		return errEmptyFinal
+	}
+	pr.plain = plain
+	return pr.nonce.increment()
+}
@@ -0,0 +1,211 @@
+// Package stream encrypts files and streams of any size to one or more
+// Kyber public keys without holding them in memory.
+//
+// A random file key is wrapped once per recipient with a key encapsulated
+// to the recipient's public key, and the payload is encrypted with the file
+// key in chunks, following the age v1 payload design (see
+// NewPayloadWriter). The encrypted stream is
+//
+//	magic "goKyber-stream\x00" || version (1 byte) || recipient count (2 bytes)
+//	for each recipient: algorithm (2 bytes) || KEM ciphertext || wrapped file key (32 bytes)
+//	header MAC (32 bytes) || payload
+//
+// where the algorithm is the gokyber.AlgorithmID of the recipient's KEM.
+// The file key is wrapped with ChaCha20-Poly1305 under a key derived from
+// the shared secret with HKDF-SHA256, and the header MAC is HMAC-SHA256 of
+// everything before it, under a key derived from the file key, so that a
+// recipient detects any change to the header, including to the stanzas of
+// the other recipients.
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	magic         = "goKyber-stream\x00"
+	version       = 1
+	wrappedSize   = FileKeySize + chacha20poly1305.Overhead
+	headerMACSize = sha256.Size
+	maxRecipients = 0xffff
+)
+
+// Recipient is a public key to encrypt to.
+type Recipient struct {
+	KEM       gokyber.KEM
+	PublicKey []byte
+}
+
+// Identity is a private key to decrypt with.
+type Identity struct {
+	KEM        gokyber.KEM
+	PrivateKey []byte
+}
+
+// ErrNoIdentityMatched is returned by Decrypt when none of the identities
+// can unwrap the file key.
+var ErrNoIdentityMatched = errors.New("no identity matched any of the recipients")
+
+// Encrypt writes the header of an encrypted stream for the recipients to
+// dst and returns a writer that encrypts the payload. The writer must be
+// closed to write the final chunk; closing it does not close dst.
+func Encrypt(dst io.Writer, recipients ...Recipient) (io.WriteCloser, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients")
+	}
+	if len(recipients) > maxRecipients {
+		return nil, errors.New("too many recipients")
+	}
+	fileKey := make([]byte, FileKeySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, err
+	}
+
+	header := append([]byte(magic), version)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(recipients)))
+	for _, r := range recipients {
+		algorithm, err := gokyber.AlgorithmID(r.KEM)
+		if err != nil {
+			return nil, err
+		}
+		ciphertext, sharedSecret, err := r.KEM.KemEncrypt(r.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		wrapped, err := wrapFileKey(sharedSecret, fileKey)
+		if err != nil {
+			return nil, err
+		}
+		header = binary.BigEndian.AppendUint16(header, algorithm)
+		header = append(header, ciphertext...)
+		header = append(header, wrapped...)
+	}
+	header = append(header, HeaderMAC(fileKey, header)...)
+	if _, err := dst.Write(header); err != nil {
+		return nil, err
+	}
+	return NewPayloadWriter(dst, fileKey)
+}
+
+// Decrypt reads the header of an encrypted stream from src, unwraps the
+// file key with the first identity that matches a recipient, checks the
+// header MAC and returns a reader of the decrypted payload. Reading returns
+// an error if the stream was truncated, reordered or modified; plaintext
+// read before such an error must be discarded.
+func Decrypt(src io.Reader, identities ...Identity) (io.Reader, error) {
+	r := bufio.NewReaderSize(src, encChunkSize+1)
+	var header bytes.Buffer
+	tr := io.TeeReader(r, &header)
+	prefix := make([]byte, len(magic)+3)
+	if _, err := io.ReadFull(tr, prefix); err != nil {
+		return nil, errors.New("invalid encrypted stream header")
+	}
+	if string(prefix[:len(magic)]) != magic {
+		return nil, errors.New("not an encrypted stream")
+	}
+	if prefix[len(magic)] != version {
+		return nil, errors.New("unsupported encrypted stream version")
+	}
+	count := int(binary.BigEndian.Uint16(prefix[len(magic)+1:]))
+	if count == 0 {
+		return nil, errors.New("encrypted stream has no recipients")
+	}
+
+	var fileKey []byte
+	for i := 0; i < count; i++ {
+		id := make([]byte, 2)
+		if _, err := io.ReadFull(tr, id); err != nil {
+			return nil, errors.New("invalid encrypted stream header")
+		}
+		algorithm := binary.BigEndian.Uint16(id)
+		kem, err := gokyber.KEMForAlgorithmID(algorithm)
+		if err != nil {
+			return nil, err
+		}
+		stanza := make([]byte, kem.CiphertextBytes()+wrappedSize)
+		if _, err := io.ReadFull(tr, stanza); err != nil {
+			return nil, errors.New("invalid encrypted stream header")
+		}
+		if fileKey == nil {
+			fileKey = unwrapStanza(algorithm, kem, identities, stanza)
+		}
+	}
+	if fileKey == nil {
+		return nil, ErrNoIdentityMatched
+	}
+	mac := make([]byte, headerMACSize)
+	if _, err := io.ReadFull(r, mac); err != nil {
+		return nil, errors.New("invalid encrypted stream header")
+	}
+	if !hmac.Equal(mac, HeaderMAC(fileKey, header.Bytes())) {
+		return nil, errors.New("encrypted stream header MAC mismatch")
+	}
+	return NewPayloadReader(r, fileKey)
+}
+
+// unwrapStanza returns the file key of a recipient stanza, if one of the
+// identities of the same algorithm can unwrap it.
+func unwrapStanza(algorithm uint16, kem gokyber.KEM, identities []Identity, stanza []byte) []byte {
+	ciphertext, wrapped := stanza[:kem.CiphertextBytes()], stanza[kem.CiphertextBytes():]
+	for _, identity := range identities {
+		if id, err := gokyber.AlgorithmID(identity.KEM); err != nil || id != algorithm {
+			continue
+		}
+		sharedSecret, err := identity.KEM.KemDecrypt(ciphertext, identity.PrivateKey)
+		if err != nil {
+			continue
+		}
+		if fileKey, err := unwrapFileKey(sharedSecret, wrapped); err == nil {
+			return fileKey
+		}
+	}
+	return nil
+}
+
+// wrapFileKey encrypts the file key under a key derived from a shared
+// secret. Every wrapping key is used once, so the nonce is zero.
+func wrapFileKey(sharedSecret, fileKey []byte) ([]byte, error) {
+	aead, err := wrapAEAD(sharedSecret)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nil, make([]byte, chacha20poly1305.NonceSize), fileKey, nil), nil
+}
+
+func unwrapFileKey(sharedSecret, wrapped []byte) ([]byte, error) {
+	aead, err := wrapAEAD(sharedSecret)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, make([]byte, chacha20poly1305.NonceSize), wrapped, nil)
+}
+
+func wrapAEAD(sharedSecret []byte) (cipher.AEAD, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecret, nil, []byte("goKyber-stream file key")), key); err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.New(key)
+}
+
+// HeaderMAC is HMAC-SHA256 of the header under a key derived from the file
+// key with HKDF-SHA256, as in the age v1 format.
+func HeaderMAC(fileKey, header []byte) []byte {
+	key := make([]byte, sha256.Size)
+	io.ReadFull(hkdf.New(sha256.New, fileKey, nil, []byte("header")), key)
+	h := hmac.New(sha256.New, key)
+	h.Write(header)
+	return h.Sum(nil)
+}
@@ -0,0 +1,196 @@
+package stream
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+func newIdentity(t *testing.T, kem gokyber.KEM) (Identity, Recipient) {
+	t.Helper()
+	privateKey, publicKey, err := kem.KemKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Identity{kem, privateKey}, Recipient{kem, publicKey}
+}
+
+func encrypt(t *testing.T, plaintext []byte, recipients ...Recipient) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := Encrypt(&buf, recipients...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Write in uneven pieces to exercise the chunk buffering.
+	for p := plaintext; len(p) > 0; {
+		n := min(len(p), 1000+len(p)%7919)
+		if _, err := w.Write(p[:n]); err != nil {
+			t.Fatal(err)
+		}
+		p = p[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decrypt(encrypted []byte, identities ...Identity) ([]byte, error) {
+	r, err := Decrypt(bytes.NewReader(encrypted), identities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestRoundTrip(t *testing.T) {
+	identity, recipient := newIdentity(t, gokyber.Kyber768)
+	for _, n := range []int{0, 1, ChunkSize - 1, ChunkSize, ChunkSize + 1, 2 * ChunkSize, 3*ChunkSize + 12345} {
+		plaintext := randomBytes(t, n)
+		encrypted := encrypt(t, plaintext, recipient)
+		chunks := max(1, (n+ChunkSize-1)/ChunkSize)
+		headerSize := len(magic) + 3 + 2 + gokyber.Kyber768.CiphertextBytes() + wrappedSize + headerMACSize
+		if want := headerSize + payloadNonceSize + n + 16*chunks; len(encrypted) != want {
+			t.Errorf("%d bytes: encrypted length %d, want %d", n, len(encrypted), want)
+		}
+		r, err := Decrypt(bytes.NewReader(encrypted), identity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decrypted, err := io.ReadAll(iotest.OneByteReader(r))
+		if err != nil {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("%d bytes: decrypted payload differs", n)
+		}
+	}
+}
+
+func TestMultipleRecipients(t *testing.T) {
+	var identities []Identity
+	var recipients []Recipient
+	for _, kem := range []gokyber.KEM{gokyber.Kyber512, gokyber.MLKEM1024, gokyber.XWing, gokyber.MLKEM768P256} {
+		identity, recipient := newIdentity(t, kem)
+		identities = append(identities, identity)
+		recipients = append(recipients, recipient)
+	}
+	plaintext := randomBytes(t, 100000)
+	encrypted := encrypt(t, plaintext, recipients...)
+	for i, identity := range identities {
+		decrypted, err := decrypt(encrypted, identity)
+		if err != nil {
+			t.Fatalf("recipient %d: %v", i, err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("recipient %d: decrypted payload differs", i)
+		}
+	}
+
+	// An identity of the same KEM but another key does not match.
+	other, _ := newIdentity(t, gokyber.Kyber512)
+	if _, err := decrypt(encrypted, other); !errors.Is(err, ErrNoIdentityMatched) {
+		t.Errorf("unrelated identity: %v", err)
+	}
+	// Among several identities, the matching one is found.
+	if _, err := decrypt(encrypted, other, identities[2]); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTampering(t *testing.T) {
+	identity, recipient := newIdentity(t, gokyber.MLKEM768)
+	plaintext := randomBytes(t, 3*ChunkSize+100)
+	encrypted := encrypt(t, plaintext, recipient)
+	headerSize := len(magic) + 3 + 2 + gokyber.MLKEM768.CiphertextBytes() + wrappedSize
+	payloadStart := headerSize + headerMACSize + payloadNonceSize
+	chunk := func(i int) []byte {
+		start := payloadStart + i*encChunkSize
+		return encrypted[start:min(start+encChunkSize, len(encrypted))]
+	}
+
+	for _, offset := range []int{
+		0,                               // magic
+		len(magic),                      // version
+		len(magic) + 2,                  // recipient count
+		len(magic) + 4,                  // algorithm
+		len(magic) + 5,                  // KEM ciphertext
+		headerSize - 1,                  // wrapped file key
+		headerSize,                      // header MAC
+		headerSize + headerMACSize,      // payload nonce
+		payloadStart,                    // first chunk
+		payloadStart + encChunkSize - 1, // first tag
+		len(encrypted) - 1,              // final tag
+	} {
+		modified := bytes.Clone(encrypted)
+		modified[offset] ^= 1
+		if _, err := decrypt(modified, identity); err == nil {
+			t.Errorf("modified byte %d accepted", offset)
+		}
+	}
+
+	for _, n := range []int{payloadStart - 1, payloadStart, payloadStart + encChunkSize, payloadStart + 2*encChunkSize, len(encrypted) - 1} {
+		if _, err := decrypt(encrypted[:n], identity); err == nil {
+			t.Errorf("stream truncated to %d bytes accepted", n)
+		}
+	}
+
+	var reordered []byte
+	reordered = append(reordered, encrypted[:payloadStart]...)
+	reordered = append(reordered, chunk(1)...)
+	reordered = append(reordered, chunk(0)...)
+	reordered = append(reordered, chunk(2)...)
+	reordered = append(reordered, chunk(3)...)
+	if _, err := decrypt(reordered, identity); err == nil {
+		t.Error("reordered chunks accepted")
+	}
+
+	if _, err := decrypt(append(bytes.Clone(encrypted), chunk(1)...), identity); err == nil {
+		t.Error("appended chunk accepted")
+	}
+	dropped := append(bytes.Clone(encrypted[:payloadStart+encChunkSize]), encrypted[payloadStart+2*encChunkSize:]...)
+	if _, err := decrypt(dropped, identity); err == nil {
+		t.Error("dropped chunk accepted")
+	}
+}
+
+func TestEmptyFinalChunk(t *testing.T) {
+	// A payload of exactly one chunk must end with that chunk marked final,
+	// not with an empty final chunk.
+	fileKey := randomBytes(t, FileKeySize)
+	var buf bytes.Buffer
+	w, err := NewPayloadWriter(&buf, fileKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pw := w.(*payloadWriter)
+	pw.buf = append(pw.buf, make([]byte, ChunkSize)...)
+	if err := pw.flush(false); err != nil {
+		t.Fatal(err)
+	}
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewPayloadReader(&buf, fileKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(r); err != errEmptyFinal {
+		t.Errorf("empty final chunk: %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package x509kem
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"time"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// KEM certificates bind a subject to an ML-KEM public key. Following
+// draft-ietf-lamps-kyber-certificates, their key usage extension is critical
+// and holds keyEncipherment only, since the key can be used for nothing
+// else; in particular it cannot sign, so the certificates are always issued
+// by a CA with a signature key and are never CAs themselves.
+//
+// crypto/x509 cannot marshal certificates of key types it does not know, so
+// CreateCertificate builds the TBSCertificate itself. It does parse them,
+// leaving the public key to ParseCertificate.
+
+var (
+	oidExtensionSubjectKeyID    = asn1.ObjectIdentifier{2, 5, 29, 14}
+	oidExtensionKeyUsage        = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtensionSubjectAltName  = asn1.ObjectIdentifier{2, 5, 29, 17}
+	oidExtensionAuthorityKeyID  = asn1.ObjectIdentifier{2, 5, 29, 35}
+	oidSignatureECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidSignatureECDSAWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidSignatureECDSAWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+	oidSignatureEd25519         = asn1.ObjectIdentifier{1, 3, 101, 112}
+)
+
+// Certificate is a parsed KEM certificate.
+type Certificate struct {
+	*x509.Certificate
+	// Params and KEMPublicKey are the subject's ML-KEM public key.
+	Params       *gokyber.ParameterSet
+	KEMPublicKey []byte
+}
+
+// signatureAlgorithm returns the signature algorithm of a CA key: ECDSA
+// with the hash matching the curve, or Ed25519.
+func signatureAlgorithm(key crypto.PublicKey) (x509.SignatureAlgorithm, asn1.ObjectIdentifier, crypto.Hash, error) {
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return x509.ECDSAWithSHA256, oidSignatureECDSAWithSHA256, crypto.SHA256, nil
+		case elliptic.P384():
+			return x509.ECDSAWithSHA384, oidSignatureECDSAWithSHA384, crypto.SHA384, nil
+		case elliptic.P521():
+			return x509.ECDSAWithSHA512, oidSignatureECDSAWithSHA512, crypto.SHA512, nil
+		}
+	case ed25519.PublicKey:
+		return x509.PureEd25519, oidSignatureEd25519, 0, nil
+	}
+	return 0, nil, 0, fmt.Errorf("unsupported CA key type %T", key)
+}
+
+// CreateCertificate issues a DER certificate for an ML-KEM public key,
+// signed by the CA certificate parent with its private key signer, an
+// ECDSA or Ed25519 key.
+//
+// Of the template, it uses SerialNumber, Subject (or RawSubject), NotBefore,
+// NotAfter, DNSNames, EmailAddresses and SubjectKeyId, which defaults to
+// the SHA-1 hash of the public key. Its KeyUsage must be zero or
+// keyEncipherment; the certificate always has keyEncipherment only.
+func CreateCertificate(template *x509.Certificate, params *gokyber.ParameterSet, publicKey []byte, parent *x509.Certificate, signer crypto.Signer) ([]byte, error) {
+	if template.SerialNumber == nil || template.SerialNumber.Sign() <= 0 {
+		return nil, errors.New("certificate serial number must be positive")
+	}
+	if template.KeyUsage != 0 && template.KeyUsage != x509.KeyUsageKeyEncipherment {
+		return nil, errors.New("KEM certificates can only have the keyEncipherment key usage")
+	}
+	if template.IsCA {
+		return nil, errors.New("KEM certificates cannot be CAs")
+	}
+	spki, err := MarshalPKIXPublicKey(params, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	algorithm, algorithmOID, hash, err := signatureAlgorithm(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	subject := template.RawSubject
+	if len(subject) == 0 {
+		if subject, err = asn1.Marshal(template.Subject.ToRDNSequence()); err != nil {
+			return nil, err
+		}
+	}
+	extensions, err := certificateExtensions(template, publicKey, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific(), func(b *cryptobyte.Builder) {
+			b.AddASN1Int64(2) // v3
+		})
+		b.AddASN1BigInt(template.SerialNumber)
+		addAlgorithmIdentifier(b, algorithmOID)
+		b.AddBytes(parent.RawSubject)
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			addTime(b, template.NotBefore)
+			addTime(b, template.NotAfter)
+		})
+		b.AddBytes(subject)
+		b.AddBytes(spki)
+		b.AddASN1(cryptobyte_asn1.Tag(3).Constructed().ContextSpecific(), func(b *cryptobyte.Builder) {
+			b.AddBytes(extensions)
+		})
+	})
+	tbs, err := b.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	signed := tbs
+	if hash != 0 {
+		h := hash.New()
+		h.Write(tbs)
+		signed = h.Sum(nil)
+	}
+	signature, err := signer.Sign(rand.Reader, signed, hash)
+	if err != nil {
+		return nil, err
+	}
+	// Catch a signer that does not belong to the parent certificate.
+	if err := parent.CheckSignature(algorithm, tbs, signature); err != nil {
+		return nil, fmt.Errorf("signature does not verify with the parent certificate: %w", err)
+	}
+
+	b = cryptobyte.Builder{}
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddBytes(tbs)
+		addAlgorithmIdentifier(b, algorithmOID)
+		b.AddASN1BitString(signature)
+	})
+	return b.Bytes()
+}
+
+// addTime adds a validity time as UTCTime until 2049 and GeneralizedTime
+// from 2050, as RFC 5280 requires.
+func addTime(b *cryptobyte.Builder, t time.Time) {
+	t = t.UTC().Truncate(time.Second)
+	if t.Year() >= 1950 && t.Year() < 2050 {
+		b.AddASN1UTCTime(t)
+	} else {
+		b.AddASN1GeneralizedTime(t)
+	}
+}
+
+// certificateExtensions returns the DER SEQUENCE of the extensions of a KEM
+// certificate.
+func certificateExtensions(template *x509.Certificate, publicKey []byte, parent *x509.Certificate) ([]byte, error) {
+	keyUsage, err := asn1.Marshal(asn1.BitString{Bytes: []byte{0x20}, BitLength: 3})
+	if err != nil {
+		return nil, err
+	}
+	subjectKeyID := template.SubjectKeyId
+	if len(subjectKeyID) == 0 {
+		h := sha1.Sum(publicKey)
+		subjectKeyID = h[:]
+	}
+
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		addExtension(b, oidExtensionKeyUsage, true, keyUsage)
+		addExtension(b, oidExtensionSubjectKeyID, false, asn1OctetString(subjectKeyID))
+		if len(parent.SubjectKeyId) > 0 {
+			var aki cryptobyte.Builder
+			aki.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+				b.AddASN1(cryptobyte_asn1.Tag(0).ContextSpecific(), func(b *cryptobyte.Builder) {
+					b.AddBytes(parent.SubjectKeyId)
+				})
+			})
+			addExtension(b, oidExtensionAuthorityKeyID, false, aki.BytesOrPanic())
+		}
+		if len(template.DNSNames) > 0 || len(template.EmailAddresses) > 0 {
+			var san cryptobyte.Builder
+			san.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+				for _, email := range template.EmailAddresses {
+					b.AddASN1(cryptobyte_asn1.Tag(1).ContextSpecific(), func(b *cryptobyte.Builder) {
+						b.AddBytes([]byte(email))
+					})
+				}
+				for _, name := range template.DNSNames {
+					b.AddASN1(cryptobyte_asn1.Tag(2).ContextSpecific(), func(b *cryptobyte.Builder) {
+						b.AddBytes([]byte(name))
+					})
+				}
+			})
+			// The subject alternative names are critical when the subject
+			// is empty (RFC 5280, section 4.2.1.6).
+			addExtension(b, oidExtensionSubjectAltName, len(template.Subject.ToRDNSequence()) == 0 && len(template.RawSubject) == 0, san.BytesOrPanic())
+		}
+	})
+	return b.Bytes()
+}
+
+func addExtension(b *cryptobyte.Builder, oid asn1.ObjectIdentifier, critical bool, value []byte) {
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1ObjectIdentifier(oid)
+		if critical {
+			b.AddASN1Boolean(true)
+		}
+		b.AddASN1OctetString(value)
+	})
+}
+
+func asn1OctetString(value []byte) []byte {
+	var b cryptobyte.Builder
+	b.AddASN1OctetString(value)
+	return b.BytesOrPanic()
+}
+
+// ParseCertificate parses a DER KEM certificate. It rejects certificates
+// whose key is not an ML-KEM key, whose key usage is not keyEncipherment
+// only, or that are CAs.
+func ParseCertificate(der []byte) (*Certificate, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	params, publicKey, err := ParsePKIXPublicKey(cert.RawSubjectPublicKeyInfo)
+	if err != nil {
+		return nil, err
+	}
+	if cert.KeyUsage != x509.KeyUsageKeyEncipherment {
+		return nil, errors.New("KEM certificate key usage is not keyEncipherment")
+	}
+	if cert.IsCA {
+		return nil, errors.New("KEM certificate is a CA")
+	}
+	return &Certificate{Certificate: cert, Params: params, KEMPublicKey: publicKey}, nil
+}
+
+// VerifyPublicKey parses a DER KEM certificate, verifies its chain with
+// opts, as crypto/x509 does, and returns the subject's ML-KEM public key.
+func VerifyPublicKey(der []byte, opts x509.VerifyOptions) (*gokyber.ParameterSet, []byte, error) {
+	cert, err := ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := cert.Verify(opts); err != nil {
+		return nil, nil, err
+	}
+	return cert.Params, cert.KEMPublicKey, nil
+}
@@ -0,0 +1,221 @@
+package x509kem
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+func newCA(t *testing.T, name string, key crypto.Signer) *x509.Certificate {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca
+}
+
+func leafTemplate() *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber:   big.NewInt(42),
+		Subject:        pkix.Name{CommonName: "alice", Organization: []string{"goKyber"}},
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		DNSNames:       []string{"alice.example.com"},
+		EmailAddresses: []string{"alice@example.com"},
+	}
+}
+
+func TestCertificate(t *testing.T) {
+	ecdsaP256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecdsaP384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	_, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	for _, tc := range []struct {
+		params    *gokyber.ParameterSet
+		key       crypto.Signer
+		algorithm x509.SignatureAlgorithm
+	}{
+		{gokyber.MLKEM512, ecdsaP256, x509.ECDSAWithSHA256},
+		{gokyber.MLKEM768, ecdsaP384, x509.ECDSAWithSHA384},
+		{gokyber.MLKEM1024, ed25519Key, x509.PureEd25519},
+	} {
+		ca := newCA(t, "goKyber test CA", tc.key)
+		privateKey, publicKey, err := tc.params.KemKeypair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := CreateCertificate(leafTemplate(), tc.params, publicKey, ca, tc.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cert.Params != tc.params || !bytes.Equal(cert.KEMPublicKey, publicKey) {
+			t.Errorf("%s: public key does not round trip", tc.params.Name)
+		}
+		if cert.SignatureAlgorithm != tc.algorithm || cert.Subject.CommonName != "alice" || cert.Issuer.CommonName != "goKyber test CA" {
+			t.Errorf("%s: %v, subject %v, issuer %v", tc.params.Name, cert.SignatureAlgorithm, cert.Subject, cert.Issuer)
+		}
+		if cert.DNSNames[0] != "alice.example.com" || cert.EmailAddresses[0] != "alice@example.com" {
+			t.Errorf("%s: alternative names %v %v", tc.params.Name, cert.DNSNames, cert.EmailAddresses)
+		}
+		if !bytes.Equal(cert.AuthorityKeyId, ca.SubjectKeyId) || len(cert.SubjectKeyId) != 20 {
+			t.Errorf("%s: key identifiers %x %x", tc.params.Name, cert.AuthorityKeyId, cert.SubjectKeyId)
+		}
+		if err := cert.CheckSignatureFrom(ca); err != nil {
+			t.Error(err)
+		}
+
+		roots := x509.NewCertPool()
+		roots.AddCert(ca)
+		params, verified, err := VerifyPublicKey(der, x509.VerifyOptions{Roots: roots})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.params.Name, err)
+		}
+		// The verified key encapsulates to the subject.
+		ciphertext, sharedSecret, err := params.KemEncrypt(verified)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decrypted, err := tc.params.KemDecrypt(ciphertext, privateKey); err != nil || !bytes.Equal(decrypted, sharedSecret) {
+			t.Errorf("%s: shared secrets differ", tc.params.Name)
+		}
+	}
+}
+
+func TestVerifyPublicKey(t *testing.T) {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ca := newCA(t, "root", caKey)
+	_, publicKey, err := gokyber.MLKEM768.KemKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := CreateCertificate(leafTemplate(), gokyber.MLKEM768, publicKey, ca, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherRoots := x509.NewCertPool()
+	otherRoots.AddCert(newCA(t, "root", otherKey))
+	if _, _, err := VerifyPublicKey(der, x509.VerifyOptions{Roots: otherRoots}); err == nil {
+		t.Error("certificate verified with an unrelated root")
+	}
+	if _, _, err := VerifyPublicKey(der, x509.VerifyOptions{Roots: roots, CurrentTime: time.Now().Add(2 * time.Hour)}); err == nil {
+		t.Error("expired certificate verified")
+	}
+	if _, _, err := VerifyPublicKey(der, x509.VerifyOptions{Roots: roots, DNSName: "bob.example.com"}); err == nil {
+		t.Error("certificate verified for another name")
+	}
+	if _, _, err := VerifyPublicKey(der, x509.VerifyOptions{Roots: roots, DNSName: "alice.example.com"}); err != nil {
+		t.Error(err)
+	}
+
+	// A modified public key breaks the signature.
+	modified := bytes.Clone(der)
+	modified[bytes.Index(modified, publicKey)+10] ^= 1
+	if _, _, err := VerifyPublicKey(modified, x509.VerifyOptions{Roots: roots}); err == nil {
+		t.Error("modified certificate verified")
+	}
+
+	// An intermediate CA with an Ed25519 key.
+	_, intermediateKey, _ := ed25519.GenerateKey(rand.Reader)
+	intermediateTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "intermediate"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	intermediateDER, err := x509.CreateCertificate(rand.Reader, intermediateTemplate, ca, intermediateKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	intermediate, err := x509.ParseCertificate(intermediateDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err = CreateCertificate(leafTemplate(), gokyber.MLKEM1024, make([]byte, gokyber.MLKEM1024.PublicKeyBytes()), intermediate, intermediateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	intermediates := x509.NewCertPool()
+	if _, _, err := VerifyPublicKey(der, x509.VerifyOptions{Roots: roots, Intermediates: intermediates}); err == nil {
+		t.Error("certificate verified without its intermediate")
+	}
+	intermediates.AddCert(intermediate)
+	if params, _, err := VerifyPublicKey(der, x509.VerifyOptions{Roots: roots, Intermediates: intermediates}); err != nil || params != gokyber.MLKEM1024 {
+		t.Errorf("chain with intermediate: %v", err)
+	}
+}
+
+func TestCreateCertificateErrors(t *testing.T) {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ca := newCA(t, "root", caKey)
+	_, publicKey, err := gokyber.MLKEM768.KemKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if _, err := CreateCertificate(leafTemplate(), gokyber.MLKEM768, publicKey, ca, otherKey); err == nil {
+		t.Error("certificate signed with a key other than the parent's")
+	}
+	template := leafTemplate()
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	if _, err := CreateCertificate(template, gokyber.MLKEM768, publicKey, ca, caKey); err == nil {
+		t.Error("certificate with digitalSignature key usage")
+	}
+	template = leafTemplate()
+	template.IsCA = true
+	if _, err := CreateCertificate(template, gokyber.MLKEM768, publicKey, ca, caKey); err == nil {
+		t.Error("KEM CA certificate")
+	}
+	template = leafTemplate()
+	template.SerialNumber = nil
+	if _, err := CreateCertificate(template, gokyber.MLKEM768, publicKey, ca, caKey); err == nil {
+		t.Error("certificate without serial number")
+	}
+	if _, err := CreateCertificate(leafTemplate(), gokyber.Kyber768, publicKey, ca, caKey); err == nil {
+		t.Error("certificate for a round-3 Kyber key")
+	}
+
+	// A certificate of another key type is not a KEM certificate.
+	leafKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, err := x509.CreateCertificate(rand.Reader, leafTemplate(), ca, leafKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseCertificate(der); err == nil {
+		t.Error("ECDSA certificate parsed as a KEM certificate")
+	}
+}
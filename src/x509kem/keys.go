@@ -0,0 +1,313 @@
+// Package x509kem encodes ML-KEM keys in the X.509 and PKCS #8 formats of
+// draft-ietf-lamps-kyber-certificates: public keys in SubjectPublicKeyInfo
+// and private keys in OneAsymmetricKey (PKCS #8), both under the
+// id-alg-ml-kem-* algorithm identifiers, whose parameters are absent. It
+// also issues and verifies X.509 certificates of ML-KEM keys (see
+// CreateCertificate).
+//
+// The round-3 Kyber parameter sets have no registered identifiers and are
+// not supported.
+package x509kem
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+var (
+	oidMLKEM512  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 4, 1}
+	oidMLKEM768  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 4, 2}
+	oidMLKEM1024 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 4, 3}
+)
+
+var parameterSetOIDs = []struct {
+	params *gokyber.ParameterSet
+	oid    asn1.ObjectIdentifier
+}{
+	{gokyber.MLKEM512, oidMLKEM512},
+	{gokyber.MLKEM768, oidMLKEM768},
+	{gokyber.MLKEM1024, oidMLKEM1024},
+}
+
+// OID returns the algorithm identifier of an ML-KEM parameter set, or of a
+// copy of one such as a traced one. Parameter sets are matched on their
+// parameters, not their names.
+func OID(p *gokyber.ParameterSet) (asn1.ObjectIdentifier, error) {
+	q := p.Predefined()
+	for _, e := range parameterSetOIDs {
+		if e.params == q {
+			return e.oid, nil
+		}
+	}
+	return nil, fmt.Errorf("parameter set %s has no algorithm identifier", p.Name)
+}
+
+// ParameterSetForOID returns the ML-KEM parameter set of an algorithm
+// identifier.
+func ParameterSetForOID(oid asn1.ObjectIdentifier) (*gokyber.ParameterSet, error) {
+	for _, e := range parameterSetOIDs {
+		if e.oid.Equal(oid) {
+			return e.params, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown algorithm identifier %v", oid)
+}
+
+func addAlgorithmIdentifier(b *cryptobyte.Builder, oid asn1.ObjectIdentifier) {
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1ObjectIdentifier(oid)
+	})
+}
+
+// readAlgorithmIdentifier reads an ML-KEM AlgorithmIdentifier, whose
+// parameters must be absent.
+func readAlgorithmIdentifier(s *cryptobyte.String) (*gokyber.ParameterSet, error) {
+	var ai cryptobyte.String
+	var oid asn1.ObjectIdentifier
+	if !s.ReadASN1(&ai, cryptobyte_asn1.SEQUENCE) || !ai.ReadASN1ObjectIdentifier(&oid) {
+		return nil, errors.New("invalid algorithm identifier")
+	}
+	if !ai.Empty() {
+		return nil, errors.New("algorithm identifier has parameters")
+	}
+	return ParameterSetForOID(oid)
+}
+
+// MarshalPKIXPublicKey returns the DER SubjectPublicKeyInfo of a public key
+// of an ML-KEM parameter set.
+func MarshalPKIXPublicKey(p *gokyber.ParameterSet, publicKey []byte) ([]byte, error) {
+	oid, err := OID(p)
+	if err != nil {
+		return nil, err
+	}
+	if len(publicKey) != p.PublicKeyBytes() {
+		return nil, errors.New("invalid public key length")
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		addAlgorithmIdentifier(b, oid)
+		b.AddASN1BitString(publicKey)
+	})
+	return b.Bytes()
+}
+
+// ParsePKIXPublicKey parses a DER SubjectPublicKeyInfo of an ML-KEM public
+// key.
+func ParsePKIXPublicKey(der []byte) (*gokyber.ParameterSet, []byte, error) {
+	s := cryptobyte.String(der)
+	var spki cryptobyte.String
+	if !s.ReadASN1(&spki, cryptobyte_asn1.SEQUENCE) || !s.Empty() {
+		return nil, nil, errors.New("invalid SubjectPublicKeyInfo")
+	}
+	p, err := readAlgorithmIdentifier(&spki)
+	if err != nil {
+		return nil, nil, err
+	}
+	var bits asn1.BitString
+	if !spki.ReadASN1BitString(&bits) || !spki.Empty() || bits.BitLength%8 != 0 {
+		return nil, nil, errors.New("invalid SubjectPublicKeyInfo")
+	}
+	if len(bits.Bytes) != p.PublicKeyBytes() {
+		return nil, nil, errors.New("invalid public key length")
+	}
+	return p, bits.Bytes, nil
+}
+
+// PrivateKeyFormat is the form of an ML-KEM private key in PKCS #8, the
+// ML-KEM-PrivateKey CHOICE of the draft.
+type PrivateKeyFormat int
+
+const (
+	// FormatSeed stores the 64-byte seed `d || z` only. It is the smallest
+	// and is recommended.
+	FormatSeed PrivateKeyFormat = iota
+	// FormatExpanded stores the expanded decapsulation key only, for
+	// implementations that cannot expand seeds.
+	FormatExpanded
+	// FormatBoth stores the seed and the expanded key, which parsers check
+	// against each other.
+	FormatBoth
+)
+
+// tagSeed is the context-specific tag [0] IMPLICIT of the seed choice.
+var tagSeed = cryptobyte_asn1.Tag(0).ContextSpecific()
+
+// PrivateKey is a parsed ML-KEM private key.
+type PrivateKey struct {
+	Params *gokyber.ParameterSet
+	// Seed is the seed the key pair was derived from, or nil for keys in
+	// FormatExpanded.
+	Seed []byte
+	// Key is the expanded decapsulation key, as used by KemDecrypt.
+	Key []byte
+	// PublicKey is the public key embedded in the decapsulation key.
+	PublicKey []byte
+	Format    PrivateKeyFormat
+}
+
+// MarshalPKCS8PrivateKey returns the DER OneAsymmetricKey of the key pair
+// derived from a seed of an ML-KEM parameter set, in the given format.
+func MarshalPKCS8PrivateKey(p *gokyber.ParameterSet, seed []byte, format PrivateKeyFormat) ([]byte, error) {
+	oid, err := OID(p)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, _, err := p.KemKeypairFromSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	switch format {
+	case FormatSeed:
+		b.AddASN1(tagSeed, func(b *cryptobyte.Builder) { b.AddBytes(seed) })
+	case FormatExpanded:
+		b.AddASN1OctetString(privateKey)
+	case FormatBoth:
+		b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			b.AddASN1OctetString(seed)
+			b.AddASN1OctetString(privateKey)
+		})
+	default:
+		return nil, errors.New("unknown private key format")
+	}
+	return marshalOneAsymmetricKey(oid, b.BytesOrPanic())
+}
+
+// MarshalPKCS8ExpandedPrivateKey returns the DER OneAsymmetricKey of an
+// expanded decapsulation key in FormatExpanded, for keys whose seed is not
+// known.
+func MarshalPKCS8ExpandedPrivateKey(p *gokyber.ParameterSet, privateKey []byte) ([]byte, error) {
+	oid, err := OID(p)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := expandedPublicKey(p, privateKey); err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddASN1OctetString(privateKey)
+	return marshalOneAsymmetricKey(oid, b.BytesOrPanic())
+}
+
+func marshalOneAsymmetricKey(oid asn1.ObjectIdentifier, privateKey []byte) ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1Int64(0)
+		addAlgorithmIdentifier(b, oid)
+		b.AddASN1OctetString(privateKey)
+	})
+	return b.Bytes()
+}
+
+// ParsePKCS8PrivateKey parses a DER OneAsymmetricKey of an ML-KEM private
+// key in any of the formats. A key in FormatBoth is rejected if its seed
+// and expanded key disagree, as is a key whose optional public key differs
+// from the one derived from the private key.
+func ParsePKCS8PrivateKey(der []byte) (*PrivateKey, error) {
+	s := cryptobyte.String(der)
+	var oak cryptobyte.String
+	var version int64
+	if !s.ReadASN1(&oak, cryptobyte_asn1.SEQUENCE) || !s.Empty() || !oak.ReadASN1Integer(&version) {
+		return nil, errors.New("invalid OneAsymmetricKey")
+	}
+	if version != 0 && version != 1 {
+		return nil, fmt.Errorf("unsupported OneAsymmetricKey version %d", version)
+	}
+	p, err := readAlgorithmIdentifier(&oak)
+	if err != nil {
+		return nil, err
+	}
+	var encoded cryptobyte.String
+	if !oak.ReadASN1(&encoded, cryptobyte_asn1.OCTET_STRING) ||
+		!oak.SkipOptionalASN1(cryptobyte_asn1.Tag(0).Constructed().ContextSpecific()) {
+		return nil, errors.New("invalid OneAsymmetricKey")
+	}
+	var publicKey cryptobyte.String
+	var hasPublicKey bool
+	if !oak.ReadOptionalASN1(&publicKey, &hasPublicKey, cryptobyte_asn1.Tag(1).ContextSpecific()) || !oak.Empty() {
+		return nil, errors.New("invalid OneAsymmetricKey")
+	}
+	if hasPublicKey && version != 1 {
+		return nil, errors.New("public key in a version 0 OneAsymmetricKey")
+	}
+
+	key, err := parsePrivateKey(p, encoded)
+	if err != nil {
+		return nil, err
+	}
+	// The [1] IMPLICIT BIT STRING starts with its count of unused bits.
+	if hasPublicKey && !bytes.Equal(publicKey, append([]byte{0}, key.PublicKey...)) {
+		return nil, errors.New("public key does not match the private key")
+	}
+	return key, nil
+}
+
+// parsePrivateKey parses the ML-KEM-PrivateKey CHOICE.
+func parsePrivateKey(p *gokyber.ParameterSet, s cryptobyte.String) (*PrivateKey, error) {
+	key := &PrivateKey{Params: p}
+	var seed, expanded cryptobyte.String
+	switch {
+	case s.PeekASN1Tag(tagSeed):
+		if !s.ReadASN1(&seed, tagSeed) || !s.Empty() {
+			return nil, errors.New("invalid ML-KEM private key")
+		}
+		key.Format = FormatSeed
+	case s.PeekASN1Tag(cryptobyte_asn1.OCTET_STRING):
+		if !s.ReadASN1(&expanded, cryptobyte_asn1.OCTET_STRING) || !s.Empty() {
+			return nil, errors.New("invalid ML-KEM private key")
+		}
+		key.Format = FormatExpanded
+	case s.PeekASN1Tag(cryptobyte_asn1.SEQUENCE):
+		var both cryptobyte.String
+		if !s.ReadASN1(&both, cryptobyte_asn1.SEQUENCE) || !s.Empty() ||
+			!both.ReadASN1(&seed, cryptobyte_asn1.OCTET_STRING) ||
+			!both.ReadASN1(&expanded, cryptobyte_asn1.OCTET_STRING) || !both.Empty() {
+			return nil, errors.New("invalid ML-KEM private key")
+		}
+		key.Format = FormatBoth
+	default:
+		return nil, errors.New("invalid ML-KEM private key")
+	}
+
+	if key.Format != FormatExpanded {
+		if len(seed) != p.SeedBytes() {
+			return nil, errors.New("invalid ML-KEM seed length")
+		}
+		privateKey, publicKey, err := p.KemKeypairFromSeed(seed)
+		if err != nil {
+			return nil, err
+		}
+		if key.Format == FormatBoth && !bytes.Equal(privateKey, expanded) {
+			return nil, errors.New("ML-KEM seed and expanded key do not match")
+		}
+		key.Seed, key.Key, key.PublicKey = bytes.Clone(seed), privateKey, publicKey
+		return key, nil
+	}
+	publicKey, err := expandedPublicKey(p, expanded)
+	if err != nil {
+		return nil, err
+	}
+	key.Key, key.PublicKey = bytes.Clone(expanded), publicKey
+	return key, nil
+}
+
+// expandedPublicKey returns the public key embedded in an expanded
+// decapsulation key, after the hash check of FIPS 203.
+func expandedPublicKey(p *gokyber.ParameterSet, privateKey []byte) ([]byte, error) {
+	if len(privateKey) != p.PrivateKeyBytes() {
+		return nil, errors.New("invalid ML-KEM private key length")
+	}
+	start := p.IndcpaSecretKeyBytes()
+	publicKey := privateKey[start : start+p.IndcpaPublicKeyBytes()]
+	h := p.Symmetric.Hash256(publicKey)
+	if !bytes.Equal(h[:], privateKey[start+len(publicKey):start+len(publicKey)+len(h)]) {
+		return nil, errors.New("invalid ML-KEM private key: public key hash mismatch")
+	}
+	return bytes.Clone(publicKey), nil
+}
@@ -0,0 +1,264 @@
+package x509kem
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"strings"
+	"testing"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+// exampleSeed is the seed of the example keys of
+// draft-ietf-lamps-kyber-certificates.
+func exampleSeed() []byte {
+	seed := make([]byte, 64)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	return seed
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// tlv returns a DER element of a tag, with the minimal length encoding.
+func tlv(tag byte, value []byte) []byte {
+	switch n := len(value); {
+	case n < 0x80:
+		return append([]byte{tag, byte(n)}, value...)
+	case n < 0x100:
+		return append([]byte{tag, 0x81, byte(n)}, value...)
+	default:
+		return append([]byte{tag, 0x82, byte(n >> 8), byte(n)}, value...)
+	}
+}
+
+// readExample returns an example key of draft-ietf-lamps-kyber-certificates,
+// derived from exampleSeed, as published in the draft and in the ML-KEM codec
+// test data of OpenSSL, and its DER contents.
+func readExample(t *testing.T, name string) ([]byte, []byte) {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name + ".pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatalf("%s: no PEM block", name)
+	}
+	return data, block.Bytes
+}
+
+func TestExampleKeys(t *testing.T) {
+	for _, tc := range []struct {
+		params *gokyber.ParameterSet
+		// publicKeyHash is SHA-256 of the public key of the example.
+		publicKeyHash string
+	}{
+		{gokyber.MLKEM512, "3ae268dccc5456ac0d0f9b39257dc48fe081383b97c400512d712b739762daee"},
+		{gokyber.MLKEM768, "0b7934c83125c788995e2ba6bd761e33046b3e40571be53e023309a29f398cc9"},
+		{gokyber.MLKEM1024, "c7b8fa0aa471d5ae18922d6ccad5b31e1d84f92ae723abfd13747018740a8530"},
+	} {
+		p := tc.params
+		name := strings.ToLower(p.Name)
+		seed := exampleSeed()
+		privateKey, publicKey, err := p.KemKeypairFromSeed(seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h := sha256.Sum256(publicKey); hex.EncodeToString(h[:]) != tc.publicKeyHash {
+			t.Errorf("%s: public key hash %x", p.Name, h)
+		}
+
+		wantPEM, wantDER := readExample(t, name+"-public")
+		spki, err := MarshalPKIXPublicKey(p, publicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(spki, wantDER) {
+			t.Errorf("%s: SubjectPublicKeyInfo\n%x\nwant\n%x", p.Name, spki, wantDER)
+		}
+		pemPublicKey, err := MarshalPublicKeyPEM(p, publicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(pemPublicKey, wantPEM) {
+			t.Errorf("%s: PEM public key\n%s", p.Name, pemPublicKey)
+		}
+		if gotParams, got, err := ParsePublicKeyPEM(wantPEM); err != nil || gotParams != p || !bytes.Equal(got, publicKey) {
+			t.Errorf("%s: example public key parsed as %v, %v", p.Name, gotParams, err)
+		}
+
+		for _, example := range []struct {
+			format PrivateKeyFormat
+			name   string
+		}{
+			{FormatSeed, "seed"},
+			{FormatExpanded, "expanded"},
+			{FormatBoth, "both"},
+		} {
+			wantPEM, wantDER := readExample(t, name+"-"+example.name)
+			der, err := MarshalPKCS8PrivateKey(p, seed, example.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(der, wantDER) {
+				t.Errorf("%s: %s private key\n%x\nwant\n%x", p.Name, example.name, der, wantDER)
+			}
+			pemKey, err := MarshalPrivateKeyPEM(p, seed, example.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(pemKey, wantPEM) {
+				t.Errorf("%s: %s PEM private key\n%s", p.Name, example.name, pemKey)
+			}
+			key, err := ParsePrivateKeyPEM(wantPEM)
+			if err != nil {
+				t.Fatalf("%s: %s: %v", p.Name, example.name, err)
+			}
+			if key.Params != p || key.Format != example.format || !bytes.Equal(key.Key, privateKey) || !bytes.Equal(key.PublicKey, publicKey) {
+				t.Errorf("%s: example %s private key parsed wrongly", p.Name, example.name)
+			}
+			if (key.Seed == nil) != (example.format == FormatExpanded) || (key.Seed != nil && !bytes.Equal(key.Seed, seed)) {
+				t.Errorf("%s: example %s private key seed %x", p.Name, example.name, key.Seed)
+			}
+		}
+	}
+}
+
+func TestPrivateKeyFormats(t *testing.T) {
+	for _, p := range []*gokyber.ParameterSet{gokyber.MLKEM512, gokyber.MLKEM768, gokyber.MLKEM1024} {
+		seed := exampleSeed()
+		privateKey, publicKey, err := p.KemKeypairFromSeed(seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, format := range []PrivateKeyFormat{FormatSeed, FormatExpanded, FormatBoth} {
+			der, err := MarshalPKCS8PrivateKey(p, seed, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			key, err := ParsePKCS8PrivateKey(der)
+			if err != nil {
+				t.Fatalf("%s, format %d: %v", p.Name, format, err)
+			}
+			if key.Params != p || key.Format != format || !bytes.Equal(key.Key, privateKey) || !bytes.Equal(key.PublicKey, publicKey) {
+				t.Errorf("%s, format %d: key does not round trip", p.Name, format)
+			}
+			if (key.Seed == nil) != (format == FormatExpanded) {
+				t.Errorf("%s, format %d: seed %x", p.Name, format, key.Seed)
+			}
+		}
+		der, err := MarshalPKCS8ExpandedPrivateKey(p, privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, _ := MarshalPKCS8PrivateKey(p, seed, FormatExpanded); !bytes.Equal(der, want) {
+			t.Errorf("%s: expanded private key differs", p.Name)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	p := gokyber.MLKEM768
+	seed := exampleSeed()
+	privateKey, publicKey, err := p.KemKeypairFromSeed(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	algorithm := mustDecodeHex(t, "300b 0609 608648016503040402")
+	oneAsymmetricKey := func(version byte, algorithm, privateKey, rest []byte) []byte {
+		body := append([]byte{0x02, 0x01, version}, algorithm...)
+		body = append(append(body, tlv(0x04, privateKey)...), rest...)
+		return tlv(0x30, body)
+	}
+	seedChoice := append([]byte{0x80, 0x40}, seed...)
+	if _, err := ParsePKCS8PrivateKey(oneAsymmetricKey(0, algorithm, seedChoice, nil)); err != nil {
+		t.Fatal(err)
+	}
+	// A version 1 key may carry the public key, which must match.
+	publicKeyField := tlv(0x81, append([]byte{0}, publicKey...))
+	if _, err := ParsePKCS8PrivateKey(oneAsymmetricKey(1, algorithm, seedChoice, publicKeyField)); err != nil {
+		t.Errorf("version 1 key with public key: %v", err)
+	}
+
+	otherPrivateKey, _, err := p.KemKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	badHash := bytes.Clone(privateKey)
+	badHash[p.IndcpaSecretKeyBytes()] ^= 1
+	mismatched := tlv(0x30, append(tlv(0x04, seed), tlv(0x04, otherPrivateKey)...))
+	wrongPublicKey := bytes.Clone(publicKeyField)
+	wrongPublicKey[len(wrongPublicKey)-1] ^= 1
+
+	for name, der := range map[string][]byte{
+		"both with mismatched keys": oneAsymmetricKey(0, algorithm, mismatched, nil),
+		"expanded with bad hash":    oneAsymmetricKey(0, algorithm, tlv(0x04, badHash), nil),
+		"short seed":                oneAsymmetricKey(0, algorithm, seedChoice[:len(seedChoice)-1], nil),
+		"trailing choice data":      oneAsymmetricKey(0, algorithm, append(bytes.Clone(seedChoice), 0), nil),
+		"unknown choice":            oneAsymmetricKey(0, algorithm, append([]byte{0x81, 0x40}, seed...), nil),
+		"algorithm parameters":      oneAsymmetricKey(0, mustDecodeHex(t, "300d 0609 608648016503040402 0500"), seedChoice, nil),
+		"unknown algorithm":         oneAsymmetricKey(0, mustDecodeHex(t, "300b 0609 608648016503040409"), seedChoice, nil),
+		"version 2":                 oneAsymmetricKey(2, algorithm, seedChoice, nil),
+		"public key in version 0":   oneAsymmetricKey(0, algorithm, seedChoice, publicKeyField),
+		"mismatched public key":     oneAsymmetricKey(1, algorithm, seedChoice, wrongPublicKey),
+		"trailing data":             append(oneAsymmetricKey(0, algorithm, seedChoice, nil), 0),
+		"empty":                     nil,
+	} {
+		if _, err := ParsePKCS8PrivateKey(der); err == nil {
+			t.Errorf("%s accepted", name)
+		}
+	}
+
+	spki, err := MarshalPKIXPublicKey(p, publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ParsePKIXPublicKey(spki[:len(spki)-1]); err == nil {
+		t.Error("truncated SubjectPublicKeyInfo accepted")
+	}
+	if _, _, err := ParsePKIXPublicKey(append(bytes.Clone(spki), 0)); err == nil {
+		t.Error("SubjectPublicKeyInfo with trailing data accepted")
+	}
+	if _, err := MarshalPKIXPublicKey(gokyber.Kyber768, publicKey); err == nil {
+		t.Error("round-3 Kyber key marshaled")
+	}
+	if _, err := MarshalPKCS8PrivateKey(p, seed, PrivateKeyFormat(3)); err == nil {
+		t.Error("unknown format accepted")
+	}
+	if _, err := OID(p.WithTracer(nil)); err != nil {
+		t.Error("traced parameter set has no identifier")
+	}
+	// Parameter sets are told apart by their parameters, not their names.
+	custom := *gokyber.MLKEM512
+	custom.Name = gokyber.MLKEM768.Name
+	if oid, err := OID(&custom); err != nil || !oid.Equal(oidMLKEM512) {
+		t.Errorf("renamed copy of ML-KEM-512 has identifier %v, %v", oid, err)
+	}
+	custom.Du = 11
+	if _, err := OID(&custom); err == nil {
+		t.Error("custom parameter set named ML-KEM-768 has an identifier")
+	}
+
+	pemPublicKey, err := MarshalPublicKeyPEM(p, publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParsePrivateKeyPEM(pemPublicKey); err == nil {
+		t.Error("public key PEM parsed as a private key")
+	}
+	if _, _, err := ParsePublicKeyPEM([]byte("not PEM")); err == nil {
+		t.Error("non-PEM data accepted")
+	}
+}
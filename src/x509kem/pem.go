@@ -0,0 +1,65 @@
+package x509kem
+
+import (
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	gokyber "github.com/Rohith04MVK/goKyber/goKyber"
+)
+
+// The PEM block types of SubjectPublicKeyInfo and PKCS #8, as in RFC 7468.
+const (
+	PublicKeyPEMType  = "PUBLIC KEY"
+	PrivateKeyPEMType = "PRIVATE KEY"
+)
+
+// MarshalPublicKeyPEM returns a public key as a PEM SubjectPublicKeyInfo.
+func MarshalPublicKeyPEM(p *gokyber.ParameterSet, publicKey []byte) ([]byte, error) {
+	der, err := MarshalPKIXPublicKey(p, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PublicKeyPEMType, Bytes: der}), nil
+}
+
+// ParsePublicKeyPEM parses the first PEM block of data as an ML-KEM public
+// key.
+func ParsePublicKeyPEM(data []byte) (*gokyber.ParameterSet, []byte, error) {
+	der, err := decodePEM(data, PublicKeyPEMType)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ParsePKIXPublicKey(der)
+}
+
+// MarshalPrivateKeyPEM returns the key pair derived from a seed as a PEM
+// PKCS #8 private key in the given format.
+func MarshalPrivateKeyPEM(p *gokyber.ParameterSet, seed []byte, format PrivateKeyFormat) ([]byte, error) {
+	der, err := MarshalPKCS8PrivateKey(p, seed, format)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PrivateKeyPEMType, Bytes: der}), nil
+}
+
+// ParsePrivateKeyPEM parses the first PEM block of data as an ML-KEM
+// private key.
+func ParsePrivateKeyPEM(data []byte) (*PrivateKey, error) {
+	der, err := decodePEM(data, PrivateKeyPEMType)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePKCS8PrivateKey(der)
+}
+
+func decodePEM(data []byte, blockType string) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if block.Type != blockType {
+		return nil, fmt.Errorf("PEM block type %q, want %q", block.Type, blockType)
+	}
+	return block.Bytes, nil
+}